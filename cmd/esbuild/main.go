@@ -38,9 +38,9 @@ var helpText = func(colors logger.Colors) string {
                         bundling, otherwise default is iife when platform
                         is browser and cjs when platform is node)
   --loader:X=L          Use loader L to load file extension X, where L is
-                        one of: base64 | binary | copy | css | dataurl |
-                        empty | file | global-css | js | json | jsx |
-                        local-css | text | ts | tsx
+                        one of: auto-dataurl | base64 | binary | copy |
+                        css | dataurl | empty | file | global-css | js |
+                        json | jsx | local-css | text | ts | tsx
   --minify              Minify the output (sets all --minify-* flags)
   --outdir=...          The output directory (for multiple entry points)
   --outfile=...         The output file (for one entry point)
@@ -61,8 +61,13 @@ var helpText = func(colors logger.Colors) string {
   --allow-overwrite         Allow output files to overwrite input files
   --analyze                 Print a report about the contents of the bundle
                             (use "--analyze=verbose" for a detailed report)
+  --asset-inline-limit=...  Inline "auto-dataurl" loader files smaller than
+                            this many bytes (default 4096, 0 to disable)
   --asset-names=...         Path template to use for "file" loader files
                             (default "[name]-[hash]")
+  --assume-pure:...         Assume certain constructs have no side effects for
+                            tree shaking (getters | property-reads |
+                            unknown-globals | try)
   --banner:T=...            Text to be prepended to each output file of type T
                             where T is one of: css | js
   --certfile=...            Certificate for serving HTTPS (see also "--keyfile")
@@ -70,28 +75,49 @@ var helpText = func(colors logger.Colors) string {
   --chunk-names=...         Path template to use for code splitting chunks
                             (default "[name]-[hash]")
   --color=...               Force use of color terminal escapes (true | false)
+  --comments=preserve       Keep all comments in non-minified output (default |
+                            preserve)
   --cors-origin=...         Allow cross-origin requests from this origin
+  --css-module-types        Write a .d.ts file next to each CSS module
+  --css-module-types-dir=...
+                            Write the .d.ts files for CSS modules to this
+                            directory instead
+  --debug-ids               Add debug IDs to output files and source maps
+                            (comment | register, default comment)
   --drop:...                Remove certain constructs (console | debugger)
   --drop-labels=...         Remove labeled statements with these label names
   --entry-names=...         Path template to use for entry point output paths
                             (default "[dir]/[name]", can also use "[hash]")
+  --error-recovery          Report all syntax errors and still print the code
+                            around them (transform only)
+  --external-helpers=...    Import runtime helpers from this module instead of
+                            including them in each output file
   --footer:T=...            Text to be appended to each output file of type T
                             where T is one of: css | js
+  --generate-helpers        Print the module used with "--external-helpers"
+                            instead of transforming stdin
   --global-name=...         The name of the global for the IIFE format
   --ignore-annotations      Enable this to work with packages that have
                             incorrect tree-shaking annotations
+  --infer-side-effects      Treat files in packages without a "sideEffects"
+                            field as side-effect free if that can be proven
   --inject:F                Import the file F into all input files and
                             automatically replace matching globals with imports
+  --inline-functions        Inline small functions that are only called once
+                            (only with --minify-syntax)
   --jsx-dev                 Use React's automatic runtime in development mode
   --jsx-factory=...         What to use for JSX instead of React.createElement
   --jsx-fragment=...        What to use for JSX instead of React.Fragment
   --jsx-import-source=...   Override the package name for the automatic runtime
                             (default "react")
+  --jsx-refresh             Register React components for Fast Refresh
   --jsx-side-effects        Do not remove unused JSX expressions
   --jsx=...                 Set to "automatic" to use React's automatic runtime
                             or to "preserve" to disable transforming JSX to JS
   --keep-names              Preserve "name" on functions and classes
   --keyfile=...             Key for serving HTTPS (see also "--certfile")
+  --known-global:N          Assume that referencing the global N has no side
+                            effects (e.g. "--known-global:MyLib.version")
   --legal-comments=...      Where to place legal comments (none | inline |
                             eof | linked | external, default eof when bundling
                             and inline otherwise)
@@ -111,9 +137,16 @@ var helpText = func(colors logger.Colors) string {
   --minify-whitespace       Remove whitespace in output files
   --minify-identifiers      Shorten identifiers in output files
   --minify-syntax           Use equivalent but shorter syntax in output files
+  --name-cache=...          Save minified top-level identifier names to a JSON
+                            file and reuse them in later builds
   --out-extension:.js=.mjs  Use a custom output extension instead of ".js"
   --outbase=...             The base path used to determine entry point output
                             paths (for multiple entry points)
+  --package-side-effects:P=...
+                            Override "sideEffects" for the package named P
+                            (true | false)
+  --polyfill=...            Import polyfills from this package (e.g. core-js)
+                            for APIs that are used but missing from the target
   --preserve-symlinks       Disable symlink resolution for module lookup
   --public-path=...         Set the base URL for the "file" loader
   --pure:N                  Mark the name N as a pure function for tree shaking
@@ -121,7 +154,13 @@ var helpText = func(colors logger.Colors) string {
   --resolve-extensions=...  A comma-separated list of implicit extensions
                             (default ".tsx,.ts,.jsx,.js,.css,.json")
   --serve-fallback=...      Serve this HTML page when the request doesn't match
+  --serve-overlay           Show build errors in an overlay in served HTML pages
   --servedir=...            What to serve in addition to generated output files
+  --source-map-ignore-list=...
+                            Regular expression for sources to put in the
+                            "ignoreList" field of generated source maps
+                            (default "(^|/)node_modules/", use an empty
+                            value to omit the field)
   --source-root=...         Sets the "sourceRoot" field in generated source maps
   --sourcefile=...          Set the source file for the source map (for stdin)
   --sourcemap=external      Do not link to the source map with a comment
@@ -396,6 +396,9 @@ func (service *serviceType) handleIncomingPacket(bytes []byte) {
 					if value, ok := request["fallback"]; ok {
 						options.Fallback = value.(string)
 					}
+					if value, ok := request["overlay"]; ok {
+						options.Overlay = value.(bool)
+					}
 					if value, ok := request["corsOrigin"].([]interface{}); ok {
 						for _, it := range value {
 							options.CORS.Origin = append(options.CORS.Origin, it.(string))
@@ -605,6 +608,7 @@ func (service *serviceType) handleBuildRequest(id uint32, request map[string]int
 	options.AbsWorkingDir = request["absWorkingDir"].(string)
 	options.NodePaths = decodeStringArray(request["nodePaths"].([]interface{}))
 	options.MangleCache, _ = request["mangleCache"].(map[string]interface{})
+	options.NameCache, _ = request["nameCache"].(map[string]interface{})
 
 	for _, entry := range entries {
 		entry := entry.([]interface{})
@@ -671,6 +675,9 @@ func (service *serviceType) handleBuildRequest(id uint32, request map[string]int
 		if options.MangleCache != nil {
 			response["mangleCache"] = result.MangleCache
 		}
+		if options.NameCache != nil {
+			response["nameCache"] = result.NameCache
+		}
 		if writeToStdout && len(result.OutputFiles) == 1 {
 			response["writeToStdout"] = result.OutputFiles[0].Contents
 		}
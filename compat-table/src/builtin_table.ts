@@ -0,0 +1,189 @@
+// This file generates "internal/compat/builtin_table.go"
+
+import fs = require('fs')
+import { Builtin, Engine, VersionRange, VersionRangeMap } from './index'
+
+interface BuiltinInfo {
+  // The name of the module that polyfills this built-in using the "core-js"
+  // naming convention (e.g. "es.array.at" for "Array.prototype.at")
+  module: string
+
+  // How this built-in is accessed in the source code. This is either a global
+  // name ("Map"), a static property ("Array.from"), or a prototype method
+  // ("Array.prototype.at").
+  access: string
+
+  // The version of the language specification that added this built-in. This
+  // is omitted for built-ins that aren't part of the language specification.
+  es?: number
+}
+
+export const builtinInfo: Record<Builtin, BuiltinInfo> = {
+  ArrayFrom: { module: 'es.array.from', access: 'Array.from', es: 2015 },
+  ArrayOf: { module: 'es.array.of', access: 'Array.of', es: 2015 },
+  ArrayPrototypeAt: { module: 'es.array.at', access: 'Array.prototype.at', es: 2022 },
+  ArrayPrototypeFind: { module: 'es.array.find', access: 'Array.prototype.find', es: 2015 },
+  ArrayPrototypeFindIndex: { module: 'es.array.find-index', access: 'Array.prototype.findIndex', es: 2015 },
+  ArrayPrototypeFindLast: { module: 'es.array.find-last', access: 'Array.prototype.findLast', es: 2023 },
+  ArrayPrototypeFindLastIndex: { module: 'es.array.find-last-index', access: 'Array.prototype.findLastIndex', es: 2023 },
+  ArrayPrototypeFlat: { module: 'es.array.flat', access: 'Array.prototype.flat', es: 2019 },
+  ArrayPrototypeFlatMap: { module: 'es.array.flat-map', access: 'Array.prototype.flatMap', es: 2019 },
+  ArrayPrototypeIncludes: { module: 'es.array.includes', access: 'Array.prototype.includes', es: 2016 },
+  ArrayPrototypeToReversed: { module: 'es.array.to-reversed', access: 'Array.prototype.toReversed', es: 2023 },
+  ArrayPrototypeToSorted: { module: 'es.array.to-sorted', access: 'Array.prototype.toSorted', es: 2023 },
+  ArrayPrototypeToSpliced: { module: 'es.array.to-spliced', access: 'Array.prototype.toSpliced', es: 2023 },
+  ArrayPrototypeWith: { module: 'es.array.with', access: 'Array.prototype.with', es: 2023 },
+  GlobalMap: { module: 'es.map', access: 'Map', es: 2015 },
+  GlobalPromise: { module: 'es.promise', access: 'Promise', es: 2015 },
+  GlobalQueueMicrotask: { module: 'web.queue-microtask', access: 'queueMicrotask' },
+  GlobalSet: { module: 'es.set', access: 'Set', es: 2015 },
+  GlobalStructuredClone: { module: 'web.structured-clone', access: 'structuredClone' },
+  GlobalSymbol: { module: 'es.symbol', access: 'Symbol', es: 2015 },
+  GlobalThis: { module: 'es.global-this', access: 'globalThis', es: 2020 },
+  GlobalWeakMap: { module: 'es.weak-map', access: 'WeakMap', es: 2015 },
+  GlobalWeakSet: { module: 'es.weak-set', access: 'WeakSet', es: 2015 },
+  MapGroupBy: { module: 'es.map.group-by', access: 'Map.groupBy', es: 2024 },
+  ObjectAssign: { module: 'es.object.assign', access: 'Object.assign', es: 2015 },
+  ObjectEntries: { module: 'es.object.entries', access: 'Object.entries', es: 2017 },
+  ObjectFromEntries: { module: 'es.object.from-entries', access: 'Object.fromEntries', es: 2019 },
+  ObjectGroupBy: { module: 'es.object.group-by', access: 'Object.groupBy', es: 2024 },
+  ObjectHasOwn: { module: 'es.object.has-own', access: 'Object.hasOwn', es: 2022 },
+  ObjectValues: { module: 'es.object.values', access: 'Object.values', es: 2017 },
+  PromiseAllSettled: { module: 'es.promise.all-settled', access: 'Promise.allSettled', es: 2020 },
+  PromiseAny: { module: 'es.promise.any', access: 'Promise.any', es: 2021 },
+  PromisePrototypeFinally: { module: 'es.promise.finally', access: 'Promise.prototype.finally', es: 2018 },
+  PromiseWithResolvers: { module: 'es.promise.with-resolvers', access: 'Promise.withResolvers', es: 2024 },
+  StringPrototypeAt: { module: 'es.string.at-alternative', access: 'String.prototype.at', es: 2022 },
+  StringPrototypeEndsWith: { module: 'es.string.ends-with', access: 'String.prototype.endsWith', es: 2015 },
+  StringPrototypeIncludes: { module: 'es.string.includes', access: 'String.prototype.includes', es: 2015 },
+  StringPrototypeIsWellFormed: { module: 'es.string.is-well-formed', access: 'String.prototype.isWellFormed', es: 2024 },
+  StringPrototypeMatchAll: { module: 'es.string.match-all', access: 'String.prototype.matchAll', es: 2020 },
+  StringPrototypePadEnd: { module: 'es.string.pad-end', access: 'String.prototype.padEnd', es: 2017 },
+  StringPrototypePadStart: { module: 'es.string.pad-start', access: 'String.prototype.padStart', es: 2017 },
+  StringPrototypeReplaceAll: { module: 'es.string.replace-all', access: 'String.prototype.replaceAll', es: 2021 },
+  StringPrototypeStartsWith: { module: 'es.string.starts-with', access: 'String.prototype.startsWith', es: 2015 },
+  StringPrototypeToWellFormed: { module: 'es.string.to-well-formed', access: 'String.prototype.toWellFormed', es: 2024 },
+  StringPrototypeTrimEnd: { module: 'es.string.trim-end', access: 'String.prototype.trimEnd', es: 2019 },
+  StringPrototypeTrimStart: { module: 'es.string.trim-start', access: 'String.prototype.trimStart', es: 2019 },
+}
+
+const simpleMap = (entries: [string, string][], indent = '\t') => {
+  let maxLength = 0
+  for (const [key] of entries) {
+    maxLength = Math.max(maxLength, key.length + 1)
+  }
+  return entries.map(([key, value]) => `${indent}${(key + ':').padEnd(maxLength)} ${value},`).join('\n')
+}
+
+const compareEngines = (a: Engine, b: Engine): number => {
+  const lowerA = a.toLowerCase()
+  const lowerB = b.toLowerCase()
+  return lowerA < lowerB ? -1 : lowerA > lowerB ? 1 : 0
+}
+
+const builtinTableMap = (map: Partial<Record<Engine, VersionRange[]>>) => {
+  const engineKeys = (Object.keys(map) as Engine[]).sort(compareEngines)
+  if (engineKeys.length === 0) return '{}'
+  return `{\n${simpleMap(engineKeys.map(engine => [engine, `{${map[engine]!.map(range => {
+    return `{start: v{${range.start.concat(0, 0).slice(0, 3).join(', ')
+      }}${range.end ? `, end: v{${range.end.concat(0, 0).slice(0, 3).join(', ')}}` : ''}}`
+  }).join(', ')}}`]), '\t\t')}\n\t}`
+}
+
+const generatedByComment = `// This file was automatically generated by "builtin_table.ts"`
+
+export const generateTableForBuiltins = (map: VersionRangeMap<Builtin>): void => {
+  const builtinKeys = (Object.keys(map) as Builtin[]).sort()
+  const globals: Record<string, Builtin[]> = {}
+  const statics: Record<string, Record<string, Builtin[]>> = {}
+  const prototypes: Record<string, Builtin[]> = {}
+
+  for (const builtin of builtinKeys) {
+    const parts = builtinInfo[builtin].access.split('.')
+    if (parts.length === 1) {
+      (globals[parts[0]] ||= []).push(builtin)
+    } else if (parts.length === 2) {
+      ((statics[parts[0]] ||= {})[parts[1]] ||= []).push(builtin)
+    } else if (parts.length === 3 && parts[1] === 'prototype') {
+      (prototypes[parts[2]] ||= []).push(builtin)
+    } else {
+      throw new Error(`Unexpected access "${builtinInfo[builtin].access}" for built-in ${builtin}`)
+    }
+  }
+
+  // Built-ins that are accessed in the same way are combined since the type
+  // of the object isn't known when a prototype method is called
+  const groupedMap = (groups: Record<string, Builtin[]>, indent: string) => simpleMap(Object.keys(groups).sort()
+    .map(key => [`"${key}"`, groups[key].join(' | ')]), indent)
+
+  fs.writeFileSync(__dirname + '/../internal/compat/builtin_table.go',
+    `${generatedByComment}
+
+package compat
+
+// This table tracks support for built-in APIs (as opposed to syntax features)
+// so that usages of missing APIs can be polyfilled. Each built-in is paired
+// with the name of the module that polyfills it using the "core-js" naming
+// convention. Built-ins that aren't part of the language specification (such
+// as "structuredClone") have no "ES" entry and aren't affected by "es*"
+// targets.
+
+type Builtin uint64
+
+const (
+${builtinKeys.map((builtin, i) => `\t${builtin}${i ? '' : ' Builtin = 1 << iota'}`).join('\n')}
+)
+
+func (builtins Builtin) Has(builtin Builtin) bool {
+\treturn (builtins & builtin) != 0
+}
+
+// Returns the name of the module that polyfills this built-in. For example,
+// this is "es.array.at" for "Array.prototype.at". The module is expected to be
+// at the path "modules/<name>.js" inside the polyfill package.
+func (builtin Builtin) Module() string {
+\treturn builtinTable[builtin].module
+}
+
+// These map identifiers and property accesses in the source code to the
+// built-ins that they may refer to. Property accesses that look like calls to
+// prototype methods are matched by name alone since the type of the object
+// isn't known, so they may map to more than one built-in.
+var GlobalBuiltins = map[string]Builtin{
+${groupedMap(globals, '\t')}
+}
+
+var StaticBuiltins = map[string]map[string]Builtin{
+${Object.keys(statics).sort().map(object => `\t"${object}": {\n${groupedMap(statics[object], '\t\t')}\n\t},`).join('\n')}
+}
+
+var PrototypeBuiltins = map[string]Builtin{
+${groupedMap(prototypes, '\t')}
+}
+
+type builtinInfo struct {
+\tmodule  string
+\tengines map[Engine][]versionRange
+}
+
+var builtinTable = map[Builtin]builtinInfo{
+${builtinKeys.map(builtin => `\t${builtin}: {module: "${builtinInfo[builtin].module}", engines: map[Engine][]versionRange${builtinTableMap(map[builtin]!)}},`).join('\n')}
+}
+
+// Return all built-ins that are not available in at least one environment
+func UnsupportedBuiltins(constraints map[Engine]Semver) (unsupported Builtin) {
+\tfor builtin, info := range builtinTable {
+\t\tfor engine, version := range constraints {
+\t\t\tversionRanges, ok := info.engines[engine]
+\t\t\tif !ok && engine == ES {
+\t\t\t\tcontinue // This isn't part of the language specification
+\t\t\t}
+\t\t\tif !ok || !isVersionSupported(versionRanges, version) {
+\t\t\t\tunsupported |= builtin
+\t\t\t}
+\t\t}
+\t}
+\treturn
+}
+`)
+}
@@ -1,7 +1,7 @@
 // This file generates "internal/compat/css_table.go"
 
 import fs = require('fs')
-import { Engine, CSSFeature, VersionRange, VersionRangeMap, CSSPrefixMap, PrefixData, CSSProperty } from './index'
+import { Engine, CSSFeature, VersionRange, VersionRangeMap, CSSPrefixMap, CSSNamePrefixMap, PrefixData, CSSProperty } from './index'
 
 const cssFeatureString = (feature: string): string => {
   return feature.replace(/([A-Z]+)/g, '-$1').slice(1).toLowerCase().replace(/[-_]+/g, '-')
@@ -47,15 +47,26 @@ const cssPrefixMap = (entries: PrefixData[]) => {
   }).join('\n')}\n\t}`
 }
 
+const cssNamePrefixTable = (names: Record<string, PrefixData[]>) => {
+  return Object.keys(names).sort().map(name => `\t"${name}": ${cssPrefixMap(names[name])},`).join('\n')
+}
+
 const generatedByComment = `// This file was automatically generated by "css_table.ts"`
 
-export const generateTableForCSS = (map: VersionRangeMap<CSSFeature>, prefixes: CSSPrefixMap): void => {
+export const generateTableForCSS = (map: VersionRangeMap<CSSFeature>, prefixes: CSSPrefixMap, namePrefixes: CSSNamePrefixMap): void => {
   const prefixNames = new Set<string>()
   for (const property in prefixes) {
     for (const { prefix } of prefixes[property as CSSProperty]!) {
       prefixNames.add(cssPrefixName(prefix))
     }
   }
+  for (const names of [namePrefixes.Values, namePrefixes.Selectors, namePrefixes.AtRules]) {
+    for (const name in names) {
+      for (const { prefix } of names[name]) {
+        prefixNames.add(cssPrefixName(prefix))
+      }
+    }
+  }
 
   fs.writeFileSync(__dirname + '/../internal/compat/css_table.go',
     `${generatedByComment}
@@ -66,7 +77,7 @@ import (
 \t"github.com/evanw/esbuild/internal/css_ast"
 )
 
-type CSSFeature uint16
+type CSSFeature uint32
 
 const (
 ${Object.keys(map).sort().map((feature, i) => `\t${feature}${i ? '' : ' CSSFeature = 1 << iota'}`).join('\n')}
@@ -131,28 +142,84 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 ${Object.keys(prefixes).sort().map(property => `\tcss_ast.${property}: ${cssPrefixMap(prefixes[property as CSSProperty]!)},`).join('\n')}
 }
 
-func CSSPrefixData(constraints map[Engine]Semver) (entries map[css_ast.D]CSSPrefix) {
-\tfor property, items := range cssPrefixTable {
-\t\tprefixes := NoPrefix
-\t\tfor engine, version := range constraints {
-\t\t\tif !engine.IsBrowser() {
-\t\t\t\t// Specifying "--target=es2020" shouldn't affect CSS
-\t\t\t\tcontinue
-\t\t\t}
-\t\t\tfor _, item := range items {
-\t\t\t\tif item.engine == engine && (item.withoutPrefix == v{} || compareVersions(item.withoutPrefix, version) > 0) {
-\t\t\t\t\tprefixes |= item.prefix
-\t\t\t\t}
-\t\t\t}
+// Prefixed values such as "-webkit-image-set()", keyed by the unprefixed name
+var cssValuePrefixTable = map[string][]prefixData{
+${cssNamePrefixTable(namePrefixes.Values)}
+}
+
+// Prefixed pseudo-classes and pseudo-elements such as "::-moz-placeholder",
+// keyed by the unprefixed name
+var cssSelectorPrefixTable = map[string][]prefixData{
+${cssNamePrefixTable(namePrefixes.Selectors)}
+}
+
+// Prefixed at-rules such as "@-webkit-keyframes", keyed by the unprefixed name
+var cssAtRulePrefixTable = map[string][]prefixData{
+${cssNamePrefixTable(namePrefixes.AtRules)}
+}
+
+func hasBrowserConstraint(constraints map[Engine]Semver) bool {
+\tfor engine := range constraints {
+\t\t// Specifying "--target=es2020" shouldn't affect CSS
+\t\tif engine.IsBrowser() {
+\t\t\treturn true
+\t\t}
+\t}
+\treturn false
+}
+
+func prefixesForConstraints(items []prefixData, constraints map[Engine]Semver) (prefixes CSSPrefix) {
+\tfor engine, version := range constraints {
+\t\tif !engine.IsBrowser() {
+\t\t\t// Specifying "--target=es2020" shouldn't affect CSS
+\t\t\tcontinue
 \t\t}
-\t\tif prefixes != NoPrefix {
-\t\t\tif entries == nil {
-\t\t\t\tentries = make(map[css_ast.D]CSSPrefix)
+\t\tfor _, item := range items {
+\t\t\tif item.engine == engine && (item.withoutPrefix == v{} || compareVersions(item.withoutPrefix, version) > 0) {
+\t\t\t\tprefixes |= item.prefix
 \t\t\t}
-\t\t\tentries[property] = prefixes
 \t\t}
 \t}
 \treturn
 }
+
+// Every property in the table has an entry as long as there is at least one
+// browser target. An entry of "NoPrefix" means the property is known to not
+// need any prefixes, so existing prefixed declarations can be removed.
+func CSSPrefixData(constraints map[Engine]Semver) (entries map[css_ast.D]CSSPrefix) {
+\tif !hasBrowserConstraint(constraints) {
+\t\treturn
+\t}
+\tentries = make(map[css_ast.D]CSSPrefix, len(cssPrefixTable))
+\tfor property, items := range cssPrefixTable {
+\t\tentries[property] = prefixesForConstraints(items, constraints)
+\t}
+\treturn
+}
+
+type CSSNamePrefixes struct {
+\tValues    map[string]CSSPrefix
+\tSelectors map[string]CSSPrefix
+\tAtRules   map[string]CSSPrefix
+}
+
+func namePrefixData(table map[string][]prefixData, constraints map[Engine]Semver) map[string]CSSPrefix {
+\tentries := make(map[string]CSSPrefix, len(table))
+\tfor name, items := range table {
+\t\tentries[name] = prefixesForConstraints(items, constraints)
+\t}
+\treturn entries
+}
+
+// This works like "CSSPrefixData" but for things other than property names
+func CSSNamePrefixData(constraints map[Engine]Semver) (data CSSNamePrefixes) {
+\tif !hasBrowserConstraint(constraints) {
+\t\treturn
+\t}
+\tdata.Values = namePrefixData(cssValuePrefixTable, constraints)
+\tdata.Selectors = namePrefixData(cssSelectorPrefixTable, constraints)
+\tdata.AtRules = namePrefixData(cssAtRulePrefixTable, constraints)
+\treturn
+}
 `)
 }
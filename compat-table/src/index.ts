@@ -6,6 +6,7 @@ import fs = require('fs')
 import path = require('path')
 import { generateTableForJS } from './js_table'
 import { generateTableForCSS } from './css_table'
+import { builtinInfo, generateTableForBuiltins } from './builtin_table'
 import * as caniuse from './caniuse'
 import * as mdn from './mdn'
 
@@ -92,7 +93,9 @@ export const jsFeatures = {
 
 export type CSSFeature = keyof typeof cssFeatures
 export const cssFeatures = {
+  CascadeLayers: true,
   ColorFunctions: true,
+  ColorMix: true,
   GradientDoublePosition: true,
   GradientInterpolation: true,
   GradientMidpoints: true,
@@ -101,19 +104,108 @@ export const cssFeatures = {
   InlineStyle: true,
   InsetProperty: true,
   IsPseudoClass: true,
+  LightDark: true,
+  LogicalProperties: true,
+  LogicalValues: true,
   MediaRange: true,
   Modern_RGB_HSL: true,
   Nesting: true,
   RebeccaPurple: true,
+  RelativeColors: true,
+}
+
+export type Builtin = keyof typeof builtins
+export const builtins = {
+  ArrayFrom: true,
+  ArrayOf: true,
+  ArrayPrototypeAt: true,
+  ArrayPrototypeFind: true,
+  ArrayPrototypeFindIndex: true,
+  ArrayPrototypeFindLast: true,
+  ArrayPrototypeFindLastIndex: true,
+  ArrayPrototypeFlat: true,
+  ArrayPrototypeFlatMap: true,
+  ArrayPrototypeIncludes: true,
+  ArrayPrototypeToReversed: true,
+  ArrayPrototypeToSorted: true,
+  ArrayPrototypeToSpliced: true,
+  ArrayPrototypeWith: true,
+  GlobalMap: true,
+  GlobalPromise: true,
+  GlobalQueueMicrotask: true,
+  GlobalSet: true,
+  GlobalStructuredClone: true,
+  GlobalSymbol: true,
+  GlobalThis: true,
+  GlobalWeakMap: true,
+  GlobalWeakSet: true,
+  MapGroupBy: true,
+  ObjectAssign: true,
+  ObjectEntries: true,
+  ObjectFromEntries: true,
+  ObjectGroupBy: true,
+  ObjectHasOwn: true,
+  ObjectValues: true,
+  PromiseAllSettled: true,
+  PromiseAny: true,
+  PromisePrototypeFinally: true,
+  PromiseWithResolvers: true,
+  StringPrototypeAt: true,
+  StringPrototypeEndsWith: true,
+  StringPrototypeIncludes: true,
+  StringPrototypeIsWellFormed: true,
+  StringPrototypeMatchAll: true,
+  StringPrototypePadEnd: true,
+  StringPrototypePadStart: true,
+  StringPrototypeReplaceAll: true,
+  StringPrototypeStartsWith: true,
+  StringPrototypeToWellFormed: true,
+  StringPrototypeTrimEnd: true,
+  StringPrototypeTrimStart: true,
 }
 
 export type CSSProperty = keyof typeof cssProperties
 export const cssProperties = {
+  DAlignContent: true,
+  DAlignItems: true,
+  DAlignSelf: true,
+  DAnimation: true,
+  DAnimationDelay: true,
+  DAnimationDirection: true,
+  DAnimationDuration: true,
+  DAnimationFillMode: true,
+  DAnimationIterationCount: true,
+  DAnimationName: true,
+  DAnimationPlayState: true,
+  DAnimationTimingFunction: true,
   DAppearance: true,
   DBackdropFilter: true,
+  DBackfaceVisibility: true,
   DBackgroundClip: true,
+  DBorderRadius: true,
   DBoxDecorationBreak: true,
+  DBoxShadow: true,
+  DBoxSizing: true,
   DClipPath: true,
+  DColumnCount: true,
+  DColumnFill: true,
+  DColumnGap: true,
+  DColumnRule: true,
+  DColumnRuleColor: true,
+  DColumnRuleStyle: true,
+  DColumnRuleWidth: true,
+  DColumnSpan: true,
+  DColumnWidth: true,
+  DColumns: true,
+  DFilter: true,
+  DFlex: true,
+  DFlexBasis: true,
+  DFlexDirection: true,
+  DFlexFlow: true,
+  DFlexGrow: true,
+  DFlexShrink: true,
+  DFlexWrap: true,
+  DFontFeatureSettings: true,
   DFontKerning: true,
   DHeight: true,
   DHyphens: true,
@@ -129,21 +221,41 @@ export const cssProperties = {
   DMaxWidth: true,
   DMinHeight: true,
   DMinWidth: true,
+  DOrder: true,
+  DPerspective: true,
+  DPerspectiveOrigin: true,
   DPosition: true,
   DPrintColorAdjust: true,
   DTabSize: true,
+  DTextAlignLast: true,
   DTextDecorationColor: true,
   DTextDecorationLine: true,
   DTextDecorationSkip: true,
+  DTextDecorationStyle: true,
   DTextEmphasisColor: true,
   DTextEmphasisPosition: true,
   DTextEmphasisStyle: true,
   DTextOrientation: true,
   DTextSizeAdjust: true,
+  DTouchAction: true,
+  DTransform: true,
+  DTransformOrigin: true,
+  DTransformStyle: true,
+  DTransition: true,
+  DTransitionDelay: true,
+  DTransitionDuration: true,
+  DTransitionProperty: true,
+  DTransitionTimingFunction: true,
   DUserSelect: true,
   DWidth: true,
+  DWritingMode: true,
 }
 
+// Prefixes that apply to something other than a property name are keyed by
+// the unprefixed name of that thing instead (e.g. "image-set" for the value
+// "-webkit-image-set()" or "placeholder" for the selector "::-moz-placeholder")
+export type CSSNamePrefixKind = 'Values' | 'Selectors' | 'AtRules'
+
 export interface Support {
   force?: boolean
   passed?: number
@@ -165,6 +277,7 @@ export type SupportMap<F extends string> = Record<F, Partial<Record<Engine, Reco
 export type VersionRangeMap<F extends string> = Partial<Record<F, Partial<Record<Engine, VersionRange[]>>>>
 export type WhyNotMap<F extends string> = Partial<Record<F, Partial<Record<Engine, string[]>>>>
 export type CSSPrefixMap = Partial<Record<CSSProperty, PrefixData[]>>
+export type CSSNamePrefixMap = Record<CSSNamePrefixKind, Record<string, PrefixData[]>>
 
 const compareVersions = (a: number[], b: number[]): number => {
   let diff = a[0] - b[0]
@@ -206,6 +319,19 @@ const mergePrefixMaps = (to: CSSPrefixMap, from: CSSPrefixMap): void => {
   }
 }
 
+const mergeNamePrefixMaps = (to: CSSNamePrefixMap, from: CSSNamePrefixMap): void => {
+  for (const kind in from) {
+    const fromNames = from[kind as CSSNamePrefixKind]
+    const toNames = to[kind as CSSNamePrefixKind]
+    for (const name in fromNames) {
+      if (name in toNames) {
+        throw new Error(`Merge conflict with ${kind}=${name}`)
+      }
+      toNames[name] = fromNames[name]
+    }
+  }
+}
+
 const supportMapToVersionRanges = <F extends string>(supportMap: SupportMap<F>): [VersionRangeMap<F>, WhyNotMap<F>] => {
   const versionRangeMap: VersionRangeMap<F> = {}
   const whyNotMap: WhyNotMap<F> = {}
@@ -557,16 +683,43 @@ import('./compat-table').then(compatTable => {
 
 const css: SupportMap<CSSFeature> = {} as SupportMap<CSSFeature>
 const cssPrefix: CSSPrefixMap = {}
+const cssNamePrefix: CSSNamePrefixMap = { Values: {}, Selectors: {}, AtRules: {} }
 for (const feature in cssFeatures) css[feature as CSSFeature] = {}
 
 mergeSupportMaps(css, caniuse.css)
 mergeSupportMaps(css, mdn.css)
 mergePrefixMaps(cssPrefix, caniuse.cssPrefix)
 mergePrefixMaps(cssPrefix, mdn.cssPrefix)
+mergeNamePrefixMaps(cssNamePrefix, mdn.cssNamePrefix)
+
+// The 2009 flexbox syntax ("display: -webkit-box") is a separate legacy
+// specification that isn't tracked by any of our data sources. It's keyed as
+// "box" here and is only used when generating fallbacks for "display: flex".
+cssNamePrefix.Values['box'] = [
+  { engine: 'Chrome', prefix: 'webkit', withoutPrefix: [21] },
+  { engine: 'Firefox', prefix: 'moz', withoutPrefix: [22] },
+  { engine: 'IOS', prefix: 'webkit', withoutPrefix: [7] },
+  { engine: 'Safari', prefix: 'webkit', withoutPrefix: [6, 1] },
+]
 
 // MDN data is wrong here, Firefox still had gradient interpolation rendering
 // bugs until version 137: https://bugzilla.mozilla.org/show_bug.cgi?id=1904106
 css.GradientInterpolation.Firefox = { 137: { force: true } }
 
 const [cssVersionRanges] = supportMapToVersionRanges(css)
-generateTableForCSS(cssVersionRanges, cssPrefix)
+generateTableForCSS(cssVersionRanges, cssPrefix, cssNamePrefix)
+
+const builtin: SupportMap<Builtin> = {} as SupportMap<Builtin>
+for (const name in builtins) builtin[name as Builtin] = {}
+
+mergeSupportMaps(builtin, mdn.builtin)
+
+// Built-ins that are part of the language specification are supported by the
+// "es*" target for the version of the specification that added them
+for (const name in builtinInfo) {
+  const { es } = builtinInfo[name as Builtin]
+  if (es) builtin[name as Builtin].ES = { [es]: { force: true } }
+}
+
+const [builtinVersionRanges] = supportMapToVersionRanges(builtin)
+generateTableForBuiltins(builtinVersionRanges)
@@ -1,7 +1,7 @@
 // This file processes data from https://developer.mozilla.org/en-US/docs/Web
 
 import bcd, { BrowserName, SimpleSupportStatement, SupportBlock } from '@mdn/browser-compat-data'
-import { CSSFeature, CSSPrefixMap, CSSProperty, Engine, JSFeature, PrefixData, Support, SupportMap } from './index'
+import { Builtin, CSSFeature, CSSNamePrefixKind, CSSNamePrefixMap, CSSPrefixMap, CSSProperty, Engine, JSFeature, PrefixData, Support, SupportMap } from './index'
 
 const supportedEnvironments: Record<string, Engine> = {
   chrome: 'Chrome',
@@ -27,6 +27,7 @@ const jsFeatures: Partial<Record<JSFeature, string>> = {
 }
 
 const cssFeatures: Partial<Record<CSSFeature, string | string[]>> = {
+  CascadeLayers: 'css.at-rules.layer',
   ColorFunctions: [
     'css.types.color.color',
     'css.types.color.lab',
@@ -34,6 +35,7 @@ const cssFeatures: Partial<Record<CSSFeature, string | string[]>> = {
     'css.types.color.oklab',
     'css.types.color.oklch',
   ],
+  ColorMix: 'css.types.color.color-mix',
   GradientDoublePosition: [
     'css.types.gradient.conic-gradient.doubleposition',
     'css.types.gradient.linear-gradient.doubleposition',
@@ -64,6 +66,18 @@ const cssFeatures: Partial<Record<CSSFeature, string | string[]>> = {
   HexRGBA: 'css.types.color.rgb_hexadecimal_notation.alpha_hexadecimal_notation',
   HWB: 'css.types.color.hwb',
   InsetProperty: 'css.properties.inset',
+  LightDark: 'css.types.color.light-dark',
+  LogicalProperties: [
+    'css.properties.border-inline',
+    'css.properties.border-start-start-radius',
+    'css.properties.inset-inline',
+    'css.properties.margin-inline',
+    'css.properties.padding-inline',
+  ],
+  LogicalValues: [
+    'css.properties.clear.flow_relative_values',
+    'css.properties.float.flow_relative_values',
+  ],
   Modern_RGB_HSL: [
     'css.types.color.hsl.alpha_parameter',
     'css.types.color.hsl.space_separated_parameters',
@@ -73,6 +87,56 @@ const cssFeatures: Partial<Record<CSSFeature, string | string[]>> = {
   ],
   Nesting: 'css.selectors.nesting',
   RebeccaPurple: 'css.types.color.named-color.rebeccapurple',
+  RelativeColors: 'css.types.color.relative_syntax',
+}
+
+const builtinFeatures: Record<Builtin, string> = {
+  ArrayFrom: 'javascript.builtins.Array.from',
+  ArrayOf: 'javascript.builtins.Array.of',
+  ArrayPrototypeAt: 'javascript.builtins.Array.at',
+  ArrayPrototypeFind: 'javascript.builtins.Array.find',
+  ArrayPrototypeFindIndex: 'javascript.builtins.Array.findIndex',
+  ArrayPrototypeFindLast: 'javascript.builtins.Array.findLast',
+  ArrayPrototypeFindLastIndex: 'javascript.builtins.Array.findLastIndex',
+  ArrayPrototypeFlat: 'javascript.builtins.Array.flat',
+  ArrayPrototypeFlatMap: 'javascript.builtins.Array.flatMap',
+  ArrayPrototypeIncludes: 'javascript.builtins.Array.includes',
+  ArrayPrototypeToReversed: 'javascript.builtins.Array.toReversed',
+  ArrayPrototypeToSorted: 'javascript.builtins.Array.toSorted',
+  ArrayPrototypeToSpliced: 'javascript.builtins.Array.toSpliced',
+  ArrayPrototypeWith: 'javascript.builtins.Array.with',
+  GlobalMap: 'javascript.builtins.Map',
+  GlobalPromise: 'javascript.builtins.Promise',
+  GlobalQueueMicrotask: 'api.queueMicrotask',
+  GlobalSet: 'javascript.builtins.Set',
+  GlobalStructuredClone: 'api.structuredClone',
+  GlobalSymbol: 'javascript.builtins.Symbol',
+  GlobalThis: 'javascript.builtins.globalThis',
+  GlobalWeakMap: 'javascript.builtins.WeakMap',
+  GlobalWeakSet: 'javascript.builtins.WeakSet',
+  MapGroupBy: 'javascript.builtins.Map.groupBy',
+  ObjectAssign: 'javascript.builtins.Object.assign',
+  ObjectEntries: 'javascript.builtins.Object.entries',
+  ObjectFromEntries: 'javascript.builtins.Object.fromEntries',
+  ObjectGroupBy: 'javascript.builtins.Object.groupBy',
+  ObjectHasOwn: 'javascript.builtins.Object.hasOwn',
+  ObjectValues: 'javascript.builtins.Object.values',
+  PromiseAllSettled: 'javascript.builtins.Promise.allSettled',
+  PromiseAny: 'javascript.builtins.Promise.any',
+  PromisePrototypeFinally: 'javascript.builtins.Promise.finally',
+  PromiseWithResolvers: 'javascript.builtins.Promise.withResolvers',
+  StringPrototypeAt: 'javascript.builtins.String.at',
+  StringPrototypeEndsWith: 'javascript.builtins.String.endsWith',
+  StringPrototypeIncludes: 'javascript.builtins.String.includes',
+  StringPrototypeIsWellFormed: 'javascript.builtins.String.isWellFormed',
+  StringPrototypeMatchAll: 'javascript.builtins.String.matchAll',
+  StringPrototypePadEnd: 'javascript.builtins.String.padEnd',
+  StringPrototypePadStart: 'javascript.builtins.String.padStart',
+  StringPrototypeReplaceAll: 'javascript.builtins.String.replaceAll',
+  StringPrototypeStartsWith: 'javascript.builtins.String.startsWith',
+  StringPrototypeToWellFormed: 'javascript.builtins.String.toWellFormed',
+  StringPrototypeTrimEnd: 'javascript.builtins.String.trimEnd',
+  StringPrototypeTrimStart: 'javascript.builtins.String.trimStart',
 }
 
 const similarPrefixedProperty: Record<string, { prefix: string, property: string }> = {
@@ -83,6 +147,41 @@ const similarPrefixedProperty: Record<string, { prefix: string, property: string
 }
 
 const cssPrefixFeatures: Record<string, CSSProperty> = {
+  'css.properties.align-content': 'DAlignContent',
+  'css.properties.align-items': 'DAlignItems',
+  'css.properties.align-self': 'DAlignSelf',
+  'css.properties.animation': 'DAnimation',
+  'css.properties.animation-delay': 'DAnimationDelay',
+  'css.properties.animation-direction': 'DAnimationDirection',
+  'css.properties.animation-duration': 'DAnimationDuration',
+  'css.properties.animation-fill-mode': 'DAnimationFillMode',
+  'css.properties.animation-iteration-count': 'DAnimationIterationCount',
+  'css.properties.animation-name': 'DAnimationName',
+  'css.properties.animation-play-state': 'DAnimationPlayState',
+  'css.properties.animation-timing-function': 'DAnimationTimingFunction',
+  'css.properties.backface-visibility': 'DBackfaceVisibility',
+  'css.properties.border-radius': 'DBorderRadius',
+  'css.properties.box-shadow': 'DBoxShadow',
+  'css.properties.box-sizing': 'DBoxSizing',
+  'css.properties.column-count': 'DColumnCount',
+  'css.properties.column-fill': 'DColumnFill',
+  'css.properties.column-gap.multicol_context': 'DColumnGap',
+  'css.properties.column-rule': 'DColumnRule',
+  'css.properties.column-rule-color': 'DColumnRuleColor',
+  'css.properties.column-rule-style': 'DColumnRuleStyle',
+  'css.properties.column-rule-width': 'DColumnRuleWidth',
+  'css.properties.column-span': 'DColumnSpan',
+  'css.properties.column-width': 'DColumnWidth',
+  'css.properties.columns': 'DColumns',
+  'css.properties.filter': 'DFilter',
+  'css.properties.flex': 'DFlex',
+  'css.properties.flex-basis': 'DFlexBasis',
+  'css.properties.flex-direction': 'DFlexDirection',
+  'css.properties.flex-flow': 'DFlexFlow',
+  'css.properties.flex-grow': 'DFlexGrow',
+  'css.properties.flex-shrink': 'DFlexShrink',
+  'css.properties.flex-wrap': 'DFlexWrap',
+  'css.properties.font-feature-settings': 'DFontFeatureSettings',
   'css.properties.height.stretch': 'DHeight',
   'css.properties.mask': 'DMask',
   'css.properties.mask-composite': 'DMaskComposite',
@@ -95,24 +194,94 @@ const cssPrefixFeatures: Record<string, CSSProperty> = {
   'css.properties.max-width.stretch': 'DMaxWidth',
   'css.properties.min-height.stretch': 'DMinHeight',
   'css.properties.min-width.stretch': 'DMinWidth',
+  'css.properties.order': 'DOrder',
+  'css.properties.perspective': 'DPerspective',
+  'css.properties.perspective-origin': 'DPerspectiveOrigin',
+  'css.properties.text-align-last': 'DTextAlignLast',
   'css.properties.text-decoration-color': 'DTextDecorationColor',
   'css.properties.text-decoration-line': 'DTextDecorationLine',
   'css.properties.text-decoration-skip': 'DTextDecorationSkip',
+  'css.properties.text-decoration-style': 'DTextDecorationStyle',
   'css.properties.text-emphasis-color': 'DTextEmphasisColor',
   'css.properties.text-emphasis-position': 'DTextEmphasisPosition',
   'css.properties.text-emphasis-style': 'DTextEmphasisStyle',
+  'css.properties.touch-action': 'DTouchAction',
+  'css.properties.transform': 'DTransform',
+  'css.properties.transform-origin': 'DTransformOrigin',
+  'css.properties.transform-style': 'DTransformStyle',
+  'css.properties.transition': 'DTransition',
+  'css.properties.transition-delay': 'DTransitionDelay',
+  'css.properties.transition-duration': 'DTransitionDuration',
+  'css.properties.transition-property': 'DTransitionProperty',
+  'css.properties.transition-timing-function': 'DTransitionTimingFunction',
   'css.properties.user-select': 'DUserSelect',
   'css.properties.width.stretch': 'DWidth',
+  'css.properties.writing-mode': 'DWritingMode',
+}
+
+const cssNamePrefixFeatures: Record<CSSNamePrefixKind, Record<string, string>> = {
+  Values: {
+    'css.properties.cursor.grab': 'grab',
+    'css.properties.cursor.grabbing': 'grabbing',
+    'css.properties.cursor.zoom-in': 'zoom-in',
+    'css.properties.cursor.zoom-out': 'zoom-out',
+    'css.properties.display.flex': 'flex',
+    'css.properties.display.inline-flex': 'inline-flex',
+    'css.properties.width.fit-content': 'fit-content',
+    'css.properties.width.max-content': 'max-content',
+    'css.properties.width.min-content': 'min-content',
+    'css.types.calc': 'calc',
+    'css.types.image.cross-fade': 'cross-fade',
+    'css.types.image.element': 'element',
+    'css.types.image.gradient.linear-gradient': 'linear-gradient',
+    'css.types.image.gradient.radial-gradient': 'radial-gradient',
+    'css.types.image.gradient.repeating-linear-gradient': 'repeating-linear-gradient',
+    'css.types.image.gradient.repeating-radial-gradient': 'repeating-radial-gradient',
+    'css.types.image.image-set': 'image-set',
+  },
+  Selectors: {
+    'css.selectors.any-link': 'any-link',
+    'css.selectors.autofill': 'autofill',
+    'css.selectors.backdrop': 'backdrop',
+    'css.selectors.file-selector-button': 'file-selector-button',
+    'css.selectors.fullscreen': 'fullscreen',
+    'css.selectors.placeholder': 'placeholder',
+    'css.selectors.read-only': 'read-only',
+    'css.selectors.read-write': 'read-write',
+    'css.selectors.selection': 'selection',
+  },
+  AtRules: {
+    'css.at-rules.keyframes': 'keyframes',
+  },
 }
 
 const alternativeNameToPrefix: Record<string, string> = {
   '-webkit-fill-available': '-webkit-',
   '-moz-available': '-moz-',
+  '-ms-flexbox': '-ms-',
+  '-ms-inline-flexbox': '-ms-',
+  '-ms-flex-align': '-ms-',
+  '-ms-flex-item-align': '-ms-',
+  '-ms-flex-line-pack': '-ms-',
+  '-ms-flex-negative': '-ms-',
+  '-ms-flex-order': '-ms-',
+  '-ms-flex-pack': '-ms-',
+  '-ms-flex-positive': '-ms-',
+  '-ms-flex-preferred-size': '-ms-',
+  '-ms-fullscreen': '-ms-',
+  '-ms-input-placeholder': '-ms-',
+  '-moz-full-screen': '-moz-',
+  '-moz-placeholder': '-moz-',
+  '-webkit-file-upload-button': '-webkit-',
+  '-webkit-full-screen': '-webkit-',
+  '-webkit-input-placeholder': '-webkit-',
 }
 
 export const js: SupportMap<JSFeature> = {} as SupportMap<JSFeature>
 export const css: SupportMap<CSSFeature> = {} as SupportMap<CSSFeature>
+export const builtin: SupportMap<Builtin> = {} as SupportMap<Builtin>
 export const cssPrefix: CSSPrefixMap = {}
+export const cssNamePrefix: CSSNamePrefixMap = { Values: {}, Selectors: {}, AtRules: {} }
 
 const isSemver = /^\d+(?:\.\d+(?:\.\d+)?)?$/
 
@@ -181,8 +350,9 @@ const addFeatures = <F extends string>(map: SupportMap<F>, features: Partial<Rec
 
 addFeatures(js, jsFeatures)
 addFeatures(css, cssFeatures)
+addFeatures(builtin, builtinFeatures)
 
-for (const fullKey in cssPrefixFeatures) {
+const prefixDataForKey = (fullKey: string): PrefixData[] => {
   const prefixData: PrefixData[] = []
   const support: SupportBlock = extractProperty(bcd, fullKey).__compat.support
 
@@ -280,5 +450,16 @@ for (const fullKey in cssPrefixFeatures) {
     }
   }
 
-  cssPrefix[cssPrefixFeatures[fullKey]] = prefixData
+  return prefixData
+}
+
+for (const fullKey in cssPrefixFeatures) {
+  cssPrefix[cssPrefixFeatures[fullKey]] = prefixDataForKey(fullKey)
+}
+
+for (const kind in cssNamePrefixFeatures) {
+  const features = cssNamePrefixFeatures[kind as CSSNamePrefixKind]
+  for (const fullKey in features) {
+    cssNamePrefix[kind as CSSNamePrefixKind][features[fullKey]] = prefixDataForKey(fullKey)
+  }
 }
@@ -77,6 +77,10 @@ type Bundle struct {
 	files       []scannerFile
 	entryPoints []graph.EntryPoint
 	options     config.Options
+
+	// This is false if any file failed to parse. Files that only had errors
+	// that the parser recovered from still count as having been parsed.
+	allFilesWereParsed bool
 }
 
 type parseArgs struct {
@@ -243,6 +247,15 @@ func parseFile(args parseArgs) {
 		source.Contents = ""
 	}
 
+	// The "auto-dataurl" loader inlines small files and emits larger files
+	if loader == config.LoaderAutoDataURL {
+		if len(source.Contents) < args.options.AssetInlineLimit {
+			loader = config.LoaderDataURL
+		} else {
+			loader = config.LoaderFile
+		}
+	}
+
 	result := parseResult{
 		file: scannerFile{
 			inputFile: graph.InputFile{
@@ -1339,7 +1352,13 @@ type scanner struct {
 	options config.Options
 
 	// Also not guarded by a mutex for the same reason
-	remaining int
+	remaining   int
+	hasFailures bool
+
+	// These are the source indices of files that belong to the polyfill package
+	// or to a package that it imports. Polyfills aren't added to these files
+	// since that could include the code for the polyfills themselves.
+	polyfillSources map[uint32]bool
 }
 
 type visitedFile struct {
@@ -1417,6 +1436,7 @@ func ScanBundle(
 		timer:           timer,
 		results:         make([]parseResult, 0, caches.SourceIndexCache.LenHint()),
 		visited:         make(map[logger.Path]visitedFile),
+		polyfillSources: make(map[uint32]bool),
 		resultChannel:   make(chan parseResult),
 		uniqueKeyPrefix: uniqueKeyPrefix,
 	}
@@ -1519,15 +1539,22 @@ func ScanBundle(
 	}
 
 	return Bundle{
-		fs:              fs,
-		res:             s.res,
-		files:           files,
-		entryPoints:     entryPointMeta,
-		uniqueKeyPrefix: uniqueKeyPrefix,
-		options:         s.options,
+		fs:                 fs,
+		res:                s.res,
+		files:              files,
+		entryPoints:        entryPointMeta,
+		uniqueKeyPrefix:    uniqueKeyPrefix,
+		options:            s.options,
+		allFilesWereParsed: !s.hasFailures,
 	}
 }
 
+// This is used by the transform API's error recovery mode to decide whether
+// code can still be generated even though errors were logged during the scan
+func (b *Bundle) AllFilesWereParsed() bool {
+	return b.allFilesWereParsed
+}
+
 type inputKind uint8
 
 const (
@@ -1597,6 +1624,16 @@ func (s *scanner) maybeParseFile(
 		optionsClone.ModuleTypeData.Type = js_ast.ModuleUnknown
 	}
 
+	// Don't add polyfills to the polyfill package or to its dependencies. Other
+	// code inside "node_modules" still gets polyfills since it may also use
+	// built-ins that are missing from the target environment.
+	if optionsClone.Polyfill != "" && helpers.IsInsideNodeModules(path.Text) &&
+		(helpers.IsInsidePackage(path.Text, optionsClone.Polyfill) ||
+			(importSource != nil && s.polyfillSources[importSource.Index])) {
+		s.polyfillSources[visited.sourceIndex] = true
+		optionsClone.Polyfill = ""
+	}
+
 	// Enable bundling for injected files so we always do tree shaking. We
 	// never want to include unnecessary code from injected files since they
 	// are essentially bundled. However, if we do this we should skip the
@@ -2186,6 +2223,7 @@ func (s *scanner) scanAllDependencies() {
 		result := <-s.resultChannel
 		s.remaining--
 		if !result.ok {
+			s.hasFailures = true
 			continue
 		}
 
@@ -2420,6 +2458,10 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 		s.options.MetafileFormat = config.MinifiedMetafile
 	}
 
+	// This must happen before the loop below, which warns about imports of
+	// files without side effects
+	inferredSideEffects := s.inferSideEffects(entryPointSourceIndexToMetaIndex)
+
 	// Now that all files have been scanned, process the final file import records
 	for sourceIndex, result := range s.results {
 		if !result.ok {
@@ -2657,7 +2699,11 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 
 						// Do not warn if this has no side effects because the parsed AST
 						// is empty. This is the case for ".d.ts" files, for example.
-						otherModule.SideEffects.Kind != graph.NoSideEffects_EmptyAST {
+						otherModule.SideEffects.Kind != graph.NoSideEffects_EmptyAST &&
+
+						// Do not warn if we proved that this has no side effects, since
+						// removing the import then doesn't change anything.
+						otherModule.SideEffects.Kind != graph.NoSideEffects_Inferred {
 
 						var notes []logger.MsgData
 						var by string
@@ -2712,6 +2758,9 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 				}
 				sb.WriteString(s.options.MetafileFormat.MaybeRemoveWhitespace("\n      }"))
 			}
+			if hasSideEffects, ok := inferredSideEffects[uint32(sourceIndex)]; ok {
+				sb.WriteString(fmt.Sprintf(s.options.MetafileFormat.MaybeRemoveWhitespace(",\n      \"inferredSideEffects\": %t"), hasSideEffects))
+			}
 			sb.WriteString(s.options.MetafileFormat.MaybeRemoveWhitespace("\n    }"))
 		}
 
@@ -2830,6 +2879,279 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 	return files
 }
 
+// This applies the "packageSideEffects" setting and then, if enabled, infers
+// "sideEffects: false" for files in packages without a "sideEffects" field.
+// The returned map contains an entry for each file that was analyzed, which
+// is true if that file was found to have side effects.
+func (s *scanner) inferSideEffects(entryPointSourceIndexToMetaIndex map[uint32]uint32) map[uint32]bool {
+	if s.options.Mode != config.ModeBundle || (!s.options.InferSideEffects && len(s.options.PackageSideEffects) == 0) {
+		return nil
+	}
+
+	// Package information comes from the resolver, so collect it from the import
+	// records of every file. All import records for the same file are resolved
+	// relative to the same "package.json" file.
+	packages := make(map[uint32]*resolver.ResolveResult)
+	for _, result := range s.results {
+		if !result.ok {
+			continue
+		}
+		if recordsPtr := result.file.inputFile.Repr.ImportRecords(); recordsPtr != nil {
+			for importRecordIndex, record := range *recordsPtr {
+				if resolveResult := result.resolveResults[importRecordIndex]; resolveResult != nil && record.SourceIndex.IsValid() {
+					packages[record.SourceIndex.GetIndex()] = resolveResult
+				}
+			}
+		}
+	}
+
+	// Apply per-package overrides first since they take precedence over both
+	// "package.json" and inference
+	candidates := make(map[uint32]bool)
+	for sourceIndex, resolveResult := range packages {
+		file := &s.results[sourceIndex].file.inputFile
+		if hasSideEffects, ok := s.options.PackageSideEffects[resolveResult.PackageName]; ok && resolveResult.PackageName != "" {
+			if hasSideEffects {
+				if file.SideEffects.Kind == graph.NoSideEffects_PackageJSON {
+					file.SideEffects = graph.SideEffects{Kind: graph.HasSideEffects}
+				}
+			} else if file.SideEffects.Kind == graph.HasSideEffects {
+				file.SideEffects = graph.SideEffects{Kind: graph.NoSideEffects_PackageOverride}
+			}
+			continue
+		}
+
+		// Only infer side effects for files in third-party packages that don't
+		// say anything about side effects themselves
+		if !s.options.InferSideEffects || resolveResult.PackageHasSideEffectsField || file.SideEffects.Kind != graph.HasSideEffects ||
+			file.Source.KeyPath.Namespace != "file" || !helpers.IsInsideNodeModules(file.Source.KeyPath.Text) {
+			continue
+		}
+		if _, ok := entryPointSourceIndexToMetaIndex[sourceIndex]; ok {
+			continue
+		}
+		if repr, ok := file.Repr.(*graph.JSRepr); ok && !repr.CSSSourceIndex.IsValid() {
+			candidates[sourceIndex] = jsFileHasLocalSideEffects(&repr.AST, s.options.AssumePure)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// A file only has no side effects if everything it imports also has no side
+	// effects. Keep propagating side effects backward through the import graph
+	// until nothing changes. Import cycles are assumed to not have side effects
+	// unless something in the cycle does.
+	hasNoSideEffects := func(sourceIndex uint32) bool {
+		if hasSideEffects, ok := candidates[sourceIndex]; ok {
+			return !hasSideEffects
+		}
+		return s.results[sourceIndex].file.inputFile.SideEffects.Kind != graph.HasSideEffects
+	}
+	for changed := true; changed; {
+		changed = false
+		for sourceIndex, hasSideEffects := range candidates {
+			if hasSideEffects {
+				continue
+			}
+			result := &s.results[sourceIndex]
+			for importRecordIndex, record := range *result.file.inputFile.Repr.ImportRecords() {
+				if record.Kind != ast.ImportStmt || record.Flags.Has(ast.IsUnused) {
+					continue
+				}
+				isPure := record.Flags.Has(ast.IsExternalWithoutSideEffects)
+				if record.SourceIndex.IsValid() {
+					isPure = hasNoSideEffects(record.SourceIndex.GetIndex())
+
+					// The secondary path may be substituted for the primary path later on
+					if resolveResult := result.resolveResults[importRecordIndex]; isPure && resolveResult != nil && resolveResult.PathPair.HasSecondary() {
+						secondaryKey := resolveResult.PathPair.Secondary
+						if secondaryKey.Namespace == "file" {
+							secondaryKey.Text = canonicalFileSystemPathForWindows(secondaryKey.Text)
+						}
+						if secondaryVisited, ok := s.visited[secondaryKey]; ok {
+							isPure = hasNoSideEffects(secondaryVisited.sourceIndex)
+						}
+					}
+				}
+				if !isPure {
+					candidates[sourceIndex] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	for sourceIndex, hasSideEffects := range candidates {
+		if !hasSideEffects {
+			s.results[sourceIndex].file.inputFile.SideEffects = graph.SideEffects{Kind: graph.NoSideEffects_Inferred}
+		}
+	}
+	return candidates
+}
+
+// Returns false if evaluating the top-level code of this file has no side
+// effects that can be observed from outside of this file, not counting the
+// side effects of any imported files.
+func jsFileHasLocalSideEffects(tree *js_ast.AST, assumePure js_ast.AssumePure) bool {
+	if tree.ExportsKind == js_ast.ExportsCommonJS || tree.UsesExportsRef || tree.UsesModuleRef ||
+		tree.HasLazyExport || tree.LiveTopLevelAwaitKeyword.Len > 0 {
+		return true
+	}
+	astHelpers := js_ast.MakeHelperContext(func(ref ast.Ref) bool {
+		return tree.Symbols[ref.InnerIndex].Kind == ast.SymbolUnbound
+	}, assumePure)
+
+	// Mutating a fresh object declared at the top level of this file can only
+	// be observed by something that imports that object, in which case this
+	// file is included anyway. So these mutations aren't considered to be side
+	// effects. Objects with getters or setters are excluded because running
+	// them may have side effects. So are classes that extend another class and
+	// objects with a custom prototype, since they may inherit getters or setters.
+	localObjects := make(map[ast.Ref]bool)
+	for _, part := range tree.Parts {
+		for _, stmt := range part.Stmts {
+			switch s := stmt.Data.(type) {
+			case *js_ast.SFunction:
+				if s.Fn.Name != nil {
+					localObjects[s.Fn.Name.Ref] = true
+				}
+
+			case *js_ast.SClass:
+				if s.Class.Name != nil && isFreshClassWithoutAccessors(&s.Class) {
+					localObjects[s.Class.Name.Ref] = true
+				}
+
+			case *js_ast.SLocal:
+				for _, decl := range s.Decls {
+					if id, ok := decl.Binding.Data.(*js_ast.BIdentifier); ok && isFreshObjectWithoutAccessors(decl.ValueOrNil) {
+						localObjects[id.Ref] = true
+					}
+				}
+			}
+		}
+	}
+
+	for partIndex, part := range tree.Parts {
+		if uint32(partIndex) == js_ast.NSExportPartIndex || part.CanBeRemovedIfUnused {
+			continue
+		}
+		for _, stmt := range part.Stmts {
+			if astHelpers.StmtsCanBeRemovedIfUnused([]js_ast.Stmt{stmt}, 0) {
+				continue
+			}
+			if s, ok := stmt.Data.(*js_ast.SExpr); ok && exprOnlyMutatesLocalObjects(astHelpers, localObjects, tree.Symbols, s.Value) {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func exprOnlyMutatesLocalObjects(helpers js_ast.HelperContext, localObjects map[ast.Ref]bool, symbols []ast.Symbol, expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EBinary:
+		switch e.Op {
+		case js_ast.BinOpComma:
+			return exprOnlyMutatesLocalObjects(helpers, localObjects, symbols, e.Left) &&
+				exprOnlyMutatesLocalObjects(helpers, localObjects, symbols, e.Right)
+
+		case js_ast.BinOpAssign:
+			// "Foo.bar = 123" or "Foo.prototype.bar = function() {}"
+			return isLocalObjectProperty(localObjects, e.Left) && helpers.ExprCanBeRemovedIfUnused(e.Right)
+		}
+
+	case *js_ast.ECall:
+		// "Object.defineProperty(Foo.prototype, 'bar', { value: 123 })"
+		if dot, ok := e.Target.Data.(*js_ast.EDot); ok && dot.Name == "defineProperty" && len(e.Args) == 3 && e.OptionalChain == js_ast.OptionalChainNone {
+			if id, ok := dot.Target.Data.(*js_ast.EIdentifier); ok && symbols[id.Ref.InnerIndex].Kind == ast.SymbolUnbound && symbols[id.Ref.InnerIndex].OriginalName == "Object" {
+				return isLocalObjectOrPrototype(localObjects, e.Args[0]) && js_ast.IsPrimitiveLiteral(e.Args[1].Data) &&
+					!isBuiltInObjectPropertyKey(e.Args[1]) && helpers.ExprCanBeRemovedIfUnused(e.Args[2])
+			}
+		}
+	}
+
+	return helpers.ExprCanBeRemovedIfUnused(expr)
+}
+
+func isLocalObjectProperty(localObjects map[ast.Ref]bool, expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EDot:
+		return e.OptionalChain == js_ast.OptionalChainNone && !isBuiltInObjectPropertyName(e.Name) &&
+			isLocalObjectOrPrototype(localObjects, e.Target)
+
+	case *js_ast.EIndex:
+		return e.OptionalChain == js_ast.OptionalChainNone && js_ast.IsPrimitiveLiteral(e.Index.Data) &&
+			!isBuiltInObjectPropertyKey(e.Index) && isLocalObjectOrPrototype(localObjects, e.Target)
+	}
+	return false
+}
+
+func isBuiltInObjectPropertyKey(key js_ast.Expr) bool {
+	str, ok := key.Data.(*js_ast.EString)
+	return ok && isBuiltInObjectPropertyName(helpers.UTF16ToString(str.Value))
+}
+
+func isLocalObjectOrPrototype(localObjects map[ast.Ref]bool, expr js_ast.Expr) bool {
+	if dot, ok := expr.Data.(*js_ast.EDot); ok && dot.Name == "prototype" && dot.OptionalChain == js_ast.OptionalChainNone {
+		expr = dot.Target
+	}
+	id, ok := expr.Data.(*js_ast.EIdentifier)
+	return ok && localObjects[id.Ref]
+}
+
+func isFreshObjectWithoutAccessors(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EFunction, *js_ast.EArrow:
+		return true
+
+	case *js_ast.EClass:
+		return isFreshClassWithoutAccessors(&e.Class)
+
+	case *js_ast.EObject:
+		for _, property := range e.Properties {
+			if property.Kind == js_ast.PropertyGetter || property.Kind == js_ast.PropertySetter ||
+				property.Kind == js_ast.PropertySpread {
+				return false
+			}
+
+			// "{ __proto__: base }" sets the prototype of the object
+			if key, ok := property.Key.Data.(*js_ast.EString); ok && helpers.UTF16EqualsString(key.Value, "__proto__") {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isFreshClassWithoutAccessors(class *js_ast.Class) bool {
+	// The base class may have static getters or setters that are inherited
+	if class.ExtendsOrNil.Data != nil {
+		return false
+	}
+	for _, property := range class.Properties {
+		if property.Kind == js_ast.PropertyGetter || property.Kind == js_ast.PropertySetter ||
+			property.Kind == js_ast.PropertyAutoAccessor {
+			return false
+		}
+	}
+	return true
+}
+
+// Assigning to these properties of a function or class doesn't just mutate
+// it. They are either read-only (which throws in strict mode code), inherited
+// accessors that throw, or they change the prototype of the object.
+func isBuiltInObjectPropertyName(name string) bool {
+	switch name {
+	case "name", "length", "prototype", "caller", "arguments", "__proto__":
+		return true
+	}
+	return false
+}
+
 func (s *scanner) iterativelyValidateTLA() bool {
 	pass := uint32(1)
 	hasTLA := false
@@ -3047,7 +3369,7 @@ type Linker func(
 	dataForSourceMaps func() []DataForSourceMap,
 ) []graph.OutputFile
 
-func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[string]interface{}, link Linker) ([]graph.OutputFile, string) {
+func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[string]interface{}, nameCache map[string]interface{}, link Linker) ([]graph.OutputFile, string) {
 	timer.Begin("Compile phase")
 	defer timer.End("Compile phase")
 
@@ -3065,6 +3387,9 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 	)) {
 		cb(mangleCache, cssUsedLocalNames)
 	}
+	options.ExclusiveNameCacheUpdate = func(cb func(nameCache map[string]interface{})) {
+		cb(nameCache)
+	}
 
 	files := make([]graph.InputFile, len(b.files))
 	for i, file := range b.files {
@@ -3089,6 +3414,7 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 		waitGroup := sync.WaitGroup{}
 		resultGroups = make([][]graph.OutputFile, len(b.entryPoints))
 		serializer := helpers.MakeSerializer(len(b.entryPoints))
+		nameCacheSerializer := helpers.MakeSerializer(len(b.entryPoints))
 		for i, entryPoint := range b.entryPoints {
 			waitGroup.Add(1)
 			go func(i int, entryPoint graph.EntryPoint) {
@@ -3106,6 +3432,12 @@ func (b *Bundle) Compile(log logger.Log, timer *helpers.Timer, mangleCache map[s
 					defer serializer.Leave(i)
 					cb(mangleCache, cssUsedLocalNames)
 				}
+				optionsClone.ExclusiveNameCacheUpdate = func(cb func(nameCache map[string]interface{})) {
+					// Serialize all accesses to the name cache in entry point order for determinism
+					nameCacheSerializer.Enter(i)
+					defer nameCacheSerializer.Leave(i)
+					cb(nameCache)
+				}
 
 				resultGroups[i] = link(&optionsClone, forked, log, b.fs, b.res, files, entryPoints,
 					b.uniqueKeyPrefix, findReachableFiles(files, entryPoints), dataForSourceMaps)
@@ -984,6 +984,228 @@ file-2.css: NOTE: The second definition of "zoom" is here:
 	})
 }
 
+func TestImportCSSFromJSICSSValues(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import * as styles from "./styles.module.css"
+				console.log(styles)
+			`,
+			"/styles.module.css": `
+				@value primary, secondary as accent, small from "./colors.module.css";
+				@value pad: 4px;
+				:import("./colors.module.css") { brand: primary }
+				:export { primaryColor: primary; gap: calc(pad + 2px) }
+				.button { color: primary; border: 1px solid accent; padding: pad; background: brand }
+				@media small { .button { padding: calc(pad * 2) } }
+			`,
+			"/colors.module.css": `
+				@value primary: #f00;
+				@value secondary: rgb(0 0 primary);
+				@value small: (max-width: 599px);
+				.primary { color: primary }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".module.css": config.LoaderLocalCSS,
+			},
+		},
+	})
+}
+
+func TestImportCSSFromJSICSSValuesErrors(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import styles from "./styles.module.css"
+				console.log(styles)
+			`,
+			"/styles.module.css": `
+				@value missing from "./values.module.css";
+				@value x from "./global.css";
+				:import("./cycle.module.css") { y: y }
+			`,
+			"/values.module.css": `
+				@value defined: 1px;
+			`,
+			"/global.css": `
+				@value x: 2px;
+			`,
+			"/cycle.module.css": `
+				@value y from "./styles.module.css";
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".module.css": config.LoaderLocalCSS,
+				".css":        config.LoaderCSS,
+			},
+		},
+		expectedCompileLog: `styles.module.css: ERROR: The value "missing" is never exported from "values.module.css"
+styles.module.css: ERROR: The value "x" is never exported from "global.css"
+NOTE: Use the "local-css" loader for "global.css" to enable ICSS values.
+styles.module.css: ERROR: Cannot import the value "y" from "cycle.module.css" because of an import cycle
+`,
+	})
+}
+
+func TestCSSModuleTypes(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.js": `
+				import styles, { button } from "./styles/button.module.css"
+				console.log(styles, button)
+			`,
+			"/src/styles/button.module.css": `
+				@value primary: #f00;
+				:export { primaryColor: primary }
+				.button { color: primary; animation: fade-in 1s }
+				.default, .is-active { color: blue }
+				@keyframes fade-in { from { opacity: 0 } }
+			`,
+			"/src/styles/global.css": `
+				.global { color: red }
+			`,
+		},
+		entryPaths: []string{"/src/entry.js", "/src/styles/global.css"},
+		options: config.Options{
+			Mode:           config.ModeBundle,
+			AbsOutputDir:   "/out",
+			CSSModuleTypes: true,
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".module.css": config.LoaderLocalCSS,
+				".css":        config.LoaderCSS,
+			},
+		},
+	})
+}
+
+func TestCSSModuleTypesDir(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.js": `
+				import styles from "./styles/button.module.css"
+				console.log(styles)
+			`,
+			"/src/styles/button.module.css": `
+				.button { color: red }
+			`,
+		},
+		entryPaths: []string{"/src/entry.js"},
+		options: config.Options{
+			Mode:                 config.ModeBundle,
+			AbsOutputDir:         "/out",
+			AbsOutputBase:        "/src",
+			CSSModuleTypes:       true,
+			AbsCSSModuleTypesDir: "/types",
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".module.css": config.LoaderLocalCSS,
+			},
+		},
+	})
+}
+
+func TestImportCSSFromJSRemoveUnusedLocalNames(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import * as styles from "./styles.module.css"
+				import { used } from "./named.module.css"
+				import defaultImport from "./default.module.css"
+				import "./side-effect.module.css"
+				console.log(styles.button, styles.icon, used, defaultImport)
+			`,
+			"/styles.module.css": `
+				.button { color: red }
+				.button.large { font-size: 2em }
+				.unused, .button:hover { color: blue }
+				.icon { composes: base; composes: shared from "./shared.module.css" }
+				.base { margin: 0 }
+				.button :not(.unused) { color: green }
+				:is(.unused, .icon) { color: yellow }
+				:is(.unused, .large) { color: orange }
+				@media (min-width: 100px) { .unused { color: red } }
+				.button { .unused & { color: red } }
+				@keyframes unused { to { opacity: 0 } }
+			`,
+			"/shared.module.css": `
+				.shared { padding: 0 }
+				.not-shared { padding: 1px }
+			`,
+			"/named.module.css": `
+				.used { color: red }
+				.unused { color: blue }
+			`,
+			"/default.module.css": `
+				.kept { color: red }
+			`,
+			"/side-effect.module.css": `
+				.kept { color: blue }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			TreeShaking:  true,
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".module.css": config.LoaderLocalCSS,
+			},
+			UnsupportedCSSFeatures: compat.Nesting,
+		},
+	})
+}
+
+func TestImportCSSFromJSRemoveUnusedLocalNamesDefaultImport(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import styles from "./styles.module.css"
+				import { named } from "./styles.module.css"
+				import captured from "./captured.module.css"
+				import exported from "./exported.module.css"
+				console.log(styles.button, styles["icon"], named, captured.used, [captured])
+				export { exported }
+			`,
+			"/styles.module.css": `
+				.button { color: red }
+				.icon { color: green }
+				.named { color: blue }
+				.unused { color: yellow }
+			`,
+			"/captured.module.css": `
+				.used { color: red }
+				.kept { color: blue }
+			`,
+			"/exported.module.css": `
+				.kept { color: red }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			TreeShaking:  true,
+			ExtensionToLoader: map[string]config.Loader{
+				".js":         config.LoaderJS,
+				".module.css": config.LoaderLocalCSS,
+			},
+		},
+	})
+}
+
 func TestImportCSSFromJSWriteToStdout(t *testing.T) {
 	css_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -2118,12 +2340,16 @@ func TestCSSExternalQueryAndHashMatchIssue1822(t *testing.T) {
 func TestCSSNestingOldBrowser(t *testing.T) {
 	css_suite.expectBundled(t, bundled{
 		files: map[string]string{
-			// These are now the only two cases that warn about ":is" not being supported
-			"/two-type-selectors.css":   `a { .c b& { color: red; } }`,
-			"/two-parent-selectors.css": `a b { .c & { color: red; } }`,
+			// These used to warn about ":is" not being supported
+			"/two-type-selectors.css":      `a { .c b& { color: red; } }`,
+			"/two-parent-selectors.css":    `a b { .c & { color: red; } }`,
+			"/only-one-warning.css":        `.a, .b .c, .d { & > & { color: red; } }`,
+			"/same-type-selectors.css":     `a, b c, d a { & > a& { color: red; } }`,
+			"/sibling-combinator.css":      `.a .b { & + & { color: red; } }`,
+			"/sibling-combinator-pair.css": `.a ~ .b { .c + & { color: red; } }`,
 
-			// Make sure this only generates one warning (even though it generates ":is" three times)
-			"/only-one-warning.css": `.a, .b .c, .d { & > & { color: red; } }`,
+			// This still warns because a generated ":is" inside another pseudo-class can't be expanded
+			"/inside-pseudo-class.css": `.a .b { :is(.c &) { color: red; } }`,
 
 			"/nested-@layer.css":          `a { @layer base { color: red; } }`,
 			"/nested-@media.css":          `a { @media screen { color: red; } }`,
@@ -2167,6 +2393,10 @@ func TestCSSNestingOldBrowser(t *testing.T) {
 			"/two-parent-selectors.css",
 
 			"/only-one-warning.css",
+			"/same-type-selectors.css",
+			"/sibling-combinator.css",
+			"/sibling-combinator-pair.css",
+			"/inside-pseudo-class.css",
 
 			"/nested-@layer.css",
 			"/nested-@media.css",
@@ -2210,11 +2440,7 @@ func TestCSSNestingOldBrowser(t *testing.T) {
 			UnsupportedCSSFeatures: compat.Nesting | compat.IsPseudoClass,
 			OriginalTargetEnv:      "chrome10",
 		},
-		expectedScanLog: `only-one-warning.css: WARNING: Transforming this CSS nesting syntax is not supported in the configured target environment (chrome10)
-NOTE: The nesting transform for this case must generate an ":is(...)" but the configured target environment does not support the ":is" pseudo-class.
-two-parent-selectors.css: WARNING: Transforming this CSS nesting syntax is not supported in the configured target environment (chrome10)
-NOTE: The nesting transform for this case must generate an ":is(...)" but the configured target environment does not support the ":is" pseudo-class.
-two-type-selectors.css: WARNING: Transforming this CSS nesting syntax is not supported in the configured target environment (chrome10)
+		expectedScanLog: `inside-pseudo-class.css: WARNING: Transforming this CSS nesting syntax is not supported in the configured target environment (chrome10)
 NOTE: The nesting transform for this case must generate an ":is(...)" but the configured target environment does not support the ":is" pseudo-class.
 `,
 	})
@@ -2542,6 +2768,69 @@ func TestCSSAtLayerBeforeImportBundle(t *testing.T) {
 	})
 }
 
+func TestCSSAtLayerLowering(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@layer reset, base;
+				@import "a.css" layer(base);
+				@import "b.css" layer;
+				.entry { color: red }
+				@layer reset {
+					#reset a { color: red }
+				}
+				@layer base.inner {
+					.inner::before { color: red }
+				}
+				@media print {
+					@layer base {
+						.print { color: red }
+					}
+				}
+			`,
+			"/a.css": `
+				.a { color: red }
+				@layer {
+					.anonymous { color: red }
+				}
+			`,
+			"/b.css": `
+				.b { color: red }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:                   config.ModeBundle,
+			AbsOutputDir:           "/out",
+			UnsupportedCSSFeatures: compat.CascadeLayers,
+		},
+	})
+}
+
+func TestCSSAtLayerLoweringImportant(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@layer a, b;
+				@layer a {
+					.x { color: red !important; background: red }
+				}
+				@layer b {
+					.x { color: green !important; background: green }
+				}
+				.x { color: blue !important; background: blue }
+				.y { color: blue !important }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:                   config.ModeBundle,
+			AbsOutputDir:           "/out",
+			UnsupportedCSSFeatures: compat.CascadeLayers,
+		},
+	})
+}
+
 func TestCSSAtLayerMergingWithImportConditions(t *testing.T) {
 	css_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -2651,3 +2940,80 @@ func TestCSSAssetPathsWithSpacesBundle(t *testing.T) {
 		},
 	})
 }
+
+func TestCSSCustomMediaAndSelectorsBundle(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@import "tokens.css";
+				@media (--narrow) { :--heading + p { color: red } }
+				@media (--wide) { a:--button:hover { color: green } }
+				@media screen and (--narrow-color) { :--nested > i { color: blue } }
+				@media not (--narrow) { :not(:--heading) { color: orange } }
+				@media (--undefined) { :--undefined { color: purple } }
+			`,
+			"/tokens.css": `
+				@custom-media --narrow (max-width: 30em);
+				@custom-media --wide (min-width: 60em), print;
+				@custom-media --narrow-color (--narrow) and (color);
+				@custom-selector :--heading h1, h2, h3;
+				@custom-selector :--button .btn;
+				@custom-selector :--nested .outer :--button;
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+		expectedCompileLog: `entry.css: WARNING: The custom media query "--undefined" is not defined
+entry.css: WARNING: The custom selector ":--undefined" is not defined
+`,
+	})
+}
+
+func TestCSSCustomMediaAndSelectorsUndefined(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@custom-media --narrow (--missing-media) and (color);
+				@custom-selector :--heading :--missing-selector h1;
+				@media (--narrow) { :--heading { color: red } }
+				@media screen and (--undefined) { :not(:--undefined) { color: blue } }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+		},
+		expectedCompileLog: `entry.css: WARNING: The custom media query "--missing-media" is not defined
+entry.css: WARNING: The custom selector ":--missing-selector" is not defined
+entry.css: WARNING: The custom media query "--undefined" is not defined
+entry.css: WARNING: The custom selector ":--undefined" is not defined
+`,
+	})
+}
+
+func TestCSSCustomSelectorsWithoutIsPseudoClass(t *testing.T) {
+	css_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.css": `
+				@custom-selector :--heading h1, .title h2;
+				:--heading + p { color: red }
+				main :--heading { color: green }
+				@custom-selector :--a :--b;
+				@custom-selector :--b :--a;
+				:--a { color: blue }
+			`,
+		},
+		entryPaths: []string{"/entry.css"},
+		options: config.Options{
+			Mode:                   config.ModeBundle,
+			AbsOutputFile:          "/out.css",
+			UnsupportedCSSFeatures: compat.IsPseudoClass,
+		},
+		expectedCompileLog: `entry.css: WARNING: Substituting the custom selector ":--heading" here requires ":is()", which is not supported in the configured target environment
+`,
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/js_ast"
 )
 
 var dce_suite = suite{
@@ -1034,6 +1035,161 @@ func TestPackageJsonSideEffectsFalseAllFork(t *testing.T) {
 	})
 }
 
+func TestInferSideEffects(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import { used } from "pure-pkg"
+				import { unused } from "impure-pkg"
+				import { alsoUnused } from "marked-pkg"
+				import "pure-pkg/widget"
+				console.log(used)
+			`,
+			"/Users/user/project/node_modules/pure-pkg/package.json": `
+				{ "name": "pure-pkg" }
+			`,
+			"/Users/user/project/node_modules/pure-pkg/index.js": `
+				export { used } from "./used"
+				export { Widget } from "./widget"
+			`,
+			"/Users/user/project/node_modules/pure-pkg/used.js": `
+				export const used = 1
+			`,
+			"/Users/user/project/node_modules/pure-pkg/widget.js": `
+				import { helper } from "./helper"
+				export function Widget() {}
+				Widget.prototype.render = function() { return helper() }
+				Widget.defaults = { size: 1 }
+				Object.defineProperty(Widget.prototype, "kind", { value: "widget" })
+			`,
+			"/Users/user/project/node_modules/pure-pkg/helper.js": `
+				export function helper() { return 1 }
+			`,
+			"/Users/user/project/node_modules/impure-pkg/package.json": `
+				{ "name": "impure-pkg" }
+			`,
+			"/Users/user/project/node_modules/impure-pkg/index.js": `
+				import "./effect"
+				export const unused = 2
+			`,
+			"/Users/user/project/node_modules/impure-pkg/effect.js": `
+				window.effect = true
+			`,
+			"/Users/user/project/node_modules/marked-pkg/package.json": `
+				{ "name": "marked-pkg", "sideEffects": true }
+			`,
+			"/Users/user/project/node_modules/marked-pkg/index.js": `
+				export function alsoUnused() {}
+				alsoUnused.keep = true
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			AbsOutputFile:    "/out.js",
+			NeedsMetafile:    true,
+			InferSideEffects: true,
+		},
+	})
+}
+
+func TestInferSideEffectsObservableMutations(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import { Foo } from "extends-pkg"
+				import { o } from "proto-pkg"
+				import { Bar } from "name-pkg"
+				import { Baz } from "length-pkg"
+			`,
+			"/Users/user/project/node_modules/extends-pkg/package.json": `
+				{ "name": "extends-pkg" }
+			`,
+			"/Users/user/project/node_modules/extends-pkg/index.js": `
+				import { Base } from "./base"
+				export class Foo extends Base {}
+				Foo.bar = 1
+			`,
+			"/Users/user/project/node_modules/extends-pkg/base.js": `
+				export class Base { static set bar(v) { console.log("setter") } }
+			`,
+			"/Users/user/project/node_modules/proto-pkg/package.json": `
+				{ "name": "proto-pkg" }
+			`,
+			"/Users/user/project/node_modules/proto-pkg/index.js": `
+				export var o = { __proto__: { set x(v) { console.log("setter") } } }
+				o.x = 1
+			`,
+			"/Users/user/project/node_modules/name-pkg/package.json": `
+				{ "name": "name-pkg" }
+			`,
+			"/Users/user/project/node_modules/name-pkg/index.js": `
+				export function Bar() {}
+				Bar.name = "throws"
+			`,
+			"/Users/user/project/node_modules/length-pkg/package.json": `
+				{ "name": "length-pkg" }
+			`,
+			"/Users/user/project/node_modules/length-pkg/index.js": `
+				export class Baz {}
+				Baz["length"] = 2
+				Object.defineProperty(Baz, "prototype", { value: {} })
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			AbsOutputFile:    "/out.js",
+			NeedsMetafile:    true,
+			InferSideEffects: true,
+		},
+	})
+}
+
+func TestInferSideEffectsPackageOverride(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import { a } from "pkg-a"
+				import { b } from "pkg-b"
+				import { c } from "pkg-c"
+			`,
+			"/Users/user/project/node_modules/pkg-a/package.json": `
+				{ "name": "pkg-a" }
+			`,
+			"/Users/user/project/node_modules/pkg-a/index.js": `
+				export function a() {}
+				a.keep = true
+			`,
+			"/Users/user/project/node_modules/pkg-b/package.json": `
+				{ "name": "pkg-b" }
+			`,
+			"/Users/user/project/node_modules/pkg-b/index.js": `
+				export const b = 1
+				console.log("removed by override")
+			`,
+			"/Users/user/project/node_modules/pkg-c/package.json": `
+				{ "name": "pkg-c", "sideEffects": false }
+			`,
+			"/Users/user/project/node_modules/pkg-c/index.js": `
+				export const c = 1
+				console.log("kept by override")
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			AbsOutputFile:    "/out.js",
+			InferSideEffects: true,
+			PackageSideEffects: map[string]bool{
+				"pkg-a": true,
+				"pkg-b": false,
+				"pkg-c": true,
+			},
+		},
+	})
+}
+
 func TestJSONLoaderRemoveUnused(t *testing.T) {
 	dce_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -3175,6 +3331,103 @@ func TestConstValueInliningBundle(t *testing.T) {
 	})
 }
 
+func TestConstValueInliningBundleFeatureFlags(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { DEBUG, LEVEL, MODE, FALLBACK, LONG_NAME } from './flags'
+				if (DEBUG) console.log('debug')
+				if (!DEBUG) console.log('not debug')
+				if (LEVEL > 1) console.log('verbose')
+				if (MODE === 'dev') { console.log('dev'); console.log('mode') }
+				console.log(FALLBACK ?? 'fallback', LONG_NAME)
+				export function check() {
+					if (DEBUG) {
+						let x = validate()
+						return x
+					}
+					if (DEBUG) {
+						var hoisted_keep = 1
+					}
+					return LEVEL
+				}
+			`,
+			"/flags.js": `
+				import './side-effect'
+				export const DEBUG = false
+				export const LEVEL = 2
+				export const MODE = 'dev'
+				export const FALLBACK = null
+				export const LONG_NAME = 'too long to inline'
+			`,
+			"/side-effect.js": `
+				console.log('side effect')
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			MinifySyntax:  true,
+		},
+	})
+}
+
+func TestConstValueInliningBundleAfterImports(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/after-import-entry.js": `
+				import { x_REMOVE } from './after-import-constants'
+				console.log(x_REMOVE)
+			`,
+			"/after-import-constants.js": `
+				import './after-import-dep'
+				export const x_REMOVE = 1
+			`,
+			"/after-import-dep.js": `
+				console.log('dep')
+			`,
+
+			"/used-before-entry.js": `
+				import { y_keep } from './used-before-constants'
+				console.log(y_keep)
+			`,
+			"/used-before-constants.js": `
+				import './after-import-dep'
+				export function get() {
+					return y_keep
+				}
+				export const y_keep = 1
+			`,
+
+			"/cycle-entry.js": `
+				import { z_keep } from './cycle-constants'
+				console.log(z_keep)
+			`,
+			"/cycle-constants.js": `
+				import './cycle-other'
+				export const z_keep = 1
+			`,
+			"/cycle-other.js": `
+				import { z_keep } from './cycle-constants'
+				console.log(z_keep) // This accesses "z_keep" before it's initialized
+			`,
+		},
+		entryPaths: []string{
+			"/after-import-entry.js",
+			"/used-before-entry.js",
+			"/cycle-entry.js",
+		},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatESModule,
+			AbsOutputDir: "/out",
+			MinifySyntax: true,
+		},
+	})
+}
+
 // Assignment to an inlined constant is not allowed since that would cause a
 // syntax error in the output. We don't just keep the reference there because
 // the declaration may actually have been completely removed already by the
@@ -5007,3 +5260,142 @@ func TestDCEOfSymbolForCall(t *testing.T) {
 		},
 	})
 }
+
+func TestInlineFunctions(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { format } from './format'
+				function add(a, b) { return a + b }
+				function log(msg) { console.log(msg) }
+				function twice(x) { return x * 2 }
+				function rec(n) { return n ? rec(n - 1) : 0 }
+				function usesThis() { return this.x }
+				export function exported(a) { return a }
+				console.log(add(foo(), bar()))
+				log(format('hi'))
+				console.log(twice(3), twice(4), rec(3), usesThis(), exported(x))
+				export function outer(p) {
+					function helper(q) { return q + p }
+					return helper(p * 2)
+				}
+				;(() => { let x = g(); h(x) })()
+				;(() => { var keep = 1; h(keep) })()
+			`,
+			"/format.js": `
+				function wrap(s) { return '[' + s + ']' }
+				export function format(s) { return wrap(s) }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:            config.ModeBundle,
+			OutputFormat:    config.FormatESModule,
+			AbsOutputFile:   "/out.js",
+			MinifySyntax:    true,
+			InlineFunctions: true,
+		},
+	})
+}
+
+const treeShakingAssumePureEntry = `
+	const propertyRead = foo.bar, nestedPropertyRead = window.foo.bar
+	const nonNullishPropertyRead = ({ x: 1 }).x, indexRead = [1, 2][0]
+	const unknownGlobal = someGlobal
+	const spread = { ...window }
+	try { const f = window.localStorage.length } catch {}
+	try { const g = window.x } finally { console.log('finally') }
+	try { const h = window.x } catch { console.log('catch') }
+`
+
+func TestTreeShakingAssumePureDefault(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": treeShakingAssumePureEntry,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTreeShakingAssumePureGetters(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": treeShakingAssumePureEntry,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			AssumePure:    js_ast.AssumePureGetters,
+		},
+	})
+}
+
+func TestTreeShakingAssumePurePropertyReads(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": treeShakingAssumePureEntry,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			AssumePure:    js_ast.AssumePurePropertyReads,
+		},
+	})
+}
+
+func TestTreeShakingAssumePureUnknownGlobals(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": treeShakingAssumePureEntry,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			AssumePure:    js_ast.AssumePureUnknownGlobals,
+		},
+	})
+}
+
+func TestTreeShakingAssumePureTryBlocks(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": treeShakingAssumePureEntry,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			AssumePure:    js_ast.AssumePureTryBlocks,
+		},
+	})
+}
+
+func TestTreeShakingKnownGlobals(t *testing.T) {
+	defines := config.ProcessDefines([]config.DefineData{
+		{KeyParts: []string{"MyLib"}, Flags: config.CanBeRemovedIfUnused},
+		{KeyParts: []string{"MyLib", "version"}, Flags: config.CanBeRemovedIfUnused},
+	})
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const removeMe1 = MyLib
+				const removeMe2 = MyLib.version
+				const keepMe1 = OtherLib
+				const keepMe2 = MyLib.version.major
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			Defines:       &defines,
+		},
+	})
+}
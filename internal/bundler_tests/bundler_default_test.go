@@ -161,6 +161,143 @@ func TestES6FromCommonJS(t *testing.T) {
 	})
 }
 
+func TestCommonJSToESMTreeShaking(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { used, live } from './ts-output'
+				import { a } from './object'
+				import * as ns from './chain'
+				console.log(used(), live, a, ns.x, ns.y)
+			`,
+			"/ts-output.js": `
+				"use strict";
+				Object.defineProperty(exports, "__esModule", { value: true });
+				exports.live = exports.unused = exports.used = void 0;
+				function helper() { return 1 }
+				exports.used = function () { return helper() };
+				exports.unused = function () { return 2 };
+				let live = 3;
+				Object.defineProperty(exports, "live", { enumerable: true, get: function () { return live; } });
+			`,
+			"/object.js": `
+				const a = 1
+				function unused() {}
+				module.exports = { a, b: 2, c: unused }
+			`,
+			"/chain.js": `
+				exports.x = exports.y = 1, module.exports.z = sideEffect()
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestCommonJSToESMDestructuredRequire(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				console.log('before')
+				const { pick, version: v } = require('./lodash')
+				const { x } = require('./mixed')
+				const mixed = require('./mixed')
+				let { y } = require('./let')
+				const { z, missing } = require('./missing')
+				console.log(pick(), v, x, mixed, y, z, missing, require('./wrapped'))
+			`,
+			"/lodash.js": `
+				function pick() { return 1 }
+				function omit() { return 2 }
+				module.exports = { pick, omit, version: '1.0' }
+			`,
+			"/mixed.js": `
+				exports.x = 1
+			`,
+			"/let.js": `
+				exports.y = 1
+			`,
+			"/missing.js": `
+				exports.z = 1
+			`,
+			"/wrapped.js": `
+				const { inner } = require('./inner')
+				module.exports = inner
+			`,
+			"/inner.js": `
+				exports.inner = 1
+				exports.unused = 2
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestCommonJSToESMFallback(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { a } from './read-exports'
+				import { b } from './reassign'
+				import { c } from './required'
+				import def from './default'
+				import * as ns from './namespace'
+				import { missing } from './missing'
+				console.log(a, b, c, def, ns, missing, require('./required'))
+			`,
+			"/read-exports.js": `
+				exports.a = 1
+				exports.other = exports.a + 1
+			`,
+			"/reassign.js": `
+				exports.b = 1
+				exports.b = 2
+			`,
+			"/required.js": `
+				exports.c = 1
+			`,
+			"/default.js": `
+				exports.d = 1
+			`,
+			"/namespace.js": `
+				exports.e = 1
+			`,
+			"/missing.js": `
+				exports.f = 1
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestCommonJSToESMEntryPoint(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				exports.foo = 1
+				exports.bar = 2
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
 // This test makes sure that ES6 imports are still recognized in nested
 // scopes. It guards against bugs where require() calls are only recognized in
 // the top-level module scope.
@@ -1339,6 +1476,82 @@ func TestSourceMap(t *testing.T) {
 	})
 }
 
+func TestSourceMapIgnoreList(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import {foo} from 'pkg'
+				import {bar} from './vendor/bar'
+				foo(bar)
+			`,
+			"/Users/user/project/src/vendor/bar.js": `
+				export function bar() { throw new Error('test') }
+			`,
+			"/Users/user/project/node_modules/pkg/index.js": `
+				export function foo(fn) { fn() }
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:                config.ModeBundle,
+			SourceMap:           config.SourceMapLinkedWithComment,
+			SourceMapIgnoreList: regexp.MustCompile("(^|/)node_modules/"),
+			AbsOutputFile:       "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestSourceMapIgnoreListCustom(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import {foo} from 'pkg'
+				import {bar} from './vendor/bar'
+				foo(bar)
+			`,
+			"/Users/user/project/src/vendor/bar.js": `
+				export function bar() { throw new Error('test') }
+			`,
+			"/Users/user/project/node_modules/pkg/index.js": `
+				export function foo(fn) { fn() }
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:                config.ModeBundle,
+			SourceMap:           config.SourceMapLinkedWithComment,
+			SourceMapIgnoreList: regexp.MustCompile("/vendor/"),
+			AbsOutputFile:       "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestDebugIDs(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import './entry.css'
+				import('./lazy').then(({ foo }) => foo())
+			`,
+			"/lazy.js": `
+				export function foo() { throw new Error('test') }
+			`,
+			"/entry.css": `
+				body { color: red }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			SourceMap:     config.SourceMapLinkedWithComment,
+			DebugIDs:      config.DebugIDsRegister,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
 // This test covers a bug where a "var" in a nested scope did not correctly
 // bind with references to that symbol in sibling scopes. Instead, the
 // references were incorrectly considered to be unbound even though the symbol
@@ -3645,6 +3858,41 @@ func TestLegalCommentsModifyIndent(t *testing.T) {
 	})
 }
 
+func TestPreserveCommentsTreeShaking(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				// This comment belongs to the import
+				import { used } from './lib'
+				/** This comment belongs to the call */
+				used()
+			`,
+			"/lib.js": `
+				//! This legal comment is kept
+				/**
+				 * This comment is kept with "used"
+				 */
+				export function used() {
+					// This comment is inside "used"
+					return 1 // This comment ends the function
+				}
+				/**
+				 * This comment is removed with "unused"
+				 */
+				export function unused() {}
+				export let alsoUnused = 1 // This comment is removed with "alsoUnused"
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			AbsOutputFile:    "/out.js",
+			LegalComments:    config.LegalCommentsInline,
+			PreserveComments: true,
+		},
+	})
+}
+
 func TestLegalCommentsAvoidSlashTagInline(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -6992,6 +7240,63 @@ func TestMinifyIdentifiersImportPathFrequencyAnalysis(t *testing.T) {
 	})
 }
 
+func TestNameCacheEmpty(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { render } from './lib'
+				let count = 0
+				function increment() { count++ }
+				increment()
+				render(count)
+			`,
+			"/lib.js": `
+				let calls = 0
+				export function render(value) { calls++; console.log(value, calls) }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			AbsOutputFile:     "/out.js",
+			MinifyIdentifiers: true,
+			NameCache:         map[string]interface{}{},
+		},
+	})
+}
+
+func TestNameCacheKeepsCachedNames(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { render } from './lib'
+				let count = 0
+				let added = 0
+				function increment() { count++; added++ }
+				increment()
+				render(count, added)
+			`,
+			"/lib.js": `
+				let calls = 0
+				export function render(value) { calls++; console.log(value, calls) }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			AbsOutputFile:     "/out.js",
+			MinifyIdentifiers: true,
+			NameCache: map[string]interface{}{
+				"entry.js:count":     "c",
+				"entry.js:increment": "o",
+				"lib.js:calls":       "n",
+				"lib.js:render":      "n", // This collides with "calls" so it gets a new name
+				"lib.js:missing":     "a", // This symbol no longer exists
+			},
+		},
+	})
+}
+
 func TestToESMWrapperOmission(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -9527,3 +9832,47 @@ func TestBundleESMWithNestedVarIssue4348(t *testing.T) {
 		},
 	})
 }
+
+func TestPolyfillBundle(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { last } from 'pkg'
+				import { clone } from './clone'
+				console.log(last([1, 2, 3]), clone(Object.hasOwn))
+			`,
+			"/clone.js": `
+				export let clone = x => structuredClone(x)
+			`,
+			"/node_modules/pkg/index.js": `
+				export let last = x => x.at(-1)
+			`,
+			"/node_modules/core-js/modules/es.array.at.js": `
+				import { define } from 'define-helper'
+				define(Array.prototype, 'at', function (i) { return this[i < 0 ? this.length + i : i] })
+			`,
+			"/node_modules/core-js/modules/es.string.at-alternative.js": `
+				import { define } from 'define-helper'
+				define(String.prototype, 'at', function (i) { return this.charAt(i < 0 ? this.length + i : i) })
+			`,
+			"/node_modules/core-js/modules/es.object.has-own.js": `
+				if (!Object.hasOwn) Object.hasOwn = (o, k) => Object.prototype.hasOwnProperty.call(o, k)
+			`,
+			"/node_modules/define-helper/index.js": `
+				export let define = (o, k, v) => Object.keys(o).findLast(x => x === k) || Object.defineProperty(o, k, { value: v })
+			`,
+			"/node_modules/core-js/modules/web.structured-clone.js": `
+				if (typeof structuredClone === 'undefined') globalThis.structuredClone = x => JSON.parse(JSON.stringify(x))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			UnsupportedBuiltins: compat.UnsupportedBuiltins(map[compat.Engine]compat.Semver{
+				compat.Chrome: {Parts: []int{90}},
+			}),
+			Polyfill: "core-js",
+		},
+	})
+}
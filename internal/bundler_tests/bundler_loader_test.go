@@ -786,6 +786,58 @@ func TestLoaderJSONSharedWithMultipleEntriesIssue413(t *testing.T) {
 	})
 }
 
+func TestLoaderAutoDataURL(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import small from "./small.svg"
+				import large from "./large.svg"
+				import "./styles.css"
+				console.log(small, large)
+			`,
+			"/styles.css": `
+				a { background: url(./small.svg) }
+				b { background: url(./large.svg) }
+			`,
+			"/small.svg": `<svg/>`,
+			"/large.svg": `<svg><!-- this file is larger than the limit --></svg>`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			AbsOutputDir:     "/out",
+			AssetInlineLimit: 16,
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".css": config.LoaderCSS,
+				".svg": config.LoaderAutoDataURL,
+			},
+		},
+	})
+}
+
+func TestLoaderAutoDataURLZeroLimit(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import small from "./small.svg"
+				console.log(small)
+			`,
+			"/small.svg": `<svg/>`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			AbsOutputDir:     "/out",
+			AssetInlineLimit: 0,
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".svg": config.LoaderAutoDataURL,
+			},
+		},
+	})
+}
+
 func TestLoaderFileWithQueryParameter(t *testing.T) {
 	loader_suite.expectBundled(t, bundled{
 		files: map[string]string{
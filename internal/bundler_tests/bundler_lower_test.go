@@ -3183,3 +3183,88 @@ func TestLowerConstIssue4448(t *testing.T) {
 `,
 	})
 }
+
+func TestLowerExternalHelpersESM(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import * as cjs from './cjs.js'
+				class Foo { x = 1 }
+				export let fn = async () => ({ ...cjs, Foo })
+			`,
+			"/cjs.js": `
+				module.exports = { __publicField: 1 }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			UnsupportedJSFeatures: es(2016),
+			AbsOutputFile:         "/out.js",
+			ExternalHelpers:       "esbuild-helpers",
+		},
+	})
+}
+
+func TestLowerExternalHelpersCommonJS(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				let __spreadValues = 'collision'
+				export let fn = async () => ({ ...__spreadValues })
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatCommonJS,
+			UnsupportedJSFeatures: es(2016),
+			AbsOutputFile:         "/out.js",
+			ExternalHelpers:       "esbuild-helpers",
+		},
+	})
+}
+
+func TestLowerExternalHelpersSplitting(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import { shared } from './shared.js'
+				export let a = async () => shared
+			`,
+			"/b.js": `
+				import { shared } from './shared.js'
+				export let b = { ...shared }
+			`,
+			"/shared.js": `
+				export let shared = async () => {}
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			CodeSplitting:         true,
+			UnsupportedJSFeatures: es(2016),
+			AbsOutputDir:          "/out",
+			ExternalHelpers:       "esbuild-helpers",
+		},
+	})
+}
+
+func TestLowerExternalHelpersNoBundle(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				export class Foo { static x = 1 }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			UnsupportedJSFeatures: es(2016),
+			AbsOutputFile:         "/out.js",
+			ExternalHelpers:       "esbuild-helpers",
+		},
+	})
+}
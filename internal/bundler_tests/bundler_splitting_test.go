@@ -597,3 +597,34 @@ func TestEdgeCaseIssue2793WithoutSplitting(t *testing.T) {
 		},
 	})
 }
+
+func TestSplittingNameCache(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import { shared, other } from './shared'
+				console.log(shared(), other())
+			`,
+			"/b.js": `
+				import { shared } from './shared'
+				console.log(shared())
+			`,
+			"/shared.js": `
+				export function shared() { return 1 }
+				export function other() { return 2 }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			CodeSplitting:     true,
+			OutputFormat:      config.FormatESModule,
+			AbsOutputDir:      "/out",
+			MinifyIdentifiers: true,
+			NameCache: map[string]interface{}{
+				"shared.js:shared":        "s",
+				"shared.js:shared:export": "x",
+			},
+		},
+	})
+}
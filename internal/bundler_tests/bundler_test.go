@@ -182,7 +182,10 @@ func (s *suite) __expectBundledImpl(t *testing.T, args bundled, fsKind fs.MockKi
 		log := logger.NewDeferLog(logKind, nil)
 		caches := cache.MakeCacheSet()
 		mockFS := fs.MockFS(args.files, fsKind, args.absWorkingDir)
-		args.options.OmitRuntimeForTests = true
+
+		// The runtime is never included in the output when its helpers are
+		// imported from an external module, so there's no need to omit it
+		args.options.OmitRuntimeForTests = args.options.ExternalHelpers == ""
 		bundle := bundler.ScanBundle(config.BuildCall, log, mockFS, caches, entryPoints, args.options, nil)
 		msgs := log.Done()
 		assertLog(t, msgs, args.expectedScanLog)
@@ -192,8 +195,17 @@ func (s *suite) __expectBundledImpl(t *testing.T, args bundled, fsKind fs.MockKi
 			return
 		}
 
+		// The name cache is updated in place, so give the bundler its own copy
+		var nameCache map[string]interface{}
+		if args.options.NameCache != nil {
+			nameCache = make(map[string]interface{}, len(args.options.NameCache))
+			for k, v := range args.options.NameCache {
+				nameCache[k] = v
+			}
+		}
+
 		log = logger.NewDeferLog(logKind, nil)
-		results, metafileJSON := bundle.Compile(log, nil, nil, linker.Link)
+		results, metafileJSON := bundle.Compile(log, nil, nil, nameCache, linker.Link)
 		msgs = log.Done()
 		assertLog(t, msgs, args.expectedCompileLog)
 
@@ -218,6 +230,17 @@ func (s *suite) __expectBundledImpl(t *testing.T, args bundled, fsKind fs.MockKi
 		if metafileJSON != "" {
 			generated += fmt.Sprintf("---------- metafile.json ----------\n%s", metafileJSON)
 		}
+		if nameCache != nil {
+			keys := make([]string, 0, len(nameCache))
+			for key := range nameCache {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			generated += "---------- name cache ----------\n"
+			for _, key := range keys {
+				generated += fmt.Sprintf("%s: %s\n", key, nameCache[key])
+			}
+		}
 		s.compareSnapshot(t, testName, generated)
 	})
 }
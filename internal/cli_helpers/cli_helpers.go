@@ -23,6 +23,8 @@ func MakeErrorWithNote(text string, note string) *ErrorWithNote {
 
 func ParseLoader(text string) (api.Loader, *ErrorWithNote) {
 	switch text {
+	case "auto-dataurl":
+		return api.LoaderAutoDataURL, nil
 	case "base64":
 		return api.LoaderBase64, nil
 	case "binary":
@@ -58,7 +60,7 @@ func ParseLoader(text string) (api.Loader, *ErrorWithNote) {
 	default:
 		return api.LoaderNone, MakeErrorWithNote(
 			fmt.Sprintf("Invalid loader value: %q", text),
-			"Valid values are \"base64\", \"binary\", \"copy\", \"css\", \"dataurl\", \"empty\", \"file\", \"global-css\", \"js\", \"json\", \"jsx\", \"local-css\", \"text\", \"ts\", or \"tsx\".",
+			"Valid values are \"auto-dataurl\", \"base64\", \"binary\", \"copy\", \"css\", \"dataurl\", \"empty\", \"file\", \"global-css\", \"js\", \"json\", \"jsx\", \"local-css\", \"text\", \"ts\", or \"tsx\".",
 		)
 	}
 }
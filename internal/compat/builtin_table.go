@@ -0,0 +1,665 @@
+// This file was automatically generated by "builtin_table.ts"
+
+package compat
+
+// This table tracks support for built-in APIs (as opposed to syntax features)
+// so that usages of missing APIs can be polyfilled. Each built-in is paired
+// with the name of the module that polyfills it using the "core-js" naming
+// convention. Built-ins that aren't part of the language specification (such
+// as "structuredClone") have no "ES" entry and aren't affected by "es*"
+// targets.
+
+type Builtin uint64
+
+const (
+	ArrayFrom Builtin = 1 << iota
+	ArrayOf
+	ArrayPrototypeAt
+	ArrayPrototypeFind
+	ArrayPrototypeFindIndex
+	ArrayPrototypeFindLast
+	ArrayPrototypeFindLastIndex
+	ArrayPrototypeFlat
+	ArrayPrototypeFlatMap
+	ArrayPrototypeIncludes
+	ArrayPrototypeToReversed
+	ArrayPrototypeToSorted
+	ArrayPrototypeToSpliced
+	ArrayPrototypeWith
+	GlobalMap
+	GlobalPromise
+	GlobalQueueMicrotask
+	GlobalSet
+	GlobalStructuredClone
+	GlobalSymbol
+	GlobalThis
+	GlobalWeakMap
+	GlobalWeakSet
+	MapGroupBy
+	ObjectAssign
+	ObjectEntries
+	ObjectFromEntries
+	ObjectGroupBy
+	ObjectHasOwn
+	ObjectValues
+	PromiseAllSettled
+	PromiseAny
+	PromisePrototypeFinally
+	PromiseWithResolvers
+	StringPrototypeAt
+	StringPrototypeEndsWith
+	StringPrototypeIncludes
+	StringPrototypeIsWellFormed
+	StringPrototypeMatchAll
+	StringPrototypePadEnd
+	StringPrototypePadStart
+	StringPrototypeReplaceAll
+	StringPrototypeStartsWith
+	StringPrototypeToWellFormed
+	StringPrototypeTrimEnd
+	StringPrototypeTrimStart
+)
+
+func (builtins Builtin) Has(builtin Builtin) bool {
+	return (builtins & builtin) != 0
+}
+
+// Returns the name of the module that polyfills this built-in. For example,
+// this is "es.array.at" for "Array.prototype.at". The module is expected to be
+// at the path "modules/<name>.js" inside the polyfill package.
+func (builtin Builtin) Module() string {
+	return builtinTable[builtin].module
+}
+
+// These map identifiers and property accesses in the source code to the
+// built-ins that they may refer to. Property accesses that look like calls to
+// prototype methods are matched by name alone since the type of the object
+// isn't known, so they may map to more than one built-in.
+var GlobalBuiltins = map[string]Builtin{
+	"Map":             GlobalMap,
+	"Promise":         GlobalPromise,
+	"Set":             GlobalSet,
+	"Symbol":          GlobalSymbol,
+	"WeakMap":         GlobalWeakMap,
+	"WeakSet":         GlobalWeakSet,
+	"globalThis":      GlobalThis,
+	"queueMicrotask":  GlobalQueueMicrotask,
+	"structuredClone": GlobalStructuredClone,
+}
+
+var StaticBuiltins = map[string]map[string]Builtin{
+	"Array": {
+		"from": ArrayFrom,
+		"of":   ArrayOf,
+	},
+	"Map": {
+		"groupBy": MapGroupBy,
+	},
+	"Object": {
+		"assign":      ObjectAssign,
+		"entries":     ObjectEntries,
+		"fromEntries": ObjectFromEntries,
+		"groupBy":     ObjectGroupBy,
+		"hasOwn":      ObjectHasOwn,
+		"values":      ObjectValues,
+	},
+	"Promise": {
+		"allSettled":    PromiseAllSettled,
+		"any":           PromiseAny,
+		"withResolvers": PromiseWithResolvers,
+	},
+}
+
+var PrototypeBuiltins = map[string]Builtin{
+	"at":            ArrayPrototypeAt | StringPrototypeAt,
+	"endsWith":      StringPrototypeEndsWith,
+	"finally":       PromisePrototypeFinally,
+	"find":          ArrayPrototypeFind,
+	"findIndex":     ArrayPrototypeFindIndex,
+	"findLast":      ArrayPrototypeFindLast,
+	"findLastIndex": ArrayPrototypeFindLastIndex,
+	"flat":          ArrayPrototypeFlat,
+	"flatMap":       ArrayPrototypeFlatMap,
+	"includes":      ArrayPrototypeIncludes | StringPrototypeIncludes,
+	"isWellFormed":  StringPrototypeIsWellFormed,
+	"matchAll":      StringPrototypeMatchAll,
+	"padEnd":        StringPrototypePadEnd,
+	"padStart":      StringPrototypePadStart,
+	"replaceAll":    StringPrototypeReplaceAll,
+	"startsWith":    StringPrototypeStartsWith,
+	"toReversed":    ArrayPrototypeToReversed,
+	"toSorted":      ArrayPrototypeToSorted,
+	"toSpliced":     ArrayPrototypeToSpliced,
+	"toWellFormed":  StringPrototypeToWellFormed,
+	"trimEnd":       StringPrototypeTrimEnd,
+	"trimStart":     StringPrototypeTrimStart,
+	"with":          ArrayPrototypeWith,
+}
+
+type builtinInfo struct {
+	module  string
+	engines map[Engine][]versionRange
+}
+
+var builtinTable = map[Builtin]builtinInfo{
+	ArrayFrom: {module: "es.array.from", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	ArrayOf: {module: "es.array.of", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	ArrayPrototypeAt: {module: "es.array.at", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{92, 0, 0}}},
+		Deno:    {{start: v{1, 12, 0}}},
+		Edge:    {{start: v{92, 0, 0}}},
+		ES:      {{start: v{2022, 0, 0}}},
+		Firefox: {{start: v{90, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Node:    {{start: v{16, 6, 0}}},
+		Opera:   {{start: v{78, 0, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	}},
+	ArrayPrototypeFind: {module: "es.array.find", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	ArrayPrototypeFindIndex: {module: "es.array.find-index", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	ArrayPrototypeFindLast: {module: "es.array.find-last", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{97, 0, 0}}},
+		Deno:    {{start: v{1, 16, 0}}},
+		Edge:    {{start: v{97, 0, 0}}},
+		ES:      {{start: v{2023, 0, 0}}},
+		Firefox: {{start: v{104, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Node:    {{start: v{18, 0, 0}}},
+		Opera:   {{start: v{83, 0, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	}},
+	ArrayPrototypeFindLastIndex: {module: "es.array.find-last-index", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{97, 0, 0}}},
+		Deno:    {{start: v{1, 16, 0}}},
+		Edge:    {{start: v{97, 0, 0}}},
+		ES:      {{start: v{2023, 0, 0}}},
+		Firefox: {{start: v{104, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Node:    {{start: v{18, 0, 0}}},
+		Opera:   {{start: v{83, 0, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	}},
+	ArrayPrototypeFlat: {module: "es.array.flat", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{69, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2019, 0, 0}}},
+		Firefox: {{start: v{62, 0, 0}}},
+		IOS:     {{start: v{12, 0, 0}}},
+		Node:    {{start: v{11, 0, 0}}},
+		Opera:   {{start: v{56, 0, 0}}},
+		Safari:  {{start: v{12, 0, 0}}},
+	}},
+	ArrayPrototypeFlatMap: {module: "es.array.flat-map", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{69, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2019, 0, 0}}},
+		Firefox: {{start: v{62, 0, 0}}},
+		IOS:     {{start: v{12, 0, 0}}},
+		Node:    {{start: v{11, 0, 0}}},
+		Opera:   {{start: v{56, 0, 0}}},
+		Safari:  {{start: v{12, 0, 0}}},
+	}},
+	ArrayPrototypeIncludes: {module: "es.array.includes", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{47, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{14, 0, 0}}},
+		ES:      {{start: v{2016, 0, 0}}},
+		Firefox: {{start: v{43, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{6, 0, 0}}},
+		Opera:   {{start: v{34, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	ArrayPrototypeToReversed: {module: "es.array.to-reversed", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{110, 0, 0}}},
+		Deno:    {{start: v{1, 31, 0}}},
+		Edge:    {{start: v{110, 0, 0}}},
+		ES:      {{start: v{2023, 0, 0}}},
+		Firefox: {{start: v{115, 0, 0}}},
+		IOS:     {{start: v{16, 0, 0}}},
+		Node:    {{start: v{20, 0, 0}}},
+		Opera:   {{start: v{96, 0, 0}}},
+		Safari:  {{start: v{16, 0, 0}}},
+	}},
+	ArrayPrototypeToSorted: {module: "es.array.to-sorted", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{110, 0, 0}}},
+		Deno:    {{start: v{1, 31, 0}}},
+		Edge:    {{start: v{110, 0, 0}}},
+		ES:      {{start: v{2023, 0, 0}}},
+		Firefox: {{start: v{115, 0, 0}}},
+		IOS:     {{start: v{16, 0, 0}}},
+		Node:    {{start: v{20, 0, 0}}},
+		Opera:   {{start: v{96, 0, 0}}},
+		Safari:  {{start: v{16, 0, 0}}},
+	}},
+	ArrayPrototypeToSpliced: {module: "es.array.to-spliced", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{110, 0, 0}}},
+		Deno:    {{start: v{1, 31, 0}}},
+		Edge:    {{start: v{110, 0, 0}}},
+		ES:      {{start: v{2023, 0, 0}}},
+		Firefox: {{start: v{115, 0, 0}}},
+		IOS:     {{start: v{16, 0, 0}}},
+		Node:    {{start: v{20, 0, 0}}},
+		Opera:   {{start: v{96, 0, 0}}},
+		Safari:  {{start: v{16, 0, 0}}},
+	}},
+	ArrayPrototypeWith: {module: "es.array.with", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{110, 0, 0}}},
+		Deno:    {{start: v{1, 31, 0}}},
+		Edge:    {{start: v{110, 0, 0}}},
+		ES:      {{start: v{2023, 0, 0}}},
+		Firefox: {{start: v{115, 0, 0}}},
+		IOS:     {{start: v{16, 0, 0}}},
+		Node:    {{start: v{20, 0, 0}}},
+		Opera:   {{start: v{96, 0, 0}}},
+		Safari:  {{start: v{16, 0, 0}}},
+	}},
+	GlobalMap: {module: "es.map", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{38, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{36, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{0, 12, 0}}},
+		Opera:   {{start: v{25, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	GlobalPromise: {module: "es.promise", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{32, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{29, 0, 0}}},
+		IOS:     {{start: v{8, 0, 0}}},
+		Node:    {{start: v{0, 12, 0}}},
+		Opera:   {{start: v{19, 0, 0}}},
+		Safari:  {{start: v{8, 0, 0}}},
+	}},
+	GlobalQueueMicrotask: {module: "web.queue-microtask", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{71, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		Firefox: {{start: v{69, 0, 0}}},
+		IOS:     {{start: v{12, 2, 0}}},
+		Node:    {{start: v{11, 0, 0}}},
+		Opera:   {{start: v{58, 0, 0}}},
+		Safari:  {{start: v{12, 1, 0}}},
+	}},
+	GlobalSet: {module: "es.set", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{38, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{36, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{0, 12, 0}}},
+		Opera:   {{start: v{25, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	GlobalStructuredClone: {module: "web.structured-clone", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{98, 0, 0}}},
+		Deno:    {{start: v{1, 14, 0}}},
+		Edge:    {{start: v{98, 0, 0}}},
+		Firefox: {{start: v{94, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Node:    {{start: v{17, 0, 0}}},
+		Opera:   {{start: v{84, 0, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	}},
+	GlobalSymbol: {module: "es.symbol", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{38, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{36, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{0, 12, 0}}},
+		Opera:   {{start: v{25, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	GlobalThis: {module: "es.global-this", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{71, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2020, 0, 0}}},
+		Firefox: {{start: v{65, 0, 0}}},
+		IOS:     {{start: v{12, 2, 0}}},
+		Node:    {{start: v{12, 0, 0}}},
+		Opera:   {{start: v{58, 0, 0}}},
+		Safari:  {{start: v{12, 1, 0}}},
+	}},
+	GlobalWeakMap: {module: "es.weak-map", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{38, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{36, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{0, 12, 0}}},
+		Opera:   {{start: v{25, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	GlobalWeakSet: {module: "es.weak-set", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{38, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{36, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{0, 12, 0}}},
+		Opera:   {{start: v{25, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	MapGroupBy: {module: "es.map.group-by", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{117, 0, 0}}},
+		Deno:    {{start: v{1, 37, 0}}},
+		Edge:    {{start: v{117, 0, 0}}},
+		ES:      {{start: v{2024, 0, 0}}},
+		Firefox: {{start: v{119, 0, 0}}},
+		IOS:     {{start: v{17, 4, 0}}},
+		Node:    {{start: v{21, 0, 0}}},
+		Opera:   {{start: v{103, 0, 0}}},
+		Safari:  {{start: v{17, 4, 0}}},
+	}},
+	ObjectAssign: {module: "es.object.assign", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	ObjectEntries: {module: "es.object.entries", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{54, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{14, 0, 0}}},
+		ES:      {{start: v{2017, 0, 0}}},
+		Firefox: {{start: v{47, 0, 0}}},
+		IOS:     {{start: v{10, 3, 0}}},
+		Node:    {{start: v{7, 0, 0}}},
+		Opera:   {{start: v{41, 0, 0}}},
+		Safari:  {{start: v{10, 1, 0}}},
+	}},
+	ObjectFromEntries: {module: "es.object.from-entries", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{73, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2019, 0, 0}}},
+		Firefox: {{start: v{63, 0, 0}}},
+		IOS:     {{start: v{12, 2, 0}}},
+		Node:    {{start: v{12, 0, 0}}},
+		Opera:   {{start: v{60, 0, 0}}},
+		Safari:  {{start: v{12, 1, 0}}},
+	}},
+	ObjectGroupBy: {module: "es.object.group-by", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{117, 0, 0}}},
+		Deno:    {{start: v{1, 37, 0}}},
+		Edge:    {{start: v{117, 0, 0}}},
+		ES:      {{start: v{2024, 0, 0}}},
+		Firefox: {{start: v{119, 0, 0}}},
+		IOS:     {{start: v{17, 4, 0}}},
+		Node:    {{start: v{21, 0, 0}}},
+		Opera:   {{start: v{103, 0, 0}}},
+		Safari:  {{start: v{17, 4, 0}}},
+	}},
+	ObjectHasOwn: {module: "es.object.has-own", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{93, 0, 0}}},
+		Deno:    {{start: v{1, 13, 0}}},
+		Edge:    {{start: v{93, 0, 0}}},
+		ES:      {{start: v{2022, 0, 0}}},
+		Firefox: {{start: v{92, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Node:    {{start: v{16, 9, 0}}},
+		Opera:   {{start: v{79, 0, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	}},
+	ObjectValues: {module: "es.object.values", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{54, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{14, 0, 0}}},
+		ES:      {{start: v{2017, 0, 0}}},
+		Firefox: {{start: v{47, 0, 0}}},
+		IOS:     {{start: v{10, 3, 0}}},
+		Node:    {{start: v{7, 0, 0}}},
+		Opera:   {{start: v{41, 0, 0}}},
+		Safari:  {{start: v{10, 1, 0}}},
+	}},
+	PromiseAllSettled: {module: "es.promise.all-settled", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{76, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2020, 0, 0}}},
+		Firefox: {{start: v{71, 0, 0}}},
+		IOS:     {{start: v{13, 0, 0}}},
+		Node:    {{start: v{12, 9, 0}}},
+		Opera:   {{start: v{63, 0, 0}}},
+		Safari:  {{start: v{13, 0, 0}}},
+	}},
+	PromiseAny: {module: "es.promise.any", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{85, 0, 0}}},
+		Deno:    {{start: v{1, 2, 0}}},
+		Edge:    {{start: v{85, 0, 0}}},
+		ES:      {{start: v{2021, 0, 0}}},
+		Firefox: {{start: v{79, 0, 0}}},
+		IOS:     {{start: v{14, 0, 0}}},
+		Node:    {{start: v{15, 0, 0}}},
+		Opera:   {{start: v{71, 0, 0}}},
+		Safari:  {{start: v{14, 0, 0}}},
+	}},
+	PromisePrototypeFinally: {module: "es.promise.finally", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{63, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{18, 0, 0}}},
+		ES:      {{start: v{2018, 0, 0}}},
+		Firefox: {{start: v{58, 0, 0}}},
+		IOS:     {{start: v{11, 3, 0}}},
+		Node:    {{start: v{10, 0, 0}}},
+		Opera:   {{start: v{50, 0, 0}}},
+		Safari:  {{start: v{11, 1, 0}}},
+	}},
+	PromiseWithResolvers: {module: "es.promise.with-resolvers", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{119, 0, 0}}},
+		Deno:    {{start: v{1, 38, 0}}},
+		Edge:    {{start: v{119, 0, 0}}},
+		ES:      {{start: v{2024, 0, 0}}},
+		Firefox: {{start: v{121, 0, 0}}},
+		IOS:     {{start: v{17, 4, 0}}},
+		Node:    {{start: v{22, 0, 0}}},
+		Opera:   {{start: v{105, 0, 0}}},
+		Safari:  {{start: v{17, 4, 0}}},
+	}},
+	StringPrototypeAt: {module: "es.string.at-alternative", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{92, 0, 0}}},
+		Deno:    {{start: v{1, 12, 0}}},
+		Edge:    {{start: v{92, 0, 0}}},
+		ES:      {{start: v{2022, 0, 0}}},
+		Firefox: {{start: v{90, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Node:    {{start: v{16, 6, 0}}},
+		Opera:   {{start: v{78, 0, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	}},
+	StringPrototypeEndsWith: {module: "es.string.ends-with", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	StringPrototypeIncludes: {module: "es.string.includes", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	StringPrototypeIsWellFormed: {module: "es.string.is-well-formed", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{111, 0, 0}}},
+		Deno:    {{start: v{1, 32, 0}}},
+		Edge:    {{start: v{111, 0, 0}}},
+		ES:      {{start: v{2024, 0, 0}}},
+		Firefox: {{start: v{119, 0, 0}}},
+		IOS:     {{start: v{16, 4, 0}}},
+		Node:    {{start: v{20, 0, 0}}},
+		Opera:   {{start: v{97, 0, 0}}},
+		Safari:  {{start: v{16, 4, 0}}},
+	}},
+	StringPrototypeMatchAll: {module: "es.string.match-all", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{73, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2020, 0, 0}}},
+		Firefox: {{start: v{67, 0, 0}}},
+		IOS:     {{start: v{13, 0, 0}}},
+		Node:    {{start: v{12, 0, 0}}},
+		Opera:   {{start: v{60, 0, 0}}},
+		Safari:  {{start: v{13, 0, 0}}},
+	}},
+	StringPrototypePadEnd: {module: "es.string.pad-end", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{57, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{15, 0, 0}}},
+		ES:      {{start: v{2017, 0, 0}}},
+		Firefox: {{start: v{48, 0, 0}}},
+		IOS:     {{start: v{10, 0, 0}}},
+		Node:    {{start: v{8, 0, 0}}},
+		Opera:   {{start: v{44, 0, 0}}},
+		Safari:  {{start: v{10, 0, 0}}},
+	}},
+	StringPrototypePadStart: {module: "es.string.pad-start", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{57, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{15, 0, 0}}},
+		ES:      {{start: v{2017, 0, 0}}},
+		Firefox: {{start: v{48, 0, 0}}},
+		IOS:     {{start: v{10, 0, 0}}},
+		Node:    {{start: v{8, 0, 0}}},
+		Opera:   {{start: v{44, 0, 0}}},
+		Safari:  {{start: v{10, 0, 0}}},
+	}},
+	StringPrototypeReplaceAll: {module: "es.string.replace-all", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{85, 0, 0}}},
+		Deno:    {{start: v{1, 2, 0}}},
+		Edge:    {{start: v{85, 0, 0}}},
+		ES:      {{start: v{2021, 0, 0}}},
+		Firefox: {{start: v{77, 0, 0}}},
+		IOS:     {{start: v{13, 4, 0}}},
+		Node:    {{start: v{15, 0, 0}}},
+		Opera:   {{start: v{71, 0, 0}}},
+		Safari:  {{start: v{13, 1, 0}}},
+	}},
+	StringPrototypeStartsWith: {module: "es.string.starts-with", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{45, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{12, 0, 0}}},
+		ES:      {{start: v{2015, 0, 0}}},
+		Firefox: {{start: v{34, 0, 0}}},
+		IOS:     {{start: v{9, 0, 0}}},
+		Node:    {{start: v{4, 0, 0}}},
+		Opera:   {{start: v{32, 0, 0}}},
+		Safari:  {{start: v{9, 0, 0}}},
+	}},
+	StringPrototypeToWellFormed: {module: "es.string.to-well-formed", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{111, 0, 0}}},
+		Deno:    {{start: v{1, 32, 0}}},
+		Edge:    {{start: v{111, 0, 0}}},
+		ES:      {{start: v{2024, 0, 0}}},
+		Firefox: {{start: v{119, 0, 0}}},
+		IOS:     {{start: v{16, 4, 0}}},
+		Node:    {{start: v{20, 0, 0}}},
+		Opera:   {{start: v{97, 0, 0}}},
+		Safari:  {{start: v{16, 4, 0}}},
+	}},
+	StringPrototypeTrimEnd: {module: "es.string.trim-end", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{66, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2019, 0, 0}}},
+		Firefox: {{start: v{61, 0, 0}}},
+		IOS:     {{start: v{12, 0, 0}}},
+		Node:    {{start: v{10, 0, 0}}},
+		Opera:   {{start: v{53, 0, 0}}},
+		Safari:  {{start: v{12, 0, 0}}},
+	}},
+	StringPrototypeTrimStart: {module: "es.string.trim-start", engines: map[Engine][]versionRange{
+		Chrome:  {{start: v{66, 0, 0}}},
+		Deno:    {{start: v{1, 0, 0}}},
+		Edge:    {{start: v{79, 0, 0}}},
+		ES:      {{start: v{2019, 0, 0}}},
+		Firefox: {{start: v{61, 0, 0}}},
+		IOS:     {{start: v{12, 0, 0}}},
+		Node:    {{start: v{10, 0, 0}}},
+		Opera:   {{start: v{53, 0, 0}}},
+		Safari:  {{start: v{12, 0, 0}}},
+	}},
+}
+
+// Return all built-ins that are not available in at least one environment
+func UnsupportedBuiltins(constraints map[Engine]Semver) (unsupported Builtin) {
+	for builtin, info := range builtinTable {
+		for engine, version := range constraints {
+			versionRanges, ok := info.engines[engine]
+			if !ok && engine == ES {
+				continue // This isn't part of the language specification
+			}
+			if !ok || !isVersionSupported(versionRanges, version) {
+				unsupported |= builtin
+			}
+		}
+	}
+	return
+}
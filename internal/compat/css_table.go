@@ -6,10 +6,12 @@ import (
 	"github.com/evanw/esbuild/internal/css_ast"
 )
 
-type CSSFeature uint16
+type CSSFeature uint32
 
 const (
-	ColorFunctions CSSFeature = 1 << iota
+	CascadeLayers CSSFeature = 1 << iota
+	ColorFunctions
+	ColorMix
 	GradientDoublePosition
 	GradientInterpolation
 	GradientMidpoints
@@ -18,14 +20,20 @@ const (
 	InlineStyle
 	InsetProperty
 	IsPseudoClass
+	LightDark
+	LogicalProperties
+	LogicalValues
 	MediaRange
 	Modern_RGB_HSL
 	Nesting
 	RebeccaPurple
+	RelativeColors
 )
 
 var StringToCSSFeature = map[string]CSSFeature{
+	"cascade-layers":           CascadeLayers,
 	"color-functions":          ColorFunctions,
+	"color-mix":                ColorMix,
 	"gradient-double-position": GradientDoublePosition,
 	"gradient-interpolation":   GradientInterpolation,
 	"gradient-midpoints":       GradientMidpoints,
@@ -34,10 +42,14 @@ var StringToCSSFeature = map[string]CSSFeature{
 	"inline-style":             InlineStyle,
 	"inset-property":           InsetProperty,
 	"is-pseudo-class":          IsPseudoClass,
+	"light-dark":               LightDark,
+	"logical-properties":       LogicalProperties,
+	"logical-values":           LogicalValues,
 	"media-range":              MediaRange,
 	"modern-rgb-hsl":           Modern_RGB_HSL,
 	"nesting":                  Nesting,
 	"rebecca-purple":           RebeccaPurple,
+	"relative-colors":          RelativeColors,
 }
 
 func (features CSSFeature) Has(feature CSSFeature) bool {
@@ -49,6 +61,14 @@ func (features CSSFeature) ApplyOverrides(overrides CSSFeature, mask CSSFeature)
 }
 
 var cssTable = map[CSSFeature]map[Engine][]versionRange{
+	CascadeLayers: {
+		Chrome:  {{start: v{99, 0, 0}}},
+		Edge:    {{start: v{99, 0, 0}}},
+		Firefox: {{start: v{97, 0, 0}}},
+		IOS:     {{start: v{15, 4, 0}}},
+		Opera:   {{start: v{85, 0, 0}}},
+		Safari:  {{start: v{15, 4, 0}}},
+	},
 	ColorFunctions: {
 		Chrome:  {{start: v{111, 0, 0}}},
 		Edge:    {{start: v{111, 0, 0}}},
@@ -57,6 +77,14 @@ var cssTable = map[CSSFeature]map[Engine][]versionRange{
 		Opera:   {{start: v{97, 0, 0}}},
 		Safari:  {{start: v{15, 4, 0}}},
 	},
+	ColorMix: {
+		Chrome:  {{start: v{111, 0, 0}}},
+		Edge:    {{start: v{111, 0, 0}}},
+		Firefox: {{start: v{113, 0, 0}}},
+		IOS:     {{start: v{16, 2, 0}}},
+		Opera:   {{start: v{97, 0, 0}}},
+		Safari:  {{start: v{16, 2, 0}}},
+	},
 	GradientDoublePosition: {
 		Chrome:  {{start: v{72, 0, 0}}},
 		Edge:    {{start: v{79, 0, 0}}},
@@ -114,6 +142,30 @@ var cssTable = map[CSSFeature]map[Engine][]versionRange{
 		Opera:   {{start: v{75, 0, 0}}},
 		Safari:  {{start: v{14, 0, 0}}},
 	},
+	LightDark: {
+		Chrome:  {{start: v{123, 0, 0}}},
+		Edge:    {{start: v{123, 0, 0}}},
+		Firefox: {{start: v{120, 0, 0}}},
+		IOS:     {{start: v{17, 5, 0}}},
+		Opera:   {{start: v{109, 0, 0}}},
+		Safari:  {{start: v{17, 5, 0}}},
+	},
+	LogicalProperties: {
+		Chrome:  {{start: v{89, 0, 0}}},
+		Edge:    {{start: v{89, 0, 0}}},
+		Firefox: {{start: v{66, 0, 0}}},
+		IOS:     {{start: v{15, 0, 0}}},
+		Opera:   {{start: v{75, 0, 0}}},
+		Safari:  {{start: v{15, 0, 0}}},
+	},
+	LogicalValues: {
+		Chrome:  {{start: v{118, 0, 0}}},
+		Edge:    {{start: v{118, 0, 0}}},
+		Firefox: {{start: v{55, 0, 0}}},
+		IOS:     {{start: v{15, 0, 0}}},
+		Opera:   {{start: v{104, 0, 0}}},
+		Safari:  {{start: v{15, 0, 0}}},
+	},
 	MediaRange: {
 		Chrome:  {{start: v{104, 0, 0}}},
 		Edge:    {{start: v{104, 0, 0}}},
@@ -147,6 +199,14 @@ var cssTable = map[CSSFeature]map[Engine][]versionRange{
 		Opera:   {{start: v{25, 0, 0}}},
 		Safari:  {{start: v{9, 0, 0}}},
 	},
+	RelativeColors: {
+		Chrome:  {{start: v{119, 0, 0}}},
+		Edge:    {{start: v{119, 0, 0}}},
+		Firefox: {{start: v{128, 0, 0}}},
+		IOS:     {{start: v{18, 0, 0}}},
+		Opera:   {{start: v{105, 0, 0}}},
+		Safari:  {{start: v{18, 0, 0}}},
+	},
 }
 
 // Return all features that are not available in at least one environment
@@ -193,6 +253,90 @@ type prefixData struct {
 }
 
 var cssPrefixTable = map[css_ast.D][]prefixData{
+	css_ast.DAlignContent: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAlignItems: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAlignSelf: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimation: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationDelay: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationDirection: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationDuration: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationFillMode: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationIterationCount: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationName: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationPlayState: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DAnimationTimingFunction: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
 	css_ast.DAppearance: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{84, 0, 0}},
 		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{84, 0, 0}},
@@ -205,6 +349,13 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{18, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{18, 0, 0}},
 	},
+	css_ast.DBackfaceVisibility: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{36, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{15, 4, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{23, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{15, 4, 0}},
+	},
 	css_ast.DBackgroundClip: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{120, 0, 0}},
 		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{15, 0, 0}},
@@ -212,6 +363,12 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{106, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{5, 0, 0}},
 	},
+	css_ast.DBorderRadius: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{5, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{4, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{4, 2, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{5, 0, 0}},
+	},
 	css_ast.DBoxDecorationBreak: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{130, 0, 0}},
 		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{130, 0, 0}},
@@ -219,12 +376,154 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{116, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix},
 	},
+	css_ast.DBoxShadow: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{10, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{4, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{5, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{5, 1, 0}},
+	},
+	css_ast.DBoxSizing: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{10, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{5, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{5, 1, 0}},
+	},
 	css_ast.DClipPath: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{55, 0, 0}},
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{13, 0, 0}},
 		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{42, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{13, 1, 0}},
 	},
+	css_ast.DColumnCount: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnFill: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnGap: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnRule: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnRuleColor: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnRuleStyle: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnRuleWidth: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnSpan: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumnWidth: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DColumns: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFilter: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{53, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 3, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{40, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 1, 0}},
+	},
+	css_ast.DFlex: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFlexBasis: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFlexDirection: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFlexFlow: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFlexGrow: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFlexShrink: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFlexWrap: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DFontFeatureSettings: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{48, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{34, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{35, 0, 0}},
+	},
 	css_ast.DFontKerning: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{33, 0, 0}},
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{12, 0, 0}},
@@ -250,6 +549,13 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: IOS, prefix: WebkitPrefix},
 		{engine: Safari, prefix: WebkitPrefix},
 	},
+	css_ast.DJustifyContent: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
 	css_ast.DMask: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{120, 0, 0}},
 		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{120, 0, 0}},
@@ -327,6 +633,27 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{122, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix},
 	},
+	css_ast.DOrder: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DPerspective: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{36, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{23, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DPerspectiveOrigin: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{36, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{23, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
 	css_ast.DPosition: {
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{13, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{13, 0, 0}},
@@ -341,6 +668,9 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{91, 0, 0}},
 		{engine: Opera, prefix: OPrefix, withoutPrefix: v{15, 0, 0}},
 	},
+	css_ast.DTextAlignLast: {
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{49, 0, 0}},
+	},
 	css_ast.DTextDecorationColor: {
 		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{36, 0, 0}},
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{12, 2, 0}},
@@ -355,6 +685,11 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{12, 2, 0}},
 		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{12, 1, 0}},
 	},
+	css_ast.DTextDecorationStyle: {
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{36, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{12, 2, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{12, 1, 0}},
+	},
 	css_ast.DTextEmphasisColor: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
 		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
@@ -377,6 +712,67 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{79, 0, 0}},
 		{engine: IOS, prefix: WebkitPrefix},
 	},
+	css_ast.DTouchAction: {
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+	},
+	css_ast.DTransform: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{36, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{10, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{23, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DTransformOrigin: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{36, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{10, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{23, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DTransformStyle: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{36, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{23, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	css_ast.DTransition: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	css_ast.DTransitionDelay: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	css_ast.DTransitionDuration: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	css_ast.DTransitionProperty: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	css_ast.DTransitionTimingFunction: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
 	css_ast.DUserSelect: {
 		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{54, 0, 0}},
 		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{79, 0, 0}},
@@ -395,28 +791,263 @@ var cssPrefixTable = map[css_ast.D][]prefixData{
 		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{122, 0, 0}},
 		{engine: Safari, prefix: WebkitPrefix},
 	},
+	css_ast.DWritingMode: {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{48, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{10, 3, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{35, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{10, 1, 0}},
+	},
 }
 
-func CSSPrefixData(constraints map[Engine]Semver) (entries map[css_ast.D]CSSPrefix) {
-	for property, items := range cssPrefixTable {
-		prefixes := NoPrefix
-		for engine, version := range constraints {
-			if !engine.IsBrowser() {
-				// Specifying "--target=es2020" shouldn't affect CSS
-				continue
-			}
-			for _, item := range items {
-				if item.engine == engine && (item.withoutPrefix == v{} || compareVersions(item.withoutPrefix, version) > 0) {
-					prefixes |= item.prefix
-				}
-			}
+// Prefixed values such as "-webkit-image-set()", keyed by the unprefixed name
+var cssValuePrefixTable = map[string][]prefixData{
+	"box": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{21, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{22, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	"calc": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	"cross-fade": {
+		{engine: Chrome, prefix: WebkitPrefix},
+		{engine: Edge, prefix: WebkitPrefix},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{10, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{10, 0, 0}},
+	},
+	"element": {
+		{engine: Firefox, prefix: MozPrefix},
+	},
+	"fit-content": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{46, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{94, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{33, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+	},
+	"flex": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	"grab": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{68, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{27, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{55, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+	},
+	"grabbing": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{68, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{27, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{55, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+	},
+	"image-set": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{113, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{99, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 0, 0}},
+	},
+	"inline-flex": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{29, 0, 0}},
+		{engine: IE, prefix: MsPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	"linear-gradient": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	"max-content": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{46, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{66, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{33, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+	},
+	"min-content": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{46, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{66, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{33, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{11, 0, 0}},
+	},
+	"radial-gradient": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	"repeating-linear-gradient": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	"repeating-radial-gradient": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{26, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{7, 0, 0}},
+		{engine: Opera, prefix: OPrefix, withoutPrefix: v{12, 1, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{6, 1, 0}},
+	},
+	"zoom-in": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{24, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{24, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	"zoom-out": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{37, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{24, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{24, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+}
+
+// Prefixed pseudo-classes and pseudo-elements such as "::-moz-placeholder",
+// keyed by the unprefixed name
+var cssSelectorPrefixTable = map[string][]prefixData{
+	"any-link": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{65, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{50, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{52, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+	"autofill": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{110, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{110, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{15, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{96, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{15, 0, 0}},
+	},
+	"backdrop": {
+		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{79, 0, 0}},
+		{engine: IE, prefix: MsPrefix},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{15, 4, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{15, 4, 0}},
+	},
+	"file-selector-button": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{89, 0, 0}},
+		{engine: Edge, prefix: WebkitPrefix, withoutPrefix: v{89, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{14, 5, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{75, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{14, 1, 0}},
+	},
+	"fullscreen": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{71, 0, 0}},
+		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{79, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{64, 0, 0}},
+		{engine: IE, prefix: MsPrefix},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{58, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{16, 4, 0}},
+	},
+	"placeholder": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{57, 0, 0}},
+		{engine: Edge, prefix: MsPrefix, withoutPrefix: v{79, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{51, 0, 0}},
+		{engine: IE, prefix: MsPrefix},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{10, 3, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{44, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{10, 1, 0}},
+	},
+	"read-only": {
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{78, 0, 0}},
+	},
+	"read-write": {
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{78, 0, 0}},
+	},
+	"selection": {
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{62, 0, 0}},
+	},
+}
+
+// Prefixed at-rules such as "@-webkit-keyframes", keyed by the unprefixed name
+var cssAtRulePrefixTable = map[string][]prefixData{
+	"keyframes": {
+		{engine: Chrome, prefix: WebkitPrefix, withoutPrefix: v{43, 0, 0}},
+		{engine: Firefox, prefix: MozPrefix, withoutPrefix: v{16, 0, 0}},
+		{engine: IOS, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+		{engine: Opera, prefix: WebkitPrefix, withoutPrefix: v{30, 0, 0}},
+		{engine: Safari, prefix: WebkitPrefix, withoutPrefix: v{9, 0, 0}},
+	},
+}
+
+func hasBrowserConstraint(constraints map[Engine]Semver) bool {
+	for engine := range constraints {
+		// Specifying "--target=es2020" shouldn't affect CSS
+		if engine.IsBrowser() {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixesForConstraints(items []prefixData, constraints map[Engine]Semver) (prefixes CSSPrefix) {
+	for engine, version := range constraints {
+		if !engine.IsBrowser() {
+			// Specifying "--target=es2020" shouldn't affect CSS
+			continue
 		}
-		if prefixes != NoPrefix {
-			if entries == nil {
-				entries = make(map[css_ast.D]CSSPrefix)
+		for _, item := range items {
+			if item.engine == engine && (item.withoutPrefix == v{} || compareVersions(item.withoutPrefix, version) > 0) {
+				prefixes |= item.prefix
 			}
-			entries[property] = prefixes
 		}
 	}
 	return
 }
+
+// Every property in the table has an entry as long as there is at least one
+// browser target. An entry of "NoPrefix" means the property is known to not
+// need any prefixes, so existing prefixed declarations can be removed.
+func CSSPrefixData(constraints map[Engine]Semver) (entries map[css_ast.D]CSSPrefix) {
+	if !hasBrowserConstraint(constraints) {
+		return
+	}
+	entries = make(map[css_ast.D]CSSPrefix, len(cssPrefixTable))
+	for property, items := range cssPrefixTable {
+		entries[property] = prefixesForConstraints(items, constraints)
+	}
+	return
+}
+
+type CSSNamePrefixes struct {
+	Values    map[string]CSSPrefix
+	Selectors map[string]CSSPrefix
+	AtRules   map[string]CSSPrefix
+}
+
+func namePrefixData(table map[string][]prefixData, constraints map[Engine]Semver) map[string]CSSPrefix {
+	entries := make(map[string]CSSPrefix, len(table))
+	for name, items := range table {
+		entries[name] = prefixesForConstraints(items, constraints)
+	}
+	return entries
+}
+
+// This works like "CSSPrefixData" but for things other than property names
+func CSSNamePrefixData(constraints map[Engine]Semver) (data CSSNamePrefixes) {
+	if !hasBrowserConstraint(constraints) {
+		return
+	}
+	data.Values = namePrefixData(cssValuePrefixTable, constraints)
+	data.Selectors = namePrefixData(cssSelectorPrefixTable, constraints)
+	data.AtRules = namePrefixData(cssAtRulePrefixTable, constraints)
+	return
+}
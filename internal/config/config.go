@@ -23,6 +23,7 @@ type JSXOptions struct {
 	ImportSource     string
 	Development      bool
 	SideEffects      bool
+	Refresh          bool
 }
 
 type TSJSX uint8
@@ -167,6 +168,18 @@ const (
 	SourceMapInlineAndExternal
 )
 
+type DebugIDs uint8
+
+const (
+	DebugIDsNone DebugIDs = iota
+
+	// Write "debugId" in the source map and a "//# debugId=" comment
+	DebugIDsComment
+
+	// Also register the debug ID with the global "_debugIds" object at run-time
+	DebugIDsRegister
+)
+
 type LegalComments uint8
 
 const (
@@ -203,6 +216,7 @@ const (
 	LoaderTS
 	LoaderTSNoAmbiguousLessThan // Used with ".mts" and ".cts"
 	LoaderTSX
+	LoaderAutoDataURL
 )
 
 var LoaderToString = []string{
@@ -225,6 +239,7 @@ var LoaderToString = []string{
 	"ts",
 	"ts",
 	"tsx",
+	"auto-dataurl",
 }
 
 func (loader Loader) IsTypeScript() bool {
@@ -423,6 +438,17 @@ type Options struct {
 		cssUsedLocalNames map[string]bool,
 	))
 
+	// This is like "ExclusiveMangleCacheUpdate" but for the name cache. It's
+	// called after all chunks have been generated, since that's when minified
+	// names for top-level symbols are assigned.
+	ExclusiveNameCacheUpdate func(cb func(nameCache map[string]interface{}))
+
+	// This remembers the minified names of top-level symbols and cross-chunk
+	// exports from a previous build. It must be treated as immutable since
+	// it's shared between threads. New names are written to the map passed
+	// to "ExclusiveNameCacheUpdate" instead.
+	NameCache map[string]interface{}
+
 	// This is the original information that was used to generate the
 	// unsupported feature sets above. It's used for error messages.
 	OriginalTargetEnv string
@@ -447,6 +473,7 @@ type Options struct {
 	ExtensionToLoader  map[string]Loader
 
 	PublicPath      string
+	ExternalHelpers string
 	InjectPaths     []string
 	InjectedDefines []InjectedDefine
 	InjectedFiles   []InjectedFile
@@ -460,6 +487,10 @@ type Options struct {
 	ChunkPathTemplate []PathTemplate
 	AssetPathTemplate []PathTemplate
 
+	// Files loaded with the "auto-dataurl" loader that are smaller than this
+	// many bytes use the "dataurl" loader, and the rest use the "file" loader
+	AssetInlineLimit int
+
 	Plugins    []Plugin
 	SourceRoot string
 	Stdin      *StdinInfo
@@ -467,8 +498,15 @@ type Options struct {
 	LineLimit  int
 
 	CSSPrefixData          map[css_ast.D]compat.CSSPrefix
+	CSSNamePrefixData      compat.CSSNamePrefixes
 	UnsupportedJSFeatures  compat.JSFeature
 	UnsupportedCSSFeatures compat.CSSFeature
+	UnsupportedBuiltins    compat.Builtin
+
+	// If this is non-empty, imports of polyfills from this package are added
+	// for any built-in APIs that are used but that are missing from the
+	// configured target environment (i.e. in "UnsupportedBuiltins").
+	Polyfill string
 
 	UnsupportedJSFeatureOverrides      compat.JSFeature
 	UnsupportedJSFeatureOverridesMask  compat.JSFeature
@@ -487,6 +525,22 @@ type Options struct {
 	AllowOverwrite    bool
 	LegalComments     LegalComments
 
+	// If true, all comments (not just legal comments and annotations) are
+	// attached to the nearby statement or expression and re-emitted by the
+	// printer. This has no effect when whitespace is being minified.
+	PreserveComments bool
+
+	// If true, small functions that are only called once are inlined into the
+	// call site and immediately-invoked arrow functions are unwrapped. This
+	// only has an effect when syntax is also being minified.
+	InlineFunctions bool
+
+	// If true, a syntax error in a JavaScript or TypeScript file only discards
+	// the statement containing it. Parsing continues after the next statement
+	// boundary so that all syntax errors are reported and the rest of the file
+	// still produces an AST.
+	ErrorRecovery bool
+
 	LogPathStyle       logger.PathStyle
 	CodePathStyle      logger.PathStyle
 	MetafilePathStyle  logger.PathStyle
@@ -504,6 +558,8 @@ type Options struct {
 	KeepNames              bool
 	IgnoreDCEAnnotations   bool
 	TreeShaking            bool
+	InferSideEffects       bool
+	AssumePure             js_ast.AssumePure
 	DropDebugger           bool
 	MangleQuoted           bool
 	Platform               Platform
@@ -511,6 +567,21 @@ type Options struct {
 	NeedsMetafile          bool
 	SourceMap              SourceMap
 	ExcludeSourcesContent  bool
+
+	// Sources with a path that matches this are listed in "ignoreList"
+	SourceMapIgnoreList *regexp.Regexp
+
+	DebugIDs DebugIDs
+
+	// If enabled, a ".d.ts" file is generated for each CSS module. These are
+	// written next to each CSS module unless "AbsCSSModuleTypesDir" is set.
+	CSSModuleTypes       bool
+	AbsCSSModuleTypesDir string
+
+	// This maps package names to a value to use for that package instead of the
+	// "sideEffects" field in "package.json" (or instead of inferring one if the
+	// "InferSideEffects" setting is enabled)
+	PackageSideEffects map[string]bool
 }
 
 type TSImportsNotUsedAsValues uint8
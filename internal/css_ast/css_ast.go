@@ -42,6 +42,32 @@ type AST struct {
 	// them in the right places.
 	LayersPreImport  [][]string
 	LayersPostImport [][]string
+
+	// These are for ICSS (Interoperable CSS), which CSS modules use to share
+	// values between CSS files and with JavaScript. They are only present for
+	// files with local names:
+	//
+	//   @value primary: #f00;
+	//   @value small, large as bp-large from "./breakpoints.css";
+	//   :import("./colors.css") { accent: primary }
+	//   :export { primaryColor: primary }
+	//
+	ICSSValues  []ICSSValue
+	ICSSImports []ICSSImport
+	ICSSExports []ICSSValue
+}
+
+type ICSSValue struct {
+	Name  string
+	Value []Token
+	Loc   logger.Loc
+}
+
+type ICSSImport struct {
+	Name              string
+	Alias             string
+	NameLoc           logger.Loc
+	ImportRecordIndex uint32
 }
 
 type Composes struct {
@@ -789,6 +815,46 @@ func (r *RAtMedia) Hash() (uint32, bool) {
 	return hash, true
 }
 
+// These are removed by the linker after being substituted into "@media"
+// rules and selectors. They are kept in the AST until then because they may
+// be defined in a different file than the one where they are used.
+//
+// Reference: https://drafts.csswg.org/mediaqueries-5/#custom-mq
+type RAtCustomMedia struct {
+	Name    string // This includes the leading "--"
+	Queries []MediaQuery
+}
+
+func (a *RAtCustomMedia) Equal(rule R, check *CrossFileEqualityCheck) bool {
+	b, ok := rule.(*RAtCustomMedia)
+	return ok && a.Name == b.Name && MediaQueriesEqual(a.Queries, b.Queries, check)
+}
+
+func (r *RAtCustomMedia) Hash() (uint32, bool) {
+	hash := uint32(12)
+	hash = helpers.HashCombineString(hash, r.Name)
+	hash = HashMediaQueries(hash, r.Queries)
+	return hash, true
+}
+
+// Reference: https://drafts.csswg.org/css-extensions-1/#custom-selectors
+type RAtCustomSelector struct {
+	Name      string // This includes the leading "--" but not the leading ":"
+	Selectors []ComplexSelector
+}
+
+func (a *RAtCustomSelector) Equal(rule R, check *CrossFileEqualityCheck) bool {
+	b, ok := rule.(*RAtCustomSelector)
+	return ok && a.Name == b.Name && ComplexSelectorsEqual(a.Selectors, b.Selectors, check)
+}
+
+func (r *RAtCustomSelector) Hash() (uint32, bool) {
+	hash := uint32(13)
+	hash = helpers.HashCombineString(hash, r.Name)
+	hash = HashComplexSelectors(hash, r.Selectors)
+	return hash, true
+}
+
 type MediaQuery struct {
 	Loc  logger.Loc
 	Data MQ
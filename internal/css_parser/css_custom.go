@@ -0,0 +1,548 @@
+package css_parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Custom media queries and custom selectors may be defined in one file and
+// used in another file, so they can't be substituted by the parser. Instead
+// the linker collects all definitions in a chunk and substitutes them into
+// each file's rules right before printing. Substitution never mutates the
+// original AST since ASTs are shared between chunks that are generated in
+// parallel.
+type CustomDefinitions struct {
+	media                  map[string][]css_ast.MediaQuery
+	selectors              map[string][]css_ast.ComplexSelector
+	resolvedMedia          map[string][]css_ast.MediaQuery
+	resolvedSelectors      map[string][]css_ast.ComplexSelector
+	unsupportedCSSFeatures compat.CSSFeature
+}
+
+func MakeCustomDefinitions(unsupportedCSSFeatures compat.CSSFeature) CustomDefinitions {
+	return CustomDefinitions{unsupportedCSSFeatures: unsupportedCSSFeatures}
+}
+
+func (d *CustomDefinitions) IsEmpty() bool {
+	return d.media == nil && d.selectors == nil
+}
+
+// Definitions must be added in the order that the files appear in the
+// output. Later definitions with the same name replace earlier ones.
+func (d *CustomDefinitions) AddDefinitions(rules []css_ast.Rule) {
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RAtCustomMedia:
+			if d.media == nil {
+				d.media = make(map[string][]css_ast.MediaQuery)
+				d.resolvedMedia = make(map[string][]css_ast.MediaQuery)
+			}
+			d.media[r.Name] = r.Queries
+
+		case *css_ast.RAtCustomSelector:
+			if d.selectors == nil {
+				d.selectors = make(map[string][]css_ast.ComplexSelector)
+				d.resolvedSelectors = make(map[string][]css_ast.ComplexSelector)
+			}
+			d.selectors[r.Name] = r.Selectors
+		}
+	}
+}
+
+// This returns a new list of rules if anything was substituted. Custom names
+// that aren't defined are left alone.
+func (d *CustomDefinitions) SubstituteInRules(rules []css_ast.Rule) []css_ast.Rule {
+	if d.IsEmpty() {
+		return rules
+	}
+	rules, _ = d.substituteInRules(rules)
+	return rules
+}
+
+type CustomWarning func(id logger.MsgID, r logger.Range, text string)
+
+// This reports uses of custom media queries and custom selectors that aren't
+// defined, as well as uses of custom selectors that can only be substituted
+// using ":is()" when ":is()" isn't supported. This isn't done during
+// substitution since that happens once per chunk, which would report the
+// same problem many times. The linker calls this once per file instead.
+func (d *CustomDefinitions) CheckRules(rules []css_ast.Rule, warn CustomWarning) {
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RSelector:
+			d.checkComplexSelectors(r.Selectors, warn)
+			d.CheckRules(r.Rules, warn)
+
+		case *css_ast.RAtMedia:
+			for _, query := range r.Queries {
+				d.checkMediaQuery(query, warn)
+			}
+			d.CheckRules(r.Rules, warn)
+
+		case *css_ast.RAtScope:
+			d.checkComplexSelectors(r.Start, warn)
+			d.checkComplexSelectors(r.End, warn)
+			d.CheckRules(r.Rules, warn)
+
+		case *css_ast.RKnownAt:
+			d.CheckRules(r.Rules, warn)
+
+		case *css_ast.RAtLayer:
+			d.CheckRules(r.Rules, warn)
+
+		case *css_ast.RAtCustomMedia:
+			for _, query := range r.Queries {
+				d.checkMediaQuery(query, warn)
+			}
+
+		case *css_ast.RAtCustomSelector:
+			d.checkComplexSelectors(r.Selectors, warn)
+		}
+	}
+}
+
+func (d *CustomDefinitions) checkMediaQuery(query css_ast.MediaQuery, warn CustomWarning) {
+	switch q := query.Data.(type) {
+	case *css_ast.MQPlainOrBoolean:
+		if name, ok := customMediaName(query); ok {
+			if _, ok := d.media[name]; !ok {
+				warn(logger.MsgID_CSS_UndefinedCustomName, logger.Range{Loc: query.Loc, Len: int32(len(name)) + 2},
+					fmt.Sprintf("The custom media query %q is not defined", name))
+			}
+		}
+
+	case *css_ast.MQType:
+		if q.AndOrNull.Data != nil {
+			d.checkMediaQuery(q.AndOrNull, warn)
+		}
+
+	case *css_ast.MQNot:
+		d.checkMediaQuery(q.Inner, warn)
+
+	case *css_ast.MQBinary:
+		for _, term := range q.Terms {
+			d.checkMediaQuery(term, warn)
+		}
+	}
+}
+
+func (d *CustomDefinitions) checkComplexSelectors(selectors []css_ast.ComplexSelector, warn CustomWarning) {
+	for _, sel := range selectors {
+		for i, compound := range sel.Selectors {
+			for j, ss := range compound.SubclassSelectors {
+				switch s := ss.Data.(type) {
+				case *css_ast.SSPseudoClass:
+					if s.IsElement || s.Args != nil || !strings.HasPrefix(s.Name, "--") {
+						continue
+					}
+					if _, ok := d.selectors[s.Name]; !ok {
+						warn(logger.MsgID_CSS_UndefinedCustomName, ss.Range,
+							fmt.Sprintf("The custom selector %q is not defined", ":"+s.Name))
+						continue
+					}
+
+					// There's no way to write "main :is(.a .b)" without ":is()" since
+					// the elements matched by "main" and ".a" may be in either order
+					if d.unsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+						for _, item := range d.resolveSelector(s.Name) {
+							if _, ok := mergeCustomSelector(sel, i, j, item); !ok {
+								warn(logger.MsgID_CSS_UnsupportedCustomSelector, ss.Range, fmt.Sprintf(
+									"Substituting the custom selector %q here requires \":is()\", which is not supported in the configured target environment", ":"+s.Name))
+								break
+							}
+						}
+					}
+
+				case *css_ast.SSPseudoClassWithSelectorList:
+					d.checkComplexSelectors(s.Selectors, warn)
+				}
+			}
+		}
+	}
+}
+
+func (d *CustomDefinitions) substituteInRules(rules []css_ast.Rule) ([]css_ast.Rule, bool) {
+	var result []css_ast.Rule
+	for i, rule := range rules {
+		if clone, ok := d.substituteInRule(rule); ok {
+			if result == nil {
+				result = append(make([]css_ast.Rule, 0, len(rules)), rules[:i]...)
+			}
+			result = append(result, clone)
+		} else if result != nil {
+			result = append(result, rule)
+		}
+	}
+	if result == nil {
+		return rules, false
+	}
+	return result, true
+}
+
+func (d *CustomDefinitions) substituteInRule(rule css_ast.Rule) (css_ast.Rule, bool) {
+	switch r := rule.Data.(type) {
+	case *css_ast.RSelector:
+		selectors, selectorsChanged := d.substituteInComplexSelectors(r.Selectors, selectorListInRule)
+		rules, rulesChanged := d.substituteInRules(r.Rules)
+		if selectorsChanged || rulesChanged {
+			clone := *r
+			clone.Selectors = selectors
+			clone.Rules = rules
+			return css_ast.Rule{Loc: rule.Loc, Data: &clone}, true
+		}
+
+	case *css_ast.RAtMedia:
+		queries, queriesChanged := d.substituteInMediaQueries(r.Queries)
+		rules, rulesChanged := d.substituteInRules(r.Rules)
+		if queriesChanged || rulesChanged {
+			clone := *r
+			clone.Queries = queries
+			clone.Rules = rules
+			return css_ast.Rule{Loc: rule.Loc, Data: &clone}, true
+		}
+
+	case *css_ast.RAtScope:
+		start, startChanged := d.substituteInComplexSelectors(r.Start, selectorListInPseudoClass)
+		end, endChanged := d.substituteInComplexSelectors(r.End, selectorListInPseudoClass)
+		rules, rulesChanged := d.substituteInRules(r.Rules)
+		if startChanged || endChanged || rulesChanged {
+			clone := *r
+			clone.Start = start
+			clone.End = end
+			clone.Rules = rules
+			return css_ast.Rule{Loc: rule.Loc, Data: &clone}, true
+		}
+
+	case *css_ast.RKnownAt:
+		if rules, ok := d.substituteInRules(r.Rules); ok {
+			clone := *r
+			clone.Rules = rules
+			return css_ast.Rule{Loc: rule.Loc, Data: &clone}, true
+		}
+
+	case *css_ast.RAtLayer:
+		if rules, ok := d.substituteInRules(r.Rules); ok {
+			clone := *r
+			clone.Rules = rules
+			return css_ast.Rule{Loc: rule.Loc, Data: &clone}, true
+		}
+	}
+
+	return rule, false
+}
+
+func (d *CustomDefinitions) resolveMedia(name string) []css_ast.MediaQuery {
+	if resolved, ok := d.resolvedMedia[name]; ok {
+		return resolved
+	}
+	queries, ok := d.media[name]
+	if !ok {
+		return nil
+	}
+
+	// Guard against cycles such as "@custom-media --a (--b); @custom-media --b (--a);"
+	d.resolvedMedia[name] = nil
+	queries, _ = d.substituteInMediaQueries(queries)
+	d.resolvedMedia[name] = queries
+	return queries
+}
+
+func customMediaName(query css_ast.MediaQuery) (string, bool) {
+	if feature, ok := query.Data.(*css_ast.MQPlainOrBoolean); ok && feature.ValueOrNil == nil && strings.HasPrefix(feature.Name, "--") {
+		return feature.Name, true
+	}
+	return "", false
+}
+
+// "@custom-media --a screen, print; @media (--a) {}" => "@media screen, print {}"
+func (d *CustomDefinitions) substituteInMediaQueries(queries []css_ast.MediaQuery) ([]css_ast.MediaQuery, bool) {
+	var result []css_ast.MediaQuery
+	for i, query := range queries {
+		var replacement []css_ast.MediaQuery
+		if name, ok := customMediaName(query); ok {
+			replacement = d.resolveMedia(name)
+		}
+		if replacement == nil {
+			if clone, ok := d.substituteInMediaCondition(query); ok {
+				replacement = []css_ast.MediaQuery{clone}
+			}
+		}
+		if replacement != nil {
+			if result == nil {
+				result = append([]css_ast.MediaQuery{}, queries[:i]...)
+			}
+			result = append(result, replacement...)
+		} else if result != nil {
+			result = append(result, query)
+		}
+	}
+	if result == nil {
+		return queries, false
+	}
+	return result, true
+}
+
+// "@custom-media --a (color); @media screen and (--a) {}" => "@media screen and (color) {}"
+func (d *CustomDefinitions) substituteInMediaCondition(query css_ast.MediaQuery) (css_ast.MediaQuery, bool) {
+	switch q := query.Data.(type) {
+	case *css_ast.MQPlainOrBoolean:
+		if name, ok := customMediaName(query); ok {
+			if condition, ok := mediaQueriesToCondition(d.resolveMedia(name)); ok {
+				return condition, true
+			}
+		}
+
+	case *css_ast.MQType:
+		if q.AndOrNull.Data != nil {
+			if inner, ok := d.substituteInMediaCondition(q.AndOrNull); ok {
+				return css_ast.MediaQuery{Loc: query.Loc, Data: &css_ast.MQType{Op: q.Op, Type: q.Type, AndOrNull: inner}}, true
+			}
+		}
+
+	case *css_ast.MQNot:
+		if inner, ok := d.substituteInMediaCondition(q.Inner); ok {
+			return css_ast.MediaQuery{Loc: query.Loc, Data: &css_ast.MQNot{Inner: inner}}, true
+		}
+
+	case *css_ast.MQBinary:
+		var terms []css_ast.MediaQuery
+		for i, term := range q.Terms {
+			if inner, ok := d.substituteInMediaCondition(term); ok {
+				if terms == nil {
+					terms = append([]css_ast.MediaQuery{}, q.Terms[:i]...)
+				}
+				terms = append(terms, inner)
+			} else if terms != nil {
+				terms = append(terms, term)
+			}
+		}
+		if terms != nil {
+			return css_ast.MediaQuery{Loc: query.Loc, Data: &css_ast.MQBinary{Op: q.Op, Terms: terms}}, true
+		}
+	}
+
+	return query, false
+}
+
+// A custom media query used inside of a media condition must be converted
+// into a condition itself, which isn't possible if it contains a media type.
+//
+//	"(a), (b)" => "(a) or (b)"
+//	"all and (a)" => "(a)"
+func mediaQueriesToCondition(queries []css_ast.MediaQuery) (css_ast.MediaQuery, bool) {
+	terms := make([]css_ast.MediaQuery, 0, len(queries))
+	for _, query := range queries {
+		switch q := query.Data.(type) {
+		case *css_ast.MQType:
+			if q.Op != css_ast.MQTypeOpNone || !strings.EqualFold(q.Type, "all") || q.AndOrNull.Data == nil {
+				return css_ast.MediaQuery{}, false
+			}
+			query = q.AndOrNull
+
+		case *css_ast.MQArbitraryTokens:
+			return css_ast.MediaQuery{}, false
+		}
+		terms = append(terms, query)
+	}
+	switch len(terms) {
+	case 0:
+		return css_ast.MediaQuery{}, false
+	case 1:
+		return terms[0], true
+	default:
+		return css_ast.MediaQuery{Loc: terms[0].Loc, Data: &css_ast.MQBinary{Op: css_ast.MQBinaryOpOr, Terms: terms}}, true
+	}
+}
+
+type selectorListKind uint8
+
+const (
+	selectorListInRule selectorListKind = iota
+	selectorListInPseudoClass
+)
+
+func (d *CustomDefinitions) resolveSelector(name string) []css_ast.ComplexSelector {
+	if resolved, ok := d.resolvedSelectors[name]; ok {
+		return resolved
+	}
+	selectors, ok := d.selectors[name]
+	if !ok {
+		return nil
+	}
+
+	// Guard against cycles such as "@custom-selector :--a :--b; @custom-selector :--b :--a;"
+	// by treating every custom selector that's part of the cycle as undefined
+	d.resolvedSelectors[name] = nil
+	selectors, _ = d.substituteInComplexSelectors(selectors, selectorListInPseudoClass)
+	if d.usesDefinedCustomSelector(selectors) {
+		return nil
+	}
+	d.resolvedSelectors[name] = selectors
+	return selectors
+}
+
+func (d *CustomDefinitions) usesDefinedCustomSelector(selectors []css_ast.ComplexSelector) bool {
+	for _, sel := range selectors {
+		for _, compound := range sel.Selectors {
+			for _, ss := range compound.SubclassSelectors {
+				switch s := ss.Data.(type) {
+				case *css_ast.SSPseudoClass:
+					if _, ok := d.selectors[s.Name]; ok && !s.IsElement && s.Args == nil {
+						return true
+					}
+
+				case *css_ast.SSPseudoClassWithSelectorList:
+					if d.usesDefinedCustomSelector(s.Selectors) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (d *CustomDefinitions) substituteInComplexSelectors(selectors []css_ast.ComplexSelector, where selectorListKind) ([]css_ast.ComplexSelector, bool) {
+	var result []css_ast.ComplexSelector
+	for i, sel := range selectors {
+		if expanded, ok := d.substituteInComplexSelector(sel, where); ok {
+			if result == nil {
+				result = append([]css_ast.ComplexSelector{}, selectors[:i]...)
+			}
+			result = append(result, expanded...)
+		} else if result != nil {
+			result = append(result, sel)
+		}
+	}
+	if result == nil {
+		return selectors, false
+	}
+	return result, true
+}
+
+// Substituting a custom selector may turn one complex selector into several
+// when ":is()" isn't available:
+//
+//	"@custom-selector :--heading h1, h2; :--heading + p {}" => "h1 + p, h2 + p {}"
+func (d *CustomDefinitions) substituteInComplexSelector(sel css_ast.ComplexSelector, where selectorListKind) ([]css_ast.ComplexSelector, bool) {
+	for i, compound := range sel.Selectors {
+		for j, ss := range compound.SubclassSelectors {
+			switch s := ss.Data.(type) {
+			case *css_ast.SSPseudoClass:
+				if s.IsElement || s.Args != nil || !strings.HasPrefix(s.Name, "--") {
+					continue
+				}
+				replacement := d.resolveSelector(s.Name)
+				if replacement == nil {
+					continue
+				}
+				var results []css_ast.ComplexSelector
+				for _, expanded := range d.expandCustomSelector(sel, i, j, replacement, where) {
+					// Substitute any remaining custom selectors
+					if more, ok := d.substituteInComplexSelector(expanded, where); ok {
+						results = append(results, more...)
+					} else {
+						results = append(results, expanded)
+					}
+				}
+				return results, true
+
+			case *css_ast.SSPseudoClassWithSelectorList:
+				if inner, ok := d.substituteInComplexSelectors(s.Selectors, selectorListInPseudoClass); ok {
+					clone := sel.Clone()
+					clone.Selectors[i].SubclassSelectors[j].Data = &css_ast.SSPseudoClassWithSelectorList{
+						Kind:      s.Kind,
+						Index:     s.Index,
+						Selectors: inner,
+					}
+					if more, ok := d.substituteInComplexSelector(clone, where); ok {
+						return more, true
+					}
+					return []css_ast.ComplexSelector{clone}, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// Replace the custom selector at "sel.Selectors[i].SubclassSelectors[j]"
+func (d *CustomDefinitions) expandCustomSelector(sel css_ast.ComplexSelector, i int, j int, replacement []css_ast.ComplexSelector, where selectorListKind) []css_ast.ComplexSelector {
+	// Custom selectors behave like ":is()", but merging them into the
+	// surrounding selector avoids ":is()" when it's not needed (and when it's
+	// not supported). At the top level this is only done for a list if ":is()"
+	// is unsupported because it changes specificity. That doesn't matter inside
+	// pseudo-classes such as ":not()" that already take a selector list.
+	if len(replacement) == 1 || where == selectorListInPseudoClass || d.unsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+		results := make([]css_ast.ComplexSelector, 0, len(replacement))
+		for _, item := range replacement {
+			if merged, ok := mergeCustomSelector(sel, i, j, item); ok {
+				results = append(results, merged)
+			} else {
+				// "main :--foo" with ".a .b" => "main :is(.a .b)"
+				results = append(results, wrapCustomSelectorWithIs(sel, i, j, []css_ast.ComplexSelector{item}))
+			}
+		}
+		return results
+	}
+
+	// ":--heading + p" => ":is(h1, h2) + p"
+	return []css_ast.ComplexSelector{wrapCustomSelectorWithIs(sel, i, j, replacement)}
+}
+
+func wrapCustomSelectorWithIs(sel css_ast.ComplexSelector, i int, j int, replacement []css_ast.ComplexSelector) css_ast.ComplexSelector {
+	clones := make([]css_ast.ComplexSelector, len(replacement))
+	for k, item := range replacement {
+		clones[k] = item.Clone()
+	}
+	clone := sel.Clone()
+	ss := &clone.Selectors[i].SubclassSelectors[j]
+	ss.Data = &css_ast.SSPseudoClassWithSelectorList{Kind: css_ast.PseudoClassIs, Selectors: clones}
+	return clone
+}
+
+func mergeCustomSelector(sel css_ast.ComplexSelector, i int, j int, item css_ast.ComplexSelector) (css_ast.ComplexSelector, bool) {
+	compound := sel.Selectors[i]
+	last := item.Selectors[len(item.Selectors)-1]
+
+	// "a:--foo" can't be merged with "b"
+	if compound.TypeSelector != nil && last.TypeSelector != nil {
+		return css_ast.ComplexSelector{}, false
+	}
+
+	// ".a :--foo" can't be merged with ".b .c" because ".b" must be inside ".a"
+	if len(item.Selectors) > 1 && (i > 0 || compound.Combinator.Byte != 0) {
+		return css_ast.ComplexSelector{}, false
+	}
+
+	// Nesting selectors in custom selectors aren't supported
+	for _, part := range item.Selectors {
+		if len(part.NestingSelectorLocs) > 0 {
+			return css_ast.ComplexSelector{}, false
+		}
+	}
+
+	item = item.Clone()
+	last = item.Selectors[len(item.Selectors)-1]
+	clone := sel.Clone()
+	merged := &clone.Selectors[i]
+	if last.TypeSelector != nil {
+		merged.TypeSelector = last.TypeSelector
+	}
+	subclassSelectors := make([]css_ast.SubclassSelector, 0, len(merged.SubclassSelectors)+len(last.SubclassSelectors)-1)
+	subclassSelectors = append(subclassSelectors, merged.SubclassSelectors[:j]...)
+	subclassSelectors = append(subclassSelectors, last.SubclassSelectors...)
+	subclassSelectors = append(subclassSelectors, merged.SubclassSelectors[j+1:]...)
+	merged.SubclassSelectors = subclassSelectors
+
+	// ":--foo > a" with ".b .c" => ".b .c > a"
+	if len(item.Selectors) > 1 {
+		merged.Combinator = last.Combinator
+		clone.Selectors = append(item.Selectors[:len(item.Selectors)-1], clone.Selectors...)
+	}
+	return clone, true
+}
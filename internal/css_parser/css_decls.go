@@ -127,6 +127,12 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 			continue
 		}
 
+		// Remove prefixed declarations that are no longer needed
+		if p.isUnnecessaryPrefixedDeclaration(decl, rules[i+1:]) {
+			rewrittenRules = rewrittenRules[:len(rewrittenRules)-1]
+			continue
+		}
+
 		// If the previous loop iteration would have clipped a color, we will
 		// duplicate it and insert the clipped copy before the unclipped copy
 		var wouldClipColor *bool
@@ -142,6 +148,31 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 			wouldClipColor = &wouldClipColorFlag
 		}
 
+		// Lower "light-dark()" colors. Outside of style rules only the light color
+		// is kept. Inside style rules, the dark color is moved into a separate
+		// "prefers-color-scheme" media query later on by splitting the style rule.
+		if p.options.unsupportedCSSFeatures.Has(compat.LightDark) {
+			if value, ok := replaceLightDark(decl.Value, false); ok {
+				if p.inSelectorSubtree == 0 {
+					decl.Value = value
+				} else {
+					p.lightDarkDeclarationsArePresent = true
+				}
+			}
+		}
+
+		// Lower logical properties and values. Declarations that depend on the
+		// writing direction are lowered later on by splitting the style rule.
+		if ltr, rtl, ok := p.lowerLogicalDeclaration(rule.Loc, decl); ok {
+			if rtl == nil || p.inSelectorSubtree == 0 {
+				rewrittenRules = rewrittenRules[:len(rewrittenRules)-1]
+				rules = append(append(append([]css_ast.Rule{}, rules[:i]...), ltr...), rules[i+1:]...)
+				i--
+				continue
+			}
+			p.directionalDeclarationsArePresent = true
+		}
+
 		switch decl.Key {
 		case css_ast.DComposes:
 			// Only process "composes" directives if we're in "local-css" or
@@ -354,7 +385,7 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 			}
 		}
 
-		if prefixes, ok := p.options.cssPrefixData[decl.Key]; ok {
+		if prefixes, ok := p.options.cssPrefixData[decl.Key]; ok && prefixes != compat.NoPrefix {
 			if declarationKeys == nil {
 				// Only generate this map if it's needed
 				declarationKeys = make(map[string]struct{})
@@ -380,13 +411,16 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 				rewrittenRules = p.insertPrefixedDeclaration(rewrittenRules, "-o-", rule.Loc, decl, declarationKeys)
 			}
 		}
+		if p.options.cssNamePrefixData.Values != nil && len(rewrittenRules) > 0 && rewrittenRules[len(rewrittenRules)-1].Data == decl {
+			rewrittenRules = p.insertPrefixedValues(rewrittenRules, rule.Loc, decl)
+		}
 
 		// If this loop iteration would have clipped a color, the out-of-gamut
 		// colors will not be clipped and this flag will be set. We then set up the
 		// next iteration of the loop to duplicate this rule and process it again
 		// with color clipping enabled.
 		if wouldClipColorFlag {
-			if p.options.unsupportedCSSFeatures.Has(compat.ColorFunctions) {
+			if p.options.unsupportedCSSFeatures.Has(compat.ColorFunctions | compat.ColorMix | compat.RelativeColors) {
 				// Only do this if there was no previous instance of that property so
 				// we avoid overwriting any manually-specified fallback values
 				for j := len(rewrittenRules) - 2; j >= 0; j-- {
@@ -423,6 +457,11 @@ func (p *parser) processDeclarations(rules []css_ast.Rule, composesContext *comp
 
 func (p *parser) insertPrefixedDeclaration(rules []css_ast.Rule, prefix string, loc logger.Loc, decl *css_ast.RDeclaration, declarationKeys map[string]struct{}) []css_ast.Rule {
 	keyText := prefix + decl.KeyText
+	if prefix == "-ms-" {
+		if msName, ok := msFlexboxPropertyNames[strings.ToLower(decl.KeyText)]; ok {
+			keyText = msName
+		}
+	}
 
 	// Don't insert a prefixed declaration if there already is one
 	if _, ok := declarationKeys[keyText]; ok {
@@ -451,6 +490,17 @@ func (p *parser) insertPrefixedDeclaration(rules []css_ast.Rule, prefix string,
 		if len(decl.Value) != 1 || decl.Value[0].Kind != css_lexer.TIdent || !strings.EqualFold(decl.Value[0].Text, "stretch") {
 			return rules
 		}
+
+	case css_ast.DAlignContent, css_ast.DAlignItems, css_ast.DAlignSelf, css_ast.DJustifyContent:
+		// Internet Explorer only supports some of these values
+		if prefix == "-ms-" {
+			if len(decl.Value) != 1 || decl.Value[0].Kind != css_lexer.TIdent {
+				return rules
+			}
+			if _, ok := msFlexboxAlignmentValue(decl.Value[0].Text); !ok {
+				return rules
+			}
+		}
 	}
 
 	value := css_ast.CloneTokensWithoutImportRecords(decl.Value)
@@ -481,6 +531,23 @@ func (p *parser) insertPrefixedDeclaration(rules []css_ast.Rule, prefix string,
 			value[0].Text = "-moz-none"
 		}
 
+	case css_ast.DAlignContent, css_ast.DAlignItems, css_ast.DAlignSelf, css_ast.DJustifyContent:
+		// Internet Explorer uses different names for these values (already checked above)
+		if prefix == "-ms-" {
+			value[0].Text, _ = msFlexboxAlignmentValue(value[0].Text)
+		}
+
+	case css_ast.DTransition, css_ast.DTransitionProperty:
+		// Properties that are being transitioned may also need the same prefix
+		prefixBit := prefixBitForText(prefix)
+		for i, token := range value {
+			if token.Kind == css_lexer.TIdent {
+				if prefixes, ok := p.options.cssPrefixData[css_ast.KnownDeclarations[strings.ToLower(token.Text)]]; ok && (prefixes&prefixBit) != 0 {
+					value[i].Text = prefix + token.Text
+				}
+			}
+		}
+
 	case css_ast.DMaskComposite:
 		// WebKit uses different names for these values
 		if prefix == "-webkit-" {
@@ -306,6 +306,13 @@ func (p *parser) lowerAndMinifyColor(token css_ast.Token, wouldClipColor *bool)
 		}
 
 	case css_lexer.TFunction:
+		// "rgb(from red r g b / 50%)" => "rgba(255, 0, 0, 0.5)"
+		if p.options.unsupportedCSSFeatures.Has(compat.RelativeColors) && isRelativeColor(token) {
+			if color, ok := parseColor(token); ok {
+				return p.tryToGenerateColor(token, color, wouldClipColor)
+			}
+		}
+
 		switch strings.ToLower(text) {
 		case "rgb", "rgba", "hsl", "hsla":
 			if p.options.unsupportedCSSFeatures.Has(compat.Modern_RGB_HSL) {
@@ -406,6 +413,14 @@ func (p *parser) lowerAndMinifyColor(token css_ast.Token, wouldClipColor *bool)
 					return p.tryToGenerateColor(token, color, wouldClipColor)
 				}
 			}
+
+		case "color-mix":
+			// "color-mix(in srgb, red, blue)" => "#800080"
+			if p.options.unsupportedCSSFeatures.Has(compat.ColorMix) {
+				if color, ok := parseColor(token); ok {
+					return p.tryToGenerateColor(token, color, wouldClipColor)
+				}
+			}
 		}
 	}
 
@@ -500,7 +515,19 @@ func parseColor(token css_ast.Token) (parsedColor, bool) {
 
 	case css_lexer.TFunction:
 		lowerText := strings.ToLower(text)
+
+		// "rgb(from red r g b / 50%)" => "rgb(255 0 0 / 50%)"
+		if isRelativeColor(token) {
+			if absolute, ok := resolveRelativeColor(token); ok {
+				return parseColor(absolute)
+			}
+			return parsedColor{}, false
+		}
+
 		switch lowerText {
+		case "color-mix":
+			return parseColorMix(*token.Children)
+
 		case "rgb", "rgba":
 			args := *token.Children
 			var r, g, b, a css_ast.Token
@@ -748,6 +775,380 @@ func parseColor(token css_ast.Token) (parsedColor, bool) {
 	return parsedColor{}, false
 }
 
+// This converts a parsed color to XYZ D65 along with an alpha value from 0 to 1
+func parsedColorToXYZ(color parsedColor) (x F64, y F64, z F64, alpha F64) {
+	alpha = helpers.NewF64(float64(hexA(color.hex))).DivConst(255)
+	if color.hasColorSpace {
+		return color.x, color.y, color.z, alpha
+	}
+	r, g, b := parsedColorToSRGB(color)
+	x, y, z = lin_srgb_to_xyz(lin_srgb(r, g, b))
+	return
+}
+
+func parsedColorToSRGB(color parsedColor) (r F64, g F64, b F64) {
+	if color.hasColorSpace {
+		return gam_srgb(xyz_to_lin_srgb(color.x, color.y, color.z))
+	}
+	r = helpers.NewF64(float64(hexR(color.hex))).DivConst(255)
+	g = helpers.NewF64(float64(hexG(color.hex))).DivConst(255)
+	b = helpers.NewF64(float64(hexB(color.hex))).DivConst(255)
+	return
+}
+
+// Colors in sRGB are converted to HSL and HWB directly instead of going
+// through XYZ. Otherwise floating-point imprecision would turn gray colors
+// into colors with a tiny amount of saturation and an arbitrary hue.
+func parsedColorToColorSpace(color parsedColor, colorSpace colorSpace) (v0 F64, v1 F64, v2 F64, alpha F64) {
+	alpha = helpers.NewF64(float64(hexA(color.hex))).DivConst(255)
+	switch colorSpace {
+	case colorSpace_srgb:
+		v0, v1, v2 = parsedColorToSRGB(color)
+		return
+
+	case colorSpace_hsl:
+		v0, v1, v2 = rgb_to_hsl(parsedColorToSRGB(color))
+		return
+
+	case colorSpace_hwb:
+		v0, v1, v2 = rgb_to_hwb(parsedColorToSRGB(color))
+		return
+	}
+	x, y, z, _ := parsedColorToXYZ(color)
+	v0, v1, v2 = xyz_to_colorSpace(x, y, z, colorSpace)
+	return
+}
+
+// Achromatic colors have a "powerless" hue, which is treated as missing
+func isHuePowerless(v0 F64, v1 F64, v2 F64, colorSpace colorSpace) bool {
+	switch colorSpace {
+	case colorSpace_hsl:
+		return math.IsNaN(v0.Value()) || v1.Value() < 1e-6
+	case colorSpace_hwb:
+		return math.IsNaN(v0.Value()) || v1.Add(v2).Value() > 100-1e-6
+	case colorSpace_lch:
+		return v1.Value() < 1e-4
+	case colorSpace_oklch:
+		return v1.Value() < 1e-6
+	}
+	return false
+}
+
+// Reference: https://drafts.csswg.org/css-color-5/#color-mix
+func parseColorMix(args []css_ast.Token) (parsedColor, bool) {
+	// Split the arguments at the commas
+	var groups [][]css_ast.Token
+	start := 0
+	for i, t := range args {
+		if t.Kind == css_lexer.TComma {
+			groups = append(groups, args[start:i])
+			start = i + 1
+		}
+	}
+	groups = append(groups, args[start:])
+	if len(groups) != 3 {
+		return parsedColor{}, false
+	}
+
+	// The first argument must be exactly "in <color-space> [<hue-method> hue]"
+	remaining, colorSpace, hueMethod, ok := removeColorInterpolation(groups[0])
+	if !ok || len(remaining) != 0 {
+		return parsedColor{}, false
+	}
+
+	// The other arguments are each a color with an optional percentage
+	var colors [2]parsedColor
+	var percentages [2]float64
+	var hasPercentage [2]bool
+	for i, group := range groups[1:] {
+		hasColor := false
+		for _, t := range group {
+			if t.Kind == css_lexer.TPercentage && !hasPercentage[i] {
+				percentage, err := strconv.ParseFloat(t.PercentageValue(), 64)
+				if err != nil || percentage < 0 || percentage > 100 {
+					return parsedColor{}, false
+				}
+				percentages[i] = percentage
+				hasPercentage[i] = true
+			} else if color, ok := parseColorOrTransparent(t); ok && !hasColor {
+				colors[i] = color
+				hasColor = true
+			} else {
+				return parsedColor{}, false
+			}
+		}
+		if !hasColor {
+			return parsedColor{}, false
+		}
+	}
+
+	// Normalize the percentages so that they add up to 100%. If they originally
+	// added up to less than 100%, the difference is applied to the alpha instead.
+	if !hasPercentage[0] && !hasPercentage[1] {
+		percentages = [2]float64{50, 50}
+	} else if !hasPercentage[1] {
+		percentages[1] = 100 - percentages[0]
+	} else if !hasPercentage[0] {
+		percentages[0] = 100 - percentages[1]
+	}
+	sum := percentages[0] + percentages[1]
+	if sum <= 0 {
+		return parsedColor{}, false
+	}
+	t := helpers.NewF64(percentages[1] / sum)
+	alphaMultiplier := math.Min(sum/100, 1)
+
+	// Convert both colors into the interpolation color space
+	a0, a1, a2, aAlpha := parsedColorToColorSpace(colors[0], colorSpace)
+	b0, b1, b2, bAlpha := parsedColorToColorSpace(colors[1], colorSpace)
+
+	// Missing hues take on the value of the other color's hue
+	if colorSpace.isPolar() {
+		aPowerless := isHuePowerless(a0, a1, a2, colorSpace)
+		bPowerless := isHuePowerless(b0, b1, b2, colorSpace)
+		switch colorSpace {
+		case colorSpace_hsl, colorSpace_hwb:
+			if aPowerless && bPowerless {
+				a0, b0 = helpers.NewF64(0), helpers.NewF64(0)
+			} else if aPowerless {
+				a0 = b0
+			} else if bPowerless {
+				b0 = a0
+			}
+
+		case colorSpace_lch, colorSpace_oklch:
+			if aPowerless && bPowerless {
+				a2, b2 = helpers.NewF64(0), helpers.NewF64(0)
+			} else if aPowerless {
+				a2 = b2
+			} else if bPowerless {
+				b2 = a2
+			}
+		}
+	}
+
+	// Interpolate using premultiplied alpha
+	a0, a1, a2 = premultiply(a0, a1, a2, aAlpha, colorSpace)
+	b0, b1, b2 = premultiply(b0, b1, b2, bAlpha, colorSpace)
+	v0, v1, v2 := interpolateColors(a0, a1, a2, b0, b1, b2, colorSpace, hueMethod, t)
+	alpha := helpers.Lerp(aAlpha, bAlpha, t)
+	v0, v1, v2 = unpremultiply(v0, v1, v2, alpha, colorSpace)
+	alphaByte := floatToByte(alpha.Value() * alphaMultiplier)
+
+	// Avoid going through XYZ for sRGB-based color spaces when possible so
+	// that floating-point imprecision doesn't affect rounding
+	var r, g, b F64
+	isRGB := true
+	switch colorSpace {
+	case colorSpace_srgb:
+		r, g, b = v0, v1, v2
+	case colorSpace_hsl:
+		r, g, b = hsl_to_rgb(v0, v1, v2)
+	case colorSpace_hwb:
+		r, g, b = hwb_to_rgb(v0, v1, v2)
+	default:
+		isRGB = false
+	}
+	if isRGB && r.Value() >= 0 && r.Value() <= 1 && g.Value() >= 0 && g.Value() <= 1 && b.Value() >= 0 && b.Value() <= 1 {
+		return parsedColor{hex: packRGBA(r, g, b, alphaByte)}, true
+	}
+
+	x, y, z := colorSpace_to_xyz(v0, v1, v2, colorSpace)
+	return parsedColor{
+		hasColorSpace: true,
+		x:             x,
+		y:             y,
+		z:             z,
+		hex:           alphaByte,
+	}, true
+}
+
+// The "transparent" keyword isn't in the color name table (so "parseColor"
+// leaves it alone) but it's commonly used with "color-mix()"
+func parseColorOrTransparent(token css_ast.Token) (parsedColor, bool) {
+	if token.Kind == css_lexer.TIdent && strings.EqualFold(token.Text, "transparent") {
+		return parsedColor{hex: 0}, true
+	}
+	return parseColor(token)
+}
+
+func isRelativeColor(token css_ast.Token) bool {
+	if token.Kind == css_lexer.TFunction && token.Children != nil {
+		if args := *token.Children; len(args) > 0 && args[0].Kind == css_lexer.TIdent && strings.EqualFold(args[0].Text, "from") {
+			return true
+		}
+	}
+	return false
+}
+
+// This substitutes the channel keywords in a relative color with the values
+// from the origin color, which only works if the origin color is known:
+//
+//	"rgb(from red r g calc(b + 51))" => "rgb(255 0 51)"
+//
+// Reference: https://drafts.csswg.org/css-color-5/#relative-colors
+func resolveRelativeColor(token css_ast.Token) (css_ast.Token, bool) {
+	args := (*token.Children)[1:]
+	if len(args) == 0 {
+		return css_ast.Token{}, false
+	}
+	origin, ok := parseColorOrTransparent(args[0])
+	if !ok {
+		return css_ast.Token{}, false
+	}
+	args = args[1:]
+
+	var colorSpace colorSpace
+	var names [3]string
+	var prefix []css_ast.Token
+	var scale float64 = 1
+	var percentages [3]bool
+
+	switch strings.ToLower(token.Text) {
+	case "rgb", "rgba":
+		colorSpace, names, scale = colorSpace_srgb, [3]string{"r", "g", "b"}, 255
+
+	case "hsl", "hsla":
+		colorSpace, names, percentages = colorSpace_hsl, [3]string{"h", "s", "l"}, [3]bool{false, true, true}
+
+	case "hwb":
+		colorSpace, names, percentages = colorSpace_hwb, [3]string{"h", "w", "b"}, [3]bool{false, true, true}
+
+	case "lab":
+		colorSpace, names = colorSpace_lab, [3]string{"l", "a", "b"}
+
+	case "lch":
+		colorSpace, names = colorSpace_lch, [3]string{"l", "c", "h"}
+
+	case "oklab":
+		colorSpace, names = colorSpace_oklab, [3]string{"l", "a", "b"}
+
+	case "oklch":
+		colorSpace, names = colorSpace_oklch, [3]string{"l", "c", "h"}
+
+	case "color":
+		if len(args) == 0 || args[0].Kind != css_lexer.TIdent {
+			return css_ast.Token{}, false
+		}
+		names = [3]string{"r", "g", "b"}
+		switch strings.ToLower(args[0].Text) {
+		case "a98-rgb":
+			colorSpace = colorSpace_a98_rgb
+		case "display-p3":
+			colorSpace = colorSpace_display_p3
+		case "prophoto-rgb":
+			colorSpace = colorSpace_prophoto_rgb
+		case "rec2020":
+			colorSpace = colorSpace_rec2020
+		case "srgb":
+			colorSpace = colorSpace_srgb
+		case "srgb-linear":
+			colorSpace = colorSpace_srgb_linear
+		case "xyz", "xyz-d65":
+			colorSpace, names = colorSpace_xyz_d65, [3]string{"x", "y", "z"}
+		case "xyz-d50":
+			colorSpace, names = colorSpace_xyz_d50, [3]string{"x", "y", "z"}
+		default:
+			return css_ast.Token{}, false
+		}
+		prefix, args = args[:1], args[1:]
+
+	default:
+		return css_ast.Token{}, false
+	}
+
+	// Expect either "c0 c1 c2" or "c0 c1 c2 / alpha"
+	if len(args) != 3 && (len(args) != 5 || args[3].Kind != css_lexer.TDelimSlash) {
+		return css_ast.Token{}, false
+	}
+
+	// Get the values of the channel keywords from the origin color
+	v0, v1, v2, alpha := parsedColorToColorSpace(origin, colorSpace)
+	values := map[string]float64{
+		names[0]: v0.Value() * scale,
+		names[1]: v1.Value() * scale,
+		names[2]: v2.Value() * scale,
+		"alpha":  alpha.Value(),
+	}
+	for name, value := range values {
+		if math.IsNaN(value) {
+			values[name] = 0 // Missing components (e.g. powerless hues) become zero
+		}
+	}
+
+	children := append([]css_ast.Token{}, prefix...)
+	for i, arg := range args {
+		if i == 3 {
+			children = append(children, arg)
+			continue
+		}
+		arg, ok := substituteChannelKeywords(arg, values)
+		if !ok {
+			return css_ast.Token{}, false
+		}
+		if i < 3 && percentages[i] && arg.Kind == css_lexer.TNumber {
+			arg.Kind = css_lexer.TPercentage
+			arg.Text += "%"
+		}
+		children = append(children, arg)
+	}
+
+	// The alpha defaults to the alpha of the origin color
+	if len(args) == 3 {
+		children = append(children,
+			css_ast.Token{Loc: token.Loc, Kind: css_lexer.TDelimSlash, Text: "/"},
+			css_ast.Token{Loc: token.Loc, Kind: css_lexer.TNumber, Text: strconv.FormatFloat(values["alpha"], 'f', -1, 64)},
+		)
+	}
+
+	token.Children = &children
+	return token, true
+}
+
+func substituteChannelKeywords(token css_ast.Token, values map[string]float64) (css_ast.Token, bool) {
+	switch token.Kind {
+	case css_lexer.TNumber, css_lexer.TPercentage, css_lexer.TDimension:
+		return token, true
+
+	case css_lexer.TIdent:
+		if value, ok := values[strings.ToLower(token.Text)]; ok {
+			token.Kind = css_lexer.TNumber
+			token.Text = strconv.FormatFloat(value, 'f', -1, 64)
+			return token, true
+		}
+
+	case css_lexer.TFunction, css_lexer.TOpenParen:
+		if token.Kind == css_lexer.TFunction && !strings.EqualFold(token.Text, "calc") {
+			break
+		}
+		children := make([]css_ast.Token, len(*token.Children))
+		for i, child := range *token.Children {
+			if child.Kind == css_lexer.TIdent || child.Kind == css_lexer.TFunction || child.Kind == css_lexer.TOpenParen {
+				var ok bool
+				if child, ok = substituteChannelKeywords(child, values); !ok {
+					return css_ast.Token{}, false
+				}
+			}
+			children[i] = child
+		}
+
+		// Evaluate the calculation, which must result in a number or percentage
+		if term := tryToParseCalcTerm(children); term != nil {
+			if numeric, ok := term.partiallySimplify().(*calcNumeric); ok && !math.IsNaN(numeric.number) && !math.IsInf(numeric.number, 0) {
+				text := strconv.FormatFloat(numeric.number, 'f', -1, 64)
+				switch numeric.unit {
+				case "":
+					return css_ast.Token{Loc: token.Loc, Kind: css_lexer.TNumber, Text: text}, true
+				case "%":
+					return css_ast.Token{Loc: token.Loc, Kind: css_lexer.TPercentage, Text: text + "%"}, true
+				}
+			}
+		}
+	}
+
+	return css_ast.Token{}, false
+}
+
 // Reference: https://drafts.csswg.org/css-color/#hwb-to-rgb
 func hwbToRgb(hue F64, white F64, black F64) (r F64, g F64, b F64) {
 	if white.Add(black).Value() >= 1 {
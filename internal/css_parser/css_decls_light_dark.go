@@ -0,0 +1,174 @@
+package css_parser
+
+import (
+	"strings"
+
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+)
+
+// This replaces each "light-dark(a, b)" function with either "a" or "b". It
+// returns false if there were no "light-dark()" functions to replace. The
+// input tokens are not modified.
+func replaceLightDark(tokens []css_ast.Token, isDark bool) ([]css_ast.Token, bool) {
+	var result []css_ast.Token
+	didReplace := false
+
+	for i, t := range tokens {
+		if t.Kind == css_lexer.TFunction && strings.EqualFold(t.Text, "light-dark") {
+			// Split the arguments at the comma
+			args := *t.Children
+			comma := -1
+			for j, arg := range args {
+				if arg.Kind == css_lexer.TComma {
+					if comma != -1 {
+						comma = -1
+						break
+					}
+					comma = j
+				}
+			}
+
+			if comma > 0 && comma+1 < len(args) {
+				var replacement []css_ast.Token
+				if isDark {
+					replacement = args[comma+1:]
+				} else {
+					replacement = args[:comma]
+				}
+				replacement, _ = replaceLightDark(replacement, isDark)
+				replacement = append([]css_ast.Token{}, replacement...)
+
+				// Move the surrounding whitespace onto the replacement
+				n := len(replacement)
+				replacement[0].Whitespace = (replacement[0].Whitespace & ^css_ast.WhitespaceBefore) | (t.Whitespace & css_ast.WhitespaceBefore)
+				replacement[n-1].Whitespace = (replacement[n-1].Whitespace & ^css_ast.WhitespaceAfter) | (t.Whitespace & css_ast.WhitespaceAfter)
+
+				if !didReplace {
+					result = append([]css_ast.Token{}, tokens[:i]...)
+					didReplace = true
+				}
+				result = append(result, replacement...)
+				continue
+			}
+		}
+
+		// Also replace nested functions such as "light-dark()" inside a gradient
+		if t.Children != nil {
+			if children, ok := replaceLightDark(*t.Children, isDark); ok {
+				t.Children = &children
+				if !didReplace {
+					result = append([]css_ast.Token{}, tokens[:i]...)
+					didReplace = true
+				}
+			}
+		}
+
+		if didReplace {
+			result = append(result, t)
+		}
+	}
+
+	if !didReplace {
+		return tokens, false
+	}
+	return result, true
+}
+
+// Browsers without "light-dark()" get the light color by default and the dark
+// color inside a "prefers-color-scheme" media query. This doesn't take the
+// "color-scheme" property into account, but it's the best we can do:
+//
+//	"a { color: light-dark(#000, #fff) }"
+//
+//	=>
+//
+//	"a { color: #000 } @media (prefers-color-scheme: dark) { a { color: #fff } }"
+func (p *parser) lowerLightDarkInRules(rules []css_ast.Rule) []css_ast.Rule {
+	var result []css_ast.Rule
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RSelector:
+			var dark []css_ast.Rule
+			var darkKeys []string
+			var children []css_ast.Rule
+			for _, child := range p.lowerLightDarkInRules(r.Rules) {
+				if decl, ok := child.Data.(*css_ast.RDeclaration); ok {
+					if lightValue, ok := replaceLightDark(decl.Value, false); ok {
+						darkValue, _ := replaceLightDark(decl.Value, true)
+						lightDecl := *decl
+						darkDecl := *decl
+						lightDecl.Value = lightValue
+						darkDecl.Value = darkValue
+						children = append(children, p.processDeclarations([]css_ast.Rule{{Loc: child.Loc, Data: &lightDecl}}, nil)...)
+						dark = append(dark, css_ast.Rule{Loc: child.Loc, Data: &darkDecl})
+						darkKeys = append(darkKeys, decl.KeyText)
+						continue
+					}
+
+					// The dark declarations are moved after the rule, so later
+					// declarations that override them must be moved there too:
+					//
+					//	"a { color: light-dark(#000, #fff); color: red }"
+					//
+					//	=>
+					//
+					//	"a { color: #000; color: red } @media (prefers-color-scheme: dark) { a { color: #fff; color: red } }"
+					//
+					for _, key := range darkKeys {
+						if declarationKeysOverlap(key, decl.KeyText) {
+							clone := *decl
+							dark = append(dark, css_ast.Rule{Loc: child.Loc, Data: &clone})
+							break
+						}
+					}
+				}
+				children = append(children, child)
+			}
+			r.Rules = children
+			result = append(result, rule)
+			if dark != nil {
+				selectors := make([]css_ast.ComplexSelector, len(r.Selectors))
+				for i, sel := range r.Selectors {
+					selectors[i] = sel.Clone()
+				}
+				result = append(result, css_ast.Rule{Loc: rule.Loc, Data: &css_ast.RAtMedia{
+					Queries: []css_ast.MediaQuery{{Loc: rule.Loc, Data: &css_ast.MQPlainOrBoolean{
+						Name:       "prefers-color-scheme",
+						ValueOrNil: []css_ast.Token{{Loc: rule.Loc, Kind: css_lexer.TIdent, Text: "dark"}},
+					}}},
+					Rules: []css_ast.Rule{{Loc: rule.Loc, Data: &css_ast.RSelector{
+						Selectors:     selectors,
+						Rules:         p.processDeclarations(dark, nil),
+						CloseBraceLoc: r.CloseBraceLoc,
+					}}},
+					CloseBraceLoc: r.CloseBraceLoc,
+				}})
+			}
+			continue
+
+		case *css_ast.RKnownAt:
+			r.Rules = p.lowerLightDarkInRules(r.Rules)
+
+		case *css_ast.RAtMedia:
+			r.Rules = p.lowerLightDarkInRules(r.Rules)
+
+		case *css_ast.RAtLayer:
+			r.Rules = p.lowerLightDarkInRules(r.Rules)
+
+		case *css_ast.RAtScope:
+			r.Rules = p.lowerLightDarkInRules(r.Rules)
+		}
+
+		result = append(result, rule)
+	}
+	return result
+}
+
+// This is conservative and treats properties as overlapping if one is a
+// prefix of the other (e.g. "border" and "border-top-color")
+func declarationKeysOverlap(a string, b string) bool {
+	a = strings.ToLower(a)
+	b = strings.ToLower(b)
+	return a == b || a == "all" || b == "all" || strings.HasPrefix(a, b+"-") || strings.HasPrefix(b, a+"-")
+}
@@ -0,0 +1,371 @@
+package css_parser
+
+import (
+	"strings"
+
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Each logical property maps to one or two physical properties. If there are
+// two, the value is either split into up to two parts (e.g. "margin-inline:
+// 1px 2px") or copied to both properties (e.g. "border-inline: 1px solid").
+// The "rtl" field is only present if the mapping depends on the direction.
+type logicalProperty struct {
+	ltr       []string
+	rtl       []string
+	copyValue bool
+}
+
+var logicalProperties = map[string]logicalProperty{
+	// Sizing
+	"block-size":      {ltr: []string{"height"}},
+	"inline-size":     {ltr: []string{"width"}},
+	"max-block-size":  {ltr: []string{"max-height"}},
+	"max-inline-size": {ltr: []string{"max-width"}},
+	"min-block-size":  {ltr: []string{"min-height"}},
+	"min-inline-size": {ltr: []string{"min-width"}},
+
+	// Margin
+	"margin-block":        {ltr: []string{"margin-top", "margin-bottom"}},
+	"margin-block-end":    {ltr: []string{"margin-bottom"}},
+	"margin-block-start":  {ltr: []string{"margin-top"}},
+	"margin-inline":       {ltr: []string{"margin-left", "margin-right"}, rtl: []string{"margin-right", "margin-left"}},
+	"margin-inline-end":   {ltr: []string{"margin-right"}, rtl: []string{"margin-left"}},
+	"margin-inline-start": {ltr: []string{"margin-left"}, rtl: []string{"margin-right"}},
+
+	// Padding
+	"padding-block":        {ltr: []string{"padding-top", "padding-bottom"}},
+	"padding-block-end":    {ltr: []string{"padding-bottom"}},
+	"padding-block-start":  {ltr: []string{"padding-top"}},
+	"padding-inline":       {ltr: []string{"padding-left", "padding-right"}, rtl: []string{"padding-right", "padding-left"}},
+	"padding-inline-end":   {ltr: []string{"padding-right"}, rtl: []string{"padding-left"}},
+	"padding-inline-start": {ltr: []string{"padding-left"}, rtl: []string{"padding-right"}},
+
+	// Inset
+	"inset-block":        {ltr: []string{"top", "bottom"}},
+	"inset-block-end":    {ltr: []string{"bottom"}},
+	"inset-block-start":  {ltr: []string{"top"}},
+	"inset-inline":       {ltr: []string{"left", "right"}, rtl: []string{"right", "left"}},
+	"inset-inline-end":   {ltr: []string{"right"}, rtl: []string{"left"}},
+	"inset-inline-start": {ltr: []string{"left"}, rtl: []string{"right"}},
+
+	// Border
+	"border-block":              {ltr: []string{"border-top", "border-bottom"}, copyValue: true},
+	"border-block-color":        {ltr: []string{"border-top-color", "border-bottom-color"}},
+	"border-block-end":          {ltr: []string{"border-bottom"}},
+	"border-block-end-color":    {ltr: []string{"border-bottom-color"}},
+	"border-block-end-style":    {ltr: []string{"border-bottom-style"}},
+	"border-block-end-width":    {ltr: []string{"border-bottom-width"}},
+	"border-block-start":        {ltr: []string{"border-top"}},
+	"border-block-start-color":  {ltr: []string{"border-top-color"}},
+	"border-block-start-style":  {ltr: []string{"border-top-style"}},
+	"border-block-start-width":  {ltr: []string{"border-top-width"}},
+	"border-block-style":        {ltr: []string{"border-top-style", "border-bottom-style"}},
+	"border-block-width":        {ltr: []string{"border-top-width", "border-bottom-width"}},
+	"border-inline":             {ltr: []string{"border-left", "border-right"}, copyValue: true},
+	"border-inline-color":       {ltr: []string{"border-left-color", "border-right-color"}, rtl: []string{"border-right-color", "border-left-color"}},
+	"border-inline-end":         {ltr: []string{"border-right"}, rtl: []string{"border-left"}},
+	"border-inline-end-color":   {ltr: []string{"border-right-color"}, rtl: []string{"border-left-color"}},
+	"border-inline-end-style":   {ltr: []string{"border-right-style"}, rtl: []string{"border-left-style"}},
+	"border-inline-end-width":   {ltr: []string{"border-right-width"}, rtl: []string{"border-left-width"}},
+	"border-inline-start":       {ltr: []string{"border-left"}, rtl: []string{"border-right"}},
+	"border-inline-start-color": {ltr: []string{"border-left-color"}, rtl: []string{"border-right-color"}},
+	"border-inline-start-style": {ltr: []string{"border-left-style"}, rtl: []string{"border-right-style"}},
+	"border-inline-start-width": {ltr: []string{"border-left-width"}, rtl: []string{"border-right-width"}},
+	"border-inline-style":       {ltr: []string{"border-left-style", "border-right-style"}, rtl: []string{"border-right-style", "border-left-style"}},
+	"border-inline-width":       {ltr: []string{"border-left-width", "border-right-width"}, rtl: []string{"border-right-width", "border-left-width"}},
+
+	// Border radius
+	"border-end-end-radius":     {ltr: []string{"border-bottom-right-radius"}, rtl: []string{"border-bottom-left-radius"}},
+	"border-end-start-radius":   {ltr: []string{"border-bottom-left-radius"}, rtl: []string{"border-bottom-right-radius"}},
+	"border-start-end-radius":   {ltr: []string{"border-top-right-radius"}, rtl: []string{"border-top-left-radius"}},
+	"border-start-start-radius": {ltr: []string{"border-top-left-radius"}, rtl: []string{"border-top-right-radius"}},
+}
+
+// Returns the physical declarations for a logical declaration in both writing
+// directions. If "ok" is true and "rtl" is nil, the declaration doesn't depend
+// on the direction and "ltr" can be used unconditionally.
+func (p *parser) lowerLogicalDeclaration(loc logger.Loc, decl *css_ast.RDeclaration) (ltr []css_ast.Rule, rtl []css_ast.Rule, ok bool) {
+	switch decl.Key {
+	case css_ast.DFloat, css_ast.DClear:
+		if !p.options.unsupportedCSSFeatures.Has(compat.LogicalValues) || len(decl.Value) != 1 || decl.Value[0].Kind != css_lexer.TIdent {
+			return
+		}
+		var ltrText, rtlText string
+		switch strings.ToLower(decl.Value[0].Text) {
+		case "inline-start":
+			ltrText, rtlText = "left", "right"
+		case "inline-end":
+			ltrText, rtlText = "right", "left"
+		default:
+			return
+		}
+		makeDecl := func(text string) []css_ast.Rule {
+			value := decl.Value[0]
+			value.Text = text
+			clone := *decl
+			clone.Value = []css_ast.Token{value}
+			return []css_ast.Rule{{Loc: loc, Data: &clone}}
+		}
+		return makeDecl(ltrText), makeDecl(rtlText), true
+	}
+
+	if !p.options.unsupportedCSSFeatures.Has(compat.LogicalProperties) {
+		return
+	}
+	property, found := logicalProperties[strings.ToLower(decl.KeyText)]
+	if !found || len(decl.Value) == 0 {
+		return
+	}
+
+	// Split the value for properties that take a value for each side
+	values := [][]css_ast.Token{decl.Value, decl.Value}
+	if len(property.ltr) == 2 && !property.copyValue {
+		if len(decl.Value) > 2 {
+			return
+		}
+		for _, t := range decl.Value {
+			// Don't do this if we encounter "var()" since it could contain both values
+			if t.Kind == css_lexer.TFunction && (strings.EqualFold(t.Text, "var") || strings.EqualFold(t.Text, "env")) {
+				return
+			}
+		}
+		values[0] = decl.Value[:1]
+		values[1] = decl.Value[len(decl.Value)-1:]
+	}
+
+	mask := ^css_ast.WhitespaceAfter
+	if p.options.minifyWhitespace {
+		mask = 0
+	}
+	makeDecls := func(keys []string) (rules []css_ast.Rule) {
+		for i, key := range keys {
+			value := css_ast.CloneTokensWithoutImportRecords(values[i])
+			if len(values[i]) < len(decl.Value) {
+				value[0].Whitespace &= mask
+			}
+			rules = append(rules, css_ast.Rule{Loc: loc, Data: &css_ast.RDeclaration{
+				KeyText:   key,
+				KeyRange:  decl.KeyRange,
+				Key:       css_ast.KnownDeclarations[key],
+				Value:     value,
+				Important: decl.Important,
+			}})
+		}
+		return
+	}
+
+	ltr = makeDecls(property.ltr)
+	if property.rtl != nil {
+		// "margin-inline: 1px" is the same in both directions
+		if len(property.ltr) != 2 || !css_ast.TokensEqualIgnoringWhitespace(values[0], values[1]) {
+			rtl = makeDecls(property.rtl)
+		}
+	}
+	ok = true
+	return
+}
+
+// Declarations that depend on the writing direction can't be lowered in place.
+// Instead they are left alone by "processDeclarations" and then moved into
+// copies of the style rule that only apply in a specific direction:
+//
+//	"a { margin-inline-start: 1px }" =>
+//	"html:not([dir=rtl]) a { margin-left: 1px } html[dir=rtl] a { margin-right: 1px }"
+//
+// The direction test is wrapped in ":where()" so that the copies have the same
+// specificity as the original rule. Otherwise they could override unrelated
+// rules with more specific selectors that come later in the file.
+//
+// The ":dir()" pseudo-class isn't used because it's not supported by the
+// browsers that need this transform, and because it can't be combined with a
+// pseudo-element. This is done at the top level after nesting has been lowered.
+func (p *parser) lowerDirectionalDeclarationsInRules(rules []css_ast.Rule) []css_ast.Rule {
+	var result []css_ast.Rule
+	for _, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RSelector:
+			var ltr []css_ast.Rule
+			var rtl []css_ast.Rule
+			var overrides []css_ast.Rule
+			var movedKeys []string
+			n := 0
+			for _, child := range r.Rules {
+				if decl, ok := child.Data.(*css_ast.RDeclaration); ok {
+					if ltrDecls, rtlDecls, ok := p.lowerLogicalDeclaration(child.Loc, decl); ok && rtlDecls != nil {
+						for _, d := range ltrDecls {
+							movedKeys = append(movedKeys, d.Data.(*css_ast.RDeclaration).KeyText)
+						}
+						for _, d := range rtlDecls {
+							movedKeys = append(movedKeys, d.Data.(*css_ast.RDeclaration).KeyText)
+						}
+
+						// Keep this after any overriding declarations that were
+						// already moved so that their order is preserved
+						if overrides != nil {
+							overrides = append(overrides, child)
+						} else {
+							ltr = append(ltr, ltrDecls...)
+							rtl = append(rtl, rtlDecls...)
+						}
+						continue
+					}
+
+					// Declarations that override a moved declaration must be moved
+					// after it to preserve the cascade within this rule
+					if anyPhysicalKeysOverlap(movedKeys, decl.KeyText) {
+						overrides = append(overrides, child)
+						movedKeys = append(movedKeys, decl.KeyText)
+						continue
+					}
+				}
+				r.Rules[n] = child
+				n++
+			}
+			if ltr == nil {
+				break
+			}
+			r.Rules = r.Rules[:n]
+			if n > 0 {
+				result = append(result, rule)
+			}
+			ltr = p.processDeclarations(ltr, nil)
+			rtl = p.processDeclarations(rtl, nil)
+			for _, child := range overrides {
+				ltrDecls, rtlDecls := p.lowerOverridingDeclaration(child)
+				ltr = append(ltr, ltrDecls...)
+				rtl = append(rtl, rtlDecls...)
+			}
+			result = append(result,
+				css_ast.Rule{Loc: rule.Loc, Data: &css_ast.RSelector{
+					Selectors:     prefixSelectorsWithDirection(r.Selectors, false),
+					Rules:         ltr,
+					CloseBraceLoc: r.CloseBraceLoc,
+				}},
+				css_ast.Rule{Loc: rule.Loc, Data: &css_ast.RSelector{
+					Selectors:     prefixSelectorsWithDirection(r.Selectors, true),
+					Rules:         rtl,
+					CloseBraceLoc: r.CloseBraceLoc,
+				}},
+			)
+			continue
+
+		case *css_ast.RKnownAt:
+			r.Rules = p.lowerDirectionalDeclarationsInRules(r.Rules)
+
+		case *css_ast.RAtMedia:
+			r.Rules = p.lowerDirectionalDeclarationsInRules(r.Rules)
+
+		case *css_ast.RAtLayer:
+			r.Rules = p.lowerDirectionalDeclarationsInRules(r.Rules)
+
+		case *css_ast.RAtScope:
+			r.Rules = p.lowerDirectionalDeclarationsInRules(r.Rules)
+		}
+
+		result = append(result, rule)
+	}
+	return result
+}
+
+// Declarations moved after a direction-dependent declaration are copied into
+// both direction-specific rules. They have already been processed by
+// "processDeclarations" unless they are direction-dependent themselves.
+func (p *parser) lowerOverridingDeclaration(child css_ast.Rule) (ltr []css_ast.Rule, rtl []css_ast.Rule) {
+	decl := child.Data.(*css_ast.RDeclaration)
+	if ltrDecls, rtlDecls, ok := p.lowerLogicalDeclaration(child.Loc, decl); ok && rtlDecls != nil {
+		return p.processDeclarations(ltrDecls, nil), p.processDeclarations(rtlDecls, nil)
+	}
+	clone := *decl
+	clone.Value = css_ast.CloneTokensWithoutImportRecords(decl.Value)
+	return []css_ast.Rule{child}, []css_ast.Rule{{Loc: child.Loc, Data: &clone}}
+}
+
+func anyPhysicalKeysOverlap(keys []string, key string) bool {
+	for _, k := range keys {
+		if physicalKeysOverlap(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// This extends "declarationKeysOverlap" with the shorthands that don't share a
+// prefix with their longhands (e.g. "inset" and "left")
+func physicalKeysOverlap(a string, b string) bool {
+	a = strings.ToLower(a)
+	b = strings.ToLower(b)
+	return declarationKeysOverlap(a, b) || shorthandCoversKey(a, b) || shorthandCoversKey(b, a)
+}
+
+func shorthandCoversKey(shorthand string, key string) bool {
+	switch shorthand {
+	case "inset":
+		return key == "top" || key == "right" || key == "bottom" || key == "left"
+
+	case "border-color", "border-style", "border-width", "border-radius":
+		return strings.HasPrefix(key, "border-") && strings.HasSuffix(key, shorthand[len("border"):])
+	}
+	return false
+}
+
+// "a, :root b" => ":where(html:not([dir=rtl])) a, :root:where(:not([dir=rtl])) b"
+func prefixSelectorsWithDirection(selectors []css_ast.ComplexSelector, isRTL bool) []css_ast.ComplexSelector {
+	var attr css_ast.SS = &css_ast.SSAttribute{
+		NamespacedName: css_ast.NamespacedName{Name: css_ast.NameToken{Kind: css_lexer.TIdent, Text: "dir"}},
+		MatcherOp:      "=",
+		MatcherValue:   "rtl",
+	}
+	if !isRTL {
+		attr = &css_ast.SSPseudoClassWithSelectorList{
+			Kind: css_ast.PseudoClassNot,
+			Selectors: []css_ast.ComplexSelector{{Selectors: []css_ast.CompoundSelector{{
+				SubclassSelectors: []css_ast.SubclassSelector{{Data: attr}},
+			}}}},
+		}
+	}
+
+	// Wrap the direction test in ":where()" so that it doesn't add specificity
+	where := func(typeSelector *css_ast.NamespacedName) css_ast.SubclassSelector {
+		return css_ast.SubclassSelector{Data: &css_ast.SSPseudoClassWithSelectorList{
+			Kind: css_ast.PseudoClassWhere,
+			Selectors: []css_ast.ComplexSelector{{Selectors: []css_ast.CompoundSelector{{
+				TypeSelector:      typeSelector,
+				SubclassSelectors: []css_ast.SubclassSelector{{Data: attr.Clone()}},
+			}}}},
+		}}
+	}
+
+	clone := make([]css_ast.ComplexSelector, len(selectors))
+	for i, sel := range selectors {
+		sel = sel.Clone()
+
+		// Merge the test into a leading "html" or ":root" instead of creating
+		// a descendant selector that would never match
+		if first := &sel.Selectors[0]; isRootCompoundSelector(*first) {
+			first.SubclassSelectors = append(first.SubclassSelectors, where(nil))
+		} else {
+			sel.Selectors = append([]css_ast.CompoundSelector{{
+				SubclassSelectors: []css_ast.SubclassSelector{where(
+					&css_ast.NamespacedName{Name: css_ast.NameToken{Kind: css_lexer.TIdent, Text: "html"}})},
+			}}, sel.Selectors...)
+		}
+		clone[i] = sel
+	}
+	return clone
+}
+
+func isRootCompoundSelector(sel css_ast.CompoundSelector) bool {
+	if sel.TypeSelector != nil {
+		return sel.TypeSelector.NamespacePrefix == nil && strings.EqualFold(sel.TypeSelector.Name.Text, "html")
+	}
+	for _, ss := range sel.SubclassSelectors {
+		if pseudo, ok := ss.Data.(*css_ast.SSPseudoClass); ok && !pseudo.IsElement && strings.EqualFold(pseudo.Name, "root") && pseudo.Args == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,356 @@
+package css_parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// ICSS (Interoperable CSS) is the low-level format that CSS modules use to
+// share values between CSS files and with JavaScript. These rules are only
+// recognized in files with local names. They are removed from the output
+// and are recorded in the AST instead so that the linker can resolve them
+// across files. See: https://github.com/css-modules/icss
+
+// Reference: https://github.com/css-modules/postcss-modules-values
+//
+//	"@value primary: #f00;"
+//	"@value primary #f00;"
+//	"@value small, large as bp-large from "./breakpoints.css";"
+//	"@value (small, large as bp-large) from "./breakpoints.css";"
+//
+// The returned boolean is false if this isn't a valid "@value" rule.
+func (p *parser) parseICSSValueRule(tokens []css_ast.Token) bool {
+	n := len(tokens)
+	if n == 0 {
+		return false
+	}
+
+	// Handle imports
+	if n >= 3 && tokens[n-2].Kind == css_lexer.TIdent && strings.EqualFold(tokens[n-2].Text, "from") && tokens[n-1].Kind == css_lexer.TString {
+		names := tokens[:n-2]
+		if len(names) == 1 && names[0].Kind == css_lexer.TOpenParen && names[0].Children != nil {
+			names = *names[0].Children
+		}
+
+		var imports []css_ast.ICSSImport
+		for len(names) > 0 {
+			name := names[0]
+			if name.Kind != css_lexer.TIdent {
+				p.reportUnexpectedICSSToken(name)
+				return false
+			}
+			alias := name.Text
+			names = names[1:]
+			if len(names) >= 2 && names[0].Kind == css_lexer.TIdent && strings.EqualFold(names[0].Text, "as") {
+				if names[1].Kind != css_lexer.TIdent {
+					p.reportUnexpectedICSSToken(names[1])
+					return false
+				}
+				alias = names[1].Text
+				names = names[2:]
+			}
+			imports = append(imports, css_ast.ICSSImport{Name: name.Text, Alias: alias, NameLoc: name.Loc})
+			if len(names) > 0 {
+				if names[0].Kind != css_lexer.TComma {
+					p.reportUnexpectedICSSToken(names[0])
+					return false
+				}
+				names = names[1:]
+			}
+		}
+
+		importRecordIndex := p.addICSSImportRecord(tokens[n-1])
+		for _, item := range imports {
+			item.ImportRecordIndex = importRecordIndex
+			p.icssImports = append(p.icssImports, item)
+		}
+		return true
+	}
+
+	// Handle definitions
+	name := tokens[0]
+	if name.Kind != css_lexer.TIdent {
+		p.reportUnexpectedICSSToken(name)
+		return false
+	}
+	value := tokens[1:]
+	if len(value) > 0 && value[0].Kind == css_lexer.TColon {
+		value = value[1:]
+	} else if len(value) == 0 {
+		p.log.AddID(logger.MsgID_CSS_CSSSyntaxError, logger.Warning, &p.tracker, css_lexer.RangeOfIdentifier(p.source, name.Loc),
+			fmt.Sprintf("Expected a value for %q", name.Text))
+		return false
+	}
+	value = trimICSSValueWhitespace(value)
+	p.icssValues = append(p.icssValues, css_ast.ICSSValue{Name: name.Text, Value: value, Loc: name.Loc})
+	return true
+}
+
+// Reference: https://github.com/css-modules/icss
+//
+//	":import("./colors.css") { accent: primary }"
+//	":export { primaryColor: accent }"
+//
+// The returned boolean is false if this isn't an ICSS rule.
+func (p *parser) parseICSSPseudoClassRule(rule css_ast.Rule) bool {
+	r, ok := rule.Data.(*css_ast.RSelector)
+	if !ok || len(r.Selectors) != 1 || len(r.Selectors[0].Selectors) != 1 {
+		return false
+	}
+	compound := r.Selectors[0].Selectors[0]
+	if compound.TypeSelector != nil || len(compound.NestingSelectorLocs) > 0 || compound.Combinator.Byte != 0 || len(compound.SubclassSelectors) != 1 {
+		return false
+	}
+	pseudo, ok := compound.SubclassSelectors[0].Data.(*css_ast.SSPseudoClass)
+	if !ok || pseudo.IsElement {
+		return false
+	}
+
+	switch pseudo.Name {
+	case "export":
+		if pseudo.Args != nil {
+			return false
+		}
+		for _, child := range r.Rules {
+			if decl, ok := child.Data.(*css_ast.RDeclaration); ok {
+				p.icssExports = append(p.icssExports, css_ast.ICSSValue{
+					Name:  decl.KeyText,
+					Value: trimICSSValueWhitespace(decl.Value),
+					Loc:   decl.KeyRange.Loc,
+				})
+			}
+		}
+		return true
+
+	case "import":
+		if len(pseudo.Args) != 1 || pseudo.Args[0].Kind != css_lexer.TString {
+			return false
+		}
+		importRecordIndex := p.addICSSImportRecord(pseudo.Args[0])
+		for _, child := range r.Rules {
+			if decl, ok := child.Data.(*css_ast.RDeclaration); ok {
+				if len(decl.Value) != 1 || decl.Value[0].Kind != css_lexer.TIdent {
+					if len(decl.Value) > 0 {
+						p.reportUnexpectedICSSToken(decl.Value[len(decl.Value)-1])
+					}
+					continue
+				}
+				p.icssImports = append(p.icssImports, css_ast.ICSSImport{
+					Name:              decl.Value[0].Text,
+					Alias:             decl.KeyText,
+					NameLoc:           decl.Value[0].Loc,
+					ImportRecordIndex: importRecordIndex,
+				})
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// ICSS imports use the same import record kind as "composes" since both
+// import names from another CSS module
+func (p *parser) addICSSImportRecord(path css_ast.Token) uint32 {
+	importRecordIndex := uint32(len(p.importRecords))
+	p.importRecords = append(p.importRecords, ast.ImportRecord{
+		Kind:  ast.ImportComposesFrom,
+		Path:  logger.Path{Text: path.Text},
+		Range: p.source.RangeOfString(path.Loc),
+	})
+	return importRecordIndex
+}
+
+func (p *parser) reportUnexpectedICSSToken(t css_ast.Token) {
+	var text string
+	switch t.Kind {
+	case css_lexer.TURL, css_lexer.TBadURL, css_lexer.TString, css_lexer.TUnterminatedString:
+		text = fmt.Sprintf("Unexpected %s", t.Kind.String())
+	default:
+		text = fmt.Sprintf("Unexpected %q", t.Text)
+	}
+	p.log.AddID(logger.MsgID_CSS_CSSSyntaxError, logger.Warning, &p.tracker, logger.Range{Loc: t.Loc}, text)
+	p.prevError = t.Loc
+}
+
+func trimICSSValueWhitespace(value []css_ast.Token) []css_ast.Token {
+	if len(value) == 0 {
+		return value
+	}
+	value = append([]css_ast.Token{}, value...)
+	value[0].Whitespace &= ^css_ast.WhitespaceBefore
+	value[len(value)-1].Whitespace &= ^css_ast.WhitespaceAfter
+	return value
+}
+
+// ICSS values may be defined in one file and used in another file, so they
+// can't be substituted by the parser. Instead the linker resolves the values
+// visible in each file and substitutes them into that file's rules right
+// before printing. Each value carries the import records that its tokens
+// refer to since values can come from other files.
+type ICSSResolvedValue struct {
+	Tokens        []css_ast.Token
+	ImportRecords []ast.ImportRecord
+}
+
+type ICSSLookup func(name string) *ICSSResolvedValue
+
+// This substitutes ICSS values for identifiers in the given tokens. Tokens
+// that aren't substituted are left as-is and the import records for the
+// substituted values are appended to "importRecords".
+func SubstituteICSSValuesInTokens(tokens []css_ast.Token, importRecords []ast.ImportRecord, lookup ICSSLookup) ([]css_ast.Token, []ast.ImportRecord) {
+	result := make([]css_ast.Token, 0, len(tokens))
+	for _, t := range tokens {
+		if t.Kind == css_lexer.TIdent {
+			if value := lookup(t.Text); value != nil && len(value.Tokens) > 0 {
+				start := len(result)
+				result, importRecords = css_ast.CloneTokensWithImportRecords(value.Tokens, value.ImportRecords, result, importRecords)
+				result[start].Whitespace |= t.Whitespace & css_ast.WhitespaceBefore
+				result[len(result)-1].Whitespace |= t.Whitespace & css_ast.WhitespaceAfter
+				continue
+			}
+		}
+		if t.Children != nil && t.Kind != css_lexer.TURL {
+			var children []css_ast.Token
+			children, importRecords = SubstituteICSSValuesInTokens(*t.Children, importRecords, lookup)
+			t.Children = &children
+		}
+		result = append(result, t)
+	}
+	return result, importRecords
+}
+
+func tokensUseICSSValues(tokens []css_ast.Token, lookup ICSSLookup) bool {
+	for _, t := range tokens {
+		if t.Kind == css_lexer.TIdent && lookup(t.Text) != nil {
+			return true
+		}
+		if t.Children != nil && t.Kind != css_lexer.TURL && tokensUseICSSValues(*t.Children, lookup) {
+			return true
+		}
+	}
+	return false
+}
+
+// This substitutes ICSS values into declaration values and "@media" queries.
+// It returns new lists if anything was substituted and never mutates the
+// original AST since ASTs are shared between chunks that are generated in
+// parallel.
+func SubstituteICSSValuesInRules(rules []css_ast.Rule, importRecords []ast.ImportRecord, lookup ICSSLookup) ([]css_ast.Rule, []ast.ImportRecord) {
+	s := icssSubstituter{importRecords: importRecords, lookup: lookup}
+	rules = s.substituteInRules(rules)
+	return rules, s.importRecords
+}
+
+type icssSubstituter struct {
+	lookup        ICSSLookup
+	importRecords []ast.ImportRecord
+	didCopy       bool
+}
+
+func (s *icssSubstituter) substituteInRules(rules []css_ast.Rule) []css_ast.Rule {
+	var result []css_ast.Rule
+	for i, rule := range rules {
+		if clone, ok := s.substituteInRule(rule); ok {
+			if result == nil {
+				result = append(make([]css_ast.Rule, 0, len(rules)), rules[:i]...)
+			}
+			result = append(result, clone)
+		} else if result != nil {
+			result = append(result, rule)
+		}
+	}
+	if result == nil {
+		return rules
+	}
+	return result
+}
+
+func (s *icssSubstituter) substituteInRule(rule css_ast.Rule) (css_ast.Rule, bool) {
+	switch r := rule.Data.(type) {
+	case *css_ast.RDeclaration:
+		if tokensUseICSSValues(r.Value, s.lookup) {
+			// Appending import records must not mutate the original AST
+			if !s.didCopy {
+				s.importRecords = append([]ast.ImportRecord{}, s.importRecords...)
+				s.didCopy = true
+			}
+			clone := *r
+			clone.Value, s.importRecords = SubstituteICSSValuesInTokens(r.Value, s.importRecords, s.lookup)
+			rule.Data = &clone
+			return rule, true
+		}
+
+	case *css_ast.RSelector:
+		if rules := s.substituteInRules(r.Rules); !sameRules(rules, r.Rules) {
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true
+		}
+
+	case *css_ast.RKnownAt:
+		if rules := s.substituteInRules(r.Rules); !sameRules(rules, r.Rules) {
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true
+		}
+
+	case *css_ast.RAtLayer:
+		if rules := s.substituteInRules(r.Rules); !sameRules(rules, r.Rules) {
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true
+		}
+
+	case *css_ast.RAtScope:
+		if rules := s.substituteInRules(r.Rules); !sameRules(rules, r.Rules) {
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true
+		}
+
+	case *css_ast.RAtMedia:
+		// "@value small: (max-width: 599px); @media small { ... }"
+		queries := r.Queries
+		for i, query := range r.Queries {
+			if mq, ok := query.Data.(*css_ast.MQType); ok && mq.Op == css_ast.MQTypeOpNone && mq.AndOrNull.Data == nil {
+				if value := s.lookup(mq.Type); value != nil && len(value.Tokens) > 0 {
+					if !s.didCopy {
+						s.importRecords = append([]ast.ImportRecord{}, s.importRecords...)
+						s.didCopy = true
+					}
+					if i == 0 || &queries[0] == &r.Queries[0] {
+						queries = append([]css_ast.MediaQuery{}, r.Queries...)
+					}
+					var tokens []css_ast.Token
+					tokens, s.importRecords = css_ast.CloneTokensWithImportRecords(value.Tokens, value.ImportRecords, nil, s.importRecords)
+					queries[i] = css_ast.MediaQuery{Loc: query.Loc, Data: &css_ast.MQArbitraryTokens{Tokens: tokens}}
+				}
+			}
+		}
+		rules := s.substituteInRules(r.Rules)
+		if len(queries) > 0 && len(r.Queries) > 0 && &queries[0] == &r.Queries[0] && sameRules(rules, r.Rules) {
+			return rule, false
+		}
+		clone := *r
+		clone.Queries = queries
+		clone.Rules = rules
+		rule.Data = &clone
+		return rule, true
+	}
+
+	return rule, false
+}
+
+func sameRules(a []css_ast.Rule, b []css_ast.Rule) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,324 @@
+package css_parser
+
+import (
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+)
+
+// Browsers without support for cascade layers ignore everything inside of an
+// "@layer" rule. To lower "@layer", the rules inside each layer are moved out
+// of the layer in place and the specificity of their selectors is increased
+// using ":not(#\#)" so that later layers still take precedence over earlier
+// layers (and unlayered styles take precedence over all layers):
+//
+//	@layer base { .a { color: red } }
+//	.b { color: blue }
+//
+//	=>
+//
+//	.a { color: red }
+//	.b:not(#\#) { color: blue }
+//
+// The layer order is reversed for "!important" declarations, so those are
+// moved into a separate rule that uses the reverse order instead.
+//
+// Layer order is determined by the first appearance of each layer across all
+// files in the chunk, so this is done by the linker once everything is known.
+// This never mutates the original AST since ASTs are shared between chunks
+// that are generated in parallel.
+func LowerCascadeLayers(asts []css_ast.AST) {
+	l := layerLowering{anonymousLayers: make(map[css_ast.R]*cascadeLayer)}
+
+	// Pass 1: Determine the layer order and the maximum specificity
+	for _, tree := range asts {
+		l.visitRules(tree.Rules, &l.root)
+	}
+	if !l.hasLayers {
+		return
+	}
+	precedence := 0
+	l.root.assignPrecedence(&precedence)
+
+	// Pass 2: Remove all layers and increase specificity to compensate
+	for i := range asts {
+		asts[i].Rules = l.lowerRules(asts[i].Rules, &l.root)
+	}
+}
+
+type cascadeLayer struct {
+	children   []*cascadeLayer
+	names      map[string]*cascadeLayer
+	precedence int
+}
+
+// Sub-layers come before their parent layer's own styles, so this assigns
+// each layer a precedence using a post-order traversal. The root represents
+// unlayered styles and has the highest precedence.
+func (layer *cascadeLayer) assignPrecedence(next *int) {
+	for _, child := range layer.children {
+		child.assignPrecedence(next)
+	}
+	layer.precedence = *next
+	*next++
+}
+
+func (layer *cascadeLayer) childForName(name []string) *cascadeLayer {
+	for _, part := range name {
+		child, ok := layer.names[part]
+		if !ok {
+			if layer.names == nil {
+				layer.names = make(map[string]*cascadeLayer)
+			}
+			child = &cascadeLayer{}
+			layer.names[part] = child
+			layer.children = append(layer.children, child)
+		}
+		layer = child
+	}
+	return layer
+}
+
+type layerLowering struct {
+	anonymousLayers map[css_ast.R]*cascadeLayer
+	root            cascadeLayer
+	maxIDCount      int
+	hasLayers       bool
+}
+
+// This returns the layer names for both "@layer" rules from the parser and
+// "@layer" rules generated by the linker for "@import" with "layer()". The
+// returned boolean is false if the rule isn't a layer rule.
+func layerNamesAndRules(rule css_ast.Rule) (names [][]string, rules []css_ast.Rule, ok bool) {
+	switch r := rule.Data.(type) {
+	case *css_ast.RAtLayer:
+		return r.Names, r.Rules, true
+
+	case *css_ast.RKnownAt:
+		if r.AtToken != "layer" {
+			return nil, nil, false
+		}
+		if len(r.Prelude) > 0 {
+			var name []string
+			for _, t := range r.Prelude {
+				if t.Kind == css_lexer.TIdent {
+					name = append(name, t.Text)
+				}
+			}
+			names = [][]string{name}
+		}
+		return names, r.Rules, true
+	}
+	return nil, nil, false
+}
+
+func (l *layerLowering) visitRules(rules []css_ast.Rule, layer *cascadeLayer) {
+	for _, rule := range rules {
+		if names, children, ok := layerNamesAndRules(rule); ok {
+			l.hasLayers = true
+			if children == nil && len(names) > 0 {
+				// "@layer a, b;"
+				for _, name := range names {
+					layer.childForName(name)
+				}
+			} else if len(names) == 1 {
+				// "@layer a { ... }"
+				l.visitRules(children, layer.childForName(names[0]))
+			} else {
+				// "@layer { ... }"
+				child := &cascadeLayer{}
+				layer.children = append(layer.children, child)
+				l.anonymousLayers[rule.Data] = child
+				l.visitRules(children, child)
+			}
+			continue
+		}
+
+		switch r := rule.Data.(type) {
+		case *css_ast.RSelector:
+			for _, complex := range r.Selectors {
+				if n := complexSelectorIDCount(complex); n > l.maxIDCount {
+					l.maxIDCount = n
+				}
+			}
+
+		case *css_ast.RKnownAt:
+			l.visitRules(r.Rules, layer)
+
+		case *css_ast.RAtMedia:
+			l.visitRules(r.Rules, layer)
+
+		case *css_ast.RAtScope:
+			l.visitRules(r.Rules, layer)
+		}
+	}
+}
+
+func (l *layerLowering) lowerRules(rules []css_ast.Rule, layer *cascadeLayer) []css_ast.Rule {
+	result := make([]css_ast.Rule, 0, len(rules))
+	for _, rule := range rules {
+		if names, children, ok := layerNamesAndRules(rule); ok {
+			if children == nil && len(names) > 0 {
+				// Layer statements only affect the layer order, which was already handled
+			} else if len(names) == 1 {
+				result = append(result, l.lowerRules(children, layer.childForName(names[0]))...)
+			} else {
+				result = append(result, l.lowerRules(children, l.anonymousLayers[rule.Data])...)
+			}
+			continue
+		}
+
+		switch r := rule.Data.(type) {
+		case *css_ast.RSelector:
+			// Layered "!important" declarations take precedence over unlayered
+			// ones, and earlier layers take precedence over later layers:
+			//
+			//	@layer a { .x { color: red !important } }
+			//	.x { color: blue !important }
+			//
+			//	=>
+			//
+			//	.x:not(#\#) { color: red !important }
+			//	.x { color: blue !important }
+			//
+			normal, important := splitImportantDeclarations(r.Rules)
+			if len(normal) > 0 || len(important) == 0 {
+				result = append(result, l.increaseRuleSpecificity(rule, r, normal, layer.precedence))
+			}
+			if len(important) > 0 {
+				result = append(result, l.increaseRuleSpecificity(rule, r, important, l.root.precedence-layer.precedence))
+			}
+			continue
+
+		case *css_ast.RKnownAt:
+			if len(r.Rules) > 0 {
+				clone := *r
+				if clone.Rules = l.lowerRules(r.Rules, layer); len(clone.Rules) == 0 {
+					continue
+				}
+				rule.Data = &clone
+			}
+
+		case *css_ast.RAtMedia:
+			if len(r.Rules) > 0 {
+				clone := *r
+				if clone.Rules = l.lowerRules(r.Rules, layer); len(clone.Rules) == 0 {
+					continue
+				}
+				rule.Data = &clone
+			}
+
+		case *css_ast.RAtScope:
+			if len(r.Rules) > 0 {
+				clone := *r
+				if clone.Rules = l.lowerRules(r.Rules, layer); len(clone.Rules) == 0 {
+					continue
+				}
+				rule.Data = &clone
+			}
+		}
+
+		result = append(result, rule)
+	}
+	return result
+}
+
+func (l *layerLowering) increaseRuleSpecificity(rule css_ast.Rule, r *css_ast.RSelector, rules []css_ast.Rule, precedence int) css_ast.Rule {
+	clone := *r
+	clone.Rules = rules
+	if count := precedence * (l.maxIDCount + 1); count > 0 {
+		clone.Selectors = make([]css_ast.ComplexSelector, len(r.Selectors))
+		for i, complex := range r.Selectors {
+			clone.Selectors[i] = increaseSpecificity(complex, count)
+		}
+	}
+	rule.Data = &clone
+	return rule
+}
+
+// Normal and "!important" declarations never override each other, so they
+// can be split into separate rules without changing the cascade. The second
+// return value is nil if there are no "!important" declarations.
+func splitImportantDeclarations(rules []css_ast.Rule) (normal []css_ast.Rule, important []css_ast.Rule) {
+	for _, rule := range rules {
+		if decl, ok := rule.Data.(*css_ast.RDeclaration); ok && decl.Important {
+			important = append(important, rule)
+		}
+	}
+	if important == nil {
+		return rules, nil
+	}
+	normal = make([]css_ast.Rule, 0, len(rules)-len(important))
+	for _, rule := range rules {
+		if decl, ok := rule.Data.(*css_ast.RDeclaration); !ok || !decl.Important {
+			normal = append(normal, rule)
+		}
+	}
+	return
+}
+
+// This appends "count" copies of ":not(#\#)" to the last compound selector.
+// Each one adds the specificity of an ID selector without changing what the
+// selector matches. They must come before any pseudo-element.
+func increaseSpecificity(complex css_ast.ComplexSelector, count int) css_ast.ComplexSelector {
+	last := len(complex.Selectors) - 1
+	clone := css_ast.ComplexSelector{Selectors: append([]css_ast.CompoundSelector{}, complex.Selectors...)}
+	compound := &clone.Selectors[last]
+	compound.SubclassSelectors = insertBeforePseudoElement(compound.SubclassSelectors, specificityBoosters(specificity{ids: count}))
+	return clone
+}
+
+// This returns a copy of "subclassSelectors" with "insert" added before the
+// first pseudo-element, since pseudo-elements must come last.
+func insertBeforePseudoElement(subclassSelectors []css_ast.SubclassSelector, insert []css_ast.SubclassSelector) []css_ast.SubclassSelector {
+	index := len(subclassSelectors)
+	for i, ss := range subclassSelectors {
+		if isPseudoElement(ss.Data) {
+			index = i
+			break
+		}
+	}
+
+	result := make([]css_ast.SubclassSelector, 0, len(subclassSelectors)+len(insert))
+	result = append(result, subclassSelectors[:index]...)
+	result = append(result, insert...)
+	return append(result, subclassSelectors[index:]...)
+}
+
+func isPseudoElement(ss css_ast.SS) bool {
+	if class, ok := ss.(*css_ast.SSPseudoClass); ok {
+		if class.IsElement {
+			return true
+		}
+		switch class.Name {
+		case "before", "after", "first-line", "first-letter":
+			return true
+		}
+	}
+	return false
+}
+
+// This only counts the ID component of the selector's specificity, which is
+// all that's needed to determine how many ":not(#\#)" selectors are needed.
+func complexSelectorIDCount(complex css_ast.ComplexSelector) (count int) {
+	for _, compound := range complex.Selectors {
+		for _, ss := range compound.SubclassSelectors {
+			switch s := ss.Data.(type) {
+			case *css_ast.SSHash:
+				count++
+
+			case *css_ast.SSPseudoClassWithSelectorList:
+				// ":where()" has no specificity but the others use their most specific argument
+				if s.Kind != css_ast.PseudoClassWhere {
+					max := 0
+					for _, inner := range s.Selectors {
+						if n := complexSelectorIDCount(inner); n > max {
+							max = n
+						}
+					}
+					count += max
+				}
+			}
+		}
+	}
+	return
+}
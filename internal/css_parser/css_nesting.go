@@ -2,9 +2,11 @@ package css_parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
 	"github.com/evanw/esbuild/internal/logger"
 )
 
@@ -98,6 +100,13 @@ func (p *parser) lowerNestingInRule(rule css_ast.Rule, results []css_ast.Rule) [
 			rules = p.lowerNestingInRule(child, rules)
 		}
 		r.Rules = rules
+
+	case *css_ast.RAtScope:
+		var rules []css_ast.Rule
+		for _, child := range r.Rules {
+			rules = p.lowerNestingInRule(child, rules)
+		}
+		r.Rules = rules
 	}
 
 	return append(results, rule)
@@ -153,136 +162,11 @@ type lowerNestingContext struct {
 func (p *parser) lowerNestingInRuleWithContext(rule css_ast.Rule, context *lowerNestingContext) css_ast.Rule {
 	switch r := rule.Data.(type) {
 	case *css_ast.RSelector:
-		oldSelectorsLen := len(r.Selectors)
-		oldSelectorsComplexity := complexSelectorTermCount(r.Selectors)
-
-		// "a { & b {} }" => "a b {}"
-		// "a { &b {} }" => "a:is(b) {}"
-		// "a { &:hover {} }" => "a:hover {}"
-		// ".x { &b {} }" => "b.x {}"
-		// "a, b { .c, d {} }" => ":is(a, b) :is(.c, d) {}"
-		// "a, b { &.c, & d, e & {} }" => ":is(a, b).c, :is(a, b) d, e :is(a, b) {}"
-
-		// Pass 1: Canonicalize and analyze our selectors
-		for i := range r.Selectors {
-			sel := &r.Selectors[i]
-
-			// Inject the implicit "&" now for simplicity later on
-			if sel.IsRelative() {
-				sel.Selectors = append([]css_ast.CompoundSelector{{NestingSelectorLocs: []logger.Loc{rule.Loc}}}, sel.Selectors...)
-			}
-		}
-
-		// Pass 2: Substitute "&" for the parent selector
-		if !p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) || len(context.parentSelectorsNoPseudo) <= 1 {
-			// If we can use ":is", or we don't have to because there's only one
-			// parent selector, or we are using ":is()" to match zero parent selectors
-			// (even if ":is" is unsupported), then substituting "&" for the parent
-			// selector is easy.
-			for i := range r.Selectors {
-				complex := &r.Selectors[i]
-				results := make([]css_ast.CompoundSelector, 0, len(complex.Selectors))
-				parent := p.multipleComplexSelectorsToSingleComplexSelector(context.parentSelectorsNoPseudo)
-				for _, compound := range complex.Selectors {
-					results = p.substituteAmpersandsInCompoundSelector(compound, parent, results, keepLeadingCombinator)
-				}
-				complex.Selectors = results
-			}
-		} else {
-			// Otherwise if we can't use ":is", the transform is more complicated.
-			// Avoiding ":is" can lead to a combinatorial explosion of cases so we
-			// want to avoid this if possible. For example:
-			//
-			//   .first, .second, .third {
-			//     & > & {
-			//       color: red;
-			//     }
-			//   }
-			//
-			// If we can use ":is" (the easy case above) then we can do this:
-			//
-			//   :is(.first, .second, .third) > :is(.first, .second, .third) {
-			//     color: red;
-			//   }
-			//
-			// But if we can't use ":is" then we have to do this instead:
-			//
-			//   .first > .first,
-			//   .first > .second,
-			//   .first > .third,
-			//   .second > .first,
-			//   .second > .second,
-			//   .second > .third,
-			//   .third > .first,
-			//   .third > .second,
-			//   .third > .third {
-			//     color: red;
-			//   }
-			//
-			// That combinatorial explosion is what the loop below implements. Note
-			// that PostCSS's implementation of nesting gets this wrong. It generates
-			// this instead:
-			//
-			//   .first > .first,
-			//   .second > .second,
-			//   .third > .third {
-			//     color: red;
-			//   }
-			//
-			// That's not equivalent, so that's an incorrect transformation.
-			var selectors []css_ast.ComplexSelector
-			var indices []int
-			for {
-				// Every time we encounter another "&", add another dimension
-				offset := 0
-				parent := func(loc logger.Loc) css_ast.ComplexSelector {
-					if offset == len(indices) {
-						indices = append(indices, 0)
-					}
-					index := indices[offset]
-					offset++
-					return context.parentSelectorsNoPseudo[index]
-				}
-
-				// Do the substitution for this particular combination
-				for i := range r.Selectors {
-					complex := r.Selectors[i]
-					results := make([]css_ast.CompoundSelector, 0, len(complex.Selectors))
-					for _, compound := range complex.Selectors {
-						results = p.substituteAmpersandsInCompoundSelector(compound, parent, results, keepLeadingCombinator)
-					}
-					complex.Selectors = results
-					selectors = append(selectors, complex)
-					offset = 0
-				}
-
-				// Do addition with carry on the indices across dimensions
-				carry := len(indices)
-				for carry > 0 {
-					index := &indices[carry-1]
-					if *index+1 < len(context.parentSelectorsNoPseudo) {
-						*index++
-						break
-					}
-					*index = 0
-					carry--
-				}
-				if carry == 0 {
-					break
-				}
-			}
-			r.Selectors = selectors
-		}
-
-		// Put limits on the combinatorial explosion to avoid using too much time and/or memory
-		if n := len(r.Selectors); n > oldSelectorsLen && n > 0xFF00 {
-			p.addExpansionError(rule.Loc, n)
-			return css_ast.Rule{}
-		}
-		if n := complexSelectorTermCount(r.Selectors); n > oldSelectorsComplexity && n > 0xFF00 {
-			p.addExpansionError(rule.Loc, n)
+		selectors, ok := p.substituteNestingSelectors(rule.Loc, r.Selectors, context)
+		if !ok {
 			return css_ast.Rule{}
 		}
+		r.Selectors = selectors
 
 		// Lower all child rules using our newly substituted selector
 		context.loweredRules = p.lowerNestingInRule(rule, context.loweredRules)
@@ -355,11 +239,217 @@ func (p *parser) lowerNestingInRuleWithContext(rule css_ast.Rule, context *lower
 		r.Rules = childContext.loweredRules
 		context.loweredRules = append(context.loweredRules, rule)
 		return css_ast.Rule{}
+
+	case *css_ast.RAtScope:
+		// The scoping root of a nested "@scope" is relative to the parent rule:
+		//
+		//   "div { @scope { color: red } }" => "@scope (div) { :where(:scope) { color: red } }"
+		//   "div { @scope (.a) { color: red } }" => "@scope (div .a) { :where(:scope) { color: red } }"
+		//   "div { @scope (& > .a) { color: red } }" => "@scope (div > .a) { :where(:scope) { color: red } }"
+		//
+		if len(r.Start) == 0 {
+			r.Start = make([]css_ast.ComplexSelector, len(context.parentSelectorsNoPseudo))
+			for i, sel := range context.parentSelectorsNoPseudo {
+				r.Start[i] = sel.Clone()
+			}
+		} else if selectors, ok := p.substituteNestingSelectors(rule.Loc, r.Start, context); ok {
+			r.Start = selectors
+		} else {
+			return css_ast.Rule{}
+		}
+
+		// Inside the scope, "&" refers to ":scope" just like at the top level.
+		// Declarations directly inside the scope apply to the scoping root.
+		var rules []css_ast.Rule
+		var declarations []css_ast.Rule
+		for _, child := range r.Rules {
+			if _, ok := child.Data.(*css_ast.RDeclaration); ok {
+				declarations = append(declarations, child)
+			} else {
+				rules = p.lowerNestingInRule(child, rules)
+			}
+		}
+		if len(declarations) > 0 {
+			rules = append([]css_ast.Rule{{Loc: rule.Loc, Data: &css_ast.RSelector{
+				Selectors: []css_ast.ComplexSelector{{Selectors: []css_ast.CompoundSelector{{
+					SubclassSelectors: []css_ast.SubclassSelector{{
+						Range: logger.Range{Loc: rule.Loc},
+						Data: &css_ast.SSPseudoClassWithSelectorList{
+							Kind: css_ast.PseudoClassWhere,
+							Selectors: []css_ast.ComplexSelector{{Selectors: []css_ast.CompoundSelector{{
+								SubclassSelectors: []css_ast.SubclassSelector{{
+									Range: logger.Range{Loc: rule.Loc},
+									Data:  &css_ast.SSPseudoClass{Name: "scope"},
+								}},
+							}}}},
+						},
+					}},
+				}}}},
+				Rules: declarations,
+			}}}, rules...)
+		}
+		r.Rules = rules
+		context.loweredRules = append(context.loweredRules, rule)
+		return css_ast.Rule{}
 	}
 
 	return rule
 }
 
+// This substitutes "&" in the selectors of a nested rule for the parent
+// selectors. The returned boolean is false if the rule should be dropped.
+func (p *parser) substituteNestingSelectors(loc logger.Loc, selectors []css_ast.ComplexSelector, context *lowerNestingContext) ([]css_ast.ComplexSelector, bool) {
+	oldSelectorsLen := len(selectors)
+	oldSelectorsComplexity := complexSelectorTermCount(selectors)
+
+	// "a { & b {} }" => "a b {}"
+	// "a { &b {} }" => "a:is(b) {}"
+	// "a { &:hover {} }" => "a:hover {}"
+	// ".x { &b {} }" => "b.x {}"
+	// "a, b { .c, d {} }" => ":is(a, b) :is(.c, d) {}"
+	// "a, b { &.c, & d, e & {} }" => ":is(a, b).c, :is(a, b) d, e :is(a, b) {}"
+
+	// Pass 1: Canonicalize and analyze our selectors
+	for i := range selectors {
+		sel := &selectors[i]
+
+		// Inject the implicit "&" now for simplicity later on
+		if sel.IsRelative() {
+			sel.Selectors = append([]css_ast.CompoundSelector{{NestingSelectorLocs: []logger.Loc{loc}}}, sel.Selectors...)
+		}
+	}
+
+	// If ":is" is unsupported and all parent selectors use pseudo-elements,
+	// then this rule can never match anything. Substituting an empty ":is()"
+	// would cause the whole rule to be dropped anyway, so just remove it.
+	if p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) && len(context.parentSelectorsNoPseudo) == 0 {
+		return nil, false
+	}
+
+	// Pass 2: Substitute "&" for the parent selector
+	if !p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) || len(context.parentSelectorsNoPseudo) <= 1 {
+		// If we can use ":is", or we don't have to because there's only one
+		// parent selector, then substituting "&" for the parent selector is easy.
+		for i := range selectors {
+			complex := &selectors[i]
+			results := make([]css_ast.CompoundSelector, 0, len(complex.Selectors))
+			parent := p.multipleComplexSelectorsToSingleComplexSelector(context.parentSelectorsNoPseudo)
+			for _, compound := range complex.Selectors {
+				results = p.substituteAmpersandsInCompoundSelector(compound, parent, results, keepLeadingCombinator)
+			}
+			complex.Selectors = results
+		}
+	} else {
+		// Otherwise if we can't use ":is", the transform is more complicated.
+		// Avoiding ":is" can lead to a combinatorial explosion of cases so we
+		// want to avoid this if possible. For example:
+		//
+		//   .first, .second, .third {
+		//     & > & {
+		//       color: red;
+		//     }
+		//   }
+		//
+		// If we can use ":is" (the easy case above) then we can do this:
+		//
+		//   :is(.first, .second, .third) > :is(.first, .second, .third) {
+		//     color: red;
+		//   }
+		//
+		// But if we can't use ":is" then we have to do this instead:
+		//
+		//   .first > .first,
+		//   .first > .second,
+		//   .first > .third,
+		//   .second > .first,
+		//   .second > .second,
+		//   .second > .third,
+		//   .third > .first,
+		//   .third > .second,
+		//   .third > .third {
+		//     color: red;
+		//   }
+		//
+		// That combinatorial explosion is what the loop below implements. Note
+		// that PostCSS's implementation of nesting gets this wrong. It generates
+		// this instead:
+		//
+		//   .first > .first,
+		//   .second > .second,
+		//   .third > .third {
+		//     color: red;
+		//   }
+		//
+		// That's not equivalent, so that's an incorrect transformation.
+		var expanded []css_ast.ComplexSelector
+		var indices []int
+		for {
+			// Every time we encounter another "&", add another dimension
+			offset := 0
+			parent := func(loc logger.Loc) css_ast.ComplexSelector {
+				if offset == len(indices) {
+					indices = append(indices, 0)
+				}
+				index := indices[offset]
+				offset++
+				return context.parentSelectorsNoPseudo[index]
+			}
+
+			// Do the substitution for this particular combination
+			for i := range selectors {
+				complex := selectors[i]
+				results := make([]css_ast.CompoundSelector, 0, len(complex.Selectors))
+				for _, compound := range complex.Selectors {
+					results = p.substituteAmpersandsInCompoundSelector(compound, parent, results, keepLeadingCombinator)
+				}
+				complex.Selectors = results
+				expanded = append(expanded, complex)
+				offset = 0
+			}
+
+			// Do addition with carry on the indices across dimensions
+			carry := len(indices)
+			for carry > 0 {
+				index := &indices[carry-1]
+				if *index+1 < len(context.parentSelectorsNoPseudo) {
+					*index++
+					break
+				}
+				*index = 0
+				carry--
+			}
+			if carry == 0 {
+				break
+			}
+		}
+		selectors = expanded
+	}
+
+	// Pass 3: If ":is" is unsupported, expand any ":is()" that was generated
+	// above by merging the parent selector into the surrounding selector
+	if p.nestingIsToExpand != nil {
+		selectors = p.expandGeneratedPseudoClassIs(selectors)
+		p.nestingIsToExpand = nil
+
+		// Omit this rule entirely if none of the expanded selectors can match
+		if len(selectors) == 0 {
+			return nil, false
+		}
+	}
+
+	// Put limits on the combinatorial explosion to avoid using too much time and/or memory
+	if n := len(selectors); n > oldSelectorsLen && n > 0xFF00 {
+		p.addExpansionError(loc, n)
+		return nil, false
+	}
+	if n := complexSelectorTermCount(selectors); n > oldSelectorsComplexity && n > 0xFF00 {
+		p.addExpansionError(loc, n)
+		return nil, false
+	}
+
+	return selectors, true
+}
+
 type leadingCombinatorStrip uint8
 
 const (
@@ -397,14 +487,15 @@ func (p *parser) substituteAmpersandsInCompoundSelector(
 		} else {
 			// ".foo .bar { :hover & {} }" => ":hover :is(.foo .bar) {}"
 			// ".foo .bar { > &:hover {} }" => ".foo .bar > :is(.foo .bar):hover {}"
-			p.reportNestingWithGeneratedPseudoClassIs(nestingSelectorLoc)
+			is := &css_ast.SSPseudoClassWithSelectorList{
+				Kind:      css_ast.PseudoClassIs,
+				Selectors: []css_ast.ComplexSelector{replacement.Clone()},
+			}
+			p.trackGeneratedPseudoClassIs(nestingSelectorLoc, is, strip)
 			single = css_ast.CompoundSelector{
 				SubclassSelectors: []css_ast.SubclassSelector{{
 					Range: logger.Range{Loc: nestingSelectorLoc},
-					Data: &css_ast.SSPseudoClassWithSelectorList{
-						Kind:      css_ast.PseudoClassIs,
-						Selectors: []css_ast.ComplexSelector{replacement.Clone()},
-					},
+					Data:  is,
 				}},
 			}
 		}
@@ -414,13 +505,14 @@ func (p *parser) substituteAmpersandsInCompoundSelector(
 		// Insert the type selector
 		if single.TypeSelector != nil {
 			if sel.TypeSelector != nil {
-				p.reportNestingWithGeneratedPseudoClassIs(nestingSelectorLoc)
+				is := &css_ast.SSPseudoClassWithSelectorList{
+					Kind:      css_ast.PseudoClassIs,
+					Selectors: []css_ast.ComplexSelector{{Selectors: []css_ast.CompoundSelector{{TypeSelector: sel.TypeSelector}}}},
+				}
+				p.trackGeneratedPseudoClassIs(nestingSelectorLoc, is, strip)
 				subclassSelectorPrefix = append(subclassSelectorPrefix, css_ast.SubclassSelector{
 					Range: sel.TypeSelector.Range(),
-					Data: &css_ast.SSPseudoClassWithSelectorList{
-						Kind:      css_ast.PseudoClassIs,
-						Selectors: []css_ast.ComplexSelector{{Selectors: []css_ast.CompoundSelector{{TypeSelector: sel.TypeSelector}}}},
-					},
+					Data:  is,
 				})
 			}
 			sel.TypeSelector = single.TypeSelector
@@ -489,6 +581,351 @@ func (p *parser) multipleComplexSelectorsToSingleComplexSelector(selectors []css
 	}
 }
 
+// Generated ":is()" selectors are remembered when ":is" is unsupported so
+// that they can be expanded away after substitution. Ones inside of another
+// pseudo-class selector list can't be expanded, so they are reported instead.
+func (p *parser) trackGeneratedPseudoClassIs(nestingSelectorLoc logger.Loc, is *css_ast.SSPseudoClassWithSelectorList, strip leadingCombinatorStrip) {
+	if strip == keepLeadingCombinator && p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) {
+		if p.nestingIsToExpand == nil {
+			p.nestingIsToExpand = make(map[*css_ast.SSPseudoClassWithSelectorList]struct{})
+		}
+		p.nestingIsToExpand[is] = struct{}{}
+		return
+	}
+	p.reportNestingWithGeneratedPseudoClassIs(nestingSelectorLoc)
+}
+
+// This replaces each generated ":is()" with the equivalent list of selectors
+// that don't use ":is()". This follows the semantics of ":is()" exactly:
+//
+//	".foo .bar { .baz & {} }" => ".foo .baz .bar, .baz .foo .bar, .baz.foo .bar {}"
+//	"div { span& {} }" => (nothing, since no element can be both)
+//
+// Note that simple selectors are never deduplicated when two compound
+// selectors are merged so that each expanded selector keeps the specificity
+// of the original ":is()" selector.
+func (p *parser) expandGeneratedPseudoClassIs(selectors []css_ast.ComplexSelector) []css_ast.ComplexSelector {
+	var results []css_ast.ComplexSelector
+	for _, complex := range selectors {
+		results = p.expandGeneratedPseudoClassIsInComplexSelector(complex, results)
+	}
+	return results
+}
+
+func (p *parser) expandGeneratedPseudoClassIsInComplexSelector(complex css_ast.ComplexSelector, results []css_ast.ComplexSelector) []css_ast.ComplexSelector {
+	for i, compound := range complex.Selectors {
+		for j, ss := range compound.SubclassSelectors {
+			is, ok := ss.Data.(*css_ast.SSPseudoClassWithSelectorList)
+			if !ok {
+				continue
+			}
+			if _, ok := p.nestingIsToExpand[is]; !ok {
+				continue
+			}
+
+			// Remove the ":is()" from its compound selector
+			rest := compound
+			rest.SubclassSelectors = make([]css_ast.SubclassSelector, 0, len(compound.SubclassSelectors)-1)
+			rest.SubclassSelectors = append(rest.SubclassSelectors, compound.SubclassSelectors[:j]...)
+			rest.SubclassSelectors = append(rest.SubclassSelectors, compound.SubclassSelectors[j+1:]...)
+			outer := make([]css_ast.CompoundSelector, 0, i+1)
+			outer = append(outer, complex.Selectors[:i]...)
+			outer = append(outer, rest)
+
+			// Merge the selector inside the ":is()" with the selector before it
+			merged, ok := mergeSelectorChains(outer, is.Selectors[0].Selectors)
+			if !ok {
+				// Fall back to only merging in the last compound selector inside the
+				// ":is()" if this case is too complex. Whatever comes before it is
+				// dropped, so this may match more elements than the original. But
+				// the dropped specificity is added back so that the fallback wins
+				// against the same rules that the original would have won against:
+				//
+				//   ".a .b { & + & {} }" => ".a .b + .b:not(.\#) {}"
+				//
+				subject, ok := mergeSubjectOfSelectorForIsFallback(rest, is.Selectors[0])
+				if !ok {
+					// There are no elements that can match both selectors
+					return results
+				}
+				merged = [][]css_ast.CompoundSelector{appendCompound(outer[:i], subject)}
+			}
+
+			// Then append whatever came after the ":is()" and keep going
+			for _, chain := range merged {
+				chain = append(chain, complex.Selectors[i+1:]...)
+				results = p.expandGeneratedPseudoClassIsInComplexSelector(css_ast.ComplexSelector{Selectors: chain}, results)
+			}
+			return results
+		}
+	}
+
+	// Avoid generating duplicate selectors
+	for _, existing := range results {
+		if existing.Equal(complex, nil) {
+			return results
+		}
+	}
+	return append(results, complex.Clone())
+}
+
+// Merging ancestor chains generates every possible interleaving of the two
+// chains, which grows exponentially. Give up on long chains and fall back.
+const maxMergedSelectorChainLength = 8
+
+// Given "a" and "b" which must both match the same element, this returns all
+// possible complex selectors that match an element matched by both of them.
+// The returned boolean is false if this isn't possible to do.
+func mergeSelectorChains(a []css_ast.CompoundSelector, b []css_ast.CompoundSelector) ([][]css_ast.CompoundSelector, bool) {
+	if len(a)+len(b) > maxMergedSelectorChainLength {
+		return nil, false
+	}
+	for _, chain := range [][]css_ast.CompoundSelector{a, b} {
+		for i, compound := range chain {
+			// Only handle the descendant and child combinators, and don't handle a
+			// leading combinator (it's relative to something we don't know about)
+			if c := compound.Combinator.Byte; (i == 0 && c != 0) || (c != 0 && c != '>') {
+				return nil, false
+			}
+
+			// Namespaces make it harder to tell if type selectors are compatible
+			if compound.TypeSelector != nil && compound.TypeSelector.NamespacePrefix != nil {
+				return nil, false
+			}
+		}
+	}
+
+	aLast := a[len(a)-1]
+	bLast := b[len(b)-1]
+	subject, status := mergeCompoundSelectorsForIs(bLast, aLast)
+	if status == mergeNeverMatches {
+		// There are no elements that can match both selectors
+		return nil, true
+	}
+
+	ancestors, ok := mergeAncestorChains(a[:len(a)-1], aLast.Combinator.Byte, b[:len(b)-1], bLast.Combinator.Byte)
+	if !ok {
+		return nil, false
+	}
+	results := make([][]css_ast.CompoundSelector, 0, len(ancestors))
+	for _, ancestor := range ancestors {
+		subject.Combinator = css_ast.Combinator{Byte: ancestor.combinator}
+		results = append(results, appendCompound(ancestor.chain, subject))
+	}
+	return results, true
+}
+
+// This merges the last compound selector of "inner" into "rest", and then adds
+// selectors that match everything to make up for the specificity of the parts
+// of "inner" that weren't merged. The returned boolean is false if there are
+// no elements that can match both selectors.
+func mergeSubjectOfSelectorForIsFallback(rest css_ast.CompoundSelector, inner css_ast.ComplexSelector) (css_ast.CompoundSelector, bool) {
+	subject := inner.Selectors[len(inner.Selectors)-1]
+	merged, status := mergeCompoundSelectorsForIs(subject, rest)
+	if status == mergeNeverMatches {
+		return css_ast.CompoundSelector{}, false
+	}
+	merged.Combinator = rest.Combinator
+	merged.SubclassSelectors = insertBeforePseudoElement(merged.SubclassSelectors,
+		specificityBoosters(complexSelectorSpecificity(inner).minus(compoundSelectorSpecificity(subject))))
+	return merged, true
+}
+
+// This is the "(A, B, C)" specificity from the CSS specification
+type specificity struct {
+	ids     int
+	classes int
+	types   int
+}
+
+func (a specificity) isLessThan(b specificity) bool {
+	if a.ids != b.ids {
+		return a.ids < b.ids
+	}
+	if a.classes != b.classes {
+		return a.classes < b.classes
+	}
+	return a.types < b.types
+}
+
+func (a specificity) minus(b specificity) specificity {
+	return specificity{ids: a.ids - b.ids, classes: a.classes - b.classes, types: a.types - b.types}
+}
+
+func complexSelectorSpecificity(complex css_ast.ComplexSelector) (result specificity) {
+	for _, compound := range complex.Selectors {
+		s := compoundSelectorSpecificity(compound)
+		result.ids += s.ids
+		result.classes += s.classes
+		result.types += s.types
+	}
+	return
+}
+
+func compoundSelectorSpecificity(compound css_ast.CompoundSelector) (result specificity) {
+	if compound.TypeSelector != nil && compound.TypeSelector.Name.Text != "*" {
+		result.types++
+	}
+	for _, ss := range compound.SubclassSelectors {
+		switch s := ss.Data.(type) {
+		case *css_ast.SSHash:
+			result.ids++
+
+		case *css_ast.SSClass, *css_ast.SSAttribute:
+			result.classes++
+
+		case *css_ast.SSPseudoClass:
+			if isPseudoElement(s) {
+				result.types++
+			} else {
+				result.classes++
+			}
+
+		case *css_ast.SSPseudoClassWithSelectorList:
+			// ":where()" has no specificity but the others use their most specific argument
+			if s.Kind == css_ast.PseudoClassWhere {
+				continue
+			}
+			if s.Kind.HasNthIndex() {
+				result.classes++
+			}
+			var max specificity
+			for _, inner := range s.Selectors {
+				if n := complexSelectorSpecificity(inner); max.isLessThan(n) {
+					max = n
+				}
+			}
+			result.ids += max.ids
+			result.classes += max.classes
+			result.types += max.types
+		}
+	}
+	return
+}
+
+// These add specificity without changing what the selector matches:
+//
+//	":not(#\#)" adds the specificity of an ID selector
+//	":not(.\#)" adds the specificity of a class selector
+//	":not(\#)" adds the specificity of a type selector
+func specificityBoosters(s specificity) (boosters []css_ast.SubclassSelector) {
+	for _, booster := range []struct {
+		count  int
+		tokens []css_ast.Token
+	}{
+		{count: s.ids, tokens: []css_ast.Token{{Kind: css_lexer.THash, Text: "#"}}},
+		{count: s.classes, tokens: []css_ast.Token{{Kind: css_lexer.TDelimDot, Text: "."}, {Kind: css_lexer.TIdent, Text: "#"}}},
+		{count: s.types, tokens: []css_ast.Token{{Kind: css_lexer.TIdent, Text: "#"}}},
+	} {
+		for i := 0; i < booster.count; i++ {
+			boosters = append(boosters, css_ast.SubclassSelector{
+				Data: &css_ast.SSPseudoClass{Name: "not", Args: booster.tokens},
+			})
+		}
+	}
+	return
+}
+
+type mergedAncestorChain struct {
+	chain      []css_ast.CompoundSelector
+	combinator uint8
+}
+
+// Both "x" and "y" are ancestor chains of the same element, and "cx" and "cy"
+// are the combinators between the end of each chain and that element.
+func mergeAncestorChains(x []css_ast.CompoundSelector, cx uint8, y []css_ast.CompoundSelector, cy uint8) (results []mergedAncestorChain, ok bool) {
+	if len(x) == 0 {
+		return []mergedAncestorChain{{chain: y, combinator: cy}}, true
+	}
+	if len(y) == 0 {
+		return []mergedAncestorChain{{chain: x, combinator: cx}}, true
+	}
+
+	xLast := x[len(x)-1]
+	yLast := y[len(y)-1]
+	combinator := uint8(0)
+	if cx == '>' || cy == '>' {
+		combinator = '>'
+	}
+
+	// The last selectors in each chain match the same element
+	merged, status := mergeCompoundSelectorsForIs(yLast, xLast)
+	if status == mergeSucceeded {
+		ancestors, ok := mergeAncestorChains(x[:len(x)-1], xLast.Combinator.Byte, y[:len(y)-1], yLast.Combinator.Byte)
+		if !ok {
+			return nil, false
+		}
+		for _, ancestor := range ancestors {
+			merged.Combinator = css_ast.Combinator{Byte: ancestor.combinator}
+			results = append(results, mergedAncestorChain{chain: appendCompound(ancestor.chain, merged), combinator: combinator})
+		}
+	}
+
+	// The last selector in "y" matches an ancestor of the last selector in "x"
+	if cy != '>' {
+		ancestors, ok := mergeAncestorChains(x[:len(x)-1], xLast.Combinator.Byte, y, 0)
+		if !ok {
+			return nil, false
+		}
+		for _, ancestor := range ancestors {
+			xLast.Combinator = css_ast.Combinator{Byte: ancestor.combinator}
+			results = append(results, mergedAncestorChain{chain: appendCompound(ancestor.chain, xLast), combinator: cx})
+		}
+	}
+
+	// The last selector in "x" matches an ancestor of the last selector in "y"
+	if cx != '>' {
+		ancestors, ok := mergeAncestorChains(x, 0, y[:len(y)-1], yLast.Combinator.Byte)
+		if !ok {
+			return nil, false
+		}
+		for _, ancestor := range ancestors {
+			yLast.Combinator = css_ast.Combinator{Byte: ancestor.combinator}
+			results = append(results, mergedAncestorChain{chain: appendCompound(ancestor.chain, yLast), combinator: cy})
+		}
+	}
+
+	return results, true
+}
+
+func appendCompound(chain []css_ast.CompoundSelector, compound css_ast.CompoundSelector) []css_ast.CompoundSelector {
+	clone := make([]css_ast.CompoundSelector, 0, len(chain)+1)
+	clone = append(clone, chain...)
+	return append(clone, compound)
+}
+
+type compoundSelectorMerge uint8
+
+const (
+	mergeSucceeded compoundSelectorMerge = iota
+	mergeNeverMatches
+)
+
+// This combines two compound selectors that match the same element. The
+// selectors from "first" come first. Two identical type selectors are merged
+// into one, so a selector that matches everything is added to make up for the
+// specificity of the one that was removed.
+func mergeCompoundSelectorsForIs(first css_ast.CompoundSelector, second css_ast.CompoundSelector) (css_ast.CompoundSelector, compoundSelectorMerge) {
+	merged := css_ast.CompoundSelector{TypeSelector: first.TypeSelector}
+	removedTypes := 0
+	if second.TypeSelector != nil && second.TypeSelector.Name.Text != "*" {
+		if first.TypeSelector == nil || first.TypeSelector.Name.Text == "*" {
+			merged.TypeSelector = second.TypeSelector
+		} else if strings.EqualFold(first.TypeSelector.Name.Text, second.TypeSelector.Name.Text) {
+			removedTypes = 1
+		} else {
+			return css_ast.CompoundSelector{}, mergeNeverMatches
+		}
+	}
+	merged.SubclassSelectors = make([]css_ast.SubclassSelector, 0, len(first.SubclassSelectors)+len(second.SubclassSelectors))
+	merged.SubclassSelectors = append(merged.SubclassSelectors, first.SubclassSelectors...)
+	merged.SubclassSelectors = append(merged.SubclassSelectors, second.SubclassSelectors...)
+	if removedTypes > 0 {
+		merged.SubclassSelectors = insertBeforePseudoElement(merged.SubclassSelectors, specificityBoosters(specificity{types: removedTypes}))
+	}
+	return merged, mergeSucceeded
+}
+
 func (p *parser) reportNestingWithGeneratedPseudoClassIs(nestingSelectorLoc logger.Loc) {
 	if p.options.unsupportedCSSFeatures.Has(compat.IsPseudoClass) {
 		_, didWarn := p.nestingWarnings[nestingSelectorLoc]
@@ -29,6 +29,10 @@ type parser struct {
 	localScope        map[string]ast.LocRef
 	globalScope       map[string]ast.LocRef
 	nestingWarnings   map[logger.Loc]struct{}
+	nestingIsToExpand map[*css_ast.SSPseudoClassWithSelectorList]struct{}
+	icssValues        []css_ast.ICSSValue
+	icssImports       []css_ast.ICSSImport
+	icssExports       []css_ast.ICSSValue
 	tracker           logger.LineColumnTracker
 	enclosingAtMedia  [][]css_ast.MediaQuery
 	layersPreImport   [][]string
@@ -43,10 +47,19 @@ type parser struct {
 	nestingIsPresent  bool
 	makeLocalSymbols  bool
 	hasSeenAtImport   bool
+
+	// This is set when a style rule contains a logical property that must be
+	// lowered differently depending on the writing direction
+	directionalDeclarationsArePresent bool
+
+	// This is set when a style rule contains a "light-dark()" color that must
+	// be lowered by splitting the style rule
+	lightDarkDeclarationsArePresent bool
 }
 
 type Options struct {
-	cssPrefixData map[css_ast.D]compat.CSSPrefix
+	cssPrefixData     map[css_ast.D]compat.CSSPrefix
+	cssNamePrefixData compat.CSSNamePrefixes
 
 	// This is an embedded struct. Always access these directly instead of off
 	// the name "optionsThatSupportStructuralEquality". This is only grouped like
@@ -81,7 +94,8 @@ func OptionsFromConfig(loader config.Loader, options *config.Options) Options {
 	}
 
 	return Options{
-		cssPrefixData: options.CSSPrefixData,
+		cssPrefixData:     options.CSSPrefixData,
+		cssNamePrefixData: options.CSSNamePrefixData,
 
 		optionsThatSupportStructuralEquality: optionsThatSupportStructuralEquality{
 			minifySyntax:           options.MinifySyntax,
@@ -116,6 +130,25 @@ func (a *Options) Equal(b *Options) bool {
 		}
 	}
 
+	// Compare "cssNamePrefixData"
+	if !namePrefixesEqual(a.cssNamePrefixData.Values, b.cssNamePrefixData.Values) ||
+		!namePrefixesEqual(a.cssNamePrefixData.Selectors, b.cssNamePrefixData.Selectors) ||
+		!namePrefixesEqual(a.cssNamePrefixData.AtRules, b.cssNamePrefixData.AtRules) {
+		return false
+	}
+
+	return true
+}
+
+func namePrefixesEqual(a map[string]compat.CSSPrefix, b map[string]compat.CSSPrefix) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		if vb, ok := b[k]; !ok || va != vb {
+			return false
+		}
+	}
 	return true
 }
 
@@ -155,6 +188,9 @@ func Parse(log logger.Log, source logger.Source, options Options) css_ast.AST {
 		Composes:             p.composes,
 		LayersPreImport:      p.layersPreImport,
 		LayersPostImport:     p.layersPostImport,
+		ICSSValues:           p.icssValues,
+		ICSSImports:          p.icssImports,
+		ICSSExports:          p.icssExports,
 	}
 }
 
@@ -408,6 +444,11 @@ loop:
 		case css_lexer.TAtKeyword:
 			rule := p.parseAtRule(atRuleContext)
 
+			// Some rules such as ICSS "@value" rules are removed from the output
+			if rule.Data == nil {
+				continue
+			}
+
 			// Disallow "@charset" and "@import" after other rules
 			if context.isTopLevel {
 				switch r := rule.Data.(type) {
@@ -482,6 +523,11 @@ loop:
 		var rule css_ast.Rule
 		if context.parseSelectors {
 			rule = p.parseSelectorRule(context.isTopLevel, parseSelectorOpts{})
+
+			// ICSS ":import" and ":export" rules are removed from the output
+			if context.isTopLevel && p.options.symbolMode == symbolModeLocal && p.parseICSSPseudoClassRule(rule) {
+				continue
+			}
 		} else {
 			rule = p.parseQualifiedRule(parseQualifiedRuleOpts{isTopLevel: context.isTopLevel})
 		}
@@ -494,6 +540,13 @@ loop:
 		}
 	}
 
+	if p.lightDarkDeclarationsArePresent && context.isTopLevel {
+		rules = p.lowerLightDarkInRules(rules)
+	}
+	if p.directionalDeclarationsArePresent && context.isTopLevel {
+		rules = p.lowerDirectionalDeclarationsInRules(rules)
+	}
+	rules = p.processRulePrefixes(rules)
 	if p.options.minifySyntax {
 		rules = p.mangleRules(rules, context.isTopLevel)
 	}
@@ -516,6 +569,7 @@ func (p *parser) parseListOfDeclarations(opts listOfDeclarationsOpts) (list []cs
 
 		case css_lexer.TEndOfFile, css_lexer.TCloseBrace:
 			list = p.processDeclarations(list, opts.composesContext)
+			list = p.processRulePrefixes(list)
 			if p.options.minifySyntax {
 				list = p.mangleRules(list, false /* isTopLevel */)
 
@@ -1193,6 +1247,26 @@ type atRuleContext struct {
 	isTopLevel           bool
 }
 
+// Custom media queries and custom selectors have names that start with "--"
+func (p *parser) expectCustomName() (string, bool) {
+	if !p.peek(css_lexer.TIdent) {
+		p.expect(css_lexer.TIdent)
+		return "", false
+	}
+	t := p.current()
+	name := p.decoded()
+	if !strings.HasPrefix(name, "--") {
+		if t.Range.Loc.Start > p.prevError.Start {
+			p.prevError = t.Range.Loc
+			p.log.AddID(logger.MsgID_CSS_CSSSyntaxError, logger.Warning, &p.tracker, t.Range,
+				fmt.Sprintf("Expected a name starting with \"--\" but found %q", name))
+		}
+		return "", false
+	}
+	p.advance()
+	return name, true
+}
+
 func (p *parser) parseAtRule(context atRuleContext) css_ast.Rule {
 	// Parse the name
 	atToken := p.decoded()
@@ -1463,6 +1537,29 @@ abortRuleParser:
 			return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RUnknownAt{AtToken: atToken, Prelude: prelude, Block: block}}
 		}
 
+	case "value":
+		// This is only special in files with local names (i.e. CSS modules)
+		if p.options.symbolMode != symbolModeLocal || !context.isTopLevel {
+			break
+		}
+
+		// Read the prelude up to the semicolon
+	valueLoop:
+		for {
+			switch p.current().Kind {
+			case css_lexer.TSemicolon, css_lexer.TEndOfFile:
+				break valueLoop
+			case css_lexer.TOpenBrace, css_lexer.TCloseBrace:
+				break abortRuleParser
+			}
+			p.parseComponentValue()
+		}
+		if !p.parseICSSValueRule(p.convertTokens(p.tokens[preludeStart:p.index])) {
+			break
+		}
+		p.expect(css_lexer.TSemicolon)
+		return css_ast.Rule{}
+
 	case "layer":
 		// Reference: https://developer.mozilla.org/en-US/docs/Web/CSS/@layer
 
@@ -1594,6 +1691,66 @@ abortRuleParser:
 
 		return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtMedia{Queries: queries, Rules: rules, CloseBraceLoc: closeBraceLoc}}
 
+	case "custom-media":
+		// Reference: https://drafts.csswg.org/mediaqueries-5/#custom-mq
+		if !context.isTopLevel {
+			break
+		}
+		p.eat(css_lexer.TWhitespace)
+		name, ok := p.expectCustomName()
+		if !ok {
+			break
+		}
+		p.eat(css_lexer.TWhitespace)
+
+		// "@custom-media --foo true;" => "@custom-media --foo all;"
+		// "@custom-media --foo false;" => "@custom-media --foo not all;"
+		var queries []css_ast.MediaQuery
+		if text := p.decoded(); p.peek(css_lexer.TIdent) && (text == "true" || text == "false") {
+			op := css_ast.MQTypeOpNone
+			if text == "false" {
+				op = css_ast.MQTypeOpNot
+			}
+			queries = []css_ast.MediaQuery{{Loc: p.current().Range.Loc, Data: &css_ast.MQType{Op: op, Type: "all"}}}
+			p.advance()
+			p.eat(css_lexer.TWhitespace)
+		} else {
+			queries = p.parseMediaQueryListUntil(func(kind css_lexer.T) bool {
+				return kind == css_lexer.TSemicolon || kind == css_lexer.TOpenBrace || kind == css_lexer.TCloseBrace
+			})
+		}
+		if len(queries) == 0 || !p.expect(css_lexer.TSemicolon) {
+			break
+		}
+		return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtCustomMedia{Name: name, Queries: queries}}
+
+	case "custom-selector":
+		// Reference: https://drafts.csswg.org/css-extensions-1/#custom-selectors
+		if !context.isTopLevel {
+			break
+		}
+		p.eat(css_lexer.TWhitespace)
+		if !p.expect(css_lexer.TColon) {
+			break
+		}
+		name, ok := p.expectCustomName()
+		if !ok {
+			break
+		}
+		p.eat(css_lexer.TWhitespace)
+		selectors, ok := p.parseSelectorList(parseSelectorOpts{
+			noLeadingCombinator: true,
+			stopOnSemicolon:     true,
+		})
+		if !ok {
+			break
+		}
+		p.eat(css_lexer.TWhitespace)
+		if !p.expect(css_lexer.TSemicolon) {
+			break
+		}
+		return css_ast.Rule{Loc: atRange.Loc, Data: &css_ast.RAtCustomSelector{Name: name, Selectors: selectors}}
+
 	case "scope":
 		var ok bool
 
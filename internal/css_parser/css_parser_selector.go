@@ -14,6 +14,7 @@ type parseSelectorOpts struct {
 	pseudoClassKind         css_ast.PseudoClassKind
 	isDeclarationContext    bool
 	stopOnCloseParen        bool
+	stopOnSemicolon         bool
 	onlyOneComplexSelector  bool
 	isForgivingSelectorList bool
 	noLeadingCombinator     bool
@@ -339,7 +340,8 @@ func (p *parser) parseComplexSelector(opts parseComplexSelectorOpts) (result css
 	}
 	for {
 		p.eat(css_lexer.TWhitespace)
-		if p.peek(css_lexer.TEndOfFile) || p.peek(css_lexer.TComma) || p.peek(stop) {
+		if p.peek(css_lexer.TEndOfFile) || p.peek(css_lexer.TComma) || p.peek(stop) ||
+			(opts.stopOnSemicolon && p.peek(css_lexer.TSemicolon)) {
 			break
 		}
 
@@ -60,16 +60,27 @@ func expectPrintedLowerUnsupported(t *testing.T, unsupportedCSSFeatures compat.C
 
 func expectPrintedWithAllPrefixes(t *testing.T, contents string, expected string, expectedLog string) {
 	t.Helper()
+	constraints := map[compat.Engine]compat.Semver{
+		compat.Chrome:  {Parts: []int{0}},
+		compat.Edge:    {Parts: []int{0}},
+		compat.Firefox: {Parts: []int{0}},
+		compat.IE:      {Parts: []int{0}},
+		compat.IOS:     {Parts: []int{0}},
+		compat.Opera:   {Parts: []int{0}},
+		compat.Safari:  {Parts: []int{0}},
+	}
 	expectPrintedCommon(t, contents+" [prefixed]", contents, expected, expectedLog, config.LoaderCSS, config.Options{
-		CSSPrefixData: compat.CSSPrefixData(map[compat.Engine]compat.Semver{
-			compat.Chrome:  {Parts: []int{0}},
-			compat.Edge:    {Parts: []int{0}},
-			compat.Firefox: {Parts: []int{0}},
-			compat.IE:      {Parts: []int{0}},
-			compat.IOS:     {Parts: []int{0}},
-			compat.Opera:   {Parts: []int{0}},
-			compat.Safari:  {Parts: []int{0}},
-		}),
+		CSSPrefixData:     compat.CSSPrefixData(constraints),
+		CSSNamePrefixData: compat.CSSNamePrefixData(constraints),
+	})
+}
+
+func expectPrintedWithTarget(t *testing.T, engine compat.Engine, version int, contents string, expected string, expectedLog string) {
+	t.Helper()
+	constraints := map[compat.Engine]compat.Semver{engine: {Parts: []int{version}}}
+	expectPrintedCommon(t, contents+" [target]", contents, expected, expectedLog, config.LoaderCSS, config.Options{
+		CSSPrefixData:     compat.CSSPrefixData(constraints),
+		CSSNamePrefixData: compat.CSSNamePrefixData(constraints),
 	})
 }
 
@@ -738,6 +749,106 @@ func TestLowerColor(t *testing.T) {
 	expectPrintedLowerMangle(t, "a { color: hwb(0.75turn 20% 40% / 0.75) }", "a {\n  color: rgba(102, 51, 153, .75);\n}\n", "")
 }
 
+func TestColorMix(t *testing.T) {
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, red, blue) }", "a {\n  color: #800080;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: COLOR-MIX(IN SRGB, red, blue) }", "a {\n  color: #800080;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, red 25%, blue) }", "a {\n  color: #4000bf;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, red, 25% blue) }", "a {\n  color: #bf0040;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, red 20%, blue 20%) }", "a {\n  color: #80008066;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, red 60%, blue 60%) }", "a {\n  color: #800080;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, red, transparent) }", "a {\n  color: #ff000080;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb-linear, red, blue) }", "a {\n  color: #bc00bc;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in hsl, white, red) }", "a {\n  color: #df9f9f;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in hsl, red, blue) }", "a {\n  color: #ff00ff;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in hsl longer hue, red, blue) }", "a {\n  color: #00ff00;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in hwb, white, blue) }", "a {\n  color: #8080ff;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in lch, white, black) }", "a {\n  color: #777777;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, color-mix(in srgb, red, blue), white) }", "a {\n  color: #c080c0;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { box-shadow: 0 0 1px color-mix(in srgb, red, blue) }", "a {\n  box-shadow: 0 0 1px #800080;\n}\n", "")
+
+	// Check for clipping
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in oklch, red, blue) }",
+		"a {\n  color: #b600bd;\n  color: color-mix(in oklch, red, blue);\n}\n", "")
+
+	// These can't be computed at compile time
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, var(--x), blue) }", "a {\n  color: color-mix(in srgb, var(--x), blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, currentcolor, blue) }", "a {\n  color: color-mix(in srgb, currentcolor, blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in srgb, red 0%, blue 0%) }", "a {\n  color: color-mix(in srgb, red 0%, blue 0%);\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(in foo, red, blue) }", "a {\n  color: color-mix(in foo, red, blue);\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.ColorMix, "a { color: color-mix(red, blue) }", "a {\n  color: color-mix(red, blue);\n}\n", "")
+
+	// This should not be lowered if it's supported
+	expectPrinted(t, "a { color: color-mix(in srgb, red, blue) }", "a {\n  color: color-mix(in srgb, red, blue);\n}\n", "")
+}
+
+func TestRelativeColors(t *testing.T) {
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from red r g b) }", "a {\n  color: #ff0000;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from red r g calc(b + 51)) }", "a {\n  color: #ff0033;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from red b g r) }", "a {\n  color: #0000ff;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from red r g b / 50%) }", "a {\n  color: #ff00007f;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from #ff000080 r g b) }", "a {\n  color: #ff000080;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from #ff000080 r g b / calc(alpha / 2)) }", "a {\n  color: #ff000040;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: hsl(from red calc(h + 120) s l) }", "a {\n  color: #00ff00;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: hsl(from red h s calc(l / 2)) }", "a {\n  color: #800000;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: hwb(from red h 50% b) }", "a {\n  color: #ff8080;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: lab(from white calc(l / 2) a b) }", "a {\n  color: #777777;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: oklch(from blue l c h) }", "a {\n  color: #0000ff;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: color(from red srgb b g r) }", "a {\n  color: #0000ff;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: color(from red xyz x y z) }", "a {\n  color: #ff0000;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from rgb(from red b g r) b g r) }", "a {\n  color: #ff0000;\n}\n", "")
+
+	// These can't be computed at compile time
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from var(--x) r g b / 50%) }", "a {\n  color: rgb(from var(--x) r g b / 50%);\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from red r g var(--b)) }", "a {\n  color: rgb(from red r g var(--b));\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: rgb(from red r g x) }", "a {\n  color: rgb(from red r g x);\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.RelativeColors, "a { color: color(from red hsl h s l) }", "a {\n  color: color(from red hsl h s l);\n}\n", "")
+
+	// This should not be lowered if it's supported
+	expectPrinted(t, "a { color: rgb(from red r g b) }", "a {\n  color: rgb(from red r g b);\n}\n", "")
+
+	// Relative colors can be used inside "color-mix()"
+	expectPrintedLowerUnsupported(t, compat.ColorMix|compat.RelativeColors, "a { color: color-mix(in srgb, rgb(from red b g r), red) }", "a {\n  color: #800080;\n}\n", "")
+}
+
+func TestLightDark(t *testing.T) {
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { color: light-dark(black, white) }",
+		"a {\n  color: black;\n}\n@media (prefers-color-scheme: dark) {\n  a {\n    color: white;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a, b { margin: 0; color: light-dark(black, white); background: light-dark(red, blue) !important }",
+		"a,\nb {\n  margin: 0;\n  color: black;\n  background: red !important;\n}\n"+
+			"@media (prefers-color-scheme: dark) {\n  a,\n  b {\n    color: white;\n    background: blue !important;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { background: linear-gradient(light-dark(red, blue), white) }",
+		"a {\n  background: linear-gradient(red, white);\n}\n@media (prefers-color-scheme: dark) {\n  a {\n    background: linear-gradient(blue, white);\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { color: light-dark(light-dark(red, green), blue) }",
+		"a {\n  color: red;\n}\n@media (prefers-color-scheme: dark) {\n  a {\n    color: blue;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "@media print { a { color: light-dark(black, white) } }",
+		"@media print {\n  a {\n    color: black;\n  }\n  @media (prefers-color-scheme: dark) {\n    a {\n      color: white;\n    }\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { b { color: light-dark(black, white) } }",
+		"a {\n  b {\n    color: black;\n  }\n  @media (prefers-color-scheme: dark) {\n    b {\n      color: white;\n    }\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark|compat.Nesting, "a { b { color: light-dark(black, white) } }",
+		"a b {\n  color: black;\n}\n@media (prefers-color-scheme: dark) {\n  a b {\n    color: white;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "@font-face { color: light-dark(black, white) }",
+		"@font-face {\n  color: black;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { color: light-dark(black) }", "a {\n  color: light-dark(black);\n}\n", "")
+
+	// Later declarations that override the dark value must still override it
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { color: light-dark(black, white); color: red }",
+		"a {\n  color: black;\n  color: red;\n}\n@media (prefers-color-scheme: dark) {\n  a {\n    color: white;\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { background: light-dark(black, white); margin: 0; background-color: red }",
+		"a {\n  background: black;\n  margin: 0;\n  background-color: red;\n}\n"+
+			"@media (prefers-color-scheme: dark) {\n  a {\n    background: white;\n    background-color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { color: red; color: light-dark(black, white) }",
+		"a {\n  color: red;\n  color: black;\n}\n@media (prefers-color-scheme: dark) {\n  a {\n    color: white;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LightDark, "a { color: light-dark(black, white); all: unset }",
+		"a {\n  color: black;\n  all: unset;\n}\n@media (prefers-color-scheme: dark) {\n  a {\n    color: white;\n    all: unset;\n  }\n}\n", "")
+
+	// The light and dark colors are lowered too
+	expectPrintedLower(t, "a { color: light-dark(hwb(90deg 20% 40%), rebeccapurple) }",
+		"a {\n  color: #669933;\n}\n@media (prefers-color-scheme: dark) {\n  a {\n    color: #663399;\n  }\n}\n", "")
+
+	// This should not be lowered if it's supported
+	expectPrinted(t, "a { color: light-dark(black, white) }", "a {\n  color: light-dark(black, white);\n}\n", "")
+}
+
 func TestBackground(t *testing.T) {
 	expectPrinted(t, "a { background: #11223344 }", "a {\n  background: #11223344;\n}\n", "")
 	expectPrintedMangle(t, "a { background: #11223344 }", "a {\n  background: #1234;\n}\n", "")
@@ -852,9 +963,21 @@ func TestGradient(t *testing.T) {
 		expectPrintedLowerUnsupported(t, compat.GradientDoublePosition, code, "a {\n  background:\n    "+gradient+
 			"(\n      color-mix(in lab, red, green) calc(1px),\n      color-mix(in lab, red, green) calc(2px),"+
 			"\n      color-mix(in lab, blue, red) calc(98%),\n      color-mix(in lab, blue, red) calc(99%));\n}\n", "")
-		expectPrintedLowerMangle(t, code, "a {\n  background:\n    "+gradient+
-			"(\n      color-mix(in lab, red, green) 1px,\n      color-mix(in lab, red, green) 2px,"+
-			"\n      color-mix(in lab, blue, red) 98%,\n      color-mix(in lab, blue, red) 99%);\n}\n", "")
+		if strings.HasSuffix(gradient, "conic-gradient") {
+			// Conic gradients can't have length positions, so only "color-mix()" is lowered
+			expectPrintedLowerMangle(t, code, "a {\n  background:\n    "+gradient+
+				"(\n      #a16c00 1px,\n      #a16c00 2px,\n      #bd0086 98%,\n      #bd0086 99%);\n  background:\n    "+gradient+
+				"(\n      color-mix(in lab, red, green) 1px,\n      color-mix(in lab, red, green) 2px,"+
+				"\n      color-mix(in lab, blue, red) 98%,\n      color-mix(in lab, blue, red) 99%);\n}\n", "")
+		} else {
+			// The mixed colors are outside of sRGB, so they are interpolated like other wide-gamut colors
+			stops := "\n      #a46817 calc(1.88px + 6.12%),\n      #a66528 calc(1.75px + 12.25%),\n      #aa5d3d calc(1.5px + 24.5%)," +
+				"\n      #b24a5b calc(1px + 49%),\n      #ba2f73 calc(0.5px + 73.5%),\n      #bd167e calc(0.25px + 85.75%),"
+			expectPrintedLowerMangle(t, code, "a {\n  background:\n    "+gradient+
+				"(\n      #a06c00 1px,\n      #a06c00 2px,"+stops+"\n      #bd0086 98%,\n      #bd0086 99%);\n  background:\n    "+gradient+
+				"(\n      color(xyz 0.2 0.183 0.022) 1px,\n      color(xyz 0.2 0.183 0.022) 2px,"+stops+
+				"\n      color(xyz 0.26 0.122 0.243) 98%,\n      color(xyz 0.26 0.122 0.243) 99%);\n}\n", "")
+		}
 
 		// Color space interpolation
 		expectPrintedLowerUnsupported(t, compat.GradientInterpolation,
@@ -1266,7 +1389,7 @@ func TestNestedSelector(t *testing.T) {
 	expectPrintedLowerUnsupported(t, everything, "a { & > b, & > c { color: red } }", "a > b,\na > c {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, "a { & > b, & + c { color: red } }", "a > b,\na + c {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, "a { > b&, > c& { color: red } }", "a > a:is(b),\na > a:is(c) {\n  color: red;\n}\n", "")
-	expectPrintedLowerUnsupported(t, everything, "a { > b&, > c& { color: red } }", "a > a:is(b),\na > a:is(c) {\n  color: red;\n}\n", nestingWarningIs+nestingWarningIs)
+	expectPrintedLowerUnsupported(t, everything, "a { > b&, > c& { color: red } }", "", "")
 	expectPrintedLowerUnsupported(t, nesting, "a { > b&, + c& { color: red } }", "a > a:is(b),\na + a:is(c) {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, "a { > &.b, > &.c { color: red } }", "a > a.b,\na > a.c {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, everything, "a { > &.b, > &.c { color: red } }", "a > a.b,\na > a.c {\n  color: red;\n}\n", "")
@@ -1281,7 +1404,8 @@ func TestNestedSelector(t *testing.T) {
 	expectPrintedLowerUnsupported(t, everything, "~ .a { > &.b, > &.c { color: red } }", "~ .a > .a.b,\n~ .a > .a.c {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, "~ .a { > &.b, + &.c { color: red } }", "~ .a > .a.b,\n~ .a + .a.c {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, ".foo .bar { > &.a, > &.b { color: red } }", ".foo .bar > :is(.foo .bar).a,\n.foo .bar > :is(.foo .bar).b {\n  color: red;\n}\n", "")
-	expectPrintedLowerUnsupported(t, everything, ".foo .bar { > &.a, > &.b { color: red } }", ".foo .bar > :is(.foo .bar).a,\n.foo .bar > :is(.foo .bar).b {\n  color: red;\n}\n", nestingWarningIs+nestingWarningIs)
+	expectPrintedLowerUnsupported(t, everything, ".foo .bar { > &.a, > &.b { color: red } }",
+		".foo .foo.bar > .bar.a,\n.foo.foo .bar > .bar.a,\n.foo .foo .bar > .bar.a,\n.foo .foo.bar > .bar.b,\n.foo.foo .bar > .bar.b,\n.foo .foo .bar > .bar.b {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, ".foo .bar { > &.a, + &.b { color: red } }", ".foo .bar > :is(.foo .bar).a,\n.foo .bar + :is(.foo .bar).b {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, ".demo { .lg { &.triangle, &.circle { color: red } } }", ".demo .lg.triangle,\n.demo .lg.circle {\n  color: red;\n}\n", "")
 	expectPrintedLowerUnsupported(t, nesting, ".demo { .lg { .triangle, .circle { color: red } } }", ".demo .lg .triangle,\n.demo .lg .circle {\n  color: red;\n}\n", "")
@@ -1352,6 +1476,40 @@ func TestNestedSelector(t *testing.T) {
 		"@supports (display: flex) {\n  @supports selector(h2 > p) {\n    a:hover,\n    b:hover {\n      color: red;\n    }\n  }\n}\n", "")
 	expectPrintedLower(t, "@layer foo { @layer bar { a, b { &:hover { color: red } } } }",
 		"@layer foo {\n  @layer bar {\n    a:hover,\n    b:hover {\n      color: red;\n    }\n  }\n}\n", "")
+	// Generated ":is()" selectors are expanded when ":is" is unsupported
+	expectPrintedLowerUnsupported(t, everything, ".foo .bar { .baz & { color: red } }", ".foo.baz .bar,\n.foo .baz .bar,\n.baz .foo .bar {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".foo > .bar { .baz & { color: red } }", ".foo.baz > .bar,\n.baz .foo > .bar {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".foo .bar { .baz > & { color: red } }", ".foo.baz > .bar,\n.foo .baz > .bar {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".foo .bar { .baz &:hover { color: red } }", ".foo.baz .bar:hover,\n.foo .baz .bar:hover,\n.baz .foo .bar:hover {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".a .b, .c { .d & { color: red } }", ".a.d .b,\n.a .d .b,\n.d .a .b,\n.d .c {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "div { span& { color: red } }", "", "")
+	expectPrintedLowerUnsupported(t, everything, "div { div& { color: red } }", "div:not(\\#) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "div { *& { color: red } }", "div {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "a { &&&.bar { color: red } }", "a.bar:not(\\#):not(\\#) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".foo .bar { :is(.baz &) { color: red } }", ":is(.baz :is(.foo .bar)) {\n  color: red;\n}\n", nestingWarningIs)
+	expectPrintedLowerUnsupported(t, everything, ".a div { div& { color: red } }", ".a div:not(\\#) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".a .b { & + & { color: red } }", ".a .b + .b:not(.\\#) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".a ~ .b { .c + & { color: red } }", ".c + .b:not(.\\#) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "#a .b { .c ~ &::after { color: red } }", ".c ~ .b:not(#\\#)::after {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "a .b { .c ~ & { color: red } }", ".c ~ .b:not(\\#) {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "a::before { &:hover { color: red } }", "", "")
+	expectPrintedLowerUnsupported(t, everything, "a::before, b { &:hover { color: red } }", "b:hover {\n  color: red;\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, "a::before { :hover & { color: red } }", "", "")
+
+	expectPrintedLowerUnsupported(t, everything, ".a .b { @container (width > 10px) { .c & { color: red } } }",
+		"@container (width > 10px) {\n  .a.c .b,\n  .a .c .b,\n  .c .a .b {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, everything, ".a .b { @media screen { @layer x { .c & { color: red } } } }",
+		"@media screen {\n  @layer x {\n    .a.c .b,\n    .a .c .b,\n    .c .a .b {\n      color: red;\n    }\n  }\n}\n", "")
+
+	// Nested "@scope" rules are relative to the parent rule
+	expectPrintedLowerUnsupported(t, nesting, "div { @scope { color: red } }", "@scope (div) {\n  :where(:scope) {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, nesting, "div { @scope (.a) { color: red } }", "@scope (div .a) {\n  :where(:scope) {\n    color: red;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, nesting, "div { @scope (& > .a) to (.b) { color: red; span { color: blue } } }",
+		"@scope (div > .a) to (.b) {\n  :where(:scope) {\n    color: red;\n  }\n  span {\n    color: blue;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, nesting, "div { color: red; @scope (.a) { & span { color: blue } } }",
+		"div {\n  color: red;\n}\n@scope (div .a) {\n  :scope span {\n    color: blue;\n  }\n}\n", "")
+	expectPrintedLowerUnsupported(t, nesting, "@scope (.a) { div { span { color: red } } }", "@scope (.a) {\n  div span {\n    color: red;\n  }\n}\n", "")
+
 	expectPrintedLower(t, ".card { @supports (selector(&)) { &:hover { color: red } } }",
 		"@supports (selector(&)) {\n  .card:hover {\n    color: red;\n  }\n}\n", "")
 	expectPrintedLower(t, "html { @layer base { color: blue; @layer support { & body { color: red } } } }",
@@ -1364,7 +1522,7 @@ func TestNestedSelector(t *testing.T) {
 	// Nested at-rules work with pseudo-elements while nested "&" rules do not
 	// See: https://github.com/evanw/esbuild/issues/4265
 	expectPrintedLower(t, "::placeholder { color: red; body & { color: green } }",
-		"::placeholder {\n  color: red;\n}\nbody :is() {\n  color: green;\n}\n", "")
+		"::placeholder {\n  color: red;\n}\n", "")
 	expectPrintedLower(t, "::placeholder { color: red; @supports (color: green) { color: green } }",
 		"::placeholder {\n  color: red;\n}\n@supports (color: green) {\n  ::placeholder {\n    color: green;\n  }\n}\n", "")
 	expectPrintedLower(t, "::placeholder { opacity: 0.5; @layer base { color: green } }",
@@ -2031,6 +2189,65 @@ func TestMarginAndPaddingAndInset(t *testing.T) {
 	expectPrintedLowerMinify(t, "a { inset: 1px 2px 3px 4px; }", "a{top:1px;right:2px;bottom:3px;left:4px}", "")
 }
 
+func TestLogicalProperties(t *testing.T) {
+	expectPrinted(t, "a { margin-inline-start: 1px }", "a {\n  margin-inline-start: 1px;\n}\n", "")
+	expectPrinted(t, "a { float: inline-start }", "a {\n  float: inline-start;\n}\n", "")
+
+	// Properties that don't depend on the writing direction are lowered in place
+	expectPrintedLower(t, "a { margin-block-start: 1px }", "a {\n  margin-top: 1px;\n}\n", "")
+	expectPrintedLower(t, "a { padding-block: 1px }", "a {\n  padding-top: 1px;\n  padding-bottom: 1px;\n}\n", "")
+	expectPrintedLower(t, "a { padding-block: 1px 2px }", "a {\n  padding-top: 1px;\n  padding-bottom: 2px;\n}\n", "")
+	expectPrintedLower(t, "a { inset-block: 0 auto !important }", "a {\n  top: 0 !important;\n  bottom: auto !important;\n}\n", "")
+	expectPrintedLower(t, "a { inline-size: 1px; max-block-size: 2px }", "a {\n  width: 1px;\n  max-height: 2px;\n}\n", "")
+	expectPrintedLower(t, "a { margin-inline: 1px }", "a {\n  margin-left: 1px;\n  margin-right: 1px;\n}\n", "")
+	expectPrintedLower(t, "a { border-inline: 1px solid red }", "a {\n  border-left: 1px solid red;\n  border-right: 1px solid red;\n}\n", "")
+	expectPrintedLower(t, "a { border-block-width: 1px 2px }", "a {\n  border-top-width: 1px;\n  border-bottom-width: 2px;\n}\n", "")
+	expectPrintedLower(t, "a { margin-block: var(--x) }", "a {\n  margin-block: var(--x);\n}\n", "")
+	expectPrintedLower(t, "a { margin-block: 1px 2px 3px }", "a {\n  margin-block: 1px 2px 3px;\n}\n", "")
+	expectPrintedLowerMinify(t, "a { padding-block: 1px 2px }", "a{padding-top:1px;padding-bottom:2px}", "")
+	expectPrintedLowerMangle(t, "a { margin-block: 0px }", "a {\n  margin-top: 0;\n  margin-bottom: 0;\n}\n", "")
+
+	// Properties that depend on the writing direction split the style rule
+	expectPrintedLower(t, "a { color: red; margin-inline-start: 1px }",
+		"a {\n  color: red;\n}\n:where(html:not([dir=rtl])) a {\n  margin-left: 1px;\n}\n:where(html[dir=rtl]) a {\n  margin-right: 1px;\n}\n", "")
+	expectPrintedLower(t, "a { margin-inline: 1px 2px }",
+		":where(html:not([dir=rtl])) a {\n  margin-left: 1px;\n  margin-right: 2px;\n}\n:where(html[dir=rtl]) a {\n  margin-right: 1px;\n  margin-left: 2px;\n}\n", "")
+	expectPrintedLower(t, "a, b::before { border-start-start-radius: 1px; border-end-start-radius: 2px }",
+		":where(html:not([dir=rtl])) a,\n:where(html:not([dir=rtl])) b::before {\n  border-top-left-radius: 1px;\n  border-bottom-left-radius: 2px;\n}\n"+
+			":where(html[dir=rtl]) a,\n:where(html[dir=rtl]) b::before {\n  border-top-right-radius: 1px;\n  border-bottom-right-radius: 2px;\n}\n", "")
+	expectPrintedLower(t, "html, :root a { inset-inline-end: 0 }",
+		"html:where(:not([dir=rtl])),\n:root:where(:not([dir=rtl])) a {\n  right: 0;\n}\nhtml:where([dir=rtl]),\n:root:where([dir=rtl]) a {\n  left: 0;\n}\n", "")
+	expectPrintedLower(t, "@media screen { a { padding-inline-end: 1px } }",
+		"@media screen {\n  :where(html:not([dir=rtl])) a {\n    padding-right: 1px;\n  }\n  :where(html[dir=rtl]) a {\n    padding-left: 1px;\n  }\n}\n", "")
+	expectPrintedLower(t, "a { &:hover { border-inline-start-color: red } }",
+		":where(html:not([dir=rtl])) a:hover {\n  border-left-color: red;\n}\n:where(html[dir=rtl]) a:hover {\n  border-right-color: red;\n}\n", "")
+	expectPrintedLowerMinify(t, "a { margin-inline-start: 1px }", ":where(html:not([dir=rtl])) a{margin-left:1px}:where(html[dir=rtl]) a{margin-right:1px}", "")
+
+	// The direction test doesn't add specificity, so ".b.c" still wins here
+	expectPrintedLower(t, ".b { margin-inline-start: 1px } .b.c { margin-left: 2px }",
+		":where(html:not([dir=rtl])) .b {\n  margin-left: 1px;\n}\n:where(html[dir=rtl]) .b {\n  margin-right: 1px;\n}\n.b.c {\n  margin-left: 2px;\n}\n", "")
+
+	// Later declarations that override a moved declaration are moved after it
+	expectPrintedLower(t, "a { margin-inline-start: 1px; margin-left: 2px; color: red }",
+		"a {\n  color: red;\n}\n:where(html:not([dir=rtl])) a {\n  margin-left: 1px;\n  margin-left: 2px;\n}\n"+
+			":where(html[dir=rtl]) a {\n  margin-right: 1px;\n  margin-left: 2px;\n}\n", "")
+	expectPrintedLower(t, "a { border-inline-start-color: red; border-color: blue; inset-inline-end: 0; inset: 1px }",
+		"a {\n  top: 1px;\n  bottom: 1px;\n}\n"+
+			":where(html:not([dir=rtl])) a {\n  border-left-color: red;\n  border-color: blue;\n  right: 0;\n  right: 1px;\n  left: 1px;\n}\n"+
+			":where(html[dir=rtl]) a {\n  border-right-color: red;\n  border-color: blue;\n  left: 0;\n  right: 1px;\n  left: 1px;\n}\n", "")
+	expectPrintedLower(t, "a { float: inline-start; float: none }",
+		":where(html:not([dir=rtl])) a {\n  float: left;\n  float: none;\n}\n:where(html[dir=rtl]) a {\n  float: right;\n  float: none;\n}\n", "")
+
+	// Outside of style rules, assume a left-to-right direction
+	expectPrintedLower(t, "@page { margin-inline-start: 1in }", "@page {\n  margin-left: 1in;\n}\n", "")
+
+	// Logical values are controlled by a separate feature
+	expectPrintedLowerUnsupported(t, compat.LogicalValues, "a { float: inline-end; margin-inline-start: 1px }",
+		"a {\n  margin-inline-start: 1px;\n}\n:where(html:not([dir=rtl])) a {\n  float: right;\n}\n:where(html[dir=rtl]) a {\n  float: left;\n}\n", "")
+	expectPrintedLowerUnsupported(t, compat.LogicalProperties, "a { clear: inline-start; margin-inline-start: 1px }",
+		"a {\n  clear: inline-start;\n}\n:where(html:not([dir=rtl])) a {\n  margin-left: 1px;\n}\n:where(html[dir=rtl]) a {\n  margin-right: 1px;\n}\n", "")
+}
+
 func TestBorderRadius(t *testing.T) {
 	expectPrinted(t, "a { border-top-left-radius: 0 0 }", "a {\n  border-top-left-radius: 0 0;\n}\n", "")
 	expectPrintedMangle(t, "a { border-top-left-radius: 0 0 }", "a {\n  border-top-left-radius: 0;\n}\n", "")
@@ -2791,6 +3008,25 @@ func TestAtScope(t *testing.T) {
 	expectPrinted(t, "@scope to (a { div { color: red } }", "@scope to (a { div { color: red } }) {\n}\n", warning)
 }
 
+func TestCustomMediaAndSelectors(t *testing.T) {
+	// These are kept in the AST and are substituted and removed by the linker
+	expectPrinted(t, "@custom-media --narrow (max-width: 30em);", "@custom-media --narrow (max-width: 30em);\n", "")
+	expectPrinted(t, "@custom-media --a screen,print;", "@custom-media --a screen, print;\n", "")
+	expectPrinted(t, "@custom-media --yes true;", "@custom-media --yes all;\n", "")
+	expectPrinted(t, "@custom-media --no false;", "@custom-media --no not all;\n", "")
+	expectPrinted(t, "@custom-selector :--heading h1,h2 , h3;", "@custom-selector :--heading h1, h2, h3;\n", "")
+	expectPrinted(t, "@custom-selector :--a .a > .b;", "@custom-selector :--a .a > .b;\n", "")
+	expectPrintedMinify(t, "@custom-media --a (color), print;", "@custom-media --a (color),print;", "")
+	expectPrintedMinify(t, "@custom-selector :--a .a > .b, c;", "@custom-selector :--a .a>.b,c;", "")
+	expectPrintedLower(t, "@custom-media --narrow (width < 30em);", "@custom-media --narrow not (min-width: 30em);\n", "")
+
+	// Invalid definitions are kept as unknown rules
+	expectPrinted(t, "@custom-media narrow (color);", "@custom-media narrow (color);\n", "<stdin>: WARNING: Expected a name starting with \"--\" but found \"narrow\"\n")
+	expectPrinted(t, "@custom-selector --a b;", "@custom-selector --a b;\n", "<stdin>: WARNING: Expected \":\"\n")
+	expectPrinted(t, "@custom-selector :--a > b;", "@custom-selector :--a > b;\n", "<stdin>: WARNING: Unexpected \">\"\n")
+	expectPrinted(t, "a { @custom-media --a (color); }", "a {\n  @custom-media --a (color);\n}\n", "")
+}
+
 func TestFontWeight(t *testing.T) {
 	expectPrintedMangle(t, "a { font-weight: normal }", "a {\n  font-weight: 400;\n}\n", "")
 	expectPrintedMangle(t, "a { font-weight: bold }", "a {\n  font-weight: 700;\n}\n", "")
@@ -2996,6 +3232,119 @@ func TestPrefixInsertion(t *testing.T) {
 		"a {\n  before: value;\n  -ms-text-size-adjust: 2;\n  -webkit-text-size-adjust: 3;\n  text-size-adjust: 3;\n  after: value;\n}\n", "")
 	expectPrintedWithAllPrefixes(t, "a { width: -webkit-fill-available; width: stretch }",
 		"a {\n  width: -webkit-fill-available;\n  width: stretch;\n}\n", "")
+
+	// Property tests
+	expectPrintedWithAllPrefixes(t, "a { transform: none }",
+		"a {\n  -webkit-transform: none;\n  -moz-transform: none;\n  -ms-transform: none;\n  transform: none;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { transition: transform 1s }",
+		"a {\n  -webkit-transition: -webkit-transform 1s;\n  -moz-transition: -moz-transform 1s;\n  -o-transition: transform 1s;\n  transition: transform 1s;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { animation: foo 1s }",
+		"a {\n  -webkit-animation: foo 1s;\n  -moz-animation: foo 1s;\n  animation: foo 1s;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { justify-content: space-between }",
+		"a {\n  -webkit-justify-content: space-between;\n  -ms-flex-pack: justify;\n  -webkit-box-pack: justify;\n  -moz-box-pack: justify;\n  justify-content: space-between;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { justify-content: space-evenly }",
+		"a {\n  -webkit-justify-content: space-evenly;\n  justify-content: space-evenly;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { align-self: flex-end }",
+		"a {\n  -webkit-align-self: flex-end;\n  -ms-flex-item-align: end;\n  align-self: flex-end;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { flex-direction: row-reverse }",
+		"a {\n  -webkit-flex-direction: row-reverse;\n  -ms-flex-direction: row-reverse;\n  -webkit-box-orient: horizontal;\n  -webkit-box-direction: reverse;\n"+
+			"  -moz-box-orient: horizontal;\n  -moz-box-direction: reverse;\n  flex-direction: row-reverse;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { order: 1 }",
+		"a {\n  -webkit-order: 1;\n  -ms-flex-order: 1;\n  -webkit-box-ordinal-group: 2;\n  -moz-box-ordinal-group: 2;\n  order: 1;\n}\n", "")
+
+	// Value tests
+	expectPrintedWithAllPrefixes(t, "a { display: flex }",
+		"a {\n  display: -webkit-box;\n  display: -moz-box;\n  display: -webkit-flex;\n  display: -ms-flexbox;\n  display: flex;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { display: inline-flex }",
+		"a {\n  display: -webkit-inline-box;\n  display: -moz-inline-box;\n  display: -webkit-inline-flex;\n  display: -ms-inline-flexbox;\n  display: inline-flex;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { display: block }", "a {\n  display: block;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { display: -webkit-box; display: flex }",
+		"a {\n  display: -webkit-box;\n  display: -moz-box;\n  display: -webkit-flex;\n  display: -ms-flexbox;\n  display: flex;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { cursor: grab }",
+		"a {\n  cursor: -webkit-grab;\n  cursor: -moz-grab;\n  cursor: grab;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { width: min-content }",
+		"a {\n  width: -webkit-min-content;\n  width: -moz-min-content;\n  width: min-content;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { color: min-content }", "a {\n  color: min-content;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: image-set(url(a.png) 1x) }",
+		"a {\n  background: -webkit-image-set(url(a.png) 1x);\n  background: image-set(url(a.png) 1x);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { --x: image-set(url(a.png) 1x) }", "a {\n  --x: image-set(url(a.png) 1x) ;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { -webkit-mask-image: image-set(url(a.png) 1x) }",
+		"a {\n  -webkit-mask-image: -webkit-image-set(url(a.png) 1x);\n  -webkit-mask-image: image-set(url(a.png) 1x);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { width: calc(100% - 1px) }",
+		"a {\n  width: -webkit-calc(100% - 1px);\n  width: -moz-calc(100% - 1px);\n  width: calc(100% - 1px);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: linear-gradient(to top right, red, blue) }",
+		"a {\n  background: -webkit-linear-gradient(bottom left, red, blue);\n  background: -moz-linear-gradient(bottom left, red, blue);\n"+
+			"  background: -o-linear-gradient(bottom left, red, blue);\n  background:\n    linear-gradient(\n      to top right,\n      red,\n      blue);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: linear-gradient(180deg, red, blue) }",
+		"a {\n  background: -webkit-linear-gradient(270deg, red, blue);\n  background: -moz-linear-gradient(270deg, red, blue);\n"+
+			"  background: -o-linear-gradient(270deg, red, blue);\n  background:\n    linear-gradient(\n      180deg,\n      red,\n      blue);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: linear-gradient(0.5turn, red, blue) }",
+		"a {\n  background:\n    linear-gradient(\n      0.5turn,\n      red,\n      blue);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: radial-gradient(circle at 10px 20px, red, blue) }",
+		"a {\n  background: -webkit-radial-gradient(10px 20px, circle, red, blue);\n  background: -moz-radial-gradient(10px 20px, circle, red, blue);\n"+
+			"  background: -o-radial-gradient(10px 20px, circle, red, blue);\n  background:\n    radial-gradient(\n      circle at 10px 20px,\n      red,\n      blue);\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { background: -webkit-linear-gradient(left, red, blue); background: linear-gradient(to right, red, blue) }",
+		"a {\n  background: -webkit-linear-gradient(left, red, blue);\n  background: -moz-linear-gradient(left, red, blue);\n"+
+			"  background: -o-linear-gradient(left, red, blue);\n  background:\n    linear-gradient(\n      to right,\n      red,\n      blue);\n}\n", "")
+
+	// Selector tests
+	expectPrintedWithAllPrefixes(t, "input::placeholder { color: red }",
+		"input::-webkit-input-placeholder {\n  color: red;\n}\ninput::-moz-placeholder {\n  color: red;\n}\n"+
+			"input:-ms-input-placeholder {\n  color: red;\n}\ninput::-ms-input-placeholder {\n  color: red;\n}\ninput::placeholder {\n  color: red;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a::selection, b { color: red }",
+		"a::-moz-selection,\nb {\n  color: red;\n}\na::selection,\nb {\n  color: red;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a:read-only { color: red }",
+		"a:-moz-read-only {\n  color: red;\n}\na:read-only {\n  color: red;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "::-moz-selection { color: red } ::selection { color: red }",
+		"::-moz-selection {\n  color: red;\n}\n::selection {\n  color: red;\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "a { &::selection { color: red } }",
+		"a {\n  &::-moz-selection {\n    color: red;\n  }\n  &::selection {\n    color: red;\n  }\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "@media screen { ::selection { color: red } }",
+		"@media screen {\n  ::-moz-selection {\n    color: red;\n  }\n  ::selection {\n    color: red;\n  }\n}\n", "")
+
+	// At-rule tests
+	expectPrintedWithAllPrefixes(t, "@keyframes foo { to { opacity: 1 } }",
+		"@-webkit-keyframes foo {\n  to {\n    opacity: 1;\n  }\n}\n@-moz-keyframes foo {\n  to {\n    opacity: 1;\n  }\n}\n@keyframes foo {\n  to {\n    opacity: 1;\n  }\n}\n", "")
+	expectPrintedWithAllPrefixes(t, "@-webkit-keyframes foo { to { opacity: 0 } } @keyframes foo { to { opacity: 1 } }",
+		"@-webkit-keyframes foo {\n  to {\n    opacity: 0;\n  }\n}\n@-moz-keyframes foo {\n  to {\n    opacity: 1;\n  }\n}\n@keyframes foo {\n  to {\n    opacity: 1;\n  }\n}\n", "")
+}
+
+func TestPrefixRemoval(t *testing.T) {
+	// Properties
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { -webkit-transform: none; -ms-transform: none; transform: none }", "a {\n  transform: none;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 30, "a { -webkit-transform: none; -ms-transform: none; transform: none }", "a {\n  -webkit-transform: none;\n  transform: none;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { -webkit-transform: none }", "a {\n  -webkit-transform: none;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { transform: none; -webkit-transform: none }", "a {\n  transform: none;\n  -webkit-transform: none;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { -webkit-transform: none !important; transform: none }",
+		"a {\n  -webkit-transform: none !important;\n  transform: none;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { -webkit-transform: none; transform: none !important }", "a {\n  transform: none !important;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { -ms-flex-pack: justify; justify-content: space-between }", "a {\n  justify-content: space-between;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { -webkit-tap-highlight-color: red; tap-highlight-color: red }",
+		"a {\n  -webkit-tap-highlight-color: red;\n  tap-highlight-color: red;\n}\n", "")
+
+	// Values
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { display: -webkit-box; display: -ms-flexbox; display: flex }", "a {\n  display: flex;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Safari, 6, "a { display: -webkit-box; display: -ms-flexbox; display: flex }",
+		"a {\n  display: -webkit-box;\n  display: -webkit-flex;\n  display: flex;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { background: -webkit-linear-gradient(left, red, blue); background: linear-gradient(to right, red, blue) }",
+		"a {\n  background:\n    linear-gradient(\n      to right,\n      red,\n      blue);\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a { background: -webkit-gradient(linear, left top, right top, from(red), to(blue)); background: linear-gradient(to right, red, blue) }",
+		"a {\n  background: -webkit-gradient(linear, left top, right top, from(red), to(blue));\n"+
+			"  background:\n    linear-gradient(\n      to right,\n      red,\n      blue);\n}\n", "")
+
+	// Selectors
+	expectPrintedWithTarget(t, compat.Firefox, 100, "a::-moz-selection { color: red } a::selection { color: red }", "a::selection {\n  color: red;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Firefox, 50, "a::-moz-selection { color: red } a::selection { color: red }",
+		"a::-moz-selection {\n  color: red;\n}\na::selection {\n  color: red;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Firefox, 100, "a::-moz-selection { color: red } a::selection { color: blue }",
+		"a::-moz-selection {\n  color: red;\n}\na::selection {\n  color: blue;\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "a:-ms-input-placeholder { color: red } a::placeholder { color: red }", "a::placeholder {\n  color: red;\n}\n", "")
+
+	// At-rules
+	expectPrintedWithTarget(t, compat.Chrome, 100, "@-webkit-keyframes foo { to { opacity: 1 } } @keyframes foo { to { opacity: 1 } }",
+		"@keyframes foo {\n  to {\n    opacity: 1;\n  }\n}\n", "")
+	expectPrintedWithTarget(t, compat.Chrome, 100, "@-webkit-keyframes foo { to { opacity: 1 } } @keyframes bar { to { opacity: 1 } }",
+		"@-webkit-keyframes foo {\n  to {\n    opacity: 1;\n  }\n}\n@keyframes bar {\n  to {\n    opacity: 1;\n  }\n}\n", "")
 }
 
 func TestNthChild(t *testing.T) {
@@ -3124,3 +3473,28 @@ func TestComposes(t *testing.T) {
 	expectPrintedLocal(t, ".foo, div { composes: bar; color: red }", ".foo,\ndiv {\n  color: red;\n}\n", badComposes)
 	expectPrintedLocal(t, ".foo { .bar { composes: foo; color: red } }", ".foo {\n  .bar {\n    color: red;\n  }\n}\n", badComposes)
 }
+
+func TestICSS(t *testing.T) {
+	// These are only special in files with local names
+	expectPrinted(t, "@value primary: red;", "@value primary: red;\n", "")
+	expectPrinted(t, ":export { primary: red }", ":export {\n  primary: red;\n}\n", "")
+	expectPrinted(t, ":import(\"x.css\") { primary: red }", ":import(\"x.css\") {\n  primary: red;\n}\n", "")
+
+	expectPrintedLocal(t, "@value primary: red;", "", "")
+	expectPrintedLocal(t, "@value primary red;", "", "")
+	expectPrintedLocal(t, "@value primary: red", "", "<stdin>: WARNING: Expected \";\" but found end of file\n")
+	expectPrintedLocal(t, "@value a, b as c from \"x.css\";", "", "")
+	expectPrintedLocal(t, "@value (a, b as c) from \"x.css\";", "", "")
+	expectPrintedLocal(t, ":export { primary: red }", "", "")
+	expectPrintedLocal(t, ":import(\"x.css\") { primary: red }", "", "")
+	expectPrintedLocal(t, ".foo { @value primary: red; }", ".foo {\n  @value primary: red;\n}\n", "")
+	expectPrintedLocal(t, ":export.foo { primary: red }", ":export.foo {\n  primary: red;\n}\n", "")
+	expectPrintedLocal(t, ":import(x) { primary: red }", ":import(x) {\n  primary: red;\n}\n", "")
+
+	expectPrintedLocal(t, "@value primary;", "@value primary;\n", "<stdin>: WARNING: Expected a value for \"primary\"\n")
+	expectPrintedLocal(t, "@value 1px from \"x.css\";", "@value 1px from \"x.css\";\n",
+		"<stdin>: WARNING: Unexpected \"1px\"\n")
+	expectPrintedLocal(t, "@value a b from \"x.css\";", "@value a b from \"x.css\";\n",
+		"<stdin>: WARNING: Unexpected \"b\"\n")
+	expectPrintedLocal(t, ":import(\"x.css\") { a: b c }", "", "<stdin>: WARNING: Unexpected \"c\"\n")
+}
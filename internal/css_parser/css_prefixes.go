@@ -0,0 +1,744 @@
+package css_parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/css_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This file handles vendor prefixes for things other than property names,
+// which are handled by "insertPrefixedDeclaration" instead. It also handles
+// the removal of vendor prefixes that are no longer needed for the current
+// set of targets. Prefixes are only removed when there is an unprefixed
+// equivalent afterward, which matches the behavior of "autoprefixer".
+
+type vendorPrefix struct {
+	text   string
+	prefix compat.CSSPrefix
+}
+
+// The order here determines the order of the generated fallbacks
+var vendorPrefixes = []vendorPrefix{
+	{text: "-webkit-", prefix: compat.WebkitPrefix},
+	{text: "-moz-", prefix: compat.MozPrefix},
+	{text: "-ms-", prefix: compat.MsPrefix},
+	{text: "-o-", prefix: compat.OPrefix},
+}
+
+func splitVendorPrefix(text string) (compat.CSSPrefix, string) {
+	lower := strings.ToLower(text)
+	for _, vendor := range vendorPrefixes {
+		if strings.HasPrefix(lower, vendor.text) {
+			return vendor.prefix, lower[len(vendor.text):]
+		}
+	}
+	if strings.HasPrefix(lower, "-khtml-") {
+		return compat.KhtmlPrefix, lower[len("-khtml-"):]
+	}
+	return compat.NoPrefix, ""
+}
+
+func prefixBitForText(text string) compat.CSSPrefix {
+	if text == "-khtml-" {
+		return compat.KhtmlPrefix
+	}
+	for _, vendor := range vendorPrefixes {
+		if vendor.text == text {
+			return vendor.prefix
+		}
+	}
+	return compat.NoPrefix
+}
+
+// Internet Explorer 10 implemented an older draft of the flexbox specification
+// that used different names for some properties and values
+var msFlexboxPropertyNames = map[string]string{
+	"align-content":   "-ms-flex-line-pack",
+	"align-items":     "-ms-flex-align",
+	"align-self":      "-ms-flex-item-align",
+	"flex-basis":      "-ms-flex-preferred-size",
+	"flex-grow":       "-ms-flex-positive",
+	"flex-shrink":     "-ms-flex-negative",
+	"justify-content": "-ms-flex-pack",
+	"order":           "-ms-flex-order",
+}
+
+func msFlexboxAlignmentValue(text string) (string, bool) {
+	switch strings.ToLower(text) {
+	case "flex-start", "start":
+		return "start", true
+	case "flex-end", "end":
+		return "end", true
+	case "space-between":
+		return "justify", true
+	case "space-around":
+		return "distribute", true
+	case "center", "baseline", "stretch":
+		return text, true
+	}
+	return "", false
+}
+
+func unprefixedPropertyName(keyText string) (compat.CSSPrefix, string) {
+	lower := strings.ToLower(keyText)
+	for name, msName := range msFlexboxPropertyNames {
+		if lower == msName {
+			return compat.MsPrefix, name
+		}
+	}
+	return splitVendorPrefix(lower)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Values
+
+// These are only prefixed when they are used as the value of certain properties
+var prefixedValueKeywords = map[string]func(key css_ast.D) bool{
+	"flex":        isDisplayProperty,
+	"inline-flex": isDisplayProperty,
+	"grab":        isCursorProperty,
+	"grabbing":    isCursorProperty,
+	"zoom-in":     isCursorProperty,
+	"zoom-out":    isCursorProperty,
+	"fit-content": isSizeProperty,
+	"max-content": isSizeProperty,
+	"min-content": isSizeProperty,
+}
+
+// These are aliases for prefixed keywords with an irregular name
+var prefixedValueKeywordAliases = map[string]string{
+	"box":            "box",
+	"inline-box":     "box",
+	"flexbox":        "flex",
+	"inline-flexbox": "inline-flex",
+}
+
+func isDisplayProperty(key css_ast.D) bool {
+	return key == css_ast.DDisplay
+}
+
+func isCursorProperty(key css_ast.D) bool {
+	return key == css_ast.DCursor
+}
+
+func isSizeProperty(key css_ast.D) bool {
+	switch key {
+	case css_ast.DWidth, css_ast.DMinWidth, css_ast.DMaxWidth,
+		css_ast.DHeight, css_ast.DMinHeight, css_ast.DMaxHeight,
+		css_ast.DInlineSize, css_ast.DMinInlineSize, css_ast.DMaxInlineSize,
+		css_ast.DBlockSize, css_ast.DMinBlockSize, css_ast.DMaxBlockSize:
+		return true
+	}
+	return false
+}
+
+func prefixedValueKeyword(text string, vendor vendorPrefix) string {
+	switch text {
+	case "flex":
+		if vendor.prefix == compat.MsPrefix {
+			return "-ms-flexbox"
+		}
+	case "inline-flex":
+		if vendor.prefix == compat.MsPrefix {
+			return "-ms-inline-flexbox"
+		}
+	}
+	return vendor.text + text
+}
+
+func (p *parser) insertPrefixedValues(rules []css_ast.Rule, loc logger.Loc, decl *css_ast.RDeclaration) []css_ast.Rule {
+	values := p.options.cssNamePrefixData.Values
+
+	// Don't add prefixes to custom properties since their values are opaque.
+	// Also only add the matching prefix to an already-prefixed property.
+	keyPrefix := compat.NoPrefix
+	if strings.HasPrefix(decl.KeyText, "--") {
+		return rules
+	} else if prefix, _ := unprefixedPropertyName(decl.KeyText); prefix != compat.NoPrefix {
+		keyPrefix = prefix
+	}
+
+	if keyPrefix == compat.NoPrefix {
+		if prefixes := values["box"]; prefixes != compat.NoPrefix {
+			rules = p.insertLegacyFlexboxDeclarations(rules, loc, decl, prefixes)
+		}
+	}
+
+	for _, vendor := range vendorPrefixes {
+		if keyPrefix != compat.NoPrefix && keyPrefix != vendor.prefix {
+			continue
+		}
+		value := css_ast.CloneTokensWithoutImportRecords(decl.Value)
+		if changed, ok := prefixValueTokens(value, decl.Key, values, vendor, true); changed && ok && !hasPreviousPrefixedValue(rules, decl.KeyText, value, vendor.text) {
+			rules = insertDeclarationBeforeLast(rules, loc, decl, decl.KeyText, value)
+		}
+	}
+	return rules
+}
+
+// Don't insert a prefixed value if there already is one. The rest of the value
+// is ignored, which matches the behavior of "autoprefixer".
+func hasPreviousPrefixedValue(rules []css_ast.Rule, keyText string, value []css_ast.Token, prefix string) bool {
+	names := make(map[string]bool)
+	collectPrefixedNames(value, prefix, names)
+	for _, rule := range rules[:len(rules)-1] {
+		if decl, ok := rule.Data.(*css_ast.RDeclaration); ok && strings.EqualFold(decl.KeyText, keyText) && tokensHaveName(decl.Value, names) {
+			return true
+		}
+	}
+	return false
+}
+
+func collectPrefixedNames(tokens []css_ast.Token, prefix string, names map[string]bool) {
+	for _, t := range tokens {
+		if (t.Kind == css_lexer.TFunction || t.Kind == css_lexer.TIdent) && strings.HasPrefix(t.Text, prefix) {
+			names[strings.ToLower(t.Text)] = true
+		}
+		if t.Children != nil {
+			collectPrefixedNames(*t.Children, prefix, names)
+		}
+	}
+}
+
+func tokensHaveName(tokens []css_ast.Token, names map[string]bool) bool {
+	for _, t := range tokens {
+		if (t.Kind == css_lexer.TFunction || t.Kind == css_lexer.TIdent) && names[strings.ToLower(t.Text)] {
+			return true
+		}
+		if t.Children != nil && tokensHaveName(*t.Children, names) {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixValueTokens(tokens []css_ast.Token, key css_ast.D, values map[string]compat.CSSPrefix, vendor vendorPrefix, isTopLevel bool) (changed bool, ok bool) {
+	for i := range tokens {
+		t := &tokens[i]
+
+		switch t.Kind {
+		case css_lexer.TFunction:
+			lower := strings.ToLower(t.Text)
+			if (values[lower] & vendor.prefix) != 0 {
+				switch lower {
+				case "linear-gradient", "repeating-linear-gradient":
+					if !convertLinearGradientToLegacySyntax(t) {
+						return false, false
+					}
+				case "radial-gradient", "repeating-radial-gradient":
+					convertRadialGradientToLegacySyntax(t)
+				}
+				t.Text = vendor.text + t.Text
+				changed = true
+			}
+
+		case css_lexer.TIdent:
+			if isTopLevel {
+				lower := strings.ToLower(t.Text)
+				if isValidKey, ok := prefixedValueKeywords[lower]; ok && isValidKey(key) && (values[lower]&vendor.prefix) != 0 {
+					t.Text = prefixedValueKeyword(lower, vendor)
+					changed = true
+				}
+			}
+		}
+
+		if t.Children != nil {
+			childChanged, childOk := prefixValueTokens(*t.Children, key, values, vendor, false)
+			if !childOk {
+				return false, false
+			}
+			changed = changed || childChanged
+		}
+	}
+	return changed, true
+}
+
+func splitTokensAtFirstComma(tokens []css_ast.Token) ([]css_ast.Token, []css_ast.Token) {
+	for i, t := range tokens {
+		if t.Kind == css_lexer.TComma {
+			return tokens[:i], tokens[i:]
+		}
+	}
+	return tokens, nil
+}
+
+// Prefixed gradients use a legacy syntax where the direction is the starting
+// point instead of the ending point, and where angles are measured from the
+// positive x axis counterclockwise instead of from the positive y axis clockwise:
+//
+//	"linear-gradient(to right, red, blue)" => "-webkit-linear-gradient(left, red, blue)"
+//	"linear-gradient(45deg, red, blue)" => "-webkit-linear-gradient(45deg, red, blue)"
+//	"linear-gradient(90deg, red, blue)" => "-webkit-linear-gradient(0deg, red, blue)"
+func convertLinearGradientToLegacySyntax(token *css_ast.Token) bool {
+	first, _ := splitTokensAtFirstComma(*token.Children)
+	if len(first) == 0 {
+		return true
+	}
+
+	if first[0].Kind == css_lexer.TIdent && strings.EqualFold(first[0].Text, "to") {
+		sides := first[1:]
+		if len(sides) == 0 || len(sides) > 2 {
+			return false
+		}
+		for i := range sides {
+			if sides[i].Kind != css_lexer.TIdent {
+				return false
+			}
+			switch strings.ToLower(sides[i].Text) {
+			case "top":
+				sides[i].Text = "bottom"
+			case "bottom":
+				sides[i].Text = "top"
+			case "left":
+				sides[i].Text = "right"
+			case "right":
+				sides[i].Text = "left"
+			default:
+				return false
+			}
+		}
+		sides[0].Whitespace &= ^css_ast.WhitespaceBefore
+		children := append(sides, (*token.Children)[len(first):]...)
+		token.Children = &children
+		return true
+	}
+
+	if first[0].Kind == css_lexer.TDimension {
+		if len(first) != 1 || !strings.EqualFold(first[0].DimensionUnit(), "deg") {
+			return false
+		}
+		degrees, err := strconv.ParseFloat(first[0].DimensionValue(), 64)
+		if err != nil {
+			return false
+		}
+		degrees = math.Mod(450-degrees, 360)
+		if degrees < 0 {
+			degrees += 360
+		}
+		text, ok := floatToStringForCalc(degrees)
+		if !ok {
+			return false
+		}
+		first[0].Text = text + "deg"
+		first[0].UnitOffset = uint16(len(text))
+	}
+	return true
+}
+
+// Prefixed radial gradients put the position first, separated by a comma:
+//
+//	"radial-gradient(circle at top, red, blue)" => "-webkit-radial-gradient(top, circle, red, blue)"
+func convertRadialGradientToLegacySyntax(token *css_ast.Token) {
+	first, rest := splitTokensAtFirstComma(*token.Children)
+	for i, t := range first {
+		if t.Kind == css_lexer.TIdent && strings.EqualFold(t.Text, "at") && i+1 < len(first) {
+			shape := first[:i]
+			position := first[i+1:]
+			comma := css_ast.Token{Loc: t.Loc, Kind: css_lexer.TComma, Text: ","}
+			if len(rest) > 0 {
+				comma.Whitespace = rest[0].Whitespace
+			}
+			children := make([]css_ast.Token, 0, len((*token.Children))+1)
+			children = append(children, position...)
+			children[0].Whitespace &= ^css_ast.WhitespaceBefore
+			if len(shape) > 0 {
+				children = append(children, comma)
+				children = append(children, shape...)
+				children[len(children)-1].Whitespace &= ^css_ast.WhitespaceAfter
+			}
+			children = append(children, rest...)
+			token.Children = &children
+			return
+		}
+	}
+}
+
+// This generates fallbacks using the 2009 flexbox syntax, which was only ever
+// implemented with prefixes and which uses different names for everything
+func (p *parser) insertLegacyFlexboxDeclarations(rules []css_ast.Rule, loc logger.Loc, decl *css_ast.RDeclaration, prefixes compat.CSSPrefix) []css_ast.Rule {
+	var ident string
+	if len(decl.Value) == 1 && decl.Value[0].Kind == css_lexer.TIdent {
+		ident = strings.ToLower(decl.Value[0].Text)
+	}
+
+	for _, vendor := range vendorPrefixes {
+		if (prefixes & vendor.prefix) == 0 {
+			continue
+		}
+
+		switch decl.Key {
+		case css_ast.DDisplay:
+			switch ident {
+			case "flex":
+				rules = insertDeclarationBeforeLast(rules, loc, decl, decl.KeyText, identTokens(decl.Value[0], vendor.text+"box"))
+			case "inline-flex":
+				rules = insertDeclarationBeforeLast(rules, loc, decl, decl.KeyText, identTokens(decl.Value[0], vendor.text+"inline-box"))
+			}
+
+		case css_ast.DFlexDirection:
+			var orient, direction string
+			switch ident {
+			case "row":
+				orient, direction = "horizontal", "normal"
+			case "row-reverse":
+				orient, direction = "horizontal", "reverse"
+			case "column":
+				orient, direction = "vertical", "normal"
+			case "column-reverse":
+				orient, direction = "vertical", "reverse"
+			default:
+				continue
+			}
+			rules = insertDeclarationBeforeLast(rules, loc, decl, vendor.text+"box-orient", identTokens(decl.Value[0], orient))
+			rules = insertDeclarationBeforeLast(rules, loc, decl, vendor.text+"box-direction", identTokens(decl.Value[0], direction))
+
+		case css_ast.DJustifyContent:
+			if value, ok := msFlexboxAlignmentValue(ident); ok && value != "distribute" && value != "baseline" && value != "stretch" {
+				rules = insertDeclarationBeforeLast(rules, loc, decl, vendor.text+"box-pack", identTokens(decl.Value[0], value))
+			}
+
+		case css_ast.DAlignItems:
+			if value, ok := msFlexboxAlignmentValue(ident); ok && value != "justify" && value != "distribute" {
+				rules = insertDeclarationBeforeLast(rules, loc, decl, vendor.text+"box-align", identTokens(decl.Value[0], value))
+			}
+
+		case css_ast.DOrder:
+			if len(decl.Value) == 1 && decl.Value[0].Kind == css_lexer.TNumber {
+				if order, err := strconv.Atoi(decl.Value[0].Text); err == nil {
+					// The ordinal group starts at 1 instead of 0
+					value := decl.Value[0]
+					value.Text = strconv.Itoa(order + 1)
+					rules = insertDeclarationBeforeLast(rules, loc, decl, vendor.text+"box-ordinal-group", []css_ast.Token{value})
+				}
+			}
+
+		case css_ast.DFlex, css_ast.DFlexGrow:
+			if len(decl.Value) > 0 && decl.Value[0].Kind == css_lexer.TNumber {
+				value := decl.Value[0]
+				value.Whitespace &= css_ast.WhitespaceBefore
+				rules = insertDeclarationBeforeLast(rules, loc, decl, vendor.text+"box-flex", []css_ast.Token{value})
+			}
+		}
+	}
+	return rules
+}
+
+func identTokens(template css_ast.Token, text string) []css_ast.Token {
+	return []css_ast.Token{{Loc: template.Loc, Kind: css_lexer.TIdent, Text: text, Whitespace: template.Whitespace & css_ast.WhitespaceBefore}}
+}
+
+// This assumes the last rule is "decl", and inserts a new declaration before
+// it. Nothing is inserted if a previous declaration already exists with the
+// same property (or with the same property and value if the property is the
+// same as that of "decl").
+func insertDeclarationBeforeLast(rules []css_ast.Rule, loc logger.Loc, decl *css_ast.RDeclaration, keyText string, value []css_ast.Token) []css_ast.Rule {
+	for _, rule := range rules[:len(rules)-1] {
+		if prevDecl, ok := rule.Data.(*css_ast.RDeclaration); ok && strings.EqualFold(prevDecl.KeyText, keyText) &&
+			(keyText != decl.KeyText || css_ast.TokensEqual(prevDecl.Value, value, nil)) {
+			return rules
+		}
+	}
+
+	rules[len(rules)-1] = css_ast.Rule{Loc: loc, Data: &css_ast.RDeclaration{
+		KeyText:   keyText,
+		KeyRange:  decl.KeyRange,
+		Value:     value,
+		Important: decl.Important,
+	}}
+	return append(rules, css_ast.Rule{Loc: loc, Data: decl})
+}
+
+// Returns true if this declaration uses a vendor prefix that isn't needed for
+// the current targets and is overridden by a later unprefixed declaration
+func (p *parser) isUnnecessaryPrefixedDeclaration(decl *css_ast.RDeclaration, later []css_ast.Rule) bool {
+	if p.options.cssPrefixData == nil || strings.HasPrefix(decl.KeyText, "--") {
+		return false
+	}
+
+	// Check for a prefixed property name
+	if prefix, name := unprefixedPropertyName(decl.KeyText); prefix != compat.NoPrefix {
+		if prefixes, ok := p.options.cssPrefixData[css_ast.KnownDeclarations[name]]; ok && (prefixes&prefix) == 0 {
+			return hasLaterDeclaration(later, name, decl.Important)
+		}
+		return false
+	}
+
+	// Check for prefixed values
+	if values := p.options.cssNamePrefixData.Values; values != nil {
+		if found, ok := valueHasOnlyUnnecessaryPrefixes(decl.Value, values); found && ok {
+			return hasLaterDeclaration(later, strings.ToLower(decl.KeyText), decl.Important)
+		}
+	}
+	return false
+}
+
+func valueHasOnlyUnnecessaryPrefixes(tokens []css_ast.Token, values map[string]compat.CSSPrefix) (found bool, ok bool) {
+	for _, t := range tokens {
+		if t.Kind == css_lexer.TFunction || t.Kind == css_lexer.TIdent {
+			if prefix, name := splitVendorPrefix(t.Text); prefix != compat.NoPrefix {
+				if t.Kind == css_lexer.TIdent {
+					if alias, ok := prefixedValueKeywordAliases[name]; ok {
+						name = alias
+					} else if _, ok := prefixedValueKeywords[name]; !ok {
+						continue
+					}
+				}
+				if prefixes, ok := values[name]; ok {
+					if (prefixes & prefix) != 0 {
+						return false, false
+					}
+					found = true
+				}
+			}
+		}
+		if t.Children != nil {
+			childFound, childOk := valueHasOnlyUnnecessaryPrefixes(*t.Children, values)
+			if !childOk {
+				return false, false
+			}
+			found = found || childFound
+		}
+	}
+	return found, true
+}
+
+func hasLaterDeclaration(later []css_ast.Rule, keyText string, important bool) bool {
+	for _, rule := range later {
+		if decl, ok := rule.Data.(*css_ast.RDeclaration); ok && strings.EqualFold(decl.KeyText, keyText) && (decl.Important || !important) {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Selectors and at-rules
+
+type prefixedPseudoClass struct {
+	name      string
+	prefix    compat.CSSPrefix
+	isElement bool
+}
+
+// Each unprefixed pseudo-class or pseudo-element maps to its prefixed forms
+var prefixedPseudoClasses = map[string][]prefixedPseudoClass{
+	"any-link": {
+		{name: "-webkit-any-link", prefix: compat.WebkitPrefix},
+		{name: "-moz-any-link", prefix: compat.MozPrefix},
+	},
+	"autofill": {
+		{name: "-webkit-autofill", prefix: compat.WebkitPrefix},
+	},
+	"backdrop": {
+		{name: "-webkit-backdrop", prefix: compat.WebkitPrefix, isElement: true},
+		{name: "-ms-backdrop", prefix: compat.MsPrefix, isElement: true},
+	},
+	"file-selector-button": {
+		{name: "-webkit-file-upload-button", prefix: compat.WebkitPrefix, isElement: true},
+	},
+	"fullscreen": {
+		{name: "-webkit-full-screen", prefix: compat.WebkitPrefix},
+		{name: "-moz-full-screen", prefix: compat.MozPrefix},
+		{name: "-ms-fullscreen", prefix: compat.MsPrefix},
+	},
+	"placeholder": {
+		{name: "-webkit-input-placeholder", prefix: compat.WebkitPrefix, isElement: true},
+		{name: "-moz-placeholder", prefix: compat.MozPrefix, isElement: true},
+
+		// Internet Explorer uses a pseudo-class while Edge uses a pseudo-element
+		{name: "-ms-input-placeholder", prefix: compat.MsPrefix},
+		{name: "-ms-input-placeholder", prefix: compat.MsPrefix, isElement: true},
+	},
+	"read-only": {
+		{name: "-moz-read-only", prefix: compat.MozPrefix},
+	},
+	"read-write": {
+		{name: "-moz-read-write", prefix: compat.MozPrefix},
+	},
+	"selection": {
+		{name: "-moz-selection", prefix: compat.MozPrefix, isElement: true},
+	},
+}
+
+var unprefixedPseudoClasses = func() map[string]string {
+	result := make(map[string]string)
+	for name, prefixed := range prefixedPseudoClasses {
+		for _, item := range prefixed {
+			result[item.name] = name
+		}
+	}
+	return result
+}()
+
+// This runs over a list of rules after they have been parsed. It inserts
+// prefixed copies of selector rules and "@keyframes" rules when needed, and
+// removes prefixed copies that are no longer needed.
+func (p *parser) processRulePrefixes(rules []css_ast.Rule) []css_ast.Rule {
+	selectors := p.options.cssNamePrefixData.Selectors
+	atRules := p.options.cssNamePrefixData.AtRules
+	if selectors == nil && atRules == nil {
+		return rules
+	}
+
+	var result []css_ast.Rule
+	for i, rule := range rules {
+		switch r := rule.Data.(type) {
+		case *css_ast.RSelector:
+			if p.isUnnecessaryPrefixedSelectorRule(r, rules[i+1:]) {
+				continue
+			}
+			for _, vendor := range vendorPrefixes {
+				for variant := 0; ; variant++ {
+					clone, ok := prefixSelectors(r.Selectors, selectors, vendor.prefix, variant)
+					if !ok {
+						break
+					}
+					if !hasSelectorRule(rules, clone) {
+						result = append(result, css_ast.Rule{Loc: rule.Loc, Data: &css_ast.RSelector{
+							Selectors:     clone,
+							Rules:         r.Rules,
+							CloseBraceLoc: r.CloseBraceLoc,
+						}})
+					}
+				}
+			}
+
+		case *css_ast.RAtKeyframes:
+			if prefix, name := splitVendorPrefix(r.AtToken); prefix != compat.NoPrefix {
+				if prefixes, ok := atRules[name]; ok && (prefixes&prefix) == 0 && hasKeyframesRule(rules[i+1:], name, r.Name.Ref) {
+					continue
+				}
+			} else if prefixes := atRules[strings.ToLower(r.AtToken)]; prefixes != compat.NoPrefix {
+				for _, vendor := range vendorPrefixes {
+					if (prefixes&vendor.prefix) != 0 && !hasKeyframesRule(rules, vendor.text+"keyframes", r.Name.Ref) {
+						result = append(result, css_ast.Rule{Loc: rule.Loc, Data: &css_ast.RAtKeyframes{
+							AtToken:       vendor.text + "keyframes",
+							Name:          r.Name,
+							Blocks:        r.Blocks,
+							CloseBraceLoc: r.CloseBraceLoc,
+						}})
+					}
+				}
+			}
+		}
+
+		result = append(result, rule)
+	}
+	return result
+}
+
+// Returns a copy of the selectors with all pseudo-classes that need the given
+// prefix replaced by their prefixed form. Some pseudo-classes have more than
+// one prefixed form, which are returned using successive "variant" values.
+func prefixSelectors(selectors []css_ast.ComplexSelector, prefixes map[string]compat.CSSPrefix, prefix compat.CSSPrefix, variant int) ([]css_ast.ComplexSelector, bool) {
+	var clone []css_ast.ComplexSelector
+	for i, complex := range selectors {
+		for j, compound := range complex.Selectors {
+			for k, ss := range compound.SubclassSelectors {
+				pseudo, ok := ss.Data.(*css_ast.SSPseudoClass)
+				if !ok || pseudo.Args != nil || (prefixes[pseudo.Name]&prefix) == 0 {
+					continue
+				}
+
+				// Find the requested variant of this prefix
+				var match *prefixedPseudoClass
+				count := 0
+				for _, item := range prefixedPseudoClasses[pseudo.Name] {
+					if item.prefix == prefix {
+						if count == variant {
+							item := item
+							match = &item
+						}
+						count++
+					}
+				}
+				if match == nil {
+					continue
+				}
+
+				if clone == nil {
+					clone = make([]css_ast.ComplexSelector, len(selectors))
+					for i, complex := range selectors {
+						clone[i] = complex.Clone()
+					}
+				}
+				clone[i].Selectors[j].SubclassSelectors[k].Data = &css_ast.SSPseudoClass{
+					Name:      match.name,
+					IsElement: match.isElement,
+				}
+			}
+		}
+	}
+	return clone, clone != nil
+}
+
+func (p *parser) isUnnecessaryPrefixedSelectorRule(r *css_ast.RSelector, later []css_ast.Rule) bool {
+	var clone []css_ast.ComplexSelector
+	for i, complex := range r.Selectors {
+		for j, compound := range complex.Selectors {
+			for k, ss := range compound.SubclassSelectors {
+				pseudo, ok := ss.Data.(*css_ast.SSPseudoClass)
+				if !ok || pseudo.Args != nil {
+					continue
+				}
+				name, ok := unprefixedPseudoClasses[pseudo.Name]
+				if !ok {
+					continue
+				}
+				prefix, _ := splitVendorPrefix(pseudo.Name)
+				if prefixes, ok := p.options.cssNamePrefixData.Selectors[name]; !ok || (prefixes&prefix) != 0 {
+					return false
+				}
+				if clone == nil {
+					clone = make([]css_ast.ComplexSelector, len(r.Selectors))
+					for i, complex := range r.Selectors {
+						clone[i] = complex.Clone()
+					}
+				}
+				isElement := false
+				for _, item := range prefixedPseudoClasses[name] {
+					isElement = isElement || item.isElement
+				}
+				clone[i].Selectors[j].SubclassSelectors[k].Data = &css_ast.SSPseudoClass{
+					Name:      name,
+					IsElement: isElement,
+				}
+			}
+		}
+	}
+	if clone == nil {
+		return false
+	}
+
+	// Only remove this rule if the unprefixed rule has the same content
+	for _, rule := range later {
+		if next, ok := rule.Data.(*css_ast.RSelector); ok && css_ast.ComplexSelectorsEqual(clone, next.Selectors, nil) && css_ast.RulesEqual(r.Rules, next.Rules, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSelectorRule(rules []css_ast.Rule, selectors []css_ast.ComplexSelector) bool {
+	for _, rule := range rules {
+		if r, ok := rule.Data.(*css_ast.RSelector); ok && css_ast.ComplexSelectorsEqual(r.Selectors, selectors, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKeyframesRule(rules []css_ast.Rule, atToken string, ref ast.Ref) bool {
+	for _, rule := range rules {
+		if r, ok := rule.Data.(*css_ast.RAtKeyframes); ok && strings.EqualFold(r.AtToken, atToken) && r.Name.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package css_parser
+
+import (
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/css_ast"
+)
+
+// A local name that's never used by JavaScript (and never composed into a
+// name that's used by JavaScript) can't appear in the document since its
+// final name is never revealed. Any selector that requires an element to
+// have that name can therefore never match, so it can be removed:
+//
+//	.button { color: red }
+//	.button.large { font-size: 2em }
+//	.unused, .button:hover { color: blue }
+//
+//	=>
+//
+//	.button { color: red }
+//	.button:hover { color: blue }
+//
+// Rules that end up without any selectors are removed entirely. This never
+// mutates the original AST since ASTs are shared between chunks that are
+// generated in parallel.
+func RemoveRulesWithUnusedLocalNames(rules []css_ast.Rule, isUnused func(ast.Ref) bool) []css_ast.Rule {
+	var result []css_ast.Rule
+	for i, rule := range rules {
+		clone, keep, changed := removeUnusedLocalNamesInRule(rule, isUnused)
+		if changed && result == nil {
+			result = append(make([]css_ast.Rule, 0, len(rules)), rules[:i]...)
+		}
+		if result != nil && keep {
+			result = append(result, clone)
+		}
+	}
+	if result == nil {
+		return rules
+	}
+	return result
+}
+
+func removeUnusedLocalNamesInRule(rule css_ast.Rule, isUnused func(ast.Ref) bool) (css_ast.Rule, bool, bool) {
+	switch r := rule.Data.(type) {
+	case *css_ast.RSelector:
+		var selectors []css_ast.ComplexSelector
+		for i, complex := range r.Selectors {
+			if complexSelectorCanMatch(complex, isUnused) {
+				if selectors != nil {
+					selectors = append(selectors, complex)
+				}
+			} else if selectors == nil {
+				selectors = append(make([]css_ast.ComplexSelector, 0, len(r.Selectors)), r.Selectors[:i]...)
+			}
+		}
+		if selectors != nil && len(selectors) == 0 {
+			return rule, false, true
+		}
+		rules := RemoveRulesWithUnusedLocalNames(r.Rules, isUnused)
+		if selectors == nil && sameRules(rules, r.Rules) {
+			return rule, true, false
+		}
+		clone := *r
+		if selectors != nil {
+			clone.Selectors = selectors
+		}
+		clone.Rules = rules
+		rule.Data = &clone
+		return rule, true, true
+
+	case *css_ast.RKnownAt:
+		if rules := RemoveRulesWithUnusedLocalNames(r.Rules, isUnused); !sameRules(rules, r.Rules) {
+			if len(rules) == 0 {
+				return rule, false, true
+			}
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true, true
+		}
+
+	case *css_ast.RAtMedia:
+		if rules := RemoveRulesWithUnusedLocalNames(r.Rules, isUnused); !sameRules(rules, r.Rules) {
+			if len(rules) == 0 {
+				return rule, false, true
+			}
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true, true
+		}
+
+	case *css_ast.RAtLayer:
+		// Keep empty "@layer" blocks since they still affect the layer order
+		if rules := RemoveRulesWithUnusedLocalNames(r.Rules, isUnused); !sameRules(rules, r.Rules) {
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true, true
+		}
+
+	case *css_ast.RAtScope:
+		if rules := RemoveRulesWithUnusedLocalNames(r.Rules, isUnused); !sameRules(rules, r.Rules) {
+			if len(rules) == 0 {
+				return rule, false, true
+			}
+			clone := *r
+			clone.Rules = rules
+			rule.Data = &clone
+			return rule, true, true
+		}
+	}
+
+	return rule, true, false
+}
+
+// This is conservative and only returns false if every element matched by the
+// selector must have an unused local name. Names inside ":not()" and other
+// pseudo-classes are ignored since they don't require anything to have them.
+func complexSelectorCanMatch(complex css_ast.ComplexSelector, isUnused func(ast.Ref) bool) bool {
+	for _, compound := range complex.Selectors {
+		for _, ss := range compound.SubclassSelectors {
+			switch s := ss.Data.(type) {
+			case *css_ast.SSClass:
+				if isUnused(s.Name.Ref) {
+					return false
+				}
+
+			case *css_ast.SSHash:
+				if isUnused(s.Name.Ref) {
+					return false
+				}
+
+			case *css_ast.SSPseudoClassWithSelectorList:
+				switch s.Kind {
+				case css_ast.PseudoClassIs, css_ast.PseudoClassWhere, css_ast.PseudoClassHas, css_ast.PseudoClassLocal, css_ast.PseudoClassGlobal:
+					canMatch := false
+					for _, inner := range s.Selectors {
+						if complexSelectorCanMatch(inner, isUnused) {
+							canMatch = true
+							break
+						}
+					}
+					if !canMatch {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
@@ -89,6 +89,18 @@ func Print(tree css_ast.AST, symbols ast.SymbolMap, options Options) PrintResult
 	return result
 }
 
+// This prints a list of tokens by itself without any surrounding rule. It's
+// used for ICSS values, which are exposed to JavaScript as strings.
+func PrintTokens(tokens []css_ast.Token, importRecords []ast.ImportRecord, symbols ast.SymbolMap, options Options) string {
+	p := printer{
+		options:       options,
+		symbols:       symbols,
+		importRecords: importRecords,
+	}
+	p.printTokens(tokens, printTokensOpts{})
+	return string(p.css)
+}
+
 func (p *printer) recordImportPathForMetafile(importRecordIndex uint32) {
 	if p.options.NeedsMetafile {
 		record := p.importRecords[importRecordIndex]
@@ -379,6 +391,27 @@ func (p *printer) printRule(rule css_ast.Rule, indent int32, omitTrailingSemicol
 		}
 		p.printRuleBlock(r.Rules, indent, r.CloseBraceLoc)
 
+	case *css_ast.RAtCustomMedia:
+		p.print("@custom-media ")
+		p.printIdent(r.Name, identNormal, 0)
+		for i, query := range r.Queries {
+			if i > 0 {
+				p.print(",")
+			}
+			if i == 0 || !p.options.MinifyWhitespace {
+				p.print(" ")
+			}
+			p.printMediaQuery(query, 0)
+		}
+		p.print(";")
+
+	case *css_ast.RAtCustomSelector:
+		p.print("@custom-selector :")
+		p.printIdent(r.Name, identNormal, 0)
+		p.print(" ")
+		p.printComplexSelectors(r.Selectors, indent, layoutSingleLine)
+		p.print(";")
+
 	case *css_ast.RAtScope:
 		p.print("@scope")
 		if len(r.Start) > 0 {
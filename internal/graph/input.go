@@ -75,6 +75,15 @@ const (
 	// unused imports to these files since running the plugin is a side effect.
 	// Removing the import would not call the plugin which is observable.
 	NoSideEffects_PureData_FromPlugin
+
+	// This file was marked as not having side effects by the "packageSideEffects"
+	// setting, which overrides the "sideEffects" field in "package.json".
+	NoSideEffects_PackageOverride
+
+	// This file is in a package without a "sideEffects" field in "package.json"
+	// and analysis showed that importing it has no observable side effects. This
+	// is only done when the "inferSideEffects" setting is enabled.
+	NoSideEffects_Inferred
 )
 
 type InputFileRepr interface {
@@ -26,6 +26,13 @@ func IsInsideNodeModules(path string) bool {
 	}
 }
 
+// Returns true if the path is inside a "node_modules/<name>" directory for
+// the given package name. The package name may be scoped (e.g. "@foo/bar").
+func IsInsidePackage(path string, name string) bool {
+	path = strings.ReplaceAll(path, "\\", "/")
+	return strings.Contains(path, "/node_modules/"+name+"/") || strings.HasPrefix(path, "node_modules/"+name+"/")
+}
+
 func IsFileURL(fileURL *url.URL) bool {
 	return fileURL.Scheme == "file" && (fileURL.Host == "" || fileURL.Host == "localhost") && strings.HasPrefix(fileURL.Path, "/")
 }
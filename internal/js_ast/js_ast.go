@@ -51,6 +51,14 @@ const (
 	LMember
 )
 
+// Nested expressions can start at the same location (e.g. "a" and "a.b" in
+// "a.b"), so a comment that trails one of them also stores how many of those
+// nested expressions are inside the one it trails. See "SameLocDepth".
+type TrailingComment struct {
+	Text  string
+	Depth int
+}
+
 type OpCode uint8
 
 func (op OpCode) IsPrefix() bool {
@@ -1580,6 +1588,19 @@ type AST struct {
 	// to enable cross-module inlining of these constants.
 	ConstValues map[ast.Ref]ConstValue
 
+	// This contains the values of top-level constants that are initialized
+	// before any other code in this file runs, but that may come after import
+	// statements. They are only inlined into other files by the linker if this
+	// file isn't part of an import cycle.
+	TopLevelConstValues map[ast.Ref]ConstValue
+
+	// These contain comments that were on the same line as the end of an
+	// expression or statement when comments are being preserved. They are
+	// printed after that expression or statement instead of before whatever
+	// comes next.
+	TrailingExprComments map[logger.Loc][]TrailingComment
+	TrailingStmtComments map[logger.Loc][]string
+
 	// Properties in here are represented as symbols instead of strings, which
 	// allows them to be renamed to smaller names.
 	MangledProps map[string]ast.Ref
@@ -1599,6 +1620,11 @@ type AST struct {
 	NamedExports            map[string]NamedExport
 	ExportStarImportRecords []uint32
 
+	// This maps each default import that is only ever used to read properties
+	// to the names of those properties. The linker uses it to determine which
+	// local names of a CSS module are used through its default export.
+	DefaultImportPropertyAccesses map[ast.Ref]map[string]bool
+
 	SourceMapComment logger.Span
 
 	// This is a list of ES6 features. They are ranges instead of booleans so
@@ -1622,6 +1648,27 @@ type AST struct {
 	UsesExportsRef bool
 	UsesModuleRef  bool
 	ExportsKind    ExportsKind
+
+	// This is present if this is a CommonJS module that only uses "exports" and
+	// "module" in ways that can be statically analyzed. The linker uses it to
+	// convert the module into an ECMAScript module if it's only ever imported
+	// using "import" statements, which means it can then be tree-shaken.
+	CommonJSToESM *CommonJSToESM
+}
+
+type CommonJSToESM struct {
+	// These parts replace all of the parts in the file. Statements that assign
+	// to several exports may be split into several parts so that each export
+	// can be tree-shaken separately, so the part indices may be different.
+	Parts                 []Part
+	TopLevelSymbolToParts map[ast.Ref][]uint32
+
+	// These are the exports of the module after it has been converted
+	NamedExports map[string]NamedExport
+
+	// This is the location of the first export, which stands in for the
+	// "export" keyword after the module has been converted
+	ExportKeyword logger.Range
 }
 
 type TSEnumValue struct {
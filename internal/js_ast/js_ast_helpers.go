@@ -12,15 +12,45 @@ import (
 )
 
 type HelperContext struct {
-	isUnbound func(ast.Ref) bool
+	isUnbound  func(ast.Ref) bool
+	assumePure AssumePure
 }
 
-func MakeHelperContext(isUnbound func(ast.Ref) bool) HelperContext {
+func MakeHelperContext(isUnbound func(ast.Ref) bool, assumePure AssumePure) HelperContext {
 	return HelperContext{
-		isUnbound: isUnbound,
+		isUnbound:  isUnbound,
+		assumePure: assumePure,
 	}
 }
 
+// These are user-specified assumptions about the code being compiled that let
+// tree shaking remove more code than would otherwise be safe to remove
+type AssumePure uint8
+
+const (
+	// Reading a property from an object that isn't null or undefined is assumed
+	// to never call a getter with side effects
+	AssumePureGetters AssumePure = 1 << iota
+
+	// Reading a property is assumed to never have side effects, even if the
+	// object could be null or undefined (which throws a TypeError)
+	AssumePurePropertyReads
+
+	// Referencing a global variable that isn't known to esbuild is assumed to
+	// never have side effects, even if it doesn't exist (which throws a
+	// ReferenceError)
+	AssumePureUnknownGlobals
+
+	// Exceptions thrown inside a "try" block with a "catch" clause are caught,
+	// so the "try" block is checked as if property reads and unknown globals
+	// have no side effects
+	AssumePureTryBlocks
+)
+
+func (flags AssumePure) Has(flag AssumePure) bool {
+	return (flags & flag) != 0
+}
+
 // If this returns true, then calling this expression captures the target of
 // the property access as "this" when calling the function in the property.
 func IsPropertyAccess(expr Expr) bool {
@@ -62,6 +92,38 @@ func Not(expr Expr) Expr {
 	return Expr{Loc: expr.Loc, Data: &EUnary{Op: UnOpNot, Value: expr}}
 }
 
+// This returns the number of nested expressions that start at the same
+// location as this expression. For example, it's 2 for "a.b()" because both
+// "a.b" and "a" also start there.
+func SameLocDepth(expr Expr) (depth int) {
+	for {
+		var inner Expr
+		switch e := expr.Data.(type) {
+		case *EDot:
+			inner = e.Target
+		case *EIndex:
+			inner = e.Target
+		case *ECall:
+			inner = e.Target
+		case *EBinary:
+			inner = e.Left
+		case *EIf:
+			inner = e.Test
+		case *ETemplate:
+			inner = e.TagOrNil
+		case *EUnary:
+			if !e.Op.IsPrefix() {
+				inner = e.Value
+			}
+		}
+		if inner.Data == nil || inner.Loc != expr.Loc {
+			return
+		}
+		expr = inner
+		depth++
+	}
+}
+
 // The given "expr" argument should be the operand of a "!" prefix operator
 // (i.e. the "x" in "!x"). This returns a simplified expression for the
 // whole operator (i.e. the "!x") if it can be simplified, or false if not.
@@ -2203,6 +2265,13 @@ func (ctx HelperContext) StmtsCanBeRemovedIfUnused(stmts []Stmt, flags StmtsCanB
 		case *SFunction, *SEmpty:
 			// These never have side effects
 
+		case *SComment:
+			// Legal comments must be kept, but other comments are only present when
+			// comments are being preserved and should be removed with their code
+			if s.IsLegalComment {
+				return false
+			}
+
 		case *SImport:
 			// Let these be removed if they are unused. Note that we also need to
 			// check if the imported file is marked as "sideEffects: false" before we
@@ -2291,7 +2360,15 @@ func (ctx HelperContext) StmtsCanBeRemovedIfUnused(stmts []Stmt, flags StmtsCanB
 			}
 
 		case *STry:
-			if !ctx.StmtsCanBeRemovedIfUnused(s.Block.Stmts, 0) || (s.Finally != nil && !ctx.StmtsCanBeRemovedIfUnused(s.Finally.Block.Stmts, 0)) {
+			blockCtx := ctx
+			if s.Catch != nil && ctx.assumePure.Has(AssumePureTryBlocks) {
+				// Anything thrown will be caught, but then the "catch" clause runs
+				blockCtx.assumePure |= AssumePurePropertyReads | AssumePureUnknownGlobals
+				if !ctx.StmtsCanBeRemovedIfUnused(s.Catch.Block.Stmts, 0) {
+					return false
+				}
+			}
+			if !blockCtx.StmtsCanBeRemovedIfUnused(s.Block.Stmts, 0) || (s.Finally != nil && !ctx.StmtsCanBeRemovedIfUnused(s.Finally.Block.Stmts, 0)) {
 				return false
 			}
 
@@ -2442,7 +2519,10 @@ func (ctx HelperContext) ExprCanBeRemovedIfUnused(expr Expr) bool {
 		return true
 
 	case *EDot:
-		return e.CanBeRemovedIfUnused
+		return e.CanBeRemovedIfUnused || ctx.propertyReadCanBeRemovedIfUnused(e.Target)
+
+	case *EIndex:
+		return e.CanBeRemovedIfUnused || (IsPrimitiveLiteral(e.Index.Data) && ctx.propertyReadCanBeRemovedIfUnused(e.Target))
 
 	case *EClass:
 		return ctx.ClassCanBeRemovedIfUnused(e.Class)
@@ -2471,7 +2551,7 @@ func (ctx HelperContext) ExprCanBeRemovedIfUnused(expr Expr) bool {
 		// incorrect but proper TDZ analysis is very complicated and would have to
 		// be very conservative, which would inhibit a lot of optimizations of code
 		// inside closures. This may need to be revisited if it proves problematic.
-		if e.CanBeRemovedIfUnused || !ctx.isUnbound(e.Ref) {
+		if e.CanBeRemovedIfUnused || !ctx.isUnbound(e.Ref) || ctx.assumePure.Has(AssumePureUnknownGlobals) {
 			return true
 		}
 
@@ -2516,9 +2596,13 @@ func (ctx HelperContext) ExprCanBeRemovedIfUnused(expr Expr) bool {
 
 	case *EObject:
 		for _, property := range e.Properties {
-			// The key must still be evaluated if it's computed or a spread
+			// The key must still be evaluated if it's computed or a spread. Spread
+			// doesn't throw for null or undefined, but it does call getters.
 			if property.Kind == PropertySpread {
-				return false
+				if !ctx.assumePure.Has(AssumePureGetters) || !ctx.ExprCanBeRemovedIfUnused(property.ValueOrNil) {
+					return false
+				}
+				continue
 			}
 			if property.Flags.Has(PropertyIsComputed) && !IsPrimitiveLiteral(property.Key.Data) && !IsSymbolInstance(property.Key.Data) {
 				return false
@@ -2634,6 +2718,18 @@ func (ctx HelperContext) ExprCanBeRemovedIfUnused(expr Expr) bool {
 	return false
 }
 
+func (ctx HelperContext) propertyReadCanBeRemovedIfUnused(target Expr) bool {
+	if ctx.assumePure.Has(AssumePurePropertyReads) {
+		return ctx.ExprCanBeRemovedIfUnused(target)
+	}
+	if ctx.assumePure.Has(AssumePureGetters) {
+		if isNullOrUndefined, _, ok := ToNullOrUndefinedWithSideEffects(target.Data); ok && !isNullOrUndefined {
+			return ctx.ExprCanBeRemovedIfUnused(target)
+		}
+	}
+	return false
+}
+
 func (ctx HelperContext) isSideEffectFreeUnboundIdentifierRef(value Expr, guardCondition Expr, isYesBranch bool) bool {
 	if id, ok := value.Data.(*EIdentifier); ok && ctx.isUnbound(id.Ref) {
 		if binary, ok := guardCondition.Data.(*EBinary); ok {
@@ -290,6 +290,11 @@ type Lexer struct {
 
 	// The log is disabled during speculative scans that may backtrack
 	IsLogDisabled bool
+
+	// When all comments are being preserved, comments before a token are not
+	// discarded when moving to the next token. Instead they are kept until the
+	// parser attaches them to something and clears them.
+	KeepCommentsUntilConsumed bool
 }
 
 type CommentBefore uint8
@@ -317,6 +322,26 @@ func NewLexer(log logger.Log, source logger.Source, ts config.TSOptions) Lexer {
 	return lexer
 }
 
+// This is like "NewLexer" except that a syntax error in the first token is
+// skipped over instead of causing a panic. It's used by the parser's error
+// recovery mode, which can't catch panics before it has a lexer.
+func NewLexerWithErrorRecovery(log logger.Log, source logger.Source, ts config.TSOptions) Lexer {
+	lexer := Lexer{
+		log:               log,
+		source:            source,
+		tracker:           logger.MakeLineColumnTracker(&source),
+		prevErrorLoc:      logger.Loc{Start: -1},
+		FnOrArrowStartLoc: logger.Loc{Start: -1},
+		ts:                ts,
+		json:              NotJSON,
+	}
+	lexer.step()
+	if !lexer.tryToScan(lexer.Next) {
+		lexer.SkipToStatementBoundary(logger.Loc{Start: -1})
+	}
+	return lexer
+}
+
 func NewLexerGlobalName(log logger.Log, source logger.Source) Lexer {
 	lexer := Lexer{
 		log:               log,
@@ -540,6 +565,165 @@ func (lexer *Lexer) ExpectOrInsertSemicolon() {
 	}
 }
 
+// This is used by the parser's error recovery mode to resume parsing after a
+// syntax error. It discards tokens until it reaches something that is likely
+// to be the start of the next statement: a ";" (which is consumed), a "}" that
+// closes the enclosing block, the end of the file, or the first token on a
+// line where automatic semicolon insertion would apply. Brackets are skipped
+// over as a unit. A statement keyword right after a closing bracket at the
+// top level also stops it even without a newline, since that bracket likely
+// ended whatever was broken (e.g. the "let" in "if (x { a } let y"). The token that it stops at is always after "start" so that
+// the parser makes progress. Errors encountered while skipping are not
+// reported because they are almost always a consequence of the original error.
+func (lexer *Lexer) SkipToStatementBoundary(start logger.Loc) {
+	oldIsLogDisabled := lexer.IsLogDisabled
+	lexer.IsLogDisabled = true
+	defer func() {
+		lexer.IsLogDisabled = oldIsLogDisabled
+	}()
+
+	// The error may have happened in the middle of scanning a token, so start
+	// over from the beginning of the current token
+	hasNewlineBefore := lexer.HasNewlineBefore
+	lexer.current = lexer.start
+	lexer.rescanCloseBraceAsTemplateToken = false
+	lexer.step()
+	lexer.nextSkippingErrors()
+	lexer.HasNewlineBefore = hasNewlineBefore
+
+	// Nothing is known about the token before the error, so assume that it
+	// could have been the end of an expression
+	prevCanEndExpr := true
+	prevWasCloseAtTopLevel := false
+	var brackets []T
+
+	for {
+		canStop := len(brackets) == 0 && lexer.start > int(start.Start)
+		isCloseAtTopLevel := false
+
+		switch lexer.Token {
+		case TEndOfFile:
+			return
+
+		case TSemicolon:
+			if len(brackets) == 0 {
+				lexer.nextSkippingErrors()
+				if lexer.start > int(start.Start) {
+					return
+				}
+				prevCanEndExpr = false
+				continue
+			}
+
+		case TOpenBrace, TOpenBracket, TOpenParen, TTemplateHead:
+			brackets = append(brackets, lexer.Token)
+
+		case TCloseBrace, TCloseBracket, TCloseParen:
+			if len(brackets) == 0 {
+				if lexer.Token == TCloseBrace && canStop {
+					return
+				}
+				isCloseAtTopLevel = true
+				break
+			}
+			last := brackets[len(brackets)-1]
+			brackets = brackets[:len(brackets)-1]
+			isCloseAtTopLevel = len(brackets) == 0
+
+			// The end of a template literal substitution continues the template
+			if last == TTemplateHead && lexer.Token == TCloseBrace {
+				if !lexer.rescanCloseBraceAsTemplateTokenSkippingErrors() {
+					continue
+				}
+				if lexer.Token == TTemplateMiddle {
+					brackets = append(brackets, TTemplateHead)
+				}
+			}
+
+		default:
+			if canStop && (((lexer.HasNewlineBefore || prevWasCloseAtTopLevel) && lexer.isStatementKeyword()) ||
+				(lexer.HasNewlineBefore && prevCanEndExpr && lexer.canStartExprAfterNewline())) {
+				return
+			}
+		}
+
+		prevCanEndExpr = lexer.canEndExpr()
+		prevWasCloseAtTopLevel = isCloseAtTopLevel
+		lexer.nextSkippingErrors()
+	}
+}
+
+func (lexer *Lexer) isStatementKeyword() bool {
+	switch lexer.Token {
+	case TBreak, TClass, TConst, TContinue, TDebugger, TDo, TExport, TFor, TFunction,
+		TIf, TImport, TReturn, TSwitch, TThrow, TTry, TVar, TWhile, TWith:
+		return true
+
+	case TIdentifier:
+		return lexer.Raw() == "let"
+	}
+	return false
+}
+
+func (lexer *Lexer) canEndExpr() bool {
+	switch lexer.Token {
+	case TIdentifier, TNumericLiteral, TStringLiteral, TBigIntegerLiteral,
+		TNoSubstitutionTemplateLiteral, TTemplateTail, TCloseBrace, TCloseBracket,
+		TCloseParen, TPlusPlus, TMinusMinus, TFalse, TNull, TSuper, TThis, TTrue:
+		return true
+	}
+	return false
+}
+
+// A newline between two tokens like these means a semicolon will be inserted
+func (lexer *Lexer) canStartExprAfterNewline() bool {
+	switch lexer.Token {
+	case TIdentifier, TNumericLiteral, TStringLiteral, TBigIntegerLiteral,
+		TDelete, TFalse, TNew, TNull, TSuper, TThis, TTrue, TTypeof, TVoid:
+		return true
+	}
+	return false
+}
+
+// This is like "Next" except that invalid input is skipped over one code
+// point at a time until a valid token is found
+func (lexer *Lexer) nextSkippingErrors() {
+	for !lexer.tryToScan(lexer.Next) || lexer.Token == TSyntaxError {
+		_, width := utf8.DecodeRuneInString(lexer.source.Contents[lexer.start:])
+		if width == 0 {
+			lexer.Token = TEndOfFile
+			return
+		}
+		lexer.current = lexer.start + width
+		lexer.step()
+	}
+}
+
+func (lexer *Lexer) rescanCloseBraceAsTemplateTokenSkippingErrors() bool {
+	if lexer.tryToScan(lexer.RescanCloseBraceAsTemplateToken) {
+		return true
+	}
+	lexer.rescanCloseBraceAsTemplateToken = false
+	lexer.current = lexer.start + 1
+	lexer.step()
+	lexer.nextSkippingErrors()
+	return false
+}
+
+func (lexer *Lexer) tryToScan(scan func()) (ok bool) {
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(LexerPanic); isLexerPanic {
+			ok = false
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	scan()
+	return true
+}
+
 // This parses a single "<" token. If that is the first part of a longer token,
 // this function splits off the first "<" and leaves the remainder of the
 // current token as another, smaller token. For example, "<<=" becomes "<=".
@@ -1001,7 +1185,9 @@ func (lexer *Lexer) Next() {
 	lexer.HasCommentBefore = 0
 	lexer.PrevTokenWasAwaitKeyword = false
 	lexer.LegalCommentsBeforeToken = lexer.LegalCommentsBeforeToken[:0]
-	lexer.CommentsBeforeToken = lexer.CommentsBeforeToken[:0]
+	if !lexer.KeepCommentsUntilConsumed {
+		lexer.CommentsBeforeToken = lexer.CommentsBeforeToken[:0]
+	}
 
 	for {
 		lexer.start = lexer.end
@@ -51,6 +51,8 @@ type parser struct {
 	injectedDotNames           map[string][]injectedDotName
 	dropLabelsMap              map[string]struct{}
 	exprComments               map[logger.Loc][]string
+	trailingExprComments       map[logger.Loc][]js_ast.TrailingComment
+	trailingStmtComments       map[logger.Loc][]string
 	mangledProps               map[string]ast.Ref
 	reservedProps              map[string]bool
 	globPatternImports         []globPatternImport
@@ -91,6 +93,11 @@ type parser struct {
 	localTypeNames             map[string]bool
 	tsEnums                    map[ast.Ref]map[string]js_ast.TSEnumValue
 	constValues                map[ast.Ref]js_ast.ConstValue
+	inlineFnCandidates         []inlineFnCandidate
+	inlineFnCalls              map[ast.Ref]*js_ast.ECall
+	inlineFnsBeingVisited      []ast.Ref
+	inlineFnRemainingArgs      []js_ast.Arg
+	refreshHookCallsForFnBody  map[logger.Loc][]refreshHookCall
 	propDerivedCtorValue       js_ast.E
 	propMethodDecoratorScope   *js_ast.Scope
 
@@ -122,6 +129,10 @@ type parser struct {
 	topLevelSymbolToParts   map[ast.Ref][]uint32
 	importNamespaceCCMap    map[importNamespaceCall]bool
 
+	// Property accesses on default imports are tracked so that the linker can
+	// tell which local names of a CSS module are used through its default export
+	defaultImportPropertyAccesses map[ast.Ref]*defaultImportPropertyAccesses
+
 	// The parser does two passes and we need to pass the scope tree information
 	// from the first pass to the second pass. That's done by tracking the calls
 	// to pushScopeForParsePass() and popScope() during the first pass in
@@ -138,6 +149,11 @@ type parser struct {
 	// binding a name to it in a parent or sibling scope.
 	scopesInOrder []scopeOrder
 
+	// In error recovery mode, this records the previous state of every scope
+	// member that is declared so that the declarations in a statement can be
+	// undone if that statement is dropped due to a syntax error
+	scopeMemberUndoLog []scopeMemberUndo
+
 	// These propagate the name from the parent context into an anonymous child
 	// expression. For example:
 	//
@@ -279,6 +295,18 @@ type parser struct {
 	// are now disabled for code inside "node_modules" directories.
 	suppressWarningsAboutWeirdCode bool
 
+	// These are the built-ins that need to be polyfilled if they are used, and
+	// the ones that have actually been used so far. The bundler clears the
+	// polyfill option for the polyfill package and its dependencies since
+	// those could include the code for the polyfills themselves.
+	polyfillBuiltins compat.Builtin
+	usedBuiltins     compat.Builtin
+
+	// This is true when the React Fast Refresh transform is enabled. It's not
+	// applied to code inside "node_modules" directories since that code isn't
+	// expected to be edited.
+	reactRefresh bool
+
 	// A file is considered to be an ECMAScript module if it has any of the
 	// features of one (e.g. the "export" keyword), otherwise it's considered
 	// a CommonJS module.
@@ -398,6 +426,11 @@ type globPatternImport struct {
 	phase            ast.ImportPhase
 }
 
+type defaultImportPropertyAccesses struct {
+	names map[string]bool
+	count uint32
+}
+
 type namespaceImportItems struct {
 	entries           map[string]ast.LocRef
 	importRecordIndex uint32
@@ -463,10 +496,12 @@ type Options struct {
 
 type optionsThatSupportStructuralEquality struct {
 	originalTargetEnv                 string
+	polyfill                          string
 	moduleTypeData                    js_ast.ModuleTypeData
 	unsupportedJSFeatures             compat.JSFeature
 	unsupportedJSFeatureOverrides     compat.JSFeature
 	unsupportedJSFeatureOverridesMask compat.JSFeature
+	unsupportedBuiltins               compat.Builtin
 
 	// Byte-sized values go here (gathered together here to keep this object compact)
 	ts                     config.TSOptions
@@ -486,6 +521,10 @@ type optionsThatSupportStructuralEquality struct {
 	treeShaking            bool
 	dropDebugger           bool
 	mangleQuoted           bool
+	preserveComments       bool
+	inlineFunctions        bool
+	assumePure             js_ast.AssumePure
+	errorRecovery          bool
 
 	// This is an internal-only option used for the implementation of Yarn PnP
 	decodeHydrateRuntimeStateYarnPnP bool
@@ -514,6 +553,8 @@ func OptionsFromConfig(options *config.Options) Options {
 			unsupportedJSFeatureOverrides:     options.UnsupportedJSFeatureOverrides,
 			unsupportedJSFeatureOverridesMask: options.UnsupportedJSFeatureOverridesMask,
 			originalTargetEnv:                 options.OriginalTargetEnv,
+			polyfill:                          options.Polyfill,
+			unsupportedBuiltins:               options.UnsupportedBuiltins,
 			ts:                                options.TS,
 			mode:                              options.Mode,
 			platform:                          options.Platform,
@@ -530,6 +571,10 @@ func OptionsFromConfig(options *config.Options) Options {
 			treeShaking:                       options.TreeShaking,
 			dropDebugger:                      options.DropDebugger,
 			mangleQuoted:                      options.MangleQuoted,
+			preserveComments:                  options.PreserveComments,
+			inlineFunctions:                   options.InlineFunctions,
+			assumePure:                        options.AssumePure,
+			errorRecovery:                     options.ErrorRecovery,
 			logPathStyle:                      options.LogPathStyle,
 			codePathStyle:                     options.CodePathStyle,
 		},
@@ -575,7 +620,7 @@ func (a *Options) Equal(b *Options) bool {
 	}
 
 	// Compare "jsx"
-	if a.jsx.Parse != b.jsx.Parse || !jsxExprsEqual(a.jsx.Factory, b.jsx.Factory) || !jsxExprsEqual(a.jsx.Fragment, b.jsx.Fragment) {
+	if a.jsx.Parse != b.jsx.Parse || a.jsx.Refresh != b.jsx.Refresh || !jsxExprsEqual(a.jsx.Factory, b.jsx.Factory) || !jsxExprsEqual(a.jsx.Fragment, b.jsx.Fragment) {
 		return false
 	}
 
@@ -622,6 +667,14 @@ const (
 	locModuleScope = -1
 )
 
+type scopeMemberUndo struct {
+	scope   *js_ast.Scope
+	name    string
+	member  js_ast.ScopeMember
+	symbol  ast.Symbol
+	existed bool
+}
+
 type scopeOrder struct {
 	scope *js_ast.Scope
 	loc   logger.Loc
@@ -680,6 +733,10 @@ type fnOrArrowDataVisit struct {
 	isDerivedClassCtor             bool
 	isOutsideFnOrArrow             bool
 	shouldLowerSuperPropertyAccess bool
+
+	// These are calls to React hooks inside this function when the React Fast
+	// Refresh transform is enabled
+	refreshHookCalls []refreshHookCall
 }
 
 // This is function-specific information used during visiting. It is saved and
@@ -740,6 +797,9 @@ type fnOnlyDataVisit struct {
 	// If true, "this" is used in current function scope.
 	hasThisUsage bool
 
+	// If true, "new.target" is used in current function scope.
+	hasNewTargetUsage bool
+
 	// Do not warn about "this" being undefined for code that the TypeScript
 	// compiler generates that looks like this:
 	//
@@ -1483,7 +1543,15 @@ func (p *parser) declareSymbol(kind ast.SymbolKind, loc logger.Loc, name string)
 	ref := p.newSymbol(kind, name)
 
 	// Check for a collision in the declaring scope
-	if existing, ok := p.currentScope.Members[name]; ok {
+	existing, ok := p.currentScope.Members[name]
+	if p.options.errorRecovery {
+		undo := scopeMemberUndo{scope: p.currentScope, name: name, member: existing, existed: ok}
+		if ok {
+			undo.symbol = p.symbols[existing.Ref.InnerIndex]
+		}
+		p.scopeMemberUndoLog = append(p.scopeMemberUndoLog, undo)
+	}
+	if ok {
 		symbol := &p.symbols[existing.Ref.InnerIndex]
 
 		switch p.canMergeSymbols(p.currentScope, symbol.Kind, kind) {
@@ -3473,6 +3541,128 @@ func (p *parser) convertExprToBinding(expr js_ast.Expr, invalidLog invalidLog) (
 	}
 }
 
+// This returns the comments before a statement so they can be stored using
+// the same mechanism as for expressions. The printer prints them on their own
+// lines before the statement. Legal comments are skipped because they are
+// already turned into separate "SComment" statements.
+func (p *parser) takeStmtComments() (comments []string) {
+	if p.exprComments != nil {
+		for _, comment := range p.lexer.CommentsBeforeToken {
+			if !p.isLegalCommentBeforeToken(comment) {
+				comments = append(comments, p.source.CommentTextWithoutIndent(comment))
+			}
+		}
+		p.lexer.CommentsBeforeToken = p.lexer.CommentsBeforeToken[:0]
+	}
+	return
+}
+
+func (p *parser) saveStmtComments(loc logger.Loc, comments []string) {
+	if len(comments) > 0 {
+		p.exprComments[loc] = append(comments, p.exprComments[loc]...)
+	}
+}
+
+// Expressions that are folded together would otherwise lose the comments
+// attached to the parts that no longer exist, so move them to what remains
+func (p *parser) moveExprComments(from logger.Loc, to logger.Loc) {
+	if comments, ok := p.exprComments[from]; ok && from != to {
+		delete(p.exprComments, from)
+		p.exprComments[to] = append(p.exprComments[to], comments...)
+	}
+	if comments, ok := p.trailingExprComments[from]; ok && from != to {
+		delete(p.trailingExprComments, from)
+		p.trailingExprComments[to] = append(p.trailingExprComments[to], comments...)
+	}
+}
+
+// Comments that start on the same line as the end of the previous token trail
+// whatever came before them instead of leading whatever comes after them. For
+// example, "// yes" trails "c" in "a ? c // yes\n : d". This returns those
+// comments and removes them from the lexer. Legal comments are never taken
+// because they are turned into separate "SComment" statements.
+func (p *parser) takeTrailingComments() (comments []string) {
+	n := 0
+	for _, comment := range p.lexer.CommentsBeforeToken {
+		if !p.isCommentOnSameLineAsPreviousToken(comment) || p.isLegalCommentBeforeToken(comment) {
+			break
+		}
+		comments = append(comments, p.source.CommentTextWithoutIndent(comment))
+		n++
+	}
+	p.lexer.CommentsBeforeToken = p.lexer.CommentsBeforeToken[n:]
+	return
+}
+
+func (p *parser) isCommentOnSameLineAsPreviousToken(comment logger.Range) bool {
+	contents := p.source.Contents
+	for i := int(comment.Loc.Start) - 1; i >= 0; i-- {
+		switch contents[i] {
+		case ' ', '\t':
+			continue
+		case '\r', '\n':
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// Only tokens that continue or end an expression are considered here. Other
+// tokens either start a new statement (in which case the comments trail the
+// whole statement instead) or may have a different meaning after a newline.
+func (p *parser) saveTrailingExprComments(expr js_ast.Expr) {
+	switch p.lexer.Token {
+	case js_lexer.TAmpersand, js_lexer.TAmpersandAmpersand, js_lexer.TAsterisk, js_lexer.TAsteriskAsterisk,
+		js_lexer.TBar, js_lexer.TBarBar, js_lexer.TCaret, js_lexer.TCloseBracket, js_lexer.TCloseParen,
+		js_lexer.TColon, js_lexer.TComma, js_lexer.TDot, js_lexer.TEqualsEquals, js_lexer.TEqualsEqualsEquals,
+		js_lexer.TExclamationEquals, js_lexer.TExclamationEqualsEquals, js_lexer.TGreaterThan,
+		js_lexer.TGreaterThanEquals, js_lexer.TGreaterThanGreaterThan, js_lexer.TGreaterThanGreaterThanGreaterThan,
+		js_lexer.TLessThan, js_lexer.TLessThanEquals, js_lexer.TLessThanLessThan, js_lexer.TMinus,
+		js_lexer.TPercent, js_lexer.TPlus, js_lexer.TQuestion, js_lexer.TQuestionDot, js_lexer.TQuestionQuestion,
+		js_lexer.TSlash, js_lexer.TIn, js_lexer.TInstanceof:
+
+	default:
+		if !p.lexer.Token.IsAssign() {
+			return
+		}
+	}
+
+	p.saveTrailingExprCommentsForExpr(expr)
+}
+
+// Block comments after a comma are more likely to be about the next item,
+// so only a single-line comment is attached to the item before the comma
+func (p *parser) saveTrailingExprCommentsAfterComma(expr js_ast.Expr) {
+	if comment := p.lexer.CommentsBeforeToken[0]; strings.HasPrefix(p.source.Contents[comment.Loc.Start:], "//") {
+		p.saveTrailingExprCommentsForExpr(expr)
+	}
+}
+
+func (p *parser) saveTrailingExprCommentsForExpr(expr js_ast.Expr) {
+	if comments := p.takeTrailingComments(); len(comments) > 0 {
+		depth := js_ast.SameLocDepth(expr)
+		for _, text := range comments {
+			p.trailingExprComments[expr.Loc] = append(p.trailingExprComments[expr.Loc], js_ast.TrailingComment{Text: text, Depth: depth})
+		}
+	}
+}
+
+func (p *parser) saveTrailingStmtComments(loc logger.Loc) {
+	if comments := p.takeTrailingComments(); len(comments) > 0 {
+		p.trailingStmtComments[loc] = append(p.trailingStmtComments[loc], comments...)
+	}
+}
+
+func (p *parser) isLegalCommentBeforeToken(comment logger.Range) bool {
+	for _, legal := range p.lexer.LegalCommentsBeforeToken {
+		if legal.Loc == comment.Loc {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *parser) saveExprCommentsHere() logger.Loc {
 	loc := p.lexer.Loc()
 	if p.exprComments != nil && len(p.lexer.CommentsBeforeToken) > 0 {
@@ -3480,8 +3670,15 @@ func (p *parser) saveExprCommentsHere() logger.Loc {
 		for i, comment := range p.lexer.CommentsBeforeToken {
 			comments[i] = p.source.CommentTextWithoutIndent(comment)
 		}
-		p.exprComments[loc] = comments
-		p.lexer.CommentsBeforeToken = p.lexer.CommentsBeforeToken[0:]
+		if p.lexer.KeepCommentsUntilConsumed {
+			// Don't overwrite comments that have already been attached here, and
+			// make sure these comments aren't attached again at the next token
+			p.exprComments[loc] = append(p.exprComments[loc], comments...)
+			p.lexer.CommentsBeforeToken = p.lexer.CommentsBeforeToken[:0]
+		} else {
+			p.exprComments[loc] = comments
+			p.lexer.CommentsBeforeToken = p.lexer.CommentsBeforeToken[0:]
+		}
 	}
 	return loc
 }
@@ -3888,6 +4085,11 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 			if p.lexer.HasNewlineBefore {
 				isSingleLine = false
 			}
+
+			// Keep same-line comments after the comma attached to this item
+			if p.trailingExprComments != nil && len(p.lexer.CommentsBeforeToken) > 0 {
+				p.saveTrailingExprCommentsAfterComma(items[len(items)-1])
+			}
 		}
 
 		if p.lexer.HasNewlineBefore {
@@ -4282,6 +4484,11 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			}
 		}
 
+		// Keep same-line comments after this expression attached to it
+		if p.trailingExprComments != nil && len(p.lexer.CommentsBeforeToken) > 0 {
+			p.saveTrailingExprComments(left)
+		}
+
 		// Stop now if this token is forbidden to follow a TypeScript "as" cast
 		operatorRange := p.lexer.Range()
 		if operatorRange.Loc == p.forbidSuffixAfterAsLoc {
@@ -5155,6 +5362,11 @@ func (p *parser) parseCallArgs() (args []js_ast.Expr, closeParenLoc logger.Loc,
 			isMultiLine = true
 		}
 		p.lexer.Next()
+
+		// Keep same-line comments after the comma attached to this argument
+		if p.trailingExprComments != nil && len(p.lexer.CommentsBeforeToken) > 0 {
+			p.saveTrailingExprCommentsAfterComma(arg)
+		}
 	}
 
 	if p.lexer.HasNewlineBefore {
@@ -6551,8 +6763,23 @@ func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOp
 			hasPropertyDecorator = true
 		}
 
+		var property js_ast.Property
+		var ok bool
+		if p.options.errorRecovery && !p.lexer.IsLogDisabled {
+			var hadError bool
+			if property, ok, hadError = p.parseClassPropertyWithErrorRecovery(opts); hadError {
+				// Let the caller report the missing "}" instead of looping forever
+				if p.lexer.Token == js_lexer.TEndOfFile {
+					break
+				}
+				continue
+			}
+		} else {
+			property, ok = p.parseProperty(p.saveExprCommentsHere(), js_ast.PropertyField, opts, nil)
+		}
+
 		// This property may turn out to be a type in TypeScript, which should be ignored
-		if property, ok := p.parseProperty(p.saveExprCommentsHere(), js_ast.PropertyField, opts, nil); ok {
+		if ok {
 			properties = append(properties, property)
 
 			// Forbid decorators on class constructors
@@ -7105,6 +7332,11 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		opts.hasNoSideEffectsComment = true
 	}
 
+	// Attach leading comments to the statement itself if we're preserving them
+	if p.options.preserveComments {
+		p.saveStmtComments(loc, p.takeStmtComments())
+	}
+
 	// Do not attach any leading comments to the next expression
 	p.lexer.CommentsBeforeToken = p.lexer.CommentsBeforeToken[:0]
 
@@ -8181,6 +8413,9 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			ref := p.declareSymbol(ast.SymbolImport, stmt.DefaultName.Loc, name)
 			p.isImportItem[ref] = true
 			stmt.DefaultName.Ref = ref
+			if p.options.mode == config.ModeBundle {
+				p.defaultImportPropertyAccesses[ref] = &defaultImportPropertyAccesses{names: make(map[string]bool)}
+			}
 		}
 
 		// Link each import item to the namespace
@@ -8501,6 +8736,113 @@ func (p *parser) forbidUsingInSwitch(loc logger.Loc) {
 		[]logger.MsgData{{Text: "Wrap this declaration in a block statement to use it here."}})
 }
 
+// In error recovery mode, a syntax error inside a statement drops that one
+// statement instead of aborting the whole parse. Any scopes that were pushed
+// and any symbols that were declared while parsing the statement are discarded
+// so that the scope tree still matches what the visit pass expects, and the
+// lexer skips ahead to the next statement boundary so that later syntax errors
+// are reported too.
+func (p *parser) parseStmtWithErrorRecovery(opts parseStmtOpts) (stmt js_ast.Stmt, ok bool) {
+	state := p.saveErrorRecoveryState()
+
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); isLexerPanic {
+			p.restoreErrorRecoveryState(state)
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	stmt = p.parseStmt(opts)
+	p.commitErrorRecoveryState(state)
+	return stmt, true
+}
+
+// Class bodies aren't made of statements, so they need their own recovery to
+// avoid dropping the whole class. A syntax error inside a class body drops
+// that one property instead and parsing resumes inside the class body.
+func (p *parser) parseClassPropertyWithErrorRecovery(opts propertyOpts) (property js_ast.Property, ok bool, hadError bool) {
+	state := p.saveErrorRecoveryState()
+
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); isLexerPanic {
+			p.restoreErrorRecoveryState(state)
+			property, ok, hadError = js_ast.Property{}, false, true
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	property, ok = p.parseProperty(p.saveExprCommentsHere(), js_ast.PropertyField, opts, nil)
+	p.commitErrorRecoveryState(state)
+	return
+}
+
+type errorRecoveryState struct {
+	fnOrArrowDataParse fnOrArrowDataParse
+	scope              *js_ast.Scope
+	start              logger.Loc
+	childCount         int
+	generatedCount     int
+	replacedCount      int
+	scopesInOrderCount int
+	undoLogCount       int
+	importRecordCount  int
+	allowIn            bool
+}
+
+func (p *parser) saveErrorRecoveryState() errorRecoveryState {
+	scope := p.currentScope
+	return errorRecoveryState{
+		fnOrArrowDataParse: p.fnOrArrowDataParse,
+		scope:              scope,
+		start:              p.lexer.Loc(),
+		childCount:         len(scope.Children),
+		generatedCount:     len(scope.Generated),
+		replacedCount:      len(scope.Replaced),
+		scopesInOrderCount: len(p.scopesInOrder),
+		undoLogCount:       len(p.scopeMemberUndoLog),
+		importRecordCount:  len(p.importRecords),
+		allowIn:            p.allowIn,
+	}
+}
+
+func (p *parser) restoreErrorRecoveryState(state errorRecoveryState) {
+	// Undo declarations in reverse order so that a name that was declared
+	// more than once ends up with its state from before the error
+	for i := len(p.scopeMemberUndoLog) - 1; i >= state.undoLogCount; i-- {
+		undo := p.scopeMemberUndoLog[i]
+		if undo.existed {
+			undo.scope.Members[undo.name] = undo.member
+			p.symbols[undo.member.Ref.InnerIndex] = undo.symbol
+		} else {
+			delete(undo.scope.Members, undo.name)
+		}
+	}
+	p.scopeMemberUndoLog = p.scopeMemberUndoLog[:state.undoLogCount]
+
+	scope := state.scope
+	p.currentScope = scope
+	scope.Children = scope.Children[:state.childCount]
+	scope.Generated = scope.Generated[:state.generatedCount]
+	scope.Replaced = scope.Replaced[:state.replacedCount]
+	p.scopesInOrder = p.scopesInOrder[:state.scopesInOrderCount]
+	p.importRecords = p.importRecords[:state.importRecordCount]
+	p.fnOrArrowDataParse = state.fnOrArrowDataParse
+	p.allowIn = state.allowIn
+	p.lexer.SkipToStatementBoundary(state.start)
+}
+
+func (p *parser) commitErrorRecoveryState(state errorRecoveryState) {
+	// Nothing before this point needs to be undone, and anything that encloses
+	// this point discards the scopes declared in here anyway if it's dropped
+	if state.undoLogCount == 0 {
+		p.scopeMemberUndoLog = p.scopeMemberUndoLog[:0]
+	}
+}
+
 func (p *parser) parseStmtsUpTo(end js_lexer.T, opts parseStmtOpts) []js_ast.Stmt {
 	stmts := []js_ast.Stmt{}
 	returnWithoutSemicolonStart := int32(-1)
@@ -8510,23 +8852,54 @@ func (p *parser) parseStmtsUpTo(end js_lexer.T, opts parseStmtOpts) []js_ast.Stm
 	for {
 		// Preserve some statement-level comments
 		comments := p.lexer.LegalCommentsBeforeToken
+		if p.lexer.Token == end && p.options.preserveComments && p.exprComments != nil {
+			// There's no statement after these comments to attach them to
+			comments = p.lexer.CommentsBeforeToken
+		}
 		if len(comments) > 0 {
 			for _, comment := range comments {
 				stmts = append(stmts, js_ast.Stmt{
 					Loc: comment.Loc,
 					Data: &js_ast.SComment{
 						Text:           p.source.CommentTextWithoutIndent(comment),
-						IsLegalComment: true,
+						IsLegalComment: p.isLegalCommentBeforeToken(comment),
 					},
 				})
 			}
 		}
 
 		if p.lexer.Token == end {
+			if p.lexer.KeepCommentsUntilConsumed {
+				// Don't attach these comments again at the next token
+				p.lexer.CommentsBeforeToken = p.lexer.CommentsBeforeToken[:0]
+			}
 			break
 		}
 
-		stmt := p.parseStmt(opts)
+		// Attach leading comments to wherever the statement ends up starting
+		// instead of to the first token, which may be a keyword like "export"
+		var stmtComments []string
+		if p.options.preserveComments {
+			stmtComments = p.takeStmtComments()
+		}
+
+		var stmt js_ast.Stmt
+		if p.options.errorRecovery && !p.lexer.IsLogDisabled {
+			var ok bool
+			if stmt, ok = p.parseStmtWithErrorRecovery(opts); !ok {
+				// Let the caller report the missing end token instead of looping forever
+				if p.lexer.Token == js_lexer.TEndOfFile {
+					break
+				}
+				continue
+			}
+		} else {
+			stmt = p.parseStmt(opts)
+		}
+		p.saveStmtComments(stmt.Loc, stmtComments)
+		if p.trailingStmtComments != nil && len(p.lexer.CommentsBeforeToken) > 0 {
+			p.saveTrailingStmtComments(stmt.Loc)
+		}
 
 		// Skip TypeScript types entirely
 		if p.options.ts.Parse {
@@ -8755,6 +9128,15 @@ func (p *parser) findSymbol(loc logger.Loc, name string) findSymbolResult {
 	return findSymbolResult{ref, declareLoc, isInsideWithScope}
 }
 
+func (p *parser) isLabelInScope(name string) bool {
+	for s := p.currentScope; s != nil && !s.Kind.StopsHoisting(); s = s.Parent {
+		if s.Kind == js_ast.ScopeLabel && name == p.symbols[s.Label.Ref.InnerIndex].OriginalName {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *parser) findLabelSymbol(loc logger.Loc, name string) (ref ast.Ref, isLoop bool, ok bool) {
 	for s := p.currentScope; s != nil && !s.Kind.StopsHoisting(); s = s.Parent {
 		if s.Kind == js_ast.ScopeLabel && name == p.symbols[s.Label.Ref.InnerIndex].OriginalName {
@@ -9015,6 +9397,12 @@ func (p *parser) visitStmts(stmts []js_ast.Stmt, kind stmtsKind) []js_ast.Stmt {
 			p.scopesInOrder = p.scopesInOrder[len(p.scopesInOrderForEnum[stmt.Loc]):]
 			continue
 		}
+		if p.reactRefresh && p.currentScope == p.moduleScope {
+			start := len(visited)
+			visited = p.visitAndAppendStmt(visited, stmt)
+			visited = p.refreshTopLevelStmts(visited, start)
+			continue
+		}
 		visited = p.visitAndAppendStmt(visited, stmt)
 	}
 
@@ -9745,6 +10133,15 @@ func (p *parser) substituteSingleUseSymbolInExpr(
 			return replacement, substituteSuccess
 		}
 
+		// The replacement for an inlined function argument can't observe or
+		// modify the other parameters of that function, so it's ok to reorder
+		// the replacement past them
+		for _, arg := range p.inlineFnRemainingArgs {
+			if id, ok := arg.Binding.Data.(*js_ast.BIdentifier); ok && id.Ref == e.Ref {
+				return expr, substituteContinue
+			}
+		}
+
 	case *js_ast.ESpread:
 		if value, status := p.substituteSingleUseSymbolInExpr(e.Value, ref, replacement, replacementCanBeRemoved); status != substituteContinue {
 			e.Value = value
@@ -10873,6 +11270,16 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			return stmts
 		}
 
+		// "(() => { a(); b() })()" => "a(); b();"
+		if p.options.inlineFunctions && p.options.minifySyntax {
+			if body, ok := p.maybeUnwrapIIFEStmt(s.Value); ok {
+				if stmtsCareAboutScope(body) {
+					return append(stmts, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SBlock{Stmts: body}})
+				}
+				return append(stmts, body...)
+			}
+		}
+
 	case *js_ast.SThrow:
 		s.Value = p.visitExpr(s.Value)
 
@@ -11317,7 +11724,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 	case *js_ast.SFunction:
-		p.visitFn(&s.Fn, s.Fn.OpenParenLoc, visitFnOpts{})
+		p.visitFn(&s.Fn, s.Fn.OpenParenLoc, visitFnOpts{isDeclaration: true})
 
 		// Strip this function declaration if it was overwritten
 		if p.symbols[s.Fn.Name.Ref.InnerIndex].Flags.Has(ast.RemoveOverwrittenFunctionDeclaration) && !s.IsExport {
@@ -11836,6 +12243,58 @@ func isSafeForConstLocalPrefix(expr js_ast.Expr) bool {
 	return false
 }
 
+// This finds top-level constants that are initialized before any other code
+// in this file is evaluated. Unlike the const local prefix, import statements
+// don't end this prefix. Instead the linker avoids inlining these constants
+// into other files if this file is part of an import cycle. Constants that are
+// used before they are declared (e.g. by an earlier function) are skipped.
+func (p *parser) computeTopLevelConstValues(parts []js_ast.Part) map[ast.Ref]js_ast.ConstValue {
+	var constValues map[ast.Ref]js_ast.ConstValue
+	usedBeforeDecl := make(map[ast.Ref]bool)
+
+	for _, part := range parts {
+		for _, stmt := range part.Stmts {
+			switch s := stmt.Data.(type) {
+			case *js_ast.SEmpty, *js_ast.SComment, *js_ast.SDirective, *js_ast.SFunction,
+				*js_ast.SImport, *js_ast.SExportFrom, *js_ast.SExportStar, *js_ast.SExportClause:
+				// These don't evaluate any code in this file
+
+			case *js_ast.SLocal:
+				for _, d := range s.Decls {
+					id, ok := d.Binding.Data.(*js_ast.BIdentifier)
+					if !ok {
+						// A non-identifier binding ends the prefix
+						return constValues
+					}
+					// Note: Top-level "const" may have been converted to "var" when bundling
+					if p.symbols[id.Ref.InnerIndex].Kind == ast.SymbolConst && d.ValueOrNil.Data != nil && !usedBeforeDecl[id.Ref] {
+						if value := js_ast.ExprToConstValue(d.ValueOrNil); value.Kind != js_ast.ConstValueNone {
+							if constValues == nil {
+								constValues = make(map[ast.Ref]js_ast.ConstValue)
+							}
+							constValues[id.Ref] = value
+							continue
+						}
+					}
+					if d.ValueOrNil.Data != nil && !isSafeForConstLocalPrefix(d.ValueOrNil) {
+						return constValues
+					}
+				}
+
+			default:
+				// Any other statement may evaluate code that observes later constants
+				return constValues
+			}
+		}
+
+		for ref := range part.SymbolUses {
+			usedBeforeDecl[ref] = true
+		}
+	}
+
+	return constValues
+}
+
 type relocateVarsMode uint8
 
 const (
@@ -11925,6 +12384,13 @@ func (p *parser) maybeTransposeIfExprChain(expr js_ast.Expr, visit func(js_ast.E
 }
 
 func (p *parser) maybeInlineIIFE(loc logger.Loc, e *js_ast.ECall) (js_ast.Expr, bool) {
+	// "(a => a + 1)(x)" => "(() => x + 1)()"
+	if len(e.Args) != 0 && p.options.inlineFunctions {
+		if arrow, ok := e.Target.Data.(*js_ast.EArrow); ok && !arrow.IsAsync {
+			p.substituteArgsIntoArrowBody(e, arrow)
+		}
+	}
+
 	if len(e.Args) != 0 {
 		return js_ast.Expr{}, false
 	}
@@ -11974,12 +12440,399 @@ func (p *parser) maybeInlineIIFE(loc logger.Loc, e *js_ast.ECall) (js_ast.Expr,
 					return value, true
 				}
 			}
+		} else if p.options.inlineFunctions && !e.CanBeUnwrappedIfUnused {
+			// "(() => { a(); b(); return c })()" => "(a(), b(), c)"
+			var value js_ast.Expr
+			for i, stmt := range stmts {
+				switch s := stmt.Data.(type) {
+				case *js_ast.SExpr:
+					if i+1 < len(stmts) {
+						value = js_ast.JoinWithComma(value, s.Value)
+					} else {
+						value = js_ast.JoinWithComma(value, js_ast.Expr{Loc: s.Value.Loc, Data: &js_ast.EUnary{Op: js_ast.UnOpVoid, Value: s.Value}})
+					}
+					continue
+
+				case *js_ast.SReturn:
+					if i+1 == len(stmts) {
+						if s.ValueOrNil.Data != nil {
+							value = js_ast.JoinWithComma(value, s.ValueOrNil)
+						} else {
+							value = js_ast.JoinWithComma(value, js_ast.Expr{Loc: stmt.Loc, Data: js_ast.EUndefinedShared})
+						}
+						return value, true
+					}
+				}
+				return js_ast.Expr{}, false
+			}
+			return value, true
 		}
 	}
 
 	return js_ast.Expr{}, false
 }
 
+// This substitutes the arguments of an immediately-invoked arrow function into
+// its body, starting from the last argument. Each substituted argument is then
+// removed from both the call and the arrow function. This stops at the first
+// argument that can't be substituted, which preserves evaluation order.
+func (p *parser) substituteArgsIntoArrowBody(call *js_ast.ECall, arrow *js_ast.EArrow) {
+	if arrow.HasRestArg {
+		return
+	}
+	for _, arg := range call.Args {
+		if _, ok := arg.Data.(*js_ast.ESpread); ok {
+			return
+		}
+	}
+
+	// Extra arguments are still evaluated, so only remove them if they are unused
+	for len(call.Args) > len(arrow.Args) && p.astHelpers.ExprCanBeRemovedIfUnused(call.Args[len(call.Args)-1]) {
+		call.Args = call.Args[:len(call.Args)-1]
+	}
+	if len(call.Args) > len(arrow.Args) {
+		return
+	}
+
+	// Make sure the symbol counts are accurate
+	oldIsControlFlowDead := p.isControlFlowDead
+	p.isControlFlowDead = false
+	defer func() { p.isControlFlowDead = oldIsControlFlowDead }()
+
+	for len(arrow.Args) > 0 {
+		last := len(arrow.Args) - 1
+		param := arrow.Args[last]
+		id, ok := param.Binding.Data.(*js_ast.BIdentifier)
+		if !ok || param.DefaultOrNil.Data != nil {
+			return
+		}
+
+		// A missing argument is undefined
+		hasArg := last < len(call.Args)
+		replacement := js_ast.Expr{Loc: param.Binding.Loc, Data: js_ast.EUndefinedShared}
+		if hasArg {
+			replacement = call.Args[last]
+		}
+
+		switch p.symbols[id.Ref.InnerIndex].UseCountEstimate {
+		case 0:
+			if !p.astHelpers.ExprCanBeRemovedIfUnused(replacement) {
+				return
+			}
+
+		case 1:
+			p.inlineFnRemainingArgs = arrow.Args[:last]
+			ok := len(arrow.Body.Block.Stmts) > 0 && p.substituteSingleUseSymbolInStmt(arrow.Body.Block.Stmts[0], id.Ref, replacement)
+			p.inlineFnRemainingArgs = nil
+			if !ok {
+				return
+			}
+
+		default:
+			return
+		}
+
+		arrow.Args = arrow.Args[:last]
+		if hasArg {
+			call.Args = call.Args[:last]
+		}
+	}
+}
+
+// "(() => { a(); b() })()" => "a(); b();"
+func (p *parser) maybeUnwrapIIFEStmt(value js_ast.Expr) ([]js_ast.Stmt, bool) {
+	if call, ok := value.Data.(*js_ast.ECall); ok && len(call.Args) == 0 &&
+		call.OptionalChain == js_ast.OptionalChainNone && !call.CanBeUnwrappedIfUnused {
+		if arrow, ok := call.Target.Data.(*js_ast.EArrow); ok && len(arrow.Args) == 0 && !arrow.IsAsync {
+			if stmts := arrow.Body.Block.Stmts; p.canMoveStmtsOutOfArrowBody(stmts) {
+				return stmts, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Statements can be moved out of an arrow function body into the enclosing
+// statement list if they don't return and don't declare anything that would
+// be hoisted to the top of the arrow function. Labels also can't be moved if
+// they would end up nested inside another label with the same name, which is
+// a syntax error. Arrow functions don't have their own "this" or "arguments"
+// so those don't need to be checked.
+func (p *parser) canMoveStmtsOutOfArrowBody(stmts []js_ast.Stmt) bool {
+	for _, stmt := range stmts {
+		if !p.canMoveStmtOutOfArrowBody(stmt) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) canMoveStmtOutOfArrowBody(stmt js_ast.Stmt) bool {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SEmpty, *js_ast.SComment, *js_ast.SDebugger, *js_ast.SExpr, *js_ast.SThrow,
+		*js_ast.SBreak, *js_ast.SContinue, *js_ast.SClass:
+		return true
+
+	case *js_ast.SLocal:
+		return s.Kind != js_ast.LocalVar
+
+	case *js_ast.SBlock:
+		return p.canMoveStmtsOutOfArrowBody(s.Stmts)
+
+	case *js_ast.SLabel:
+		return !p.isLabelInScope(p.symbols[s.Name.Ref.InnerIndex].OriginalName) && p.canMoveStmtOutOfArrowBody(s.Stmt)
+
+	case *js_ast.SIf:
+		return p.canMoveStmtOutOfArrowBody(s.Yes) && (s.NoOrNil.Data == nil || p.canMoveStmtOutOfArrowBody(s.NoOrNil))
+
+	case *js_ast.SFor:
+		return (s.InitOrNil.Data == nil || p.canMoveStmtOutOfArrowBody(s.InitOrNil)) && p.canMoveStmtOutOfArrowBody(s.Body)
+
+	case *js_ast.SForIn:
+		return p.canMoveStmtOutOfArrowBody(s.Init) && p.canMoveStmtOutOfArrowBody(s.Body)
+
+	case *js_ast.SForOf:
+		return p.canMoveStmtOutOfArrowBody(s.Init) && p.canMoveStmtOutOfArrowBody(s.Body)
+
+	case *js_ast.SWhile:
+		return p.canMoveStmtOutOfArrowBody(s.Body)
+
+	case *js_ast.SDoWhile:
+		return p.canMoveStmtOutOfArrowBody(s.Body)
+
+	case *js_ast.STry:
+		return p.canMoveStmtsOutOfArrowBody(s.Block.Stmts) &&
+			(s.Catch == nil || p.canMoveStmtsOutOfArrowBody(s.Catch.Block.Stmts)) &&
+			(s.Finally == nil || p.canMoveStmtsOutOfArrowBody(s.Finally.Block.Stmts))
+
+	case *js_ast.SSwitch:
+		for _, c := range s.Cases {
+			if !p.canMoveStmtsOutOfArrowBody(c.Body) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Everything else (e.g. "return", "var", and function declarations) can't
+	// be moved out of the function body
+	return false
+}
+
+// A function declaration can be inlined into its only call site if it's
+// small and doesn't depend on being a function (i.e. it doesn't use "this",
+// "arguments", or "new.target"). It's converted into an arrow function at the
+// call site, which is then simplified further.
+type inlineFnCandidate struct {
+	fn *js_ast.Fn
+}
+
+func (p *parser) maybeAddInlineFnCandidate(fn *js_ast.Fn) {
+	if p.currentScope != p.moduleScope && p.currentScope.Kind != js_ast.ScopeFunctionBody {
+		return
+	}
+	if fn.IsAsync || fn.IsGenerator || fn.HasRestArg || p.fnOnlyDataVisit.hasThisUsage || p.fnOnlyDataVisit.hasNewTargetUsage {
+		return
+	}
+	if fn.ArgumentsRef != ast.InvalidRef && p.symbols[fn.ArgumentsRef.InnerIndex].UseCountEstimate > 0 {
+		return
+	}
+	for _, arg := range fn.Args {
+		if _, ok := arg.Binding.Data.(*js_ast.BIdentifier); !ok || arg.DefaultOrNil.Data != nil || len(arg.Decorators) > 0 {
+			return
+		}
+	}
+
+	// Only inline small functions
+	switch stmts := fn.Body.Block.Stmts; len(stmts) {
+	case 0:
+	case 1:
+		switch stmts[0].Data.(type) {
+		case *js_ast.SReturn, *js_ast.SExpr:
+		default:
+			return
+		}
+	default:
+		return
+	}
+
+	p.inlineFnCandidates = append(p.inlineFnCandidates, inlineFnCandidate{fn: fn})
+}
+
+func (p *parser) recordInlineFnCall(ref ast.Ref, call *js_ast.ECall, hasSpread bool) {
+	if p.isControlFlowDead || p.symbols[ref.InnerIndex].Kind != ast.SymbolHoistedFunction {
+		return
+	}
+	if p.inlineFnCalls == nil {
+		p.inlineFnCalls = make(map[ast.Ref]*js_ast.ECall)
+	}
+
+	// A nil entry means this function can't be inlined
+	if _, ok := p.inlineFnCalls[ref]; ok || hasSpread || call.Kind != js_ast.NormalCall ||
+		call.OptionalChain != js_ast.OptionalChainNone || call.CanBeUnwrappedIfUnused {
+		p.inlineFnCalls[ref] = nil
+		return
+	}
+
+	// Recursive calls can't be inlined
+	for _, visiting := range p.inlineFnsBeingVisited {
+		if visiting == ref {
+			p.inlineFnCalls[ref] = nil
+			return
+		}
+	}
+
+	p.inlineFnCalls[ref] = call
+}
+
+// This returns the only call to this function if it can be inlined there
+func (p *parser) inlineFnCall(c inlineFnCandidate) *js_ast.ECall {
+	ref := c.fn.Name.Ref
+	symbol := &p.symbols[ref.InnerIndex]
+	if symbol.UseCountEstimate != 1 || symbol.Link != ast.InvalidRef || symbol.Flags.Has(ast.DidKeepName) || p.currentScope.ContainsDirectEval {
+		return nil
+	}
+	call := p.inlineFnCalls[ref]
+	if call == nil {
+		return nil
+	}
+	if id, ok := call.Target.Data.(*js_ast.EIdentifier); !ok || id.Ref != ref {
+		return nil
+	}
+	return call
+}
+
+// "function f(a) { return a + 1 } g(f(x))" => "g((a => a + 1)(x))" => "g(x + 1)"
+func (p *parser) inlineFnIntoCall(fn *js_ast.Fn, call *js_ast.ECall) {
+	ref := fn.Name.Ref
+	p.symbols[ref.InnerIndex].UseCountEstimate--
+	if part := p.currentPart; part != nil {
+		delete(part.SymbolUses, ref)
+		delete(part.SymbolCallUses, ref)
+	}
+
+	arrow := &js_ast.EArrow{Args: fn.Args, Body: fn.Body, PreferExpr: true}
+	call.Target = js_ast.Expr{Loc: call.Target.Loc, Data: arrow}
+	p.substituteArgsIntoArrowBody(call, arrow)
+}
+
+func findFnDeclaration(stmts []js_ast.Stmt, fn *js_ast.Fn) int {
+	for i, stmt := range stmts {
+		if s, ok := stmt.Data.(*js_ast.SFunction); ok && &s.Fn == fn {
+			if s.IsExport {
+				break
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// This is called at the end of a function body. All uses of the functions
+// declared in that function body have been visited by now.
+func (p *parser) inlineSingleUseFnsInStmts(stmts []js_ast.Stmt) []js_ast.Stmt {
+	for _, c := range p.inlineFnCandidates {
+		if call := p.inlineFnCall(c); call != nil {
+			if i := findFnDeclaration(stmts, c.fn); i != -1 {
+				p.inlineFnIntoCall(c.fn, call)
+				stmts = append(stmts[:i:i], stmts[i+1:]...)
+			}
+		}
+	}
+	return stmts
+}
+
+// This is called after all top-level statements have been visited. Top-level
+// functions are only inlined when tree shaking is enabled, since otherwise
+// they must be kept around. The function declaration may be in a different
+// part than the call, so everything about the function's part is moved into
+// the call's part.
+func (p *parser) inlineSingleUseTopLevelFns(parts []js_ast.Part) {
+	for _, c := range p.inlineFnCandidates {
+		ref := c.fn.Name.Ref
+		call := p.inlineFnCall(c)
+		if call == nil {
+			continue
+		}
+		isExported := false
+		for _, export := range p.namedExports {
+			if export.Ref == ref {
+				isExported = true
+				break
+			}
+		}
+		if isExported {
+			continue
+		}
+
+		// Find the part containing the declaration and the part containing the call
+		fnPart, callPart := -1, -1
+		stmtIndex := -1
+		for i, part := range parts {
+			if stmtIndex == -1 {
+				if j := findFnDeclaration(part.Stmts, c.fn); j != -1 {
+					fnPart, stmtIndex = i, j
+				}
+			}
+			if part.SymbolUses[ref].CountEstimate > 0 || part.SymbolCallUses[ref].CallCountEstimate > 0 {
+				callPart = i
+			}
+		}
+		if fnPart == -1 || callPart == -1 {
+			continue
+		}
+
+		from := &parts[fnPart]
+		to := &parts[callPart]
+		from.Stmts = append(from.Stmts[:stmtIndex:stmtIndex], from.Stmts[stmtIndex+1:]...)
+		if from != to {
+			for otherRef, use := range from.SymbolUses {
+				otherUse := to.SymbolUses[otherRef]
+				otherUse.CountEstimate += use.CountEstimate
+				to.SymbolUses[otherRef] = otherUse
+			}
+			for otherRef, use := range from.SymbolCallUses {
+				if to.SymbolCallUses == nil {
+					to.SymbolCallUses = make(map[ast.Ref]js_ast.SymbolCallUse)
+				}
+				otherUse := to.SymbolCallUses[otherRef]
+				otherUse.CallCountEstimate += use.CallCountEstimate
+				otherUse.SingleArgNonSpreadCallCountEstimate += use.SingleArgNonSpreadCallCountEstimate
+				to.SymbolCallUses[otherRef] = otherUse
+			}
+			for otherRef, uses := range from.ImportSymbolPropertyUses {
+				if to.ImportSymbolPropertyUses == nil {
+					to.ImportSymbolPropertyUses = make(map[ast.Ref]map[string]js_ast.SymbolUse)
+				}
+				toUses := to.ImportSymbolPropertyUses[otherRef]
+				if toUses == nil {
+					toUses = make(map[string]js_ast.SymbolUse)
+					to.ImportSymbolPropertyUses[otherRef] = toUses
+				}
+				for name, use := range uses {
+					otherUse := toUses[name]
+					otherUse.CountEstimate += use.CountEstimate
+					toUses[name] = otherUse
+				}
+			}
+			to.ImportRecordIndices = append(to.ImportRecordIndices, from.ImportRecordIndices...)
+			to.Scopes = append(to.Scopes, from.Scopes...)
+			from.SymbolUses = make(map[ast.Ref]js_ast.SymbolUse)
+			from.SymbolCallUses = nil
+			from.ImportSymbolPropertyUses = nil
+			from.ImportRecordIndices = nil
+			from.Scopes = nil
+			if len(from.Stmts) == 0 {
+				from.CanBeRemovedIfUnused = true
+			}
+		}
+
+		p.currentPart = to
+		p.inlineFnIntoCall(c.fn, call)
+		p.currentPart = nil
+	}
+}
+
 func (p *parser) iifeCanBeRemovedIfUnused(args []js_ast.Arg, body js_ast.FnBody) bool {
 	for _, arg := range args {
 		if arg.DefaultOrNil.Data != nil && !p.astHelpers.ExprCanBeRemovedIfUnused(arg.DefaultOrNil) {
@@ -12947,6 +13800,13 @@ func (p *parser) maybeRewritePropertyAccess(
 	isTemplateTag bool,
 	preferQuotedKey bool,
 ) (js_ast.Expr, bool) {
+	if id, ok := target.Data.(*js_ast.EImportIdentifier); ok && !p.isControlFlowDead {
+		if accesses, ok := p.defaultImportPropertyAccesses[id.Ref]; ok {
+			accesses.names[name] = true
+			accesses.count++
+		}
+	}
+
 	if id, ok := target.Data.(*js_ast.EIdentifier); ok {
 		// Rewrite property accesses on explicit namespace imports as an identifier.
 		// This lets us replace them easily in the printer to rebind them to
@@ -13602,6 +14462,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		if !p.fnOnlyDataVisit.isNewTargetAllowed {
 			p.log.AddError(&p.tracker, e.Range, "Cannot use \"new.target\" here:")
 		}
+		p.fnOnlyDataVisit.hasNewTargetUsage = true
 
 	case *js_ast.EString:
 		if e.LegacyOctalLoc.Start > 0 {
@@ -13710,6 +14571,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		e.MustKeepDueToWithStmt = result.isInsideWithScope
 		e.Ref = result.ref
 
+		// Check for usage of a global API that may need to be polyfilled
+		if p.polyfillBuiltins != 0 && p.symbols[result.ref.InnerIndex].Kind == ast.SymbolUnbound {
+			p.usedBuiltins |= compat.GlobalBuiltins[name] & p.polyfillBuiltins
+		}
+
 		// Handle referencing a class name within that class's computed property
 		// key. This is not allowed, and must fail at run-time:
 		//
@@ -14351,6 +15217,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		})
 		e.Target = target
 
+		// Check for usage of a static or prototype API that may need to be polyfilled
+		if p.polyfillBuiltins != 0 {
+			p.usedBuiltins |= p.builtinsForPropertyAccess(e.Target, e.Name) & p.polyfillBuiltins
+		}
+
 		// Lower "super.prop" if necessary
 		if e.OptionalChain == js_ast.OptionalChainNone && in.assignTarget == js_ast.AssignTargetNone &&
 			!isCallTarget && p.shouldLowerSuperPropertyAccess(e.Target) {
@@ -15317,6 +16188,10 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			}
 		}
 
+		if p.reactRefresh {
+			p.recordRefreshHookCall(e)
+		}
+
 		// Our hack for reading Yarn PnP files is implemented here:
 		if p.options.decodeHydrateRuntimeStateYarnPnP {
 			if id, ok := e.Target.Data.(*js_ast.EIdentifier); ok && p.symbols[id.Ref.InnerIndex].OriginalName == "hydrateRuntimeState" && len(e.Args) >= 1 {
@@ -15433,6 +16308,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				}
 			}
 
+			// Remember calls to function declarations since they may be inlined
+			if p.options.inlineFunctions && p.options.minifySyntax {
+				p.recordInlineFnCall(t.Ref, e, hasSpread)
+			}
+
 			// Handle certain special cases
 			if len(e.Args) <= 1 && !hasSpread {
 				if symbol := &p.symbols[t.Ref.InnerIndex]; symbol.Kind == ast.SymbolUnbound {
@@ -15895,6 +16775,10 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			}
 		}
 
+		if p.reactRefresh {
+			p.saveRefreshHookCalls(e.Body)
+		}
+
 		p.fnOnlyDataVisit.isInsideAsyncArrowFn = oldInsideAsyncArrowFn
 		p.fnOrArrowDataVisit = oldFnOrArrowData
 
@@ -16393,12 +17277,14 @@ func (v *binaryExprVisitor) visitRightAndFinish(p *parser) js_ast.Expr {
 	case js_ast.BinOpAdd:
 		// "'abc' + 'xyz'" => "'abcxyz'"
 		if result := js_ast.FoldStringAddition(e.Left, e.Right, js_ast.StringAdditionNormal); result.Data != nil {
+			p.moveExprComments(e.Right.Loc, result.Loc)
 			return result
 		}
 
 		if left, ok := e.Left.Data.(*js_ast.EBinary); ok && left.Op == js_ast.BinOpAdd {
 			// "x + 'abc' + 'xyz'" => "x + 'abcxyz'"
 			if result := js_ast.FoldStringAddition(left.Right, e.Right, js_ast.StringAdditionWithNestedLeft); result.Data != nil {
+				p.moveExprComments(e.Right.Loc, result.Loc)
 				return js_ast.Expr{Loc: v.loc, Data: &js_ast.EBinary{Op: left.Op, Left: left.Left, Right: result}}
 			}
 		}
@@ -17200,6 +18086,7 @@ type visitFnOpts struct {
 	isMethod               bool
 	isDerivedClassCtor     bool
 	isLoweredPrivateMethod bool
+	isDeclaration          bool
 }
 
 func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc, opts visitFnOpts) {
@@ -17239,11 +18126,31 @@ func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc, opts visitFnOpts) {
 	if fn.Name != nil {
 		p.validateDeclaredSymbolName(fn.Name.Loc, p.symbols[fn.Name.Ref.InnerIndex].OriginalName)
 	}
+	oldInlineFnCandidates := p.inlineFnCandidates
+	p.inlineFnCandidates = nil
+	if opts.isDeclaration {
+		p.inlineFnsBeingVisited = append(p.inlineFnsBeingVisited, fn.Name.Ref)
+	}
 	fn.Body.Block.Stmts = p.visitStmtsAndPrependTempRefs(fn.Body.Block.Stmts, prependTempRefsOpts{fnBodyLoc: &fn.Body.Loc, kind: stmtsFnBody})
+	if opts.isDeclaration {
+		p.inlineFnsBeingVisited = p.inlineFnsBeingVisited[:len(p.inlineFnsBeingVisited)-1]
+	}
+	if p.inlineFnCandidates != nil {
+		fn.Body.Block.Stmts = p.inlineSingleUseFnsInStmts(fn.Body.Block.Stmts)
+	}
+	p.inlineFnCandidates = oldInlineFnCandidates
 	p.popScope()
 	p.lowerFunction(&fn.IsAsync, &fn.IsGenerator, &fn.Args, fn.Body.Loc, &fn.Body.Block, nil, &fn.HasRestArg, false /* isArrow */)
 	p.popScope()
 
+	if opts.isDeclaration && p.options.inlineFunctions && p.options.minifySyntax {
+		p.maybeAddInlineFnCandidate(fn)
+	}
+
+	if p.reactRefresh {
+		p.saveRefreshHookCalls(fn.Body)
+	}
+
 	p.fnOrArrowDataVisit = oldFnOrArrowData
 	p.fnOnlyDataVisit = oldFnOnlyData
 }
@@ -17774,6 +18681,8 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 		namedImports:            make(map[ast.Ref]js_ast.NamedImport),
 		namedExports:            make(map[string]js_ast.NamedExport),
 
+		defaultImportPropertyAccesses: make(map[ast.Ref]*defaultImportPropertyAccesses),
+
 		// For JSX runtime imports
 		jsxRuntimeImports: make(map[string]ast.LocRef),
 		jsxLegacyImports:  make(map[string]ast.LocRef),
@@ -17785,6 +18694,11 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 		shouldAddKeyComment: options.mangleProps != nil || options.reserveProps != nil,
 
 		suppressWarningsAboutWeirdCode: helpers.IsInsideNodeModules(source.KeyPath.Text),
+		reactRefresh:                   options.jsx.Refresh && !helpers.IsInsideNodeModules(source.KeyPath.Text),
+	}
+
+	if options.polyfill != "" {
+		p.polyfillBuiltins = options.unsupportedBuiltins
 	}
 
 	if len(options.dropLabels) > 0 {
@@ -17796,11 +18710,22 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 
 	if !options.minifyWhitespace {
 		p.exprComments = make(map[logger.Loc][]string)
+
+		// Don't let the lexer drop comments that appear between tokens where the
+		// parser doesn't look for them (e.g. before a binary operator). Comments
+		// on the same line as the end of an expression or statement are attached
+		// to it. Other comments are attached to the next place where the parser
+		// looks for comments.
+		if options.preserveComments {
+			p.lexer.KeepCommentsUntilConsumed = true
+			p.trailingExprComments = make(map[logger.Loc][]js_ast.TrailingComment)
+			p.trailingStmtComments = make(map[logger.Loc][]string)
+		}
 	}
 
 	p.astHelpers = js_ast.MakeHelperContext(func(ref ast.Ref) bool {
 		return p.symbols[ref.InnerIndex].Kind == ast.SymbolUnbound
-	})
+	}, p.options.assumePure)
 
 	p.pushScopeForParsePass(js_ast.ScopeEntry, logger.Loc{Start: locModuleScope})
 
@@ -17834,7 +18759,13 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		options.jsx.ImportSource = defaultJSXImportSource
 	}
 
-	p := newParser(log, source, js_lexer.NewLexer(log, source, options.ts), &options)
+	var lexer js_lexer.Lexer
+	if options.errorRecovery {
+		lexer = js_lexer.NewLexerWithErrorRecovery(log, source, options.ts)
+	} else {
+		lexer = js_lexer.NewLexer(log, source, options.ts)
+	}
+	p := newParser(log, source, lexer, &options)
 
 	// Consume a leading hashbang comment
 	hashbang := ""
@@ -18121,6 +19052,13 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		})
 	}
 
+	// Inline top-level functions that are only called once. Don't do this if
+	// everything will be wrapped in a try/catch for "using" declarations since
+	// then all top-level statements end up in the same nested scope.
+	if p.inlineFnCandidates != nil && p.options.treeShaking && !p.willWrapModuleInTryCatchForUsing {
+		p.inlineSingleUseTopLevelFns(parts)
+	}
+
 	// Pop the module scope to apply the "ContainsDirectEval" rules
 	p.popScope()
 
@@ -18603,7 +19541,77 @@ func sortedKeysOfMapStringLocRef(in map[string]ast.LocRef) []string {
 	return keys
 }
 
+func (p *parser) builtinsForPropertyAccess(target js_ast.Expr, name string) compat.Builtin {
+	// "Object.hasOwn"
+	if id, ok := target.Data.(*js_ast.EIdentifier); ok {
+		if symbol := &p.symbols[id.Ref.InnerIndex]; symbol.Kind == ast.SymbolUnbound {
+			if statics, ok := compat.StaticBuiltins[symbol.OriginalName]; ok {
+				if builtin, ok := statics[name]; ok {
+					return builtin
+				}
+			}
+		}
+	}
+
+	// "array.at"
+	return compat.PrototypeBuiltins[name]
+}
+
+// Polyfills are imported for their side effects only, so these are generated
+// as bare import statements (e.g. "import 'core-js/modules/es.array.at.js'")
+func (p *parser) generatePolyfillImportStmts(parts []js_ast.Part) []js_ast.Part {
+	for builtin := compat.Builtin(1); builtin != 0 && builtin <= p.usedBuiltins; builtin <<= 1 {
+		if !p.usedBuiltins.Has(builtin) {
+			continue
+		}
+		path := p.options.polyfill + "/modules/" + builtin.Module() + ".js"
+		namespaceRef := p.newSymbol(ast.SymbolOther, "import_"+js_ast.GenerateNonUniqueNameFromPath(path))
+		p.moduleScope.Generated = append(p.moduleScope.Generated, namespaceRef)
+		importRecordIndex := p.addImportRecord(ast.ImportStmt, ast.EvaluationPhase, logger.Range{}, path, nil, ast.WasOriginallyBareImport)
+		parts = append(parts, js_ast.Part{
+			DeclaredSymbols:     []js_ast.DeclaredSymbol{{Ref: namespaceRef, IsTopLevel: true}},
+			ImportRecordIndices: []uint32{importRecordIndex},
+			Stmts: []js_ast.Stmt{{Data: &js_ast.SImport{
+				NamespaceRef:      namespaceRef,
+				ImportRecordIndex: importRecordIndex,
+			}}},
+		})
+	}
+	return parts
+}
+
+func (p *parser) computeTopLevelSymbolToParts(parts []js_ast.Part) map[ast.Ref][]uint32 {
+	// Map locals to parts
+	topLevelSymbolToParts := make(map[ast.Ref][]uint32)
+	for partIndex, part := range parts {
+		for _, declared := range part.DeclaredSymbols {
+			if declared.IsTopLevel {
+				// If this symbol was merged, use the symbol at the end of the
+				// linked list in the map. This is the case for multiple "var"
+				// declarations with the same name, for example.
+				ref := declared.Ref
+				for p.symbols[ref.InnerIndex].Link != ast.InvalidRef {
+					ref = p.symbols[ref.InnerIndex].Link
+				}
+				topLevelSymbolToParts[ref] = append(topLevelSymbolToParts[ref], uint32(partIndex))
+			}
+		}
+	}
+
+	// Pulling in the exports of this module always pulls in the export part
+	topLevelSymbolToParts[p.exportsRef] = append(topLevelSymbolToParts[p.exportsRef], js_ast.NSExportPartIndex)
+	return topLevelSymbolToParts
+}
+
 func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, directives []string) js_ast.AST {
+	// Insert an import statement for each polyfill that's needed. These come
+	// first so that the polyfills are installed before any other code runs.
+	// Note that the first part is reserved for the namespace export.
+	if p.usedBuiltins != 0 {
+		polyfills := p.generatePolyfillImportStmts([]js_ast.Part{before[js_ast.NSExportPartIndex]})
+		before = append(polyfills, before[js_ast.NSExportPartIndex+1:]...)
+	}
+
 	// Insert an import statement for any runtime imports we generated
 	if len(p.runtimeImports) > 0 && !p.options.omitRuntimeForTests {
 		keys := sortedKeysOfMapStringLocRef(p.runtimeImports)
@@ -18742,28 +19750,10 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 	}
 
 	// Analyze cross-part dependencies for tree shaking and code splitting
-	{
-		// Map locals to parts
-		p.topLevelSymbolToParts = make(map[ast.Ref][]uint32)
-		for partIndex, part := range parts {
-			for _, declared := range part.DeclaredSymbols {
-				if declared.IsTopLevel {
-					// If this symbol was merged, use the symbol at the end of the
-					// linked list in the map. This is the case for multiple "var"
-					// declarations with the same name, for example.
-					ref := declared.Ref
-					for p.symbols[ref.InnerIndex].Link != ast.InvalidRef {
-						ref = p.symbols[ref.InnerIndex].Link
-					}
-					p.topLevelSymbolToParts[ref] = append(
-						p.topLevelSymbolToParts[ref], uint32(partIndex))
-				}
-			}
-		}
+	p.topLevelSymbolToParts = p.computeTopLevelSymbolToParts(parts)
 
-		// Pulling in the exports of this module always pulls in the export part
-		p.topLevelSymbolToParts[p.exportsRef] = append(p.topLevelSymbolToParts[p.exportsRef], js_ast.NSExportPartIndex)
-	}
+	// Check whether this CommonJS module could be converted to ESM by the linker
+	commonJSToESM := p.convertCommonJSToESM(parts)
 
 	// Make a wrapper symbol in case we need to be wrapped in a closure
 	wrapperRef := p.newSymbol(ast.SymbolOther, "require_"+p.source.IdentifierName)
@@ -18777,6 +19767,13 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		nestedScopeSlotCounts = renamer.AssignNestedScopeSlots(p.moduleScope, p.symbols)
 	}
 
+	// Top-level constants are only made available to the linker, which may
+	// inline them into other files when they are imported
+	var topLevelConstValues map[ast.Ref]js_ast.ConstValue
+	if p.options.minifySyntax && p.options.mode == config.ModeBundle {
+		topLevelConstValues = p.computeTopLevelConstValues(parts)
+	}
+
 	exportsKind := js_ast.ExportsNone
 	usesExportsRef := p.symbols[p.exportsRef.InnerIndex].UseCountEstimate > 0
 	usesModuleRef := p.symbols[p.moduleRef.InnerIndex].UseCountEstimate > 0
@@ -18808,6 +19805,18 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		}
 	}
 
+	// Only keep track of default imports that are never used for anything other
+	// than property accesses. Any other use could read any property.
+	var defaultImportPropertyAccesses map[ast.Ref]map[string]bool
+	for ref, accesses := range p.defaultImportPropertyAccesses {
+		if accesses.count > 0 && accesses.count == p.symbols[ref.InnerIndex].UseCountEstimate {
+			if defaultImportPropertyAccesses == nil {
+				defaultImportPropertyAccesses = make(map[ast.Ref]map[string]bool)
+			}
+			defaultImportPropertyAccesses[ref] = accesses.names
+		}
+	}
+
 	return js_ast.AST{
 		Parts:                           parts,
 		ModuleTypeData:                  p.options.moduleTypeData,
@@ -18821,9 +19830,13 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		Directives:                      directives,
 		NamedImports:                    p.namedImports,
 		NamedExports:                    p.namedExports,
+		DefaultImportPropertyAccesses:   defaultImportPropertyAccesses,
 		TSEnums:                         p.tsEnums,
 		ConstValues:                     p.constValues,
+		TopLevelConstValues:             topLevelConstValues,
 		ExprComments:                    p.exprComments,
+		TrailingExprComments:            p.trailingExprComments,
+		TrailingStmtComments:            p.trailingStmtComments,
 		NestedScopeSlotCounts:           nestedScopeSlotCounts,
 		TopLevelSymbolToPartsFromParser: p.topLevelSymbolToParts,
 		ExportStarImportRecords:         p.exportStarImportRecords,
@@ -18837,6 +19850,7 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		UsesExportsRef: usesExportsRef,
 		UsesModuleRef:  usesModuleRef,
 		ExportsKind:    exportsKind,
+		CommonJSToESM:  commonJSToESM,
 
 		// ES6 features
 		ExportKeyword:            p.esmExportKeyword,
@@ -0,0 +1,536 @@
+package js_parser
+
+import (
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// CommonJS modules are normally wrapped in a closure when bundled, which means
+// none of their code can be tree-shaken. However, many CommonJS modules (and
+// especially the ones generated by the TypeScript compiler) only ever use
+// "exports" and "module" to assign to export properties at the top level:
+//
+//	Object.defineProperty(exports, "__esModule", { value: true });
+//	exports.bar = exports.foo = void 0;
+//	exports.foo = 123;
+//	exports.bar = function () {};
+//
+// If every use of "exports" and "module" in a file has one of these forms,
+// this generates an alternate version of the affected parts where each export
+// is a top-level variable instead:
+//
+//	var foo = 123;
+//	var bar = function () {};
+//
+// Whether or not these alternate parts are used is decided by the linker
+// because it's only valid if nothing observes the "module.exports" object
+// itself, which depends on how this file is imported by other files.
+
+type commonJSExportTarget struct {
+	alias     string
+	loc       logger.Loc
+	isDropped bool
+}
+
+type commonJSExportChain struct {
+	// These are ordered from outermost to innermost, so "a = b = c" is "[a, b]"
+	targets []commonJSExportTarget
+
+	// This is either the value being assigned or the return value of a getter
+	value     js_ast.Expr
+	getterRef ast.Ref
+}
+
+type commonJSExportStmt struct {
+	chains    []commonJSExportChain
+	partIndex uint32
+	stmtIndex uint32
+}
+
+func (p *parser) convertCommonJSToESM(parts []js_ast.Part) *js_ast.CommonJSToESM {
+	if p.options.mode != config.ModeBundle || !p.options.treeShaking || p.isFileConsideredToHaveESMExports ||
+		p.hasTopLevelReturn || p.moduleScope.ContainsDirectEval || p.esmImportMeta.Len > 0 || p.topLevelAwaitKeyword.Len > 0 {
+		return nil
+	}
+	exportsUseCount := p.symbols[p.exportsRef.InnerIndex].UseCountEstimate
+	moduleUseCount := p.symbols[p.moduleRef.InnerIndex].UseCountEstimate
+	if exportsUseCount == 0 && moduleUseCount == 0 {
+		return nil
+	}
+
+	var exportStmts []commonJSExportStmt
+	var exportsUses uint32
+	var moduleUses uint32
+	sawExportsObject := false
+	sawModuleExportsProperty := false
+
+	for partIndex, part := range parts {
+		for stmtIndex, stmt := range part.Stmts {
+			sexpr, ok := stmt.Data.(*js_ast.SExpr)
+			if !ok {
+				continue
+			}
+			exportStmt := commonJSExportStmt{partIndex: uint32(partIndex), stmtIndex: uint32(stmtIndex)}
+
+			// "module.exports = { foo, bar: 123 }"
+			if binary, ok := sexpr.Value.Data.(*js_ast.EBinary); ok && binary.Op == js_ast.BinOpAssign && p.isModuleExports(binary.Left) {
+				object, ok := binary.Right.Data.(*js_ast.EObject)
+				if !ok || sawExportsObject || sawModuleExportsProperty || exportsUses > 0 {
+					return nil
+				}
+				seen := make(map[string]bool)
+				for _, property := range object.Properties {
+					key, ok := property.Key.Data.(*js_ast.EString)
+					if !ok || property.Kind != js_ast.PropertyField || property.Flags.Has(js_ast.PropertyIsComputed) || property.ValueOrNil.Data == nil {
+						return nil
+					}
+					alias := helpers.UTF16ToString(key.Value)
+					if alias == "__proto__" || seen[alias] {
+						return nil
+					}
+					seen[alias] = true
+					exportStmt.chains = append(exportStmt.chains, commonJSExportChain{
+						targets:   []commonJSExportTarget{{alias: alias, loc: property.Key.Loc}},
+						value:     property.ValueOrNil,
+						getterRef: ast.InvalidRef,
+					})
+				}
+				moduleUses++
+				sawExportsObject = true
+				exportStmts = append(exportStmts, exportStmt)
+				continue
+			}
+
+			// "Object.defineProperty(exports, 'foo', { enumerable: true, get: function () { return foo } })"
+			if call, ok := sexpr.Value.Data.(*js_ast.ECall); ok {
+				alias, aliasLoc, getterRef, ok := p.matchCommonJSDefineProperty(call)
+				if !ok || sawExportsObject {
+					continue
+				}
+				exportsUses++
+				if getterRef != ast.InvalidRef {
+					exportStmt.chains = append(exportStmt.chains, commonJSExportChain{
+						targets:   []commonJSExportTarget{{alias: alias, loc: aliasLoc}},
+						getterRef: getterRef,
+					})
+				}
+				exportStmts = append(exportStmts, exportStmt)
+				continue
+			}
+
+			// "exports.foo = exports.bar = 123, module.exports.baz = 456"
+			var stmtExportsUses uint32
+			var stmtModuleUses uint32
+			for _, expr := range flattenCommaExpr(sexpr.Value, nil) {
+				chain := commonJSExportChain{getterRef: ast.InvalidRef}
+				for {
+					binary, ok := expr.Data.(*js_ast.EBinary)
+					if !ok || binary.Op != js_ast.BinOpAssign {
+						break
+					}
+					alias, aliasLoc, isModule, ok := p.matchCommonJSExportProperty(binary.Left)
+					if !ok {
+						break
+					}
+					if isModule {
+						stmtModuleUses++
+					} else {
+						stmtExportsUses++
+					}
+					chain.targets = append(chain.targets, commonJSExportTarget{alias: alias, loc: aliasLoc})
+					expr = binary.Right
+				}
+				if len(chain.targets) == 0 {
+					// Leave the rest of the statement alone. If it uses "exports" or
+					// "module", the use count check below will fail.
+					exportStmt.chains = nil
+					break
+				}
+				chain.value = expr
+				exportStmt.chains = append(exportStmt.chains, chain)
+			}
+			if exportStmt.chains != nil {
+				exportsUses += stmtExportsUses
+				moduleUses += stmtModuleUses
+				if stmtModuleUses > 0 && !sawExportsObject {
+					sawModuleExportsProperty = true
+				}
+				exportStmts = append(exportStmts, exportStmt)
+			}
+		}
+	}
+
+	// Bail if there are any uses of "exports" or "module" that we didn't match.
+	// Also "exports" is no longer the exports object after "module.exports" has
+	// been reassigned, so we don't attempt to handle that combination.
+	if exportsUses != exportsUseCount || moduleUses != moduleUseCount || (sawExportsObject && exportsUses > 0) {
+		return nil
+	}
+
+	// Assignments of "undefined" that come before the real assignment are a
+	// TypeScript idiom that can be dropped. Anything else that assigns to the
+	// same export more than once is too complicated to convert.
+	assigned := make(map[string]bool)
+	initialized := make(map[string]bool)
+	for _, exportStmt := range exportStmts {
+		for _, chain := range exportStmt.chains {
+			isPlaceholder := chain.getterRef == ast.InvalidRef && isUndefinedPlaceholder(chain.value)
+			for i := len(chain.targets) - 1; i >= 0; i-- {
+				alias := chain.targets[i].alias
+				if assigned[alias] {
+					return nil
+				}
+				if isPlaceholder {
+					initialized[alias] = true
+				} else {
+					assigned[alias] = true
+				}
+			}
+		}
+	}
+	for i := range exportStmts {
+		for j := range exportStmts[i].chains {
+			chain := &exportStmts[i].chains[j]
+			isPlaceholder := chain.getterRef == ast.InvalidRef && isUndefinedPlaceholder(chain.value)
+			for k := len(chain.targets) - 1; k >= 0; k-- {
+				target := &chain.targets[k]
+				if target.alias == "__esModule" {
+					// The marker is only meaningful for the "module.exports" object
+					target.isDropped = true
+				} else if isPlaceholder {
+					if assigned[target.alias] || !initialized[target.alias] {
+						target.isDropped = true
+					}
+					initialized[target.alias] = false
+				}
+			}
+		}
+	}
+
+	// Generate a top-level symbol for each export
+	result := &js_ast.CommonJSToESM{
+		NamedExports: make(map[string]js_ast.NamedExport),
+	}
+	replacements := make(map[uint32][]js_ast.Part)
+	merged := make(map[uint32]js_ast.Part)
+	mergedStmts := make(map[uint32]map[uint32][]js_ast.Stmt)
+	for _, exportStmt := range exportStmts {
+		original := parts[exportStmt.partIndex]
+		var chainParts []js_ast.Part
+
+		for _, chain := range exportStmt.chains {
+			var stmts []js_ast.Stmt
+			var declared []js_ast.DeclaredSymbol
+			var uses []ast.Ref
+
+			if chain.getterRef != ast.InvalidRef {
+				target := chain.targets[0]
+				if target.alias != "__esModule" {
+					result.NamedExports[target.alias] = js_ast.NamedExport{Ref: chain.getterRef, AliasLoc: target.loc}
+				}
+				continue
+			}
+
+			var decls []js_ast.Decl
+			value := chain.value
+			for i := len(chain.targets) - 1; i >= 0; i-- {
+				target := chain.targets[i]
+				if target.isDropped {
+					continue
+				}
+				name := js_ast.ForceValidIdentifier("", target.alias)
+				if target.alias == "default" {
+					name = p.source.IdentifierName + "_default"
+				}
+				ref := p.newSymbol(ast.SymbolHoisted, name)
+				result.NamedExports[target.alias] = js_ast.NamedExport{Ref: ref, AliasLoc: target.loc}
+				declared = append(declared, js_ast.DeclaredSymbol{Ref: ref, IsTopLevel: true})
+				decls = append(decls, js_ast.Decl{
+					Binding:    js_ast.Binding{Loc: target.loc, Data: &js_ast.BIdentifier{Ref: ref}},
+					ValueOrNil: value,
+				})
+				value = js_ast.Expr{Loc: target.loc, Data: &js_ast.EIdentifier{Ref: ref}}
+				uses = append(uses, ref)
+			}
+
+			if len(decls) > 0 {
+				// The last reference to the innermost symbol was never used
+				uses = uses[:len(uses)-1]
+				stmts = append(stmts, js_ast.Stmt{Loc: decls[0].Binding.Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}})
+			} else if !p.astHelpers.ExprCanBeRemovedIfUnused(chain.value) {
+				stmts = append(stmts, js_ast.Stmt{Loc: chain.value.Loc, Data: &js_ast.SExpr{Value: chain.value}})
+			}
+
+			// Each chain gets its own copy of the part. Symbol uses aren't tracked
+			// per chain, so each copy conservatively uses everything the original
+			// statement used unless the value is simple enough to check here.
+			part := p.cloneCommonJSExportPart(original)
+			isSimple := true
+			switch e := chain.value.Data.(type) {
+			case *js_ast.EIdentifier:
+				part.SymbolUses = map[ast.Ref]js_ast.SymbolUse{e.Ref: {CountEstimate: 1}}
+			case *js_ast.EImportIdentifier:
+				part.SymbolUses = map[ast.Ref]js_ast.SymbolUse{e.Ref: {CountEstimate: 1}}
+			case *js_ast.EString, *js_ast.ENumber, *js_ast.EBoolean, *js_ast.ENull, *js_ast.EUndefined:
+				part.SymbolUses = make(map[ast.Ref]js_ast.SymbolUse)
+			default:
+				isSimple = false
+			}
+			if isSimple {
+				part.ImportRecordIndices = nil
+				part.SymbolCallUses = nil
+				part.ImportSymbolPropertyUses = nil
+			}
+			part.Stmts = stmts
+			part.DeclaredSymbols = declared
+			for _, ref := range uses {
+				use := part.SymbolUses[ref]
+				use.CountEstimate++
+				part.SymbolUses[ref] = use
+			}
+			chainParts = append(chainParts, part)
+		}
+
+		// If the statement is the only thing in its part, each chain can become
+		// a separate part so that unused exports can be removed individually:
+		//
+		//	module.exports = { foo, bar: 123 }
+		//
+		// This isn't done if the statement contains nested scopes (e.g. function
+		// expressions) since scopes aren't tracked per chain. Otherwise everything
+		// is merged back into a copy of the original part.
+		if len(original.Stmts) == 1 && len(original.DeclaredSymbols) == 0 && len(original.Scopes) == 0 {
+			var split []js_ast.Part
+			for _, part := range chainParts {
+				if len(part.Stmts) > 0 {
+					part.CanBeRemovedIfUnused = p.astHelpers.StmtsCanBeRemovedIfUnused(part.Stmts, 0)
+					split = append(split, part)
+				}
+			}
+			replacements[exportStmt.partIndex] = split
+			continue
+		}
+
+		part, ok := merged[exportStmt.partIndex]
+		if !ok {
+			part = p.cloneCommonJSExportPart(original)
+			mergedStmts[exportStmt.partIndex] = make(map[uint32][]js_ast.Stmt)
+		}
+		var stmts []js_ast.Stmt
+		for _, chainPart := range chainParts {
+			stmts = append(stmts, chainPart.Stmts...)
+			part.DeclaredSymbols = append(part.DeclaredSymbols, chainPart.DeclaredSymbols...)
+			for ref, use := range chainPart.SymbolUses {
+				if old, ok := part.SymbolUses[ref]; !ok || old.CountEstimate < use.CountEstimate {
+					part.SymbolUses[ref] = use
+				}
+			}
+		}
+		mergedStmts[exportStmt.partIndex][exportStmt.stmtIndex] = stmts
+		merged[exportStmt.partIndex] = part
+	}
+
+	if len(exportStmts) > 0 {
+		first := exportStmts[0]
+		result.ExportKeyword = js_lexer.RangeOfIdentifier(p.source, parts[first.partIndex].Stmts[first.stmtIndex].Loc)
+	}
+
+	// Replace the original statements in the merged parts
+	for partIndex, part := range merged {
+		var stmts []js_ast.Stmt
+		for stmtIndex, stmt := range part.Stmts {
+			if replacement, ok := mergedStmts[partIndex][uint32(stmtIndex)]; ok {
+				stmts = append(stmts, replacement...)
+			} else {
+				stmts = append(stmts, stmt)
+			}
+		}
+		part.Stmts = stmts
+		part.CanBeRemovedIfUnused = p.astHelpers.StmtsCanBeRemovedIfUnused(stmts, 0)
+		replacements[partIndex] = []js_ast.Part{part}
+	}
+
+	for partIndex, part := range parts {
+		if replacement, ok := replacements[uint32(partIndex)]; ok {
+			result.Parts = append(result.Parts, replacement...)
+		} else {
+			result.Parts = append(result.Parts, part)
+		}
+	}
+	result.TopLevelSymbolToParts = p.computeTopLevelSymbolToParts(result.Parts)
+	return result
+}
+
+// Copies a part without the uses of "exports" and "module"
+func (p *parser) cloneCommonJSExportPart(part js_ast.Part) js_ast.Part {
+	uses := make(map[ast.Ref]js_ast.SymbolUse, len(part.SymbolUses))
+	for ref, use := range part.SymbolUses {
+		if ref != p.exportsRef && ref != p.moduleRef {
+			uses[ref] = use
+		}
+	}
+	part.SymbolUses = uses
+	part.DeclaredSymbols = append([]js_ast.DeclaredSymbol{}, part.DeclaredSymbols...)
+	return part
+}
+
+// Returns true for "module.exports"
+func (p *parser) isModuleExports(expr js_ast.Expr) bool {
+	if dot, ok := expr.Data.(*js_ast.EDot); ok && dot.Name == "exports" && dot.OptionalChain == js_ast.OptionalChainNone {
+		if id, ok := dot.Target.Data.(*js_ast.EIdentifier); ok && id.Ref == p.moduleRef {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches "exports.foo", "exports['foo']", "module.exports.foo", and
+// "module.exports['foo']"
+func (p *parser) matchCommonJSExportProperty(expr js_ast.Expr) (alias string, aliasLoc logger.Loc, isModule bool, ok bool) {
+	var target js_ast.Expr
+	switch e := expr.Data.(type) {
+	case *js_ast.EDot:
+		if e.OptionalChain != js_ast.OptionalChainNone {
+			return
+		}
+		target, alias, aliasLoc = e.Target, e.Name, e.NameLoc
+
+	case *js_ast.EIndex:
+		index, isString := e.Index.Data.(*js_ast.EString)
+		if e.OptionalChain != js_ast.OptionalChainNone || !isString {
+			return
+		}
+		target, alias, aliasLoc = e.Target, helpers.UTF16ToString(index.Value), e.Index.Loc
+
+	default:
+		return
+	}
+
+	if id, isIdentifier := target.Data.(*js_ast.EIdentifier); isIdentifier && id.Ref == p.exportsRef {
+		ok = true
+	} else if p.isModuleExports(target) {
+		isModule = true
+		ok = true
+	}
+	return
+}
+
+// Matches "Object.defineProperty(exports, ...)" for the "__esModule" marker
+// and for live bindings to top-level symbols. The returned symbol is invalid
+// for the "__esModule" marker.
+func (p *parser) matchCommonJSDefineProperty(call *js_ast.ECall) (alias string, aliasLoc logger.Loc, getterRef ast.Ref, ok bool) {
+	getterRef = ast.InvalidRef
+	dot, isDot := call.Target.Data.(*js_ast.EDot)
+	if !isDot || dot.Name != "defineProperty" || dot.OptionalChain != js_ast.OptionalChainNone ||
+		call.OptionalChain != js_ast.OptionalChainNone || len(call.Args) != 3 {
+		return
+	}
+	if id, isIdentifier := dot.Target.Data.(*js_ast.EIdentifier); !isIdentifier ||
+		p.symbols[id.Ref.InnerIndex].Kind != ast.SymbolUnbound || p.symbols[id.Ref.InnerIndex].OriginalName != "Object" {
+		return
+	}
+	if id, isIdentifier := call.Args[0].Data.(*js_ast.EIdentifier); !isIdentifier || id.Ref != p.exportsRef {
+		return
+	}
+	key, isString := call.Args[1].Data.(*js_ast.EString)
+	object, isObject := call.Args[2].Data.(*js_ast.EObject)
+	if !isString || !isObject {
+		return
+	}
+	alias, aliasLoc = helpers.UTF16ToString(key.Value), call.Args[1].Loc
+
+	// "Object.defineProperty(exports, '__esModule', { value: true })"
+	if alias == "__esModule" {
+		ok = p.astHelpers.ExprCanBeRemovedIfUnused(call.Args[2])
+		return
+	}
+
+	// "{ enumerable: true, get: function () { return foo } }"
+	for _, property := range object.Properties {
+		key, isString := property.Key.Data.(*js_ast.EString)
+		if !isString || property.Flags.Has(js_ast.PropertyIsComputed) {
+			return
+		}
+		switch helpers.UTF16ToString(key.Value) {
+		case "enumerable":
+			if value, isBoolean := property.ValueOrNil.Data.(*js_ast.EBoolean); property.Kind != js_ast.PropertyField || !isBoolean || !value.Value {
+				return
+			}
+
+		case "get":
+			var body *js_ast.FnBody
+			switch value := property.ValueOrNil.Data.(type) {
+			case *js_ast.EFunction:
+				if property.Kind == js_ast.PropertyField || property.Kind == js_ast.PropertyMethod {
+					if len(value.Fn.Args) == 0 && !value.Fn.IsAsync && !value.Fn.IsGenerator {
+						body = &value.Fn.Body
+					}
+				}
+			case *js_ast.EArrow:
+				if property.Kind == js_ast.PropertyField && len(value.Args) == 0 && !value.IsAsync {
+					body = &value.Body
+				}
+			}
+			if body == nil || len(body.Block.Stmts) != 1 {
+				return
+			}
+			ret, isReturn := body.Block.Stmts[0].Data.(*js_ast.SReturn)
+			if !isReturn {
+				return
+			}
+			id, isIdentifier := ret.ValueOrNil.Data.(*js_ast.EIdentifier)
+			if !isIdentifier || !p.isTopLevelDeclaredSymbol(id.Ref) {
+				return
+			}
+			getterRef = id.Ref
+
+		default:
+			return
+		}
+	}
+	ok = getterRef != ast.InvalidRef
+	return
+}
+
+// Returns true if this symbol is declared in the top-level scope of this file
+// and isn't an import or one of the CommonJS-specific symbols
+func (p *parser) isTopLevelDeclaredSymbol(ref ast.Ref) bool {
+	if ref == p.exportsRef || ref == p.moduleRef || ref == p.requireRef {
+		return false
+	}
+	if _, ok := p.namedImports[ref]; ok {
+		return false
+	}
+	symbol := &p.symbols[ref.InnerIndex]
+	if symbol.Kind == ast.SymbolUnbound || symbol.Kind == ast.SymbolImport {
+		return false
+	}
+	member, ok := p.moduleScope.Members[symbol.OriginalName]
+	return ok && member.Ref == ref
+}
+
+func isUndefinedPlaceholder(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EUndefined:
+		return true
+
+	case *js_ast.EUnary:
+		if e.Op == js_ast.UnOpVoid {
+			_, ok := e.Value.Data.(*js_ast.ENumber)
+			return ok
+		}
+	}
+	return false
+}
+
+func flattenCommaExpr(expr js_ast.Expr, exprs []js_ast.Expr) []js_ast.Expr {
+	if binary, ok := expr.Data.(*js_ast.EBinary); ok && binary.Op == js_ast.BinOpComma {
+		exprs = flattenCommaExpr(binary.Left, exprs)
+		return flattenCommaExpr(binary.Right, exprs)
+	}
+	return append(exprs, expr)
+}
@@ -0,0 +1,361 @@
+package js_parser
+
+// This file implements the React Fast Refresh transform. It mirrors what the
+// "react-refresh/babel" plugin does so that the output can be used with the
+// "react-refresh/runtime" package. Top-level components are registered with
+// "$RefreshReg$" and functions that call hooks are given a signature using
+// "$RefreshSig$". Both of these globals are expected to be provided by the
+// development server.
+
+import (
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+type refreshHookCall struct {
+	call *js_ast.ECall
+	name string
+
+	// This is the source text of the argument that holds the initial state for
+	// "useState" and "useReducer". Editing the initial state resets the state.
+	initialStateText string
+}
+
+// Hooks are detected using the same naming convention as React's lint rules
+func refreshHookName(symbols []ast.Symbol, target js_ast.Expr) string {
+	var name string
+	switch t := target.Data.(type) {
+	case *js_ast.EIdentifier:
+		name = symbols[t.Ref.InnerIndex].OriginalName
+	case *js_ast.EImportIdentifier:
+		name = symbols[t.Ref.InnerIndex].OriginalName
+	case *js_ast.EDot:
+		name = t.Name
+	default:
+		return ""
+	}
+	if len(name) > 3 && strings.HasPrefix(name, "use") && name[3] >= 'A' && name[3] <= 'Z' {
+		return name
+	}
+	return ""
+}
+
+// Calls to these hooks don't need to be checked by the runtime since they
+// can't change between edits
+func isBuiltInReactHook(name string) bool {
+	switch name {
+	case "useActionState", "useCallback", "useContext", "useDebugValue", "useDeferredValue",
+		"useEffect", "useFormState", "useFormStatus", "useId", "useImperativeHandle",
+		"useInsertionEffect", "useLayoutEffect", "useMemo", "useOptimistic", "useReducer",
+		"useRef", "useState", "useSyncExternalStore", "useTransition":
+		return true
+	}
+	return false
+}
+
+// Components are detected by naming convention
+func isRefreshComponentName(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+func (p *parser) recordRefreshHookCall(e *js_ast.ECall) {
+	if p.fnOrArrowDataVisit.isOutsideFnOrArrow {
+		return
+	}
+	name := refreshHookName(p.symbols, e.Target)
+	if name == "" {
+		return
+	}
+	hook := refreshHookCall{call: e, name: name}
+	stateArg := -1
+	if name == "useState" {
+		stateArg = 0
+	} else if name == "useReducer" {
+		stateArg = 1
+	}
+	if stateArg != -1 && stateArg < len(e.Args) {
+		end := e.CloseParenLoc
+		if stateArg+1 < len(e.Args) {
+			end = e.Args[stateArg+1].Loc
+		}
+		text := p.sourceTextBetween(e.Args[stateArg].Loc, end)
+		hook.initialStateText = strings.TrimSuffix(text, ",")
+	}
+	p.fnOrArrowDataVisit.refreshHookCalls = append(p.fnOrArrowDataVisit.refreshHookCalls, hook)
+}
+
+// This is called after a function or arrow function body has been visited
+func (p *parser) saveRefreshHookCalls(body js_ast.FnBody) {
+	if len(p.fnOrArrowDataVisit.refreshHookCalls) > 0 {
+		if p.refreshHookCallsForFnBody == nil {
+			p.refreshHookCallsForFnBody = make(map[logger.Loc][]refreshHookCall)
+		}
+		p.refreshHookCallsForFnBody[body.Loc] = p.fnOrArrowDataVisit.refreshHookCalls
+	}
+}
+
+func (p *parser) sourceTextBetween(start logger.Loc, end logger.Loc) string {
+	if start.Start < 0 || start.Start > end.Start || int(end.Start) > len(p.source.Contents) {
+		return ""
+	}
+	return strings.TrimSpace(p.source.Contents[start.Start:end.Start])
+}
+
+// This is called after a top-level statement has been visited. Only the
+// statements starting at "start" are new, and they are all rewritten here.
+func (p *parser) refreshTopLevelStmts(stmts []js_ast.Stmt, start int) []js_ast.Stmt {
+	var result []js_ast.Stmt
+	for _, stmt := range stmts[start:] {
+		var after []js_ast.Stmt
+
+		switch s := stmt.Data.(type) {
+		case *js_ast.SFunction:
+			after = p.refreshFnDeclaration(&s.Fn)
+
+		case *js_ast.SExportDefault:
+			switch v := s.Value.Data.(type) {
+			case *js_ast.SFunction:
+				// Anonymous function declarations are ignored like in Babel
+				if v.Fn.Name != nil {
+					after = p.refreshFnDeclaration(&v.Fn)
+				}
+
+			case *js_ast.SExpr:
+				if p.refreshInlineFn(&v.Value) {
+					// "export default memo(() => {})" => "export default _c = memo(() => {})"
+					ref := p.generateTempRef(tempRefNeedsDeclare, "_c")
+					v.Value = js_ast.Assign(p.refreshIdentifier(v.Value.Loc, ref), v.Value)
+					after = append(after, p.refreshRegister(v.Value.Loc, ref, "%default%"))
+				}
+			}
+
+		case *js_ast.SLocal:
+			for i := range s.Decls {
+				decl := &s.Decls[i]
+				id, ok := decl.Binding.Data.(*js_ast.BIdentifier)
+				if !ok || decl.ValueOrNil.Data == nil {
+					continue
+				}
+				name := p.symbols[id.Ref.InnerIndex].OriginalName
+
+				// "const Foo = () => {}"
+				if body, preferExpr := refreshFnBody(decl.ValueOrNil); body != nil {
+					if sig, ok := p.refreshSignature(body, preferExpr, decl.ValueOrNil.Loc); ok {
+						after = append(after, p.refreshSignatureStmt(decl.Binding.Loc, sig, p.refreshIdentifier(decl.Binding.Loc, id.Ref)))
+					}
+					if isRefreshComponentName(name) {
+						after = append(after, p.refreshRegister(decl.Binding.Loc, id.Ref, name))
+					}
+					continue
+				}
+
+				// "const Foo = memo(() => {})"
+				if p.refreshInlineFn(&decl.ValueOrNil) && isRefreshComponentName(name) {
+					after = append(after, p.refreshRegister(decl.Binding.Loc, id.Ref, name))
+				}
+			}
+		}
+
+		result = append(result, stmt)
+		result = append(result, after...)
+	}
+	return append(stmts[:start], result...)
+}
+
+// The returned flag is non-nil for arrow functions. It must be cleared if a
+// statement is added to the body since the body can no longer be an expression.
+func refreshFnBody(expr js_ast.Expr) (*js_ast.FnBody, *bool) {
+	switch e := expr.Data.(type) {
+	case *js_ast.EArrow:
+		return &e.Body, &e.PreferExpr
+	case *js_ast.EFunction:
+		return &e.Fn.Body, nil
+	}
+	return nil, nil
+}
+
+// "function Foo() {}"
+func (p *parser) refreshFnDeclaration(fn *js_ast.Fn) (after []js_ast.Stmt) {
+	loc := fn.Name.Loc
+	if sig, ok := p.refreshSignature(&fn.Body, nil, loc); ok {
+		after = append(after, p.refreshSignatureStmt(loc, sig, p.refreshIdentifier(loc, fn.Name.Ref)))
+	}
+	if name := p.symbols[fn.Name.Ref.InnerIndex].OriginalName; isRefreshComponentName(name) {
+		after = append(after, p.refreshRegister(loc, fn.Name.Ref, name))
+	}
+	return
+}
+
+// This handles an inline function that may be wrapped in calls to higher-order
+// components such as "memo(forwardRef(() => {}))". Any function with a hook
+// signature is wrapped in a call to the signature function. This returns true
+// if an inline function was found.
+func (p *parser) refreshInlineFn(expr *js_ast.Expr) bool {
+	if body, preferExpr := refreshFnBody(*expr); body != nil {
+		if sig, ok := p.refreshSignature(body, preferExpr, expr.Loc); ok {
+			*expr = p.refreshSignatureCall(expr.Loc, sig, *expr)
+		}
+		return true
+	}
+	if call, ok := expr.Data.(*js_ast.ECall); ok && len(call.Args) > 0 && refreshHookName(p.symbols, call.Target) == "" {
+		return p.refreshInlineFn(&call.Args[0])
+	}
+	return false
+}
+
+type refreshSignature struct {
+	ref   ast.Ref
+	key   string
+	hooks []refreshHookCall
+}
+
+// If the function calls any hooks, this generates a "_s = $RefreshSig$()"
+// variable and inserts a "_s()" call at the start of the function body
+func (p *parser) refreshSignature(body *js_ast.FnBody, preferExpr *bool, loc logger.Loc) (refreshSignature, bool) {
+	hooks, ok := p.refreshHookCallsForFnBody[body.Loc]
+	if !ok {
+		return refreshSignature{}, false
+	}
+
+	// Find the destructuring patterns for hook calls in variable declarations
+	lhs := make(map[*js_ast.ECall]string)
+	for _, stmt := range body.Block.Stmts {
+		if local, ok := stmt.Data.(*js_ast.SLocal); ok {
+			for _, decl := range local.Decls {
+				if call, ok := decl.ValueOrNil.Data.(*js_ast.ECall); ok {
+					text := p.sourceTextBetween(decl.Binding.Loc, decl.ValueOrNil.Loc)
+					lhs[call] = strings.TrimSpace(strings.TrimSuffix(text, "="))
+				}
+			}
+		}
+	}
+
+	// The key changes when the hooks that are called change. The runtime
+	// resets the state of the component when that happens.
+	var sb strings.Builder
+	for i, hook := range hooks {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(hook.name)
+		sb.WriteByte('{')
+		sb.WriteString(lhs[hook.call])
+		if hook.initialStateText != "" {
+			sb.WriteByte('(')
+			sb.WriteString(hook.initialStateText)
+			sb.WriteByte(')')
+		}
+		sb.WriteByte('}')
+	}
+
+	// Generate "var _s = $RefreshSig$();"
+	ref := p.newSymbol(ast.SymbolOther, "_s")
+	p.currentScope.Generated = append(p.currentScope.Generated, ref)
+	p.tempRefsToDeclare = append(p.tempRefsToDeclare, tempRef{
+		ref:        ref,
+		valueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: p.refreshGlobal(loc, "$RefreshSig$")}},
+	})
+
+	// Generate "_s();" at the start of the function body
+	call := js_ast.Stmt{Loc: body.Loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: body.Loc, Data: &js_ast.ECall{
+		Target: p.refreshIdentifier(body.Loc, ref),
+	}}}}
+	body.Block.Stmts = append([]js_ast.Stmt{call}, body.Block.Stmts...)
+	if preferExpr != nil {
+		*preferExpr = false
+	}
+
+	return refreshSignature{ref: ref, key: sb.String(), hooks: hooks}, true
+}
+
+// Generates "_s(fn, key)" where the call returns the function
+func (p *parser) refreshSignatureCall(loc logger.Loc, sig refreshSignature, fn js_ast.Expr) js_ast.Expr {
+	args := []js_ast.Expr{fn, {Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(sig.key)}}}
+
+	// Calls to custom hooks are passed as a function so that the runtime can
+	// also check the signatures of those hooks. The third argument forces the
+	// state to be reset every time if there's a "@refresh reset" comment.
+	var customHooks []js_ast.Expr
+	for _, hook := range sig.hooks {
+		if !isBuiltInReactHook(hook.name) {
+			if callee, ok := p.refreshCloneCallee(hook.call.Target); ok {
+				customHooks = append(customHooks, callee)
+			}
+		}
+	}
+	forceReset := p.refreshHasForceResetComment()
+	if forceReset || len(customHooks) > 0 {
+		args = append(args, js_ast.Expr{Loc: loc, Data: &js_ast.EBoolean{Value: forceReset}})
+	}
+	if len(customHooks) > 0 {
+		args = append(args, js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+			ArgumentsRef: ast.InvalidRef,
+			Body: js_ast.FnBody{Loc: loc, Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SReturn{
+				ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: customHooks}},
+			}}}}},
+		}}})
+	}
+
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: p.refreshIdentifier(loc, sig.ref), Args: args}}
+}
+
+func (p *parser) refreshSignatureStmt(loc logger.Loc, sig refreshSignature, fn js_ast.Expr) js_ast.Stmt {
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: p.refreshSignatureCall(loc, sig, fn)}}
+}
+
+// Generates "$RefreshReg$(ref, name)"
+func (p *parser) refreshRegister(loc logger.Loc, ref ast.Ref, name string) js_ast.Stmt {
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target: p.refreshGlobal(loc, "$RefreshReg$"),
+		Args: []js_ast.Expr{
+			p.refreshIdentifier(loc, ref),
+			{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(name)}},
+		},
+	}}}}
+}
+
+func (p *parser) refreshIdentifier(loc logger.Loc, ref ast.Ref) js_ast.Expr {
+	p.recordUsage(ref)
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+}
+
+func (p *parser) refreshGlobal(loc logger.Loc, name string) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.findSymbol(loc, name).ref}}
+}
+
+// The callee of a custom hook call is copied into the signature so that the
+// runtime can look up that hook's signature too
+func (p *parser) refreshCloneCallee(expr js_ast.Expr) (js_ast.Expr, bool) {
+	switch e := expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		clone := *e
+		p.recordUsage(e.Ref)
+		return js_ast.Expr{Loc: expr.Loc, Data: &clone}, true
+
+	case *js_ast.EImportIdentifier:
+		clone := *e
+		p.recordUsage(e.Ref)
+		return js_ast.Expr{Loc: expr.Loc, Data: &clone}, true
+
+	case *js_ast.EDot:
+		if target, ok := p.refreshCloneCallee(e.Target); ok {
+			clone := *e
+			clone.Target = target
+			return js_ast.Expr{Loc: expr.Loc, Data: &clone}, true
+		}
+	}
+	return js_ast.Expr{}, false
+}
+
+func (p *parser) refreshHasForceResetComment() bool {
+	for _, comment := range p.lexer.AllComments {
+		if strings.Contains(p.source.CommentTextWithoutIndent(comment), "@refresh reset") {
+			return true
+		}
+	}
+	return false
+}
@@ -88,6 +88,35 @@ func expectPrintedCommon(t *testing.T, contents string, expected string, options
 	})
 }
 
+func expectPrintedErrorRecovery(t *testing.T, contents string, expectedErrors string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		options := config.Options{
+			OmitRuntimeForTests: true,
+			ErrorRecovery:       true,
+		}
+		tree, ok := Parse(log, test.SourceForTest(contents), OptionsFromConfig(&options))
+		msgs := log.Done()
+		var text strings.Builder
+		for _, msg := range msgs {
+			if msg.Kind != logger.Warning {
+				text.WriteString(msg.String(logger.OutputOptions{}, logger.TerminalInfo{}))
+			}
+		}
+		test.AssertEqualWithDiff(t, text.String(), expectedErrors)
+		if !ok {
+			t.Fatal("Parse error")
+		}
+		symbols := ast.NewSymbolMap(1)
+		symbols.SymbolsForSource[0] = tree.Symbols
+		r := renamer.NewNoOpRenamer(symbols)
+		js := js_printer.Print(tree, symbols, r, js_printer.Options{}).JS
+		test.AssertEqualWithDiff(t, string(js), expected)
+	})
+}
+
 func expectPrinted(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{})
@@ -100,6 +129,14 @@ func expectPrintedMangle(t *testing.T, contents string, expected string) {
 	})
 }
 
+func expectPrintedInlineFunctions(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		MinifySyntax:    true,
+		InlineFunctions: true,
+	})
+}
+
 func expectPrintedNormalAndMangle(t *testing.T, contents string, normal string, mangle string) {
 	expectPrinted(t, contents, normal)
 	expectPrintedMangle(t, contents, mangle)
@@ -124,6 +161,16 @@ func expectPrintedMangleTarget(t *testing.T, esVersion int, contents string, exp
 	})
 }
 
+func expectPrintedPolyfill(t *testing.T, engine compat.Engine, version int, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		UnsupportedBuiltins: compat.UnsupportedBuiltins(map[compat.Engine]compat.Semver{
+			engine: {Parts: []int{version}},
+		}),
+		Polyfill: "core-js",
+	})
+}
+
 func expectPrintedASCII(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{
@@ -185,6 +232,16 @@ func expectPrintedJSXSideEffects(t *testing.T, contents string, expected string)
 	})
 }
 
+func expectPrintedJSXRefresh(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		JSX: config.JSXOptions{
+			Parse:   true,
+			Refresh: true,
+		},
+	})
+}
+
 func expectPrintedMangleJSX(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{
@@ -4744,6 +4801,41 @@ func TestMangleIIFE(t *testing.T) {
 		"let x = () => {\n  let x = void z();\n  foo(x);\n};\n")
 }
 
+func TestInlineFunctions(t *testing.T) {
+	// These functions must not be inlined
+	expectPrintedInlineFunctions(t, "function f() { function g(y) { return y } return g(1) + g(2) }",
+		"function f() {\n  function g(y) {\n    return y;\n  }\n  return g(1) + g(2);\n}\n")
+	expectPrintedInlineFunctions(t, "function f() { function g(y) { return y ? g(y - 1) : 0 } return g(1) }",
+		"function f() {\n  function g(y) {\n    return y ? g(y - 1) : 0;\n  }\n  return g(1);\n}\n")
+	expectPrintedInlineFunctions(t, "function f() { function g() { return this } return g() }",
+		"function f() {\n  function g() {\n    return this;\n  }\n  return g();\n}\n")
+	expectPrintedInlineFunctions(t, "function f() { function g() { return arguments } return g() }",
+		"function f() {\n  function g() {\n    return arguments;\n  }\n  return g();\n}\n")
+	expectPrintedInlineFunctions(t, "function f() { function g(...y) { return y } return g(1) }",
+		"function f() {\n  function g(...y) {\n    return y;\n  }\n  return g(1);\n}\n")
+	expectPrintedInlineFunctions(t, "function f() { function g(y) { return y } return g(...z) }",
+		"function f() {\n  function g(y) {\n    return y;\n  }\n  return g(...z);\n}\n")
+	expectPrintedInlineFunctions(t, "function f() { function g(y) { return y } h(g); return g(1) }",
+		"function f() {\n  function g(y) {\n    return y;\n  }\n  return h(g), g(1);\n}\n")
+	expectPrintedInlineFunctions(t, "function f() { function g(y) { return y } return eval(g(1)) }",
+		"function f() {\n  function g(y) {\n    return y;\n  }\n  return eval(g(1));\n}\n")
+
+	// Immediately-invoked arrow functions
+	expectPrintedInlineFunctions(t, "((a, b) => a + b)(1, x)", "1 + x;\n")
+	expectPrintedInlineFunctions(t, "(() => { a(); return b })()", "a(), b;\n")
+	expectPrintedInlineFunctions(t, "x = (() => { a(); return b })()", "x = (a(), b);\n")
+	expectPrintedInlineFunctions(t, "x = (() => { a(); b() })()", "x = void (a(), b());\n")
+	expectPrintedInlineFunctions(t, "(() => { a(); b() })()", "a(), b();\n")
+	expectPrintedInlineFunctions(t, "(() => { let x = a(); b(x) })()", "{\n  let x = a();\n  b(x);\n}\n")
+	expectPrintedInlineFunctions(t, "(() => { if (x) return; a() })()", "x || a();\n")
+	expectPrintedInlineFunctions(t, "(() => { var x = a(); b(x) })()", "(() => {\n  var x = a();\n  b(x);\n})();\n")
+	expectPrintedInlineFunctions(t, "(() => { function x() {} b(x) })()", "(() => {\n  function x() {\n  }\n  b(x);\n})();\n")
+	expectPrintedInlineFunctions(t, "(async () => { a(); b() })()", "(async () => (a(), b()))();\n")
+	expectPrintedInlineFunctions(t, "a: for (;;) (() => { b: { x(); break b } })()", "for (; ; ) b: {\n  x();\n  break b;\n}\n")
+	expectPrintedInlineFunctions(t, "outer: for (let i = 0; i < 2; i++) { (() => { outer: { console.log(i); break outer } })(); if (i) continue outer }",
+		"outer: for (let i = 0; i < 2; i++)\n  if ((() => {\n    outer: {\n      console.log(i);\n      break outer;\n    }\n  })(), i) continue outer;\n")
+}
+
 func TestMangleTemplate(t *testing.T) {
 	expectPrintedNormalAndMangle(t, "_ = `a${x}b${y}c`", "_ = `a${x}b${y}c`;\n", "_ = `a${x}b${y}c`;\n")
 	expectPrintedNormalAndMangle(t, "_ = `a${x}b${'y'}c`", "_ = `a${x}b${\"y\"}c`;\n", "_ = `a${x}byc`;\n")
@@ -6121,6 +6213,52 @@ func TestJSXSideEffects(t *testing.T) {
 	expectPrintedJSXSideEffects(t, "<></>", "React.createElement(React.Fragment, null);\n")
 }
 
+func TestJSXRefresh(t *testing.T) {
+	// Components are registered
+	expectPrintedJSXRefresh(t, "function Foo() { return <div/> }",
+		"function Foo() {\n  return /* @__PURE__ */ React.createElement(\"div\", null);\n}\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "export const Foo = () => <div/>",
+		"export const Foo = () => /* @__PURE__ */ React.createElement(\"div\", null);\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "export default function Foo() {}",
+		"export default function Foo() {\n}\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "export default memo(() => {})",
+		"var _c;\nexport default _c = memo(() => {\n});\n$RefreshReg$(_c, \"%default%\");\n")
+	expectPrintedJSXRefresh(t, "const Foo = memo(forwardRef(() => {}))",
+		"const Foo = memo(forwardRef(() => {\n}));\n$RefreshReg$(Foo, \"Foo\");\n")
+
+	// Other things are not registered
+	expectPrintedJSXRefresh(t, "function foo() {}", "function foo() {\n}\n")
+	expectPrintedJSXRefresh(t, "const Foo = 123", "const Foo = 123;\n")
+	expectPrintedJSXRefresh(t, "export default function() {}", "export default function() {\n}\n")
+	expectPrintedJSXRefresh(t, "{ function Foo() {} }", "{\n  let Foo = function() {\n  };\n  var Foo = Foo;\n}\n")
+	expectPrintedJSXRefresh(t, "function foo() { function Foo() {} }", "function foo() {\n  function Foo() {\n  }\n}\n")
+
+	// Hook calls give the function a signature
+	expectPrintedJSXRefresh(t, "function Foo() { const [x, setX] = useState(0) }",
+		"var _s = $RefreshSig$();\nfunction Foo() {\n  _s();\n  const [x, setX] = useState(0);\n}\n"+
+			"_s(Foo, \"useState{[x, setX](0)}\");\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "const Foo = () => { useReducer(r, 1, init); React.useEffect(f) }",
+		"var _s = $RefreshSig$();\nconst Foo = () => {\n  _s();\n  useReducer(r, 1, init);\n  React.useEffect(f);\n};\n"+
+			"_s(Foo, \"useReducer{(1)}\\nuseEffect{}\");\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "const Foo = () => useState()",
+		"var _s = $RefreshSig$();\nconst Foo = () => {\n  _s();\n  return useState();\n};\n"+
+			"_s(Foo, \"useState{}\");\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "function useFoo() { const x = useBar() }",
+		"var _s = $RefreshSig$();\nfunction useFoo() {\n  _s();\n  const x = useBar();\n}\n"+
+			"_s(useFoo, \"useBar{x}\", false, function() {\n  return [\n    useBar\n  ];\n});\n")
+	expectPrintedJSXRefresh(t, "const Foo = memo(() => { useState() })",
+		"var _s = $RefreshSig$();\nconst Foo = memo(_s(() => {\n  _s();\n  useState();\n}, \"useState{}\"));\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "// @refresh reset\nfunction Foo() { useState() }",
+		"var _s = $RefreshSig$();\nfunction Foo() {\n  _s();\n  useState();\n}\n"+
+			"_s(Foo, \"useState{}\", true);\n$RefreshReg$(Foo, \"Foo\");\n")
+
+	// Hook calls in nested functions belong to the nested function
+	expectPrintedJSXRefresh(t, "function Foo() { return () => useState() }",
+		"function Foo() {\n  return () => useState();\n}\n$RefreshReg$(Foo, \"Foo\");\n")
+	expectPrintedJSXRefresh(t, "function Foo() { use() + usefoo() }",
+		"function Foo() {\n  use() + usefoo();\n}\n$RefreshReg$(Foo, \"Foo\");\n")
+}
+
 func TestPreserveOptionalChainParentheses(t *testing.T) {
 	expectPrinted(t, "a?.b.c", "a?.b.c;\n")
 	expectPrinted(t, "(a?.b).c", "(a?.b).c;\n")
@@ -6980,3 +7118,86 @@ func TestUsing(t *testing.T) {
 	expectPrintedMangle(t, "using x = null, y = z", "using x = null, y = z;\n")
 	expectPrintedMangle(t, "using x = z, y = undefined", "using x = z, y = void 0;\n")
 }
+
+func TestErrorRecovery(t *testing.T) {
+	// Statements with errors are dropped and parsing resumes after them
+	expectPrintedErrorRecovery(t, "a(); b = ; c()", "<stdin>: ERROR: Unexpected \";\"\n", "a();\nc();\n")
+	expectPrintedErrorRecovery(t, "a = 1 +; b = ); c = ]; d()",
+		"<stdin>: ERROR: Unexpected \";\"\n<stdin>: ERROR: Unexpected \")\"\n<stdin>: ERROR: Unexpected \"]\"\n", "d();\n")
+	expectPrintedErrorRecovery(t, "a()\nb = (1 +\nif (c) d()", "<stdin>: ERROR: Unexpected \"if\"\n", "a();\nif (c) d();\n")
+	expectPrintedErrorRecovery(t, "a = b +\nc d\ne()", "<stdin>: ERROR: Expected \";\" but found \"d\"\n", "e();\n")
+
+	// A statement keyword after an unmatched closing bracket starts a new statement even without a newline
+	expectPrintedErrorRecovery(t, "}}} let a = 1",
+		"<stdin>: ERROR: Unexpected \"}\"\n<stdin>: ERROR: Unexpected \"}\"\n<stdin>: ERROR: Unexpected \"}\"\n", "let a = 1;\n")
+	expectPrintedErrorRecovery(t, ") ) let b", "<stdin>: ERROR: Unexpected \")\"\n", "let b;\n")
+	expectPrintedErrorRecovery(t, "if (x { a } let w = 3", "<stdin>: ERROR: Expected \")\" but found \"{\"\n", "let w = 3;\n")
+	expectPrintedErrorRecovery(t, "a = [b(] if (c) d()", "<stdin>: ERROR: Unexpected \"]\"\n", "if (c) d();\n")
+
+	// Errors inside nested blocks only drop the nested statement
+	expectPrintedErrorRecovery(t, "function f() { a(; return 1 }\nf()", "<stdin>: ERROR: Unexpected \";\"\n",
+		"function f() {\n  return 1;\n}\nf();\n")
+	expectPrintedErrorRecovery(t, "if (a) { b( } else { c() }", "<stdin>: ERROR: Unexpected \"}\"\n", "if (a) {\n} else {\n  c();\n}\n")
+	expectPrintedErrorRecovery(t, "a(() => { let b = ; return 1 })", "<stdin>: ERROR: Unexpected \";\"\n", "a(() => {\n  return 1;\n});\n")
+	expectPrintedErrorRecovery(t, "function f(a, { b) { return a }\nf()", "<stdin>: ERROR: Expected \"}\" but found \")\"\n", "f();\n")
+	expectPrintedErrorRecovery(t, "function f() {\n  a(\n", "<stdin>: ERROR: Unexpected end of file\n", "function f() {\n}\n")
+
+	// Errors inside class bodies only drop the property
+	expectPrintedErrorRecovery(t, "class A { b( {} }\nc()", "<stdin>: ERROR: Expected \")\" but found \"}\"\n", "class A {\n}\nc();\n")
+	expectPrintedErrorRecovery(t, "class A { x = ; y = 2 }\nz()", "<stdin>: ERROR: Unexpected \";\"\n", "class A {\n  y = 2;\n}\nz();\n")
+	expectPrintedErrorRecovery(t, "class A { x = 1\n  m( {\n  }\n  n() {}\n}", "<stdin>: ERROR: Expected \")\" but found \"n\"\n",
+		"class A {\n  x = 1;\n  n() {\n  }\n}\n")
+	expectPrintedErrorRecovery(t, "class A { #x = ; #x = 1; m() { return this.#x } }", "<stdin>: ERROR: Unexpected \";\"\n",
+		"class A {\n  #x = 1;\n  m() {\n    return this.#x;\n  }\n}\n")
+	expectPrintedErrorRecovery(t, "class A { x = 1 +", "<stdin>: ERROR: Unexpected end of file\n", "")
+
+	// Declarations in dropped statements are discarded
+	expectPrintedErrorRecovery(t, "let b = ;\nlet b = 1", "<stdin>: ERROR: Unexpected \";\"\n", "let b = 1;\n")
+	expectPrintedErrorRecovery(t, "function f() {}\nfunction f( {}\nf()", "<stdin>: ERROR: Expected \")\" but found \"f\"\n",
+		"function f() {\n}\nf();\n")
+	expectPrintedErrorRecovery(t, "import a from 'a' +\nimport b from 'b'\nb()", "<stdin>: ERROR: Expected \";\" but found \"+\"\n",
+		"import b from \"b\";\nb();\n")
+
+	// Skipping over tokens must handle template literals
+	expectPrintedErrorRecovery(t, "a = `${ b + }`; c()", "<stdin>: ERROR: Unexpected \"}\"\n", "c();\n")
+	expectPrintedErrorRecovery(t, "a = `${ b + }${ `${ c }` }`; d()", "<stdin>: ERROR: Unexpected \"}\"\n", "d();\n")
+
+	// Errors from the lexer
+	expectPrintedErrorRecovery(t, "a = \"b\nc()", "<stdin>: ERROR: Unterminated string literal\n", "c();\n")
+	expectPrintedErrorRecovery(t, "a()\n\u274C\nb()", "<stdin>: ERROR: Unexpected \"\u274C\"\n", "a();\nb();\n")
+	expectPrintedErrorRecovery(t, "\"a\nb()", "<stdin>: ERROR: Unterminated string literal\n", "a;\nb();\n")
+}
+
+func TestPolyfill(t *testing.T) {
+	// Globals
+	expectPrintedPolyfill(t, compat.Chrome, 90, "structuredClone(x)",
+		"import \"core-js/modules/web.structured-clone.js\";\nstructuredClone(x);\n")
+	expectPrintedPolyfill(t, compat.Chrome, 100, "structuredClone(x)", "structuredClone(x);\n")
+	expectPrintedPolyfill(t, compat.Chrome, 90, "let structuredClone; structuredClone(x)", "let structuredClone;\nstructuredClone(x);\n")
+	expectPrintedPolyfill(t, compat.ES, 5, "new Map",
+		"import \"core-js/modules/es.map.js\";\n/* @__PURE__ */ new Map();\n")
+
+	// Static methods
+	expectPrintedPolyfill(t, compat.Chrome, 90, "Object.hasOwn(x, y)",
+		"import \"core-js/modules/es.object.has-own.js\";\nObject.hasOwn(x, y);\n")
+	expectPrintedPolyfill(t, compat.ES, 2021, "Object.hasOwn(x, y)",
+		"import \"core-js/modules/es.object.has-own.js\";\nObject.hasOwn(x, y);\n")
+	expectPrintedPolyfill(t, compat.ES, 2022, "Object.hasOwn(x, y)", "Object.hasOwn(x, y);\n")
+	expectPrintedPolyfill(t, compat.Chrome, 90, "let Object; Object.hasOwn(x, y)", "let Object;\nObject.hasOwn(x, y);\n")
+	expectPrintedPolyfill(t, compat.Chrome, 90, "Promise.withResolvers()",
+		"import \"core-js/modules/es.promise.with-resolvers.js\";\nPromise.withResolvers();\n")
+
+	// Prototype methods are matched by name
+	expectPrintedPolyfill(t, compat.Chrome, 90, "x.at(-1)",
+		"import \"core-js/modules/es.array.at.js\";\nimport \"core-js/modules/es.string.at-alternative.js\";\nx.at(-1);\n")
+	expectPrintedPolyfill(t, compat.Chrome, 90, "x?.toSorted()",
+		"import \"core-js/modules/es.array.to-sorted.js\";\nx?.toSorted();\n")
+	expectPrintedPolyfill(t, compat.Chrome, 120, "x.at(-1).toSorted()", "x.at(-1).toSorted();\n")
+
+	// Each polyfill is only imported once
+	expectPrintedPolyfill(t, compat.Chrome, 90, "x.findLast(f); y.findLast(g)",
+		"import \"core-js/modules/es.array.find-last.js\";\nx.findLast(f);\ny.findLast(g);\n")
+
+	// Web APIs aren't affected by "es*" targets
+	expectPrintedPolyfill(t, compat.ES, 5, "structuredClone(x)", "structuredClone(x);\n")
+}
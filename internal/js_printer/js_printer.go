@@ -281,6 +281,12 @@ type printer struct {
 	callTarget             js_ast.E
 	exprComments           map[logger.Loc][]string
 	printedExprComments    map[logger.Loc]bool
+	trailingExprComments   map[logger.Loc][]js_ast.TrailingComment
+	trailingStmtComments   map[logger.Loc][]string
+	printedTrailingExpr    map[logger.Loc]int
+	trailingExprParentLoc  logger.Loc
+	printedTrailingStmt    map[logger.Loc]bool
+	pendingLineComment     string
 	hasLegalComment        map[string]struct{}
 	extractedLegalComments []string
 	js                     []byte
@@ -310,15 +316,49 @@ type printer struct {
 }
 
 func (p *printer) print(text string) {
+	if p.pendingLineComment != "" {
+		text = p.printPendingLineComment(text)
+	}
 	p.js = append(p.js, text...)
 }
 
 // This is the same as "print(string(bytes))" without any unnecessary temporary
 // allocations
 func (p *printer) printBytes(bytes []byte) {
+	if p.pendingLineComment != "" {
+		p.print("")
+	}
 	p.js = append(p.js, bytes...)
 }
 
+// A single-line comment that trails an expression can't be printed until it's
+// known what comes next. Commas and semicolons are printed before the comment
+// so that they stay on the same line. Then the comment ends the line, which
+// is continued on the next line if nothing else ends it first.
+func (p *printer) printPendingLineComment(text string) string {
+	i := 0
+	for i < len(text) && (text[i] == ',' || text[i] == ';') {
+		i++
+	}
+	p.js = append(p.js, text[:i]...)
+	text = text[i:]
+	if text == "" && p.pendingLineComment != "" {
+		return text
+	}
+
+	p.js = append(p.js, ' ')
+	p.js = append(p.js, p.pendingLineComment...)
+	p.pendingLineComment = ""
+	if !strings.HasPrefix(text, "\n") {
+		p.js = append(p.js, '\n')
+		p.options.Indent++
+		p.printIndent()
+		p.options.Indent--
+		text = strings.TrimLeft(text, " ")
+	}
+	return text
+}
+
 type printQuotedFlags uint8
 
 const (
@@ -331,12 +371,18 @@ func (p *printer) printQuotedUTF8(text string, flags printQuotedFlags) {
 }
 
 func (p *printer) addSourceMapping(loc logger.Loc) {
+	if p.pendingLineComment != "" {
+		p.print("")
+	}
 	if p.options.AddSourceMappings {
 		p.builder.AddSourceMapping(loc, "", p.js)
 	}
 }
 
 func (p *printer) addSourceMappingForName(loc logger.Loc, name string, ref ast.Ref) {
+	if p.pendingLineComment != "" {
+		p.print("")
+	}
 	if p.options.AddSourceMappings {
 		if originalName := p.symbols.Get(ast.FollowSymbols(p.symbols, ref)).OriginalName; originalName != name {
 			p.builder.AddSourceMapping(loc, originalName, p.js)
@@ -443,6 +489,9 @@ func (p *printer) canPrintIdentifierUTF16(name []uint16) bool {
 
 func (p *printer) printIdentifier(name string) {
 	if p.options.ASCIIOnly {
+		if p.pendingLineComment != "" {
+			p.print("")
+		}
 		p.js = QuoteIdentifier(p.js, name, p.options.UnsupportedFeatures)
 	} else {
 		p.print(name)
@@ -560,6 +609,15 @@ func (p *printer) willPrintExprCommentsForAnyOf(exprs []js_ast.Expr) bool {
 }
 
 func (p *printer) printBinding(binding js_ast.Binding) {
+	p.printBindingWithoutTrailingComments(binding)
+
+	// Bindings are parsed as expressions first, so they can have trailing comments
+	if p.trailingExprComments != nil {
+		p.printTrailingExprComments(js_ast.Expr{Loc: binding.Loc}, true)
+	}
+}
+
+func (p *printer) printBindingWithoutTrailingComments(binding js_ast.Binding) {
 	switch b := binding.Data.(type) {
 	case *js_ast.BMissing:
 		p.addSourceMapping(binding.Loc)
@@ -1713,6 +1771,13 @@ func (p *printer) simplifyUnusedExpr(expr js_ast.Expr) js_ast.Expr {
 		}
 	}
 
+	// Constants inlined from other files may cause parts of the expression (or
+	// the whole expression) to become dead code. For example, "if (DEBUG) f()"
+	// is "DEBUG && f()" which becomes "false && f()" after "DEBUG" is inlined.
+	if folded := p.lateConstantFoldUnaryOrBinaryOrIfExpr(expr); folded.Data != expr.Data {
+		return p.astHelpers.SimplifyUnusedExpr(folded, p.options.UnsupportedFeatures)
+	}
+
 	return expr
 }
 
@@ -1854,6 +1919,69 @@ func (p *printer) lateConstantFoldUnaryOrBinaryOrIfExpr(expr js_ast.Expr) js_ast
 	return expr
 }
 
+// The parser has already removed dead branches from "if" statements. However,
+// the test may only become a known constant at print time when it references
+// constants inlined from other files. This returns the branch that should be
+// printed instead (which may be empty), but only if the other branch can be
+// removed without changing the meaning of the code.
+func (p *printer) tryToRemoveDeadIfBranch(s *js_ast.SIf) (js_ast.Stmt, bool) {
+	test := p.lateConstantFoldUnaryOrBinaryOrIfExpr(s.Test)
+	if test.Data == s.Test.Data {
+		return js_ast.Stmt{}, false
+	}
+
+	boolean, sideEffects, ok := js_ast.ToBooleanWithSideEffects(test.Data)
+	if !ok || sideEffects != js_ast.NoSideEffects {
+		return js_ast.Stmt{}, false
+	}
+
+	live, dead := s.Yes, s.NoOrNil
+	if !boolean {
+		live, dead = dead, live
+	}
+	if dead.Data != nil && !canRemoveDeadStmt(dead) {
+		return js_ast.Stmt{}, false
+	}
+	return live, true
+}
+
+// Hoisted declarations ("var" and "function") affect the enclosing scope even
+// when they are in a dead branch, so only remove statements without them
+func canRemoveDeadStmt(stmt js_ast.Stmt) bool {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SEmpty, *js_ast.SExpr, *js_ast.SThrow, *js_ast.SReturn,
+		*js_ast.SBreak, *js_ast.SContinue, *js_ast.SDebugger:
+		return true
+
+	case *js_ast.SBlock:
+		for _, child := range s.Stmts {
+			if !canRemoveDeadStmt(child) {
+				return false
+			}
+		}
+		return true
+
+	case *js_ast.SIf:
+		return canRemoveDeadStmt(s.Yes) && (s.NoOrNil.Data == nil || canRemoveDeadStmt(s.NoOrNil))
+	}
+
+	return false
+}
+
+func hasLexicalDecls(stmts []js_ast.Stmt) bool {
+	for _, stmt := range stmts {
+		switch s := stmt.Data.(type) {
+		case *js_ast.SLocal:
+			if s.Kind != js_ast.LocalVar {
+				return true
+			}
+		case *js_ast.SClass, *js_ast.SFunction:
+			return true
+		}
+	}
+	return false
+}
+
 func (p *printer) isUnboundIdentifier(expr js_ast.Expr) bool {
 	id, ok := expr.Data.(*js_ast.EIdentifier)
 	return ok && p.symbols.Get(ast.FollowSymbols(p.symbols, id.Ref)).Kind == ast.SymbolUnbound
@@ -1968,6 +2096,71 @@ func (p *printer) printExprCommentsAfterCloseTokenAtLoc(loc logger.Loc) {
 	}
 }
 
+// Print any stored comments that were on the same line as the end of this
+// expression. Single-line comments must be followed by a newline, so the rest
+// of the expression continues on the next line.
+// Nested expressions can start at the same location, so each comment is only
+// printed after the expression that it trailed in the original source. That's
+// determined by the number of nested expressions at the same location, which
+// may have changed if the AST was transformed. So any comments that are still
+// left are printed after the outermost expression at that location.
+func (p *printer) printTrailingExprComments(expr js_ast.Expr, isOutermost bool) {
+	comments := p.trailingExprComments[expr.Loc]
+	printed := p.printedTrailingExpr[expr.Loc]
+	if printed < len(comments) {
+		flags := p.saveExprStartFlags()
+		depth := js_ast.SameLocDepth(expr)
+
+		for _, comment := range comments[printed:] {
+			if comment.Depth > depth && !isOutermost {
+				break
+			}
+			printed++
+			text := p.escapeTrailingComment(comment.Text)
+			if strings.HasPrefix(text, "//") {
+				if p.pendingLineComment != "" {
+					p.print("\n")
+				}
+				p.pendingLineComment = text
+			} else {
+				p.print(" ")
+				p.print(text)
+			}
+		}
+
+		// Remember which comments were printed so we don't print them again
+		p.printedTrailingExpr[expr.Loc] = printed
+
+		p.restoreExprStartFlags(flags)
+	}
+}
+
+// Print any stored comments that were on the same line as the end of this
+// statement. Statements end with a newline, so these go before it.
+func (p *printer) printTrailingStmtCommentsAtLoc(loc logger.Loc) {
+	if comments := p.trailingStmtComments[loc]; comments != nil && !p.printedTrailingStmt[loc] {
+		if n := len(p.js); n > 0 && p.js[n-1] == '\n' {
+			p.js = p.js[:n-1]
+		}
+		for _, comment := range comments {
+			p.print(" ")
+			p.print(p.escapeTrailingComment(comment))
+		}
+		p.print("\n")
+
+		// Mark these comments as printed so we don't print them again
+		p.printedTrailingStmt[loc] = true
+	}
+}
+
+func (p *printer) escapeTrailingComment(text string) string {
+	// Avoid generating a comment containing the character sequence "</script"
+	if !p.options.UnsupportedFeatures.Has(compat.InlineScript) {
+		text = helpers.EscapeClosingTag(text, "/script")
+	}
+	return text
+}
+
 func (p *printer) printExprWithoutLeadingNewline(expr js_ast.Expr, level js_ast.L, flags printExprFlags) {
 	if !p.options.MinifyWhitespace && p.willPrintExprCommentsAtLoc(expr.Loc) {
 		p.print("(")
@@ -2003,6 +2196,18 @@ const (
 )
 
 func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFlags) {
+	if p.trailingExprComments == nil {
+		p.printExprWithoutTrailingComments(expr, level, flags)
+		return
+	}
+	parentLoc := p.trailingExprParentLoc
+	p.trailingExprParentLoc = expr.Loc
+	p.printExprWithoutTrailingComments(expr, level, flags)
+	p.trailingExprParentLoc = parentLoc
+	p.printTrailingExprComments(expr, parentLoc != expr.Loc)
+}
+
+func (p *printer) printExprWithoutTrailingComments(expr js_ast.Expr, level js_ast.L, flags printExprFlags) {
 	// If syntax compression is enabled, do a pre-pass over unary and binary
 	// operators to inline bitwise operations of cross-module inlined constants.
 	// This makes the output a little tighter if people construct bit masks in
@@ -2368,6 +2573,16 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 							p.printExpr(p.guardAgainstBehaviorChangeDueToSubstitution(value, flags), level, flags)
 							break
 						}
+
+						// "(() => { x })()" => "void x"
+						if stmt, ok := stmts[0].Data.(*js_ast.SExpr); ok {
+							value := stmt.Value
+							if (flags & exprResultIsUnused) == 0 {
+								value = js_ast.Expr{Loc: value.Loc, Data: &js_ast.EUnary{Op: js_ast.UnOpVoid, Value: value}}
+							}
+							p.printExpr(p.guardAgainstBehaviorChangeDueToSubstitution(value, flags), level, flags)
+							break
+						}
 					}
 				}
 			}
@@ -3775,6 +3990,13 @@ func (p *printer) printBlock(loc logger.Loc, block js_ast.SBlock) {
 	p.printNewline()
 
 	p.options.Indent++
+
+	// Comments before a block that is the body of another statement are printed
+	// inside the block since there's nowhere else to put them
+	if !p.options.MinifyWhitespace {
+		p.printExprCommentsAfterCloseTokenAtLoc(loc)
+	}
+
 	for _, stmt := range block.Stmts {
 		p.printSemicolonIfNeeded()
 		p.printStmt(stmt, canOmitStatement)
@@ -4099,10 +4321,27 @@ const (
 )
 
 func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
+	if p.trailingStmtComments == nil {
+		p.printStmtWithoutTrailingComments(stmt, flags)
+		return
+	}
+	start := len(p.js)
+	p.printStmtWithoutTrailingComments(stmt, flags)
+	if len(p.js) > start {
+		p.printTrailingStmtCommentsAtLoc(stmt.Loc)
+	}
+}
+
+func (p *printer) printStmtWithoutTrailingComments(stmt js_ast.Stmt, flags printStmtFlags) {
 	if p.options.LineLimit > 0 {
 		p.printNewlinePastLineLimit()
 	}
 
+	// Print any preserved comments that were attached to this statement
+	if !p.options.MinifyWhitespace {
+		p.printExprCommentsAfterCloseTokenAtLoc(stmt.Loc)
+	}
+
 	switch s := stmt.Data.(type) {
 	case *js_ast.SComment:
 		text := s.Text
@@ -4378,6 +4617,29 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 		}
 
 	case *js_ast.SIf:
+		// Remove dead branches due to constants inlined from other files
+		if p.options.MinifySyntax {
+			if live, ok := p.tryToRemoveDeadIfBranch(s); ok {
+				if live.Data != nil {
+					if block, ok := live.Data.(*js_ast.SBlock); ok && (flags&canOmitStatement) != 0 && !hasLexicalDecls(block.Stmts) {
+						// "if (true) { a(); b() }" => "a(); b();"
+						for _, child := range block.Stmts {
+							p.printStmt(child, canOmitStatement)
+						}
+					} else {
+						p.printStmt(live, flags)
+					}
+				} else if (flags & canOmitStatement) == 0 {
+					// "for (;;) if (false) a();" => "for (;;) ;"
+					p.addSourceMapping(stmt.Loc)
+					p.printIndent()
+					p.print(";")
+					p.printNewline()
+				}
+				break
+			}
+		}
+
 		p.addSourceMapping(stmt.Loc)
 		p.printIndent()
 		p.printIf(s)
@@ -4932,6 +5194,7 @@ type Options struct {
 	ToESMRef            ast.Ref
 	RuntimeRequireRef   ast.Ref
 	UnsupportedFeatures compat.JSFeature
+	AssumePure          js_ast.AssumePure
 	Indent              int
 	LineLimit           int
 	OutputFormat        config.Format
@@ -4992,10 +5255,22 @@ func Print(tree js_ast.AST, symbols ast.SymbolMap, r renamer.Renamer, options Op
 		p.printedExprComments = make(map[logger.Loc]bool)
 	}
 
+	if !options.MinifyWhitespace {
+		if tree.TrailingExprComments != nil {
+			p.trailingExprComments = tree.TrailingExprComments
+			p.printedTrailingExpr = make(map[logger.Loc]int)
+			p.trailingExprParentLoc = logger.Loc{Start: -1}
+		}
+		if tree.TrailingStmtComments != nil {
+			p.trailingStmtComments = tree.TrailingStmtComments
+			p.printedTrailingStmt = make(map[logger.Loc]bool)
+		}
+	}
+
 	p.astHelpers = js_ast.MakeHelperContext(func(ref ast.Ref) bool {
 		ref = ast.FollowSymbols(symbols, ref)
 		return symbols.Get(ref).Kind == ast.SymbolUnbound
-	})
+	}, options.AssumePure)
 
 	// Add the top-level directive if present
 	for _, directive := range tree.Directives {
@@ -5011,6 +5286,9 @@ func Print(tree js_ast.AST, symbols ast.SymbolMap, r renamer.Renamer, options Op
 			p.printSemicolonIfNeeded()
 		}
 	}
+	if p.pendingLineComment != "" {
+		p.print("\n")
+	}
 
 	result := PrintResult{
 		JS:                     p.js,
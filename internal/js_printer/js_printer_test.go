@@ -63,6 +63,13 @@ func expectPrintedMangle(t *testing.T, contents string, expected string) {
 	})
 }
 
+func expectPrintedPreserveComments(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents+" [preserve comments]", contents, expected, config.Options{
+		PreserveComments: true,
+	})
+}
+
 func expectPrintedMangleMinify(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents+" [mangled, minified]", contents, expected, config.Options{
@@ -582,6 +589,44 @@ func TestCommentsAndParentheses(t *testing.T) {
 	expectPrinted(t, "function *x() { yield (/* foo */ y) }", "function* x() {\n  yield (\n    /* foo */\n    y\n  );\n}\n")
 }
 
+func TestPreserveComments(t *testing.T) {
+	expectPrinted(t, "// a\nx()\n// b", "x();\n")
+	expectPrintedPreserveComments(t, "// a\nx()\n// b", "// a\nx();\n// b\n")
+	expectPrintedPreserveComments(t, "/** a */\n/* b */ x()", "/** a */\n/* b */\nx();\n")
+	expectPrintedPreserveComments(t, "/*! a */\n// b\nx()", "/*! a */\n// b\nx();\n")
+	expectPrintedPreserveComments(t, "x() // a\ny()", "x(); // a\ny();\n")
+	expectPrintedPreserveComments(t, "/** a */\nexport function f() {}", "/** a */\nexport function f() {\n}\n")
+	expectPrintedPreserveComments(t, "/** a */\nexport default class {}", "/** a */\nexport default class {\n}\n")
+	expectPrintedPreserveComments(t, "function f() {\n  // a\n  x()\n  // b\n}", "function f() {\n  // a\n  x();\n  // b\n}\n")
+	expectPrintedPreserveComments(t, "if (x)\n  // a\n  y()", "if (x)\n  // a\n  y();\n")
+	expectPrintedPreserveComments(t, "/* a\n   b */\n{\n  /* c\n     d */\n  x()\n}",
+		"/* a\n   b */\n{\n  /* c\n     d */\n  x();\n}\n")
+	expectPrintedPreserveComments(t, "x = [\n  // a\n  1,\n]", "x = [\n  // a\n  1\n];\n")
+	expectPrintedPreserveComments(t, "x = 1 /* a */ + /* b */ 2", "x = 1 /* a */ + /* b */\n2;\n")
+	expectPrintedPreserveComments(t, "x = 'a' /* b */ + /* c */ 'd'", "x = /* c */\n\"ad\" /* b */;\n")
+	expectPrintedPreserveComments(t, "if (x /* a */) { y() /* b */ }", "if (x /* a */) {\n  y(); /* b */\n}\n")
+	expectPrintedPreserveComments(t, "f(/* a */ x, /* b */ y /* c */)", "f(\n  /* a */\n  x,\n  /* b */\n  y /* c */\n);\n")
+
+	// Comments on the same line as the end of an expression or statement stay there
+	expectPrintedPreserveComments(t, "if (x /* a */) { y() }", "if (x /* a */) {\n  y();\n}\n")
+	expectPrintedPreserveComments(t, "if (x // a\n) { y() }", "if (x // a\n  ) {\n  y();\n}\n")
+	expectPrintedPreserveComments(t, "let a = b // cond\n  ? c // yes\n  : d // no", "let a = b // cond\n  ? c // yes\n  : d; // no\n")
+	expectPrintedPreserveComments(t, "let a = b /* cond */ ? c /* yes */ : d /* no */", "let a = b /* cond */ ? c /* yes */ : d; /* no */\n")
+	expectPrintedPreserveComments(t, "x = a // a\n  .b // b\n  + c", "x = a // a\n  .b // b\n  + c;\n")
+	expectPrintedPreserveComments(t, "x = f(a) // a\n  || b", "x = f(a) // a\n  || b;\n")
+	expectPrintedPreserveComments(t, "f(a, // a\n  b // b\n)", "f(\n  a, // a\n  b // b\n);\n")
+	expectPrintedPreserveComments(t, "x = [1, // a\n  2, // b\n]", "x = [\n  1, // a\n  2 // b\n];\n")
+	expectPrintedPreserveComments(t, "x = [1, /* a */ 2]", "x = [\n  1,\n  /* a */\n  2\n];\n")
+	expectPrintedPreserveComments(t, "f((a // a\n) => b)", "f((a // a\n  ) => b);\n")
+	expectPrintedPreserveComments(t, "x() // a\n\n// b\ny()", "x(); // a\n// b\ny();\n")
+
+	// Comments are never preserved when whitespace is minified
+	expectPrintedCommon(t, "// a\nx() [preserve comments, minified]", "// a\nx()", "x();", config.Options{
+		PreserveComments: true,
+		MinifyWhitespace: true,
+	})
+}
+
 func TestPureComment(t *testing.T) {
 	expectPrinted(t,
 		"(function() { foo() })",
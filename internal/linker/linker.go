@@ -9,6 +9,7 @@ package linker
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
 	"fmt"
@@ -66,12 +67,24 @@ type linkerContext struct {
 	// Property mangling results go here
 	mangledProps map[ast.Ref]string
 
+	// Name cache entries for cross-chunk export aliases go here. Entries
+	// for top-level symbols are stored on each chunk instead since those are
+	// assigned in parallel.
+	nameCacheUpdates map[string]string
+
 	// We may need to refer to the CommonJS "module" symbol for exports
 	unboundModuleRef ast.Ref
 
 	// We may need to refer to the "__esm" and/or "__commonJS" runtime symbols
 	cjsRuntimeRef ast.Ref
 	esmRuntimeRef ast.Ref
+
+	// ICSS values from CSS modules are resolved across files before linking
+	icssValues map[uint32]*icssValues
+
+	// Local CSS names that aren't used by any live JavaScript code go here.
+	// Style rules that require these names can never match and are removed.
+	unusedLocalCSSNames map[ast.Ref]bool
 }
 
 type partRange struct {
@@ -102,6 +115,10 @@ type chunkInfo struct {
 	// is the substitution of the final hash into "finalTemplate".
 	finalRelPath string
 
+	// If debug IDs are enabled, this is a UUID derived from the same data as
+	// the final hash. It's written to both the chunk and its source map.
+	debugID string
+
 	// If non-empty, this chunk needs to generate an external legal comments file.
 	externalLegalComments []byte
 
@@ -184,6 +201,14 @@ type chunkReprJS struct {
 	crossChunkPrefixStmts  []js_ast.Stmt
 	crossChunkSuffixStmts  []js_ast.Stmt
 
+	// Runtime helpers used by this chunk that are imported from the external
+	// helpers module instead of being included in the chunk itself
+	externalHelpers []ast.Ref
+
+	// Names that were assigned to top-level symbols in this chunk that should
+	// be written back to the name cache
+	nameCacheUpdates map[string]string
+
 	cssChunkIndex uint32
 	hasCSSChunk   bool
 }
@@ -251,6 +276,7 @@ func Link(
 		dataForSourceMaps:    dataForSourceMaps,
 		uniqueKeyPrefix:      uniqueKeyPrefix,
 		uniqueKeyPrefixBytes: []byte(uniqueKeyPrefix),
+		nameCacheUpdates:     make(map[string]string),
 		graph: graph.CloneLinkerGraph(
 			inputFiles,
 			reachableFiles,
@@ -309,6 +335,8 @@ func Link(
 		c.unboundModuleRef = ast.InvalidRef
 	}
 
+	c.convertCommonJSToESM()
+	c.mergeTopLevelConstValues()
 	c.scanImportsAndExports()
 
 	// Stop now if there were errors
@@ -316,10 +344,16 @@ func Link(
 		c.options.ExclusiveMangleCacheUpdate(func(map[string]interface{}, map[string]bool) {
 			// Always do this so that we don't cause other entry points when there are errors
 		})
+		c.options.ExclusiveNameCacheUpdate(func(map[string]interface{}) {
+			// Always do this so that we don't cause other entry points when there are errors
+		})
 		return []graph.OutputFile{}
 	}
 
 	c.treeShakingAndCodeSplitting()
+	if c.options.TreeShaking {
+		c.findUnusedLocalCSSNames()
+	}
 
 	if c.options.Mode == config.ModePassThrough {
 		for _, entryPoint := range c.graph.EntryPoints() {
@@ -346,7 +380,47 @@ func Link(
 	// won't hit concurrent map mutation hazards
 	ast.FollowAllSymbols(c.graph.Symbols)
 
-	return c.generateChunksInParallel(additionalFiles)
+	if c.options.CSSModuleTypes {
+		additionalFiles = append(additionalFiles, c.generateCSSModuleTypes()...)
+	}
+
+	outputFiles := c.generateChunksInParallel(additionalFiles)
+
+	// Write the names used by this build into the name cache in chunk order for determinism
+	c.options.ExclusiveNameCacheUpdate(func(nameCache map[string]interface{}) {
+		c.updateNameCache(nameCache)
+	})
+
+	return outputFiles
+}
+
+// The name cache remembers the minified names of top-level symbols and
+// cross-chunk exports between builds. Symbols are identified by the pretty
+// path of the file that declares them and their original name, which (unlike
+// symbol refs) is stable between builds.
+func (c *linkerContext) nameCacheKey(ref ast.Ref) string {
+	file := &c.graph.Files[ref.SourceIndex]
+	return file.InputFile.Source.PrettyPaths.Rel + ":" + c.graph.Symbols.Get(ref).OriginalName
+}
+
+func (c *linkerContext) updateNameCache(nameCache map[string]interface{}) {
+	if nameCache == nil {
+		return
+	}
+	for key, name := range c.nameCacheUpdates {
+		nameCache[key] = name
+	}
+	for _, chunk := range c.chunks {
+		if chunkRepr, ok := chunk.chunkRepr.(*chunkReprJS); ok {
+			for key, name := range chunkRepr.nameCacheUpdates {
+				// An earlier chunk may have already assigned a name to this key
+				if _, ok := c.nameCacheUpdates[key]; !ok {
+					c.nameCacheUpdates[key] = name
+					nameCache[key] = name
+				}
+			}
+		}
+	}
 }
 
 func (c *linkerContext) mangleProps(mangleCache map[string]interface{}) {
@@ -546,6 +620,71 @@ func (c *linkerContext) mangleLocalCSS(usedLocalNames map[string]bool) {
 	}
 }
 
+// This generates a TypeScript declaration file for each CSS module that
+// declares the same names that its JavaScript stub exports. That way the
+// types can't get out of sync with the CSS like they can with a separate
+// tool that watches the file system.
+func (c *linkerContext) generateCSSModuleTypes() (results []graph.OutputFile) {
+	c.timer.Begin("Generate CSS module types")
+	defer c.timer.End("Generate CSS module types")
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		repr, ok := file.InputFile.Repr.(*graph.CSSRepr)
+		if !ok || file.InputFile.Loader != config.LoaderLocalCSS || file.InputFile.Source.KeyPath.Namespace != "file" {
+			continue
+		}
+
+		// Determine where to write the file
+		absPath := file.InputFile.Source.KeyPath.Text + ".d.ts"
+		if c.options.AbsCSSModuleTypesDir != "" {
+			if relPath, ok := c.fs.Rel(c.options.AbsOutputBase, absPath); ok {
+				absPath = c.fs.Join(c.options.AbsCSSModuleTypesDir, relPath)
+			} else {
+				absPath = c.fs.Join(c.options.AbsCSSModuleTypesDir, c.fs.Base(absPath))
+			}
+		}
+
+		// These must be in the same order as "generateCodeForLazyExport"
+		var names []string
+		for _, local := range repr.AST.LocalSymbols {
+			names = append(names, c.graph.Symbols.Get(local.Ref).OriginalName)
+		}
+		if values, ok := c.icssValues[sourceIndex]; ok {
+			for _, name := range values.exportOrder {
+				if _, ok := repr.AST.LocalScope[name]; !ok {
+					names = append(names, name)
+				}
+			}
+		}
+
+		sb := strings.Builder{}
+		sb.WriteString("declare const styles: {\n")
+		for _, name := range names {
+			sb.WriteString(fmt.Sprintf("  readonly %s: string;\n", helpers.QuoteForJSON(name, c.options.ASCIIOnly)))
+		}
+		sb.WriteString("};\nexport default styles;\n")
+		for _, name := range names {
+			// Only names that are valid identifiers can be declared this way
+			if _, ok := js_lexer.Keywords[name]; ok || js_lexer.StrictModeReservedWords[name] || !js_ast.IsIdentifier(name) {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("export declare const %s: string;\n", name))
+		}
+		contents := []byte(sb.String())
+
+		results = append(results, graph.OutputFile{
+			AbsPath:  absPath,
+			Contents: contents,
+			JSONMetadataChunk: fmt.Sprintf(
+				c.options.MetafileFormat.MaybeRemoveWhitespace("{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": {},\n      \"bytes\": %d\n    }"),
+				len(contents)),
+		})
+	}
+
+	return
+}
+
 // Currently the automatic chunk generation algorithm should by construction
 // never generate chunks that import each other since files are allocated to
 // chunks based on which entry points they are reachable from.
@@ -627,6 +766,10 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 	// paths of each chunk. This can technically be done in parallel but it
 	// probably doesn't matter so much because we're not hashing that much data.
 	visited := make([]uint32, len(c.chunks))
+	var debugIDVisited []uint32
+	if c.options.DebugIDs != config.DebugIDsNone {
+		debugIDVisited = make([]uint32, len(c.chunks))
+	}
 	var finalBytes []byte
 	for chunkIndex := range c.chunks {
 		chunk := &c.chunks[chunkIndex]
@@ -642,6 +785,15 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 			hashSubstitution = &finalString
 		}
 
+		// The debug ID is derived from the same data as the final hash so that it
+		// changes whenever the final contents of this chunk change. It uses a
+		// larger hash than the file name because it needs 128 bits of data.
+		if debugIDVisited != nil {
+			hash := sha256.New()
+			c.appendIsolatedHashesForImportedChunks(hash, uint32(chunkIndex), debugIDVisited, ^uint32(chunkIndex))
+			chunk.debugID = debugIDFromHash(hash.Sum(nil))
+		}
+
 		// Render the last remaining placeholder in the template
 		chunk.finalRelPath = config.TemplateToString(config.SubstituteTemplate(chunk.finalTemplate, config.PathPlaceholders{
 			Hash: hashSubstitution,
@@ -689,6 +841,13 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 					return c.pathBetweenChunks(finalRelDir, finalRelPathForImport)
 				})
 
+			// Register the debug ID at run-time so that it can be found from a stack
+			// trace. This goes at the end so that it doesn't affect the source map.
+			if _, ok := chunk.chunkRepr.(*chunkReprJS); ok && chunk.debugID != "" && c.options.DebugIDs == config.DebugIDsRegister {
+				outputContentsJoiner.EnsureNewlineAtEnd()
+				outputContentsJoiner.AddString(c.debugIDRegistration(chunk.debugID))
+			}
+
 			// Generate the optional legal comments file for this chunk
 			if len(chunk.externalLegalComments) > 0 {
 				finalRelPathForLegalComments := chunk.finalRelPath + ".LEGAL.txt"
@@ -714,10 +873,23 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 				})
 			}
 
+			// Write the debug ID comment before the source map comment
+			if chunk.debugID != "" {
+				outputContentsJoiner.EnsureNewlineAtEnd()
+				outputContentsJoiner.AddString(commentPrefix)
+				outputContentsJoiner.AddString("# debugId=")
+				outputContentsJoiner.AddString(chunk.debugID)
+				outputContentsJoiner.AddString(commentSuffix)
+				outputContentsJoiner.AddString("\n")
+			}
+
 			// Generate the optional source map for this chunk
 			if c.options.SourceMap != config.SourceMapNone && chunk.outputSourceMap.HasContent() {
 				outputSourceMap := chunk.outputSourceMap.Finalize(outputSourceMapShifts)
 				finalRelPathForSourceMap := chunk.finalRelPath + ".map"
+				if chunk.debugID != "" {
+					outputSourceMap = addDebugIDToSourceMap(outputSourceMap, chunk.debugID)
+				}
 
 				// Potentially write a trailing source map comment
 				switch c.options.SourceMap {
@@ -1136,12 +1308,32 @@ func (c *linkerContext) computeCrossChunkDependencies() {
 		case config.FormatESModule:
 			r := renamer.ExportRenamer{}
 			var items []js_ast.ClauseItem
-			for _, export := range c.sortedCrossChunkExportItems(chunkMetas[chunkIndex].exports) {
-				var alias string
-				if c.options.MinifyIdentifiers {
-					alias = r.NextMinifiedName()
-				} else {
-					alias = r.NextRenamedName(c.graph.Symbols.Get(export.Ref).OriginalName)
+			sortedExports := c.sortedCrossChunkExportItems(chunkMetas[chunkIndex].exports)
+
+			// Reuse export aliases from the name cache if possible. These must be
+			// reserved before any new aliases are generated to avoid collisions.
+			var cachedAliases map[ast.Ref]string
+			if c.options.MinifyIdentifiers && c.options.NameCache != nil {
+				cachedAliases = make(map[ast.Ref]string)
+				for _, export := range sortedExports {
+					key := c.nameCacheKey(export.Ref) + ":export"
+					if alias, ok := c.options.NameCache[key].(string); ok && js_ast.IsIdentifier(alias) && r.Reserve(alias) {
+						cachedAliases[export.Ref] = alias
+					}
+				}
+			}
+
+			for _, export := range sortedExports {
+				alias, ok := cachedAliases[export.Ref]
+				if !ok {
+					if c.options.MinifyIdentifiers {
+						alias = r.NextMinifiedName()
+					} else {
+						alias = r.NextRenamedName(c.graph.Symbols.Get(export.Ref).OriginalName)
+					}
+				}
+				if c.options.MinifyIdentifiers && c.options.NameCache != nil {
+					c.nameCacheUpdates[c.nameCacheKey(export.Ref)+":export"] = alias
 				}
 				items = append(items, js_ast.ClauseItem{Name: ast.LocRef{Ref: export.Ref}, Alias: alias})
 				chunkRepr.exportsToOtherChunks[export.Ref] = alias
@@ -1291,6 +1483,311 @@ func (c *linkerContext) sortedCrossChunkExportItems(exportRefs map[ast.Ref]bool)
 	return result
 }
 
+// The parser records an alternate ESM form for CommonJS modules that only use
+// "exports" and "module" in simple ways. That form can only be used if nothing
+// needs the "module.exports" object itself. Specifically the module must not
+// be an entry point, must only be imported using "import" statements, and
+// those imports must only use named exports that are known to exist. Doing
+// this lets the code in the module be tree-shaken.
+func (c *linkerContext) convertCommonJSToESM() {
+	if !c.options.TreeShaking {
+		return
+	}
+
+	c.timer.Begin("Convert CommonJS to ESM")
+	defer c.timer.End("Convert CommonJS to ESM")
+
+	candidates := make(map[uint32]*graph.JSRepr)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		if repr, ok := file.InputFile.Repr.(*graph.JSRepr); ok && repr.AST.CommonJSToESM != nil &&
+			repr.AST.ExportsKind == js_ast.ExportsCommonJS && !file.IsEntryPoint() {
+			candidates[sourceIndex] = repr
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	// Rule out modules that are imported in a way that needs "module.exports"
+	hasDestructuredRequires := make(map[uint32]bool)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok {
+			continue
+		}
+		requires := c.findDestructuredRequires(repr)
+		requiresByRecord := make(map[uint32]*destructuredRequire, len(requires))
+		for i := range requires {
+			requiresByRecord[requires[i].importRecordIndex] = &requires[i]
+		}
+		for importRecordIndex, record := range repr.AST.ImportRecords {
+			if !record.SourceIndex.IsValid() {
+				continue
+			}
+			otherSourceIndex := record.SourceIndex.GetIndex()
+
+			// "const { foo } = require('path')" is treated like "import { foo } from 'path'"
+			if require, ok := requiresByRecord[uint32(importRecordIndex)]; ok {
+				if otherRepr, ok := candidates[otherSourceIndex]; ok {
+					for _, item := range require.items {
+						if _, ok := otherRepr.AST.CommonJSToESM.NamedExports[item.Alias]; !ok {
+							delete(candidates, otherSourceIndex)
+							break
+						}
+					}
+				}
+				continue
+			}
+
+			if record.Kind != ast.ImportStmt || record.Flags.Has(ast.ContainsDefaultAlias) {
+				delete(candidates, otherSourceIndex)
+			}
+		}
+		if len(requires) > 0 {
+			hasDestructuredRequires[sourceIndex] = true
+		}
+		for _, importRecordIndex := range repr.AST.ExportStarImportRecords {
+			if record := &repr.AST.ImportRecords[importRecordIndex]; record.SourceIndex.IsValid() {
+				delete(candidates, record.SourceIndex.GetIndex())
+			}
+		}
+		for ref, named := range repr.AST.NamedImports {
+			record := &repr.AST.ImportRecords[named.ImportRecordIndex]
+			if !record.SourceIndex.IsValid() {
+				continue
+			}
+			otherSourceIndex := record.SourceIndex.GetIndex()
+			otherRepr, ok := candidates[otherSourceIndex]
+			if !ok {
+				continue
+			}
+			if named.AliasIsStar {
+				if named.IsExported || c.graph.Symbols.Get(ref).UseCountEstimate > 0 {
+					delete(candidates, otherSourceIndex)
+				}
+			} else if _, ok := otherRepr.AST.CommonJSToESM.NamedExports[named.Alias]; !ok {
+				delete(candidates, otherSourceIndex)
+			}
+		}
+	}
+
+	for sourceIndex, repr := range candidates {
+		// Clone the symbol uses since the linker may mutate them later
+		parts := append([]js_ast.Part{}, repr.AST.CommonJSToESM.Parts...)
+		for i := range parts {
+			part := &parts[i]
+			uses := make(map[ast.Ref]js_ast.SymbolUse, len(part.SymbolUses))
+			for ref, use := range part.SymbolUses {
+				uses[ref] = use
+			}
+			part.SymbolUses = uses
+		}
+		repr.AST.Parts = parts
+		repr.AST.TopLevelSymbolToPartsFromParser = repr.AST.CommonJSToESM.TopLevelSymbolToParts
+
+		resolvedExports := make(map[string]graph.ExportData, len(repr.AST.CommonJSToESM.NamedExports))
+		for alias, name := range repr.AST.CommonJSToESM.NamedExports {
+			resolvedExports[alias] = graph.ExportData{
+				Ref:         name.Ref,
+				SourceIndex: sourceIndex,
+				NameLoc:     name.AliasLoc,
+			}
+		}
+
+		repr.AST.NamedExports = repr.AST.CommonJSToESM.NamedExports
+		repr.AST.ExportKeyword = repr.AST.CommonJSToESM.ExportKeyword
+		repr.AST.ExportsKind = js_ast.ExportsESM
+		repr.AST.UsesExportsRef = false
+		repr.AST.UsesModuleRef = false
+		repr.Meta.ResolvedExports = resolvedExports
+	}
+
+	// Turn destructured "require()" calls of converted modules into imports.
+	// This iterates over the reachable files instead of the map for determinism.
+	// The statements are found again since converting a file may have changed
+	// its part indices.
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if !hasDestructuredRequires[sourceIndex] {
+			continue
+		}
+		repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		for _, require := range c.findDestructuredRequires(repr) {
+			record := &repr.AST.ImportRecords[require.importRecordIndex]
+			if _, ok := candidates[record.SourceIndex.GetIndex()]; !ok {
+				continue
+			}
+			namespaceRef := c.graph.GenerateNewSymbol(sourceIndex, ast.SymbolOther, "import_"+js_ast.GenerateNonUniqueNameFromPath(record.Path.Text))
+			for _, item := range require.items {
+				c.graph.Symbols.Get(item.Name.Ref).Kind = ast.SymbolImport
+				repr.AST.NamedImports[item.Name.Ref] = js_ast.NamedImport{
+					Alias:             item.Alias,
+					AliasLoc:          item.AliasLoc,
+					NamespaceRef:      namespaceRef,
+					ImportRecordIndex: require.importRecordIndex,
+				}
+			}
+			items := require.items
+			part := &repr.AST.Parts[require.partIndex]
+			part.Stmts = []js_ast.Stmt{{Loc: part.Stmts[0].Loc, Data: &js_ast.SImport{
+				Items:             &items,
+				NamespaceRef:      namespaceRef,
+				ImportRecordIndex: require.importRecordIndex,
+			}}}
+			part.CanBeRemovedIfUnused = true
+			record.Kind = ast.ImportStmt
+		}
+	}
+}
+
+// This is a top-level "const { foo, bar: baz } = require('path')" statement
+type destructuredRequire struct {
+	items             []js_ast.ClauseItem
+	partIndex         uint32
+	importRecordIndex uint32
+}
+
+func (c *linkerContext) findDestructuredRequires(repr *graph.JSRepr) (requires []destructuredRequire) {
+next:
+	for partIndex, part := range repr.AST.Parts {
+		if len(part.Stmts) != 1 {
+			continue
+		}
+		local, ok := part.Stmts[0].Data.(*js_ast.SLocal)
+		if !ok || local.IsExport || len(local.Decls) != 1 {
+			continue
+		}
+		decl := local.Decls[0]
+		object, ok := decl.Binding.Data.(*js_ast.BObject)
+		if !ok || len(object.Properties) == 0 {
+			continue
+		}
+		require, ok := decl.ValueOrNil.Data.(*js_ast.ERequireString)
+		if !ok {
+			continue
+		}
+		items := make([]js_ast.ClauseItem, 0, len(object.Properties))
+		for _, property := range object.Properties {
+			key, ok := property.Key.Data.(*js_ast.EString)
+			if !ok || property.IsComputed || property.IsSpread || property.DefaultValueOrNil.Data != nil {
+				continue next
+			}
+			// Top-level "const" declarations are converted to "var" when bundling,
+			// so check the symbol instead. Imports can't be reassigned.
+			id, ok := property.Value.Data.(*js_ast.BIdentifier)
+			if !ok || c.graph.Symbols.Get(id.Ref).Kind != ast.SymbolConst {
+				continue next
+			}
+			items = append(items, js_ast.ClauseItem{
+				Alias:    helpers.UTF16ToString(key.Value),
+				AliasLoc: property.Key.Loc,
+				Name:     ast.LocRef{Loc: property.Value.Loc, Ref: id.Ref},
+			})
+		}
+		requires = append(requires, destructuredRequire{
+			items:             items,
+			partIndex:         uint32(partIndex),
+			importRecordIndex: require.ImportRecordIndex,
+		})
+	}
+	return
+}
+
+// Top-level constants that come after import statements can only be inlined
+// into other files if the file that declares them isn't part of an import
+// cycle. Otherwise another file in the cycle could observe the constant before
+// it has been initialized, and inlining it would hide the resulting TDZ error.
+func (c *linkerContext) mergeTopLevelConstValues() {
+	var filesInCycles map[uint32]bool
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok || repr.AST.TopLevelConstValues == nil {
+			continue
+		}
+
+		// Only compute the import cycles if they are actually needed
+		if filesInCycles == nil {
+			filesInCycles = c.findFilesInImportCycles()
+		}
+		if filesInCycles[sourceIndex] {
+			continue
+		}
+
+		if c.graph.ConstValues == nil {
+			c.graph.ConstValues = make(map[ast.Ref]js_ast.ConstValue)
+		}
+		for ref, value := range repr.AST.TopLevelConstValues {
+			c.graph.ConstValues[ref] = value
+		}
+	}
+}
+
+// This returns the set of files that can import themselves, either directly or
+// indirectly. It uses Tarjan's algorithm to find strongly-connected components.
+func (c *linkerContext) findFilesInImportCycles() map[uint32]bool {
+	type visitState struct {
+		index   int
+		lowLink int
+		onStack bool
+	}
+
+	filesInCycles := make(map[uint32]bool)
+	states := make(map[uint32]*visitState)
+	var stack []uint32
+	var visit func(sourceIndex uint32)
+
+	visit = func(sourceIndex uint32) {
+		state := &visitState{index: len(states), lowLink: len(states), onStack: true}
+		states[sourceIndex] = state
+		stack = append(stack, sourceIndex)
+		hasSelfImport := false
+
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+			for _, record := range repr.AST.ImportRecords {
+				if !record.SourceIndex.IsValid() {
+					continue
+				}
+				otherSourceIndex := record.SourceIndex.GetIndex()
+				if otherSourceIndex == sourceIndex {
+					hasSelfImport = true
+				}
+				if other, ok := states[otherSourceIndex]; !ok {
+					visit(otherSourceIndex)
+					if other := states[otherSourceIndex]; other.lowLink < state.lowLink {
+						state.lowLink = other.lowLink
+					}
+				} else if other.onStack && other.index < state.lowLink {
+					state.lowLink = other.index
+				}
+			}
+		}
+
+		// Pop off this strongly-connected component if this is its root
+		if state.lowLink == state.index {
+			end := len(stack) - 1
+			for stack[end] != sourceIndex {
+				end--
+			}
+			component := stack[end:]
+			stack = stack[:end]
+			for _, otherSourceIndex := range component {
+				states[otherSourceIndex].onStack = false
+				if len(component) > 1 || hasSelfImport {
+					filesInCycles[otherSourceIndex] = true
+				}
+			}
+		}
+	}
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if _, ok := states[sourceIndex]; !ok {
+			visit(sourceIndex)
+		}
+	}
+	return filesInCycles
+}
+
 func (c *linkerContext) scanImportsAndExports() {
 	c.timer.Begin("Scan imports and exports")
 	defer c.timer.End("Scan imports and exports")
@@ -1462,6 +1959,19 @@ func (c *linkerContext) scanImportsAndExports() {
 
 		file.InputFile.AdditionalFiles = additionalFiles
 	}
+
+	// Resolve ICSS values across CSS modules. This must be done after all URLs
+	// have been inlined above since values may be copied into other files.
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.CSSRepr); ok &&
+			(len(repr.AST.ICSSValues) > 0 || len(repr.AST.ICSSImports) > 0 || len(repr.AST.ICSSExports) > 0) {
+			c.resolveICSSValues(sourceIndex)
+		}
+	}
+
+	// Report problems with custom media queries and custom selectors here
+	// instead of during substitution, which happens once per chunk
+	c.checkCustomCSSDefinitions()
 	c.timer.End("Step 1")
 
 	// Step 2: Propagate dynamic export status for export star statements that
@@ -2107,6 +2617,131 @@ func (c *linkerContext) validateComposesFromProperties(rootFile *graph.LinkerFil
 	}
 }
 
+type icssValues struct {
+	// This maps the names that can be used in this file's CSS to their values
+	scope map[string]*css_parser.ICSSResolvedValue
+
+	// These are exposed to other CSS modules and to JavaScript
+	exports     map[string]*css_parser.ICSSResolvedValue
+	exportOrder []string
+
+	// Imports that failed are tracked to avoid reporting the same error twice
+	failedImports map[string]bool
+
+	isResolving bool
+}
+
+func (values *icssValues) addExport(name string, value *css_parser.ICSSResolvedValue) {
+	if _, ok := values.exports[name]; !ok {
+		values.exportOrder = append(values.exportOrder, name)
+	}
+	values.exports[name] = value
+}
+
+// ICSS values can be imported from other CSS modules using either "@value"
+// or ":import", and exported using either "@value" or ":export". The values
+// for each file are resolved once and are then shared between all chunks.
+// Custom media queries and custom selectors may be defined in any file, so
+// this uses all definitions in the bundle. Substitution only uses definitions
+// in the same chunk, but this is only used for warnings.
+func (c *linkerContext) checkCustomCSSDefinitions() {
+	definitions := css_parser.MakeCustomDefinitions(c.options.UnsupportedCSSFeatures)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.CSSRepr); ok {
+			definitions.AddDefinitions(repr.AST.Rules)
+		}
+	}
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		if repr, ok := file.InputFile.Repr.(*graph.CSSRepr); ok {
+			definitions.CheckRules(repr.AST.Rules, func(id logger.MsgID, r logger.Range, text string) {
+				c.log.AddID(id, logger.Warning, file.LineColumnTracker(), r, text)
+			})
+		}
+	}
+}
+
+func (c *linkerContext) resolveICSSValues(sourceIndex uint32) *icssValues {
+	if values, ok := c.icssValues[sourceIndex]; ok {
+		return values
+	}
+
+	file := &c.graph.Files[sourceIndex]
+	repr := file.InputFile.Repr.(*graph.CSSRepr)
+	values := &icssValues{
+		scope:         make(map[string]*css_parser.ICSSResolvedValue),
+		exports:       make(map[string]*css_parser.ICSSResolvedValue),
+		failedImports: make(map[string]bool),
+		isResolving:   true,
+	}
+	if c.icssValues == nil {
+		c.icssValues = make(map[uint32]*icssValues)
+	}
+	c.icssValues[sourceIndex] = values
+
+	// Imported values are also re-exported, which matches other tools
+	for _, item := range repr.AST.ICSSImports {
+		record := repr.AST.ImportRecords[item.ImportRecordIndex]
+		if !record.SourceIndex.IsValid() {
+			continue
+		}
+		otherFile := &c.graph.Files[record.SourceIndex.GetIndex()]
+		if _, ok := otherFile.InputFile.Repr.(*graph.CSSRepr); !ok {
+			continue
+		}
+		otherValues := c.resolveICSSValues(record.SourceIndex.GetIndex())
+		otherPath := otherFile.InputFile.Source.PrettyPaths.Select(c.options.LogPathStyle)
+		nameRange := css_lexer.RangeOfIdentifier(file.InputFile.Source, item.NameLoc)
+
+		if otherValues.isResolving {
+			c.log.AddError(file.LineColumnTracker(), nameRange,
+				fmt.Sprintf("Cannot import the value %q from %q because of an import cycle", item.Name, otherPath))
+			values.failedImports[item.Alias] = true
+			continue
+		}
+
+		value, ok := otherValues.exports[item.Name]
+		if !ok {
+			values.failedImports[item.Alias] = true
+			if otherValues.failedImports[item.Name] {
+				continue
+			}
+			var notes []logger.MsgData
+			if otherFile.InputFile.Loader == config.LoaderCSS {
+				notes = append(notes, logger.MsgData{Text: fmt.Sprintf(
+					"Use the \"local-css\" loader for %q to enable ICSS values.", otherPath)})
+			}
+			c.log.AddErrorWithNotes(file.LineColumnTracker(), nameRange,
+				fmt.Sprintf("The value %q is never exported from %q", item.Name, otherPath), notes)
+			continue
+		}
+
+		values.scope[item.Alias] = value
+		values.addExport(item.Alias, value)
+	}
+
+	// Values may refer to earlier values, including imported ones
+	lookup := func(name string) *css_parser.ICSSResolvedValue {
+		return values.scope[name]
+	}
+	resolve := func(tokens []css_ast.Token) *css_parser.ICSSResolvedValue {
+		importRecords := repr.AST.ImportRecords[:len(repr.AST.ImportRecords):len(repr.AST.ImportRecords)]
+		tokens, importRecords = css_parser.SubstituteICSSValuesInTokens(tokens, importRecords, lookup)
+		return &css_parser.ICSSResolvedValue{Tokens: tokens, ImportRecords: importRecords}
+	}
+	for _, item := range repr.AST.ICSSValues {
+		value := resolve(item.Value)
+		values.scope[item.Name] = value
+		values.addExport(item.Name, value)
+	}
+	for _, item := range repr.AST.ICSSExports {
+		values.addExport(item.Name, resolve(item.Value))
+	}
+
+	values.isResolving = false
+	return values
+}
+
 func (c *linkerContext) generateCodeForLazyExport(sourceIndex uint32) {
 	file := &c.graph.Files[sourceIndex]
 	repr := file.InputFile.Repr.(*graph.JSRepr)
@@ -2178,6 +2813,25 @@ func (c *linkerContext) generateCodeForLazyExport(sourceIndex uint32) {
 				})
 			}
 
+			// Also populate the exports with ICSS values, but don't override any
+			// local names since those are what "composes" refers to
+			if values, ok := c.icssValues[cssSourceIndex]; ok {
+				for _, name := range values.exportOrder {
+					if _, ok := css.AST.LocalScope[name]; ok {
+						continue
+					}
+					value := values.exports[name]
+					text := css_printer.PrintTokens(value.Tokens, value.ImportRecords, c.graph.Symbols, css_printer.Options{
+						MinifyWhitespace: c.options.MinifyWhitespace,
+						ASCIIOnly:        c.options.ASCIIOnly,
+					})
+					exports.Properties = append(exports.Properties, js_ast.Property{
+						Key:        js_ast.Expr{Loc: lazyValue.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(name)}},
+						ValueOrNil: js_ast.Expr{Loc: lazyValue.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(text)}},
+					})
+				}
+			}
+
 			lazyValue.Data = &exports
 		}
 	}
@@ -3164,6 +3818,154 @@ func (c *linkerContext) treeShakingAndCodeSplitting() {
 	c.timer.End("Code splitting")
 }
 
+// The JavaScript stub for a CSS module exports each local name separately, so
+// tree shaking has already determined which local names are used. If the
+// default export is used, the names read from it by property accesses on
+// default imports are used too. Names that are composed into a used name are
+// also considered to be used.
+func (c *linkerContext) findUnusedLocalCSSNames() {
+	type localName struct {
+		repr *graph.CSSRepr
+		ref  ast.Ref
+	}
+	used := make(map[ast.Ref]bool)
+	var worklist []localName
+	markUsed := func(repr *graph.CSSRepr, ref ast.Ref) {
+		if !used[ref] {
+			used[ref] = true
+			worklist = append(worklist, localName{repr, ref})
+		}
+	}
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.CSSRepr)
+		if !ok || len(repr.AST.LocalSymbols) == 0 {
+			continue
+		}
+
+		// Be conservative if the exports aren't tracked individually. Also don't
+		// remove anything if JavaScript never uses any of the names since the
+		// file was likely imported for its side effects.
+		var stubRepr *graph.JSRepr
+		var defaultNames map[string]bool
+		if repr.JSSourceIndex.IsValid() {
+			if stub := &c.graph.Files[repr.JSSourceIndex.GetIndex()]; stub.IsLive {
+				stubRepr = stub.InputFile.Repr.(*graph.JSRepr)
+				if stubRepr.AST.ExportsKind == js_ast.ExportsCommonJS {
+					stubRepr = nil
+				} else if c.isExportLive(stubRepr, "default") {
+					if names, ok := c.propertyAccessesOnDefaultExport(repr.JSSourceIndex.GetIndex()); ok {
+						defaultNames = names
+					} else {
+						stubRepr = nil
+					}
+				}
+			}
+		}
+		isUsed := make([]bool, len(repr.AST.LocalSymbols))
+		isAnyUsed := false
+		if stubRepr != nil {
+			for i, local := range repr.AST.LocalSymbols {
+				name := c.graph.Symbols.Get(local.Ref).OriginalName
+				if _, ok := stubRepr.Meta.ResolvedExports[name]; !ok || c.isExportLive(stubRepr, name) || defaultNames[name] {
+					isUsed[i] = true
+					isAnyUsed = true
+				}
+			}
+		}
+
+		for i, local := range repr.AST.LocalSymbols {
+			if isUsed[i] || !isAnyUsed {
+				markUsed(repr, local.Ref)
+			}
+		}
+	}
+
+	// Anything composed into a used name is also used
+	for len(worklist) > 0 {
+		item := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		if composes, ok := item.repr.AST.Composes[item.ref]; ok {
+			for _, name := range composes.Names {
+				markUsed(item.repr, name.Ref)
+			}
+			for _, name := range composes.ImportedNames {
+				if record := item.repr.AST.ImportRecords[name.ImportRecordIndex]; record.SourceIndex.IsValid() {
+					if otherRepr, ok := c.graph.Files[record.SourceIndex.GetIndex()].InputFile.Repr.(*graph.CSSRepr); ok {
+						if otherName, ok := otherRepr.AST.LocalScope[name.Alias]; ok {
+							markUsed(otherRepr, otherName.Ref)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.CSSRepr); ok {
+			for _, local := range repr.AST.LocalSymbols {
+				if !used[local.Ref] {
+					if c.unusedLocalCSSNames == nil {
+						c.unusedLocalCSSNames = make(map[ast.Ref]bool)
+					}
+					c.unusedLocalCSSNames[local.Ref] = true
+				}
+			}
+		}
+	}
+}
+
+// Returns the names of all properties read from the default export of the
+// given file. This fails if the default export could be used in any other way,
+// such as by "require()", by a namespace import, or by passing the default
+// import around as a value.
+func (c *linkerContext) propertyAccessesOnDefaultExport(sourceIndex uint32) (map[string]bool, bool) {
+	if c.graph.Files[sourceIndex].IsEntryPoint() {
+		return nil, false
+	}
+	names := make(map[string]bool)
+	for _, otherSourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[otherSourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok {
+			continue
+		}
+		for _, record := range repr.AST.ImportRecords {
+			if record.SourceIndex.IsValid() && record.SourceIndex.GetIndex() == sourceIndex &&
+				(record.Kind != ast.ImportStmt || (record.Flags&ast.ContainsImportStar) != 0) {
+				return nil, false
+			}
+		}
+		for ref, named := range repr.AST.NamedImports {
+			if record := &repr.AST.ImportRecords[named.ImportRecordIndex]; named.Alias == "default" &&
+				record.SourceIndex.IsValid() && record.SourceIndex.GetIndex() == sourceIndex {
+				accesses, ok := repr.AST.DefaultImportPropertyAccesses[ref]
+				if !ok {
+					return nil, false
+				}
+				for name := range accesses {
+					names[name] = true
+				}
+			}
+		}
+	}
+	return names, true
+}
+
+func (c *linkerContext) isUnusedLocalCSSName(ref ast.Ref) bool {
+	return c.unusedLocalCSSNames[ref]
+}
+
+func (c *linkerContext) isExportLive(repr *graph.JSRepr, alias string) bool {
+	if export, ok := repr.Meta.ResolvedExports[alias]; ok {
+		for _, partIndex := range repr.TopLevelSymbolToParts(export.Ref) {
+			if repr.AST.Parts[partIndex].IsLive {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (c *linkerContext) markFileReachableForCodeSplitting(sourceIndex uint32, entryPointBit uint, distanceFromEntryPoint uint32) {
 	file := &c.graph.Files[sourceIndex]
 	if !file.IsLive {
@@ -3946,6 +4748,10 @@ func (c *linkerContext) computeChunks() {
 
 	// Figure out which JS files are in which chunk
 	for _, sourceIndex := range c.graph.ReachableFiles {
+		// The runtime isn't in any chunk when its helpers are imported instead
+		if sourceIndex == runtime.SourceIndex && c.options.ExternalHelpers != "" {
+			continue
+		}
 		if file := &c.graph.Files[sourceIndex]; file.IsLive {
 			if _, ok := file.InputFile.Repr.(*graph.JSRepr); ok {
 				key := file.EntryBits.String()
@@ -4166,7 +4972,7 @@ func (c *linkerContext) findImportedPartsInJSOrder(chunk *chunkInfo) (js []uint3
 	// dependencies before dependents
 	var visit func(uint32)
 	visit = func(sourceIndex uint32) {
-		if visited[sourceIndex] {
+		if visited[sourceIndex] || (sourceIndex == runtime.SourceIndex && c.options.ExternalHelpers != "") {
 			return
 		}
 
@@ -4959,6 +5765,7 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 		MinifyIdentifiers:            c.options.MinifyIdentifiers,
 		MinifyWhitespace:             c.options.MinifyWhitespace,
 		MinifySyntax:                 c.options.MinifySyntax,
+		AssumePure:                   c.options.AssumePure,
 		LineLimit:                    c.options.LineLimit,
 		ASCIIOnly:                    c.options.ASCIIOnly,
 		ToCommonJSRef:                toCommonJSRef,
@@ -5304,6 +6111,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 		MinifyIdentifiers:            c.options.MinifyIdentifiers,
 		MinifyWhitespace:             c.options.MinifyWhitespace,
 		MinifySyntax:                 c.options.MinifySyntax,
+		AssumePure:                   c.options.AssumePure,
 		LineLimit:                    c.options.LineLimit,
 		ASCIIOnly:                    c.options.ASCIIOnly,
 		ToCommonJSRef:                toCommonJSRef,
@@ -5317,6 +6125,43 @@ func (c *linkerContext) generateEntryPointTailJS(
 	return
 }
 
+func (c *linkerContext) assignNamesUsingNameCache(r *renamer.MinifyRenamer, minifier *ast.NameMinifier, chunkRepr *chunkReprJS) {
+	// Only use keys that identify a single top-level symbol in this chunk.
+	// Generated symbols sometimes share the same original name.
+	refs := r.TopLevelSymbols()
+	keys := make(map[ast.Ref]string, len(refs))
+	keyCounts := make(map[string]int, len(refs))
+	for _, ref := range refs {
+		key := c.nameCacheKey(ref)
+		keys[ref] = key
+		keyCounts[key]++
+	}
+	cachedNames := make(map[ast.Ref]string)
+	for ref, key := range keys {
+		if keyCounts[key] != 1 {
+			delete(keys, ref)
+		} else if name, ok := c.options.NameCache[key].(string); ok {
+			cachedNames[ref] = name
+		}
+	}
+
+	r.AssignNamesByFrequency(minifier, cachedNames)
+
+	// Remember the names that were used, including ones from the cache. Only
+	// symbols declared in this chunk are remembered. Symbols imported from
+	// other chunks will try to use the same name as in the declaring chunk.
+	inThisChunk := make(map[uint32]bool, len(chunkRepr.filesInChunkInOrder))
+	for _, sourceIndex := range chunkRepr.filesInChunkInOrder {
+		inThisChunk[sourceIndex] = true
+	}
+	chunkRepr.nameCacheUpdates = make(map[string]string, len(keys))
+	for ref, key := range keys {
+		if inThisChunk[ref.SourceIndex] {
+			chunkRepr.nameCacheUpdates[key] = r.NameForSymbol(ref)
+		}
+	}
+}
+
 func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []uint32, timer *helpers.Timer) renamer.Renamer {
 	if c.options.MinifyIdentifiers {
 		timer.Begin("Minify symbols")
@@ -5356,7 +6201,8 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 
 	// Make sure imports get a chance to be renamed too
 	var sortedImportsFromOtherChunks stableRefArray
-	for _, imports := range chunk.chunkRepr.(*chunkReprJS).importsFromOtherChunks {
+	chunkRepr := chunk.chunkRepr.(*chunkReprJS)
+	for _, imports := range chunkRepr.importsFromOtherChunks {
 		for _, item := range imports {
 			sortedImportsFromOtherChunks = append(sortedImportsFromOtherChunks, stableRef{
 				StableSourceIndex: c.graph.StableSourceIndices[item.ref.SourceIndex],
@@ -5364,6 +6210,12 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 			})
 		}
 	}
+	for _, ref := range chunkRepr.externalHelpers {
+		sortedImportsFromOtherChunks = append(sortedImportsFromOtherChunks, stableRef{
+			StableSourceIndex: c.graph.StableSourceIndices[ref.SourceIndex],
+			Ref:               ref,
+		})
+	}
 	sort.Sort(sortedImportsFromOtherChunks)
 
 	// Minification uses frequency analysis to give shorter names to more frequent symbols
@@ -5446,7 +6298,11 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 		// cheap to compute.
 		minifier := ast.DefaultNameMinifierJS.ShuffleByCharFreq(freq)
 		timer.Begin("Assign names by frequency")
-		r.AssignNamesByFrequency(&minifier)
+		if c.options.NameCache != nil {
+			c.assignNamesUsingNameCache(r, &minifier, chunkRepr)
+		} else {
+			r.AssignNamesByFrequency(&minifier, nil)
+		}
 		timer.End("Assign names by frequency")
 		return r
 	}
@@ -5565,6 +6421,76 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 	return r
 }
 
+// Find all runtime helpers used by the code in this chunk. These are sorted
+// by name for determinism.
+func (c *linkerContext) findExternalHelpersInChunk(chunkRepr *chunkReprJS) []ast.Ref {
+	used := make(map[ast.Ref]bool)
+	var refs []ast.Ref
+
+	for _, partRange := range chunkRepr.partsInChunkInOrder {
+		repr := c.graph.Files[partRange.sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		for _, part := range repr.AST.Parts[partRange.partIndexBegin:partRange.partIndexEnd] {
+			if !part.IsLive {
+				continue
+			}
+			for ref := range part.SymbolUses {
+				if importData, ok := repr.Meta.ImportsToBind[ref]; ok && importData.SourceIndex == runtime.SourceIndex && !used[importData.Ref] {
+					used[importData.Ref] = true
+					refs = append(refs, importData.Ref)
+				}
+			}
+		}
+	}
+
+	sort.Slice(refs, func(i int, j int) bool {
+		return c.graph.Symbols.Get(refs[i]).OriginalName < c.graph.Symbols.Get(refs[j]).OriginalName
+	})
+	return refs
+}
+
+// This generates the statement that imports the runtime helpers used by a
+// chunk from the external helpers module. The import record for the helpers
+// module is appended to the provided import records.
+func (c *linkerContext) generateExternalHelpersImport(refs []ast.Ref, importRecords []ast.ImportRecord) (js_ast.Stmt, []ast.ImportRecord) {
+	importRecordIndex := uint32(len(importRecords))
+
+	if c.options.OutputFormat == config.FormatCommonJS {
+		// "var { __toESM, __publicField } = require('helpers');"
+		properties := make([]js_ast.PropertyBinding, 0, len(refs))
+		for _, ref := range refs {
+			properties = append(properties, js_ast.PropertyBinding{
+				Key:   js_ast.Expr{Data: &js_ast.EString{Value: helpers.StringToUTF16(c.graph.Symbols.Get(ref).OriginalName)}},
+				Value: js_ast.Binding{Data: &js_ast.BIdentifier{Ref: ref}},
+			})
+		}
+		importRecords = append(importRecords, ast.ImportRecord{
+			Kind: ast.ImportRequire,
+			Path: logger.Path{Text: c.options.ExternalHelpers},
+		})
+		return js_ast.Stmt{Data: &js_ast.SLocal{
+			Kind: js_ast.LocalVar,
+			Decls: []js_ast.Decl{{
+				Binding:    js_ast.Binding{Data: &js_ast.BObject{Properties: properties}},
+				ValueOrNil: js_ast.Expr{Data: &js_ast.ERequireString{ImportRecordIndex: importRecordIndex}},
+			}},
+		}}, importRecords
+	}
+
+	// "import { __toESM, __publicField } from 'helpers';"
+	items := make([]js_ast.ClauseItem, 0, len(refs))
+	for _, ref := range refs {
+		items = append(items, js_ast.ClauseItem{Name: ast.LocRef{Ref: ref}, Alias: c.graph.Symbols.Get(ref).OriginalName})
+	}
+	importRecords = append(importRecords, ast.ImportRecord{
+		Kind: ast.ImportStmt,
+		Path: logger.Path{Text: c.options.ExternalHelpers},
+	})
+	return js_ast.Stmt{Data: &js_ast.SImport{
+		Items:             &items,
+		ImportRecordIndex: importRecordIndex,
+	}}, importRecords
+}
+
 func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.WaitGroup) {
 	defer c.recoverInternalError(chunkWaitGroup, runtime.SourceIndex)
 
@@ -5584,6 +6510,9 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 	toCommonJSRef := ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__toCommonJS"].Ref)
 	toESMRef := ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__toESM"].Ref)
 	runtimeRequireRef := ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__require"].Ref)
+	if c.options.ExternalHelpers != "" {
+		chunkRepr.externalHelpers = c.findExternalHelpersInChunk(chunkRepr)
+	}
 	r := c.renameSymbolsInChunk(chunk, chunkRepr.filesInChunkInOrder, timer)
 	dataForSourceMaps := c.dataForSourceMaps()
 
@@ -5649,9 +6578,15 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 				Flags: ast.ShouldNotBeExternalInMetafile | ast.ContainsUniqueKey,
 			}
 		}
+		crossChunkPrefixStmts := chunkRepr.crossChunkPrefixStmts
+		if len(chunkRepr.externalHelpers) > 0 {
+			var stmt js_ast.Stmt
+			stmt, crossChunkImportRecords = c.generateExternalHelpersImport(chunkRepr.externalHelpers, crossChunkImportRecords)
+			crossChunkPrefixStmts = append([]js_ast.Stmt{stmt}, crossChunkPrefixStmts...)
+		}
 		crossChunkResult := js_printer.Print(js_ast.AST{
 			ImportRecords: crossChunkImportRecords,
-			Parts:         []js_ast.Part{{Stmts: chunkRepr.crossChunkPrefixStmts}},
+			Parts:         []js_ast.Part{{Stmts: crossChunkPrefixStmts}},
 		}, c.graph.Symbols, r, printOptions)
 		crossChunkPrefix = crossChunkResult.JS
 		jsonMetadataImports = crossChunkResult.JSONMetadataImports
@@ -6121,9 +7056,19 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 	// never change the "../" count.
 	chunkAbsDir := c.fs.Dir(c.fs.Join(c.options.AbsOutputDir, config.TemplateToString(chunk.finalTemplate)))
 
+	// Collect "@custom-media" and "@custom-selector" definitions from all files
+	// in the chunk. These may be used in a different file than the one they
+	// are defined in, so this must be done before any file is processed.
+	timer.Begin("Prepare CSS ASTs")
+	customDefinitions := css_parser.MakeCustomDefinitions(c.options.UnsupportedCSSFeatures)
+	for _, entry := range chunkRepr.importsInChunkInOrder {
+		if entry.kind == cssImportSourceIndex {
+			customDefinitions.AddDefinitions(c.graph.Files[entry.sourceIndex].InputFile.Repr.(*graph.CSSRepr).AST.Rules)
+		}
+	}
+
 	// Remove duplicate rules across files. This must be done in serial, not
 	// in parallel, and must be done from the last rule to the first rule.
-	timer.Begin("Prepare CSS ASTs")
 	asts := make([]css_ast.AST, len(chunkRepr.importsInChunkInOrder))
 	var remover css_parser.DeadRuleRemover
 	if c.options.MinifySyntax {
@@ -6195,6 +7140,8 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 				case *css_ast.RAtCharset:
 					compileResults[i].hasCharset = true
 					continue
+				case *css_ast.RAtCustomMedia, *css_ast.RAtCustomSelector:
+					continue
 				case *css_ast.RAtLayer:
 					didFindAtLayer = true
 				case *css_ast.RAtImport:
@@ -6219,6 +7166,15 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 				rules = append(rules, rule)
 			}
 
+			rules = customDefinitions.SubstituteInRules(rules)
+			if c.unusedLocalCSSNames != nil {
+				rules = css_parser.RemoveRulesWithUnusedLocalNames(rules, c.isUnusedLocalCSSName)
+			}
+			if values, ok := c.icssValues[entry.sourceIndex]; ok && len(values.scope) > 0 {
+				rules, ast.ImportRecords = css_parser.SubstituteICSSValuesInRules(rules, ast.ImportRecords, func(name string) *css_parser.ICSSResolvedValue {
+					return values.scope[name]
+				})
+			}
 			rules, ast.ImportRecords = wrapRulesWithConditions(rules, ast.ImportRecords, entry.conditions, entry.conditionImportRecords)
 
 			// Remove top-level duplicate rules across files
@@ -6230,6 +7186,12 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 			asts[i] = ast
 		}
 	}
+
+	// Remove "@layer" rules if they are unsupported. This must be done after
+	// all files have been prepared since layer order spans across files.
+	if c.options.UnsupportedCSSFeatures.Has(compat.CascadeLayers) {
+		css_parser.LowerCascadeLayers(asts)
+	}
 	timer.End("Prepare CSS ASTs")
 
 	// Generate CSS for each file in parallel
@@ -6908,6 +7870,50 @@ func (c *linkerContext) generateIsolatedHash(chunk *chunkInfo, channel chan []by
 	channel <- hash.Sum(nil)
 }
 
+// Debug IDs are formatted as UUIDs. This uses the layout of a version 4 UUID
+// even though the bits aren't random so that tools that validate the format
+// will accept it.
+func debugIDFromHash(bytes []byte) string {
+	var id [16]byte
+	copy(id[:], bytes)
+	id[6] = (id[6] & 0x0F) | 0x40
+	id[8] = (id[8] & 0x3F) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+// Generated source maps always end with "\n}\n", so the field can be inserted
+// right before that without having to parse the JSON
+func addDebugIDToSourceMap(sourceMap []byte, debugID string) []byte {
+	end := len(sourceMap) - len("\n}\n")
+	result := make([]byte, 0, len(sourceMap)+len(debugID)+20)
+	result = append(result, sourceMap[:end]...)
+	result = append(result, ",\n  \"debugId\": \""...)
+	result = append(result, debugID...)
+	result = append(result, '"')
+	result = append(result, sourceMap[end:]...)
+	return result
+}
+
+// This stores the debug ID in a global map keyed by the stack trace of an
+// error created in this file. Error reporting code can then search the keys
+// for the URL of a stack frame to find the debug ID for that frame. This is
+// deliberately written in ES5 syntax so that it works with every target.
+func (c *linkerContext) debugIDRegistration(debugID string) string {
+	if c.options.MinifyWhitespace {
+		return `!function(){try{var e=typeof globalThis<"u"?globalThis:typeof self<"u"?self:typeof window<"u"?window:typeof global<"u"?global:{},s=new e.Error().stack;s&&((e._debugIds=e._debugIds||{})[s]="` +
+			debugID + `")}catch(e){}}();` + "\n"
+	}
+	return `!function() {
+  try {
+    var g = typeof globalThis !== "undefined" ? globalThis : typeof self !== "undefined" ? self : typeof window !== "undefined" ? window : typeof global !== "undefined" ? global : {};
+    var s = new g.Error().stack;
+    if (s) (g._debugIds = g._debugIds || {})[s] = "` + debugID + `";
+  } catch (e) {
+  }
+}();
+`
+}
+
 func hashWriteUint32(hash hash.Hash, value uint32) {
 	var lengthBytes [4]byte
 	binary.LittleEndian.PutUint32(lengthBytes[:], value)
@@ -7093,16 +8099,22 @@ func (c *linkerContext) generateSourceMapForChunk(
 	}
 
 	// Write the sources
+	var ignoreList []int
 	j.AddString(",\n  \"sources\": [")
 	for i, item := range items {
 		if i != 0 {
 			j.AddString(", ")
 		}
 
+		// Sources are matched against the ignore list using their original path
+		// (with forward slashes) or their original URL if they aren't a file
+		ignoreListPath := item.source
+
 		// Modify the absolute path to the original file to be relative to the
 		// directory that will contain the output file for this chunk
 		if sourceURL, err := url.Parse(item.source); err == nil && helpers.IsFileURL(sourceURL) {
 			sourcePath := helpers.FilePathFromFileURL(c.fs, sourceURL)
+			ignoreListPath = strings.ReplaceAll(sourcePath, "\\", "/")
 			if relPath, ok := c.fs.Rel(chunkAbsDir, sourcePath); ok {
 				// Make sure to always use forward slashes, even on Windows
 				relativeURL := url.URL{Path: strings.ReplaceAll(relPath, "\\", "/")}
@@ -7114,6 +8126,10 @@ func (c *linkerContext) generateSourceMapForChunk(
 		}
 
 		j.AddBytes(helpers.QuoteForJSON(item.source, c.options.ASCIIOnly))
+
+		if c.options.SourceMapIgnoreList != nil && c.options.SourceMapIgnoreList.MatchString(ignoreListPath) {
+			ignoreList = append(ignoreList, i)
+		}
 	}
 	j.AddString("]")
 
@@ -7134,6 +8150,25 @@ func (c *linkerContext) generateSourceMapForChunk(
 		j.AddString("]")
 	}
 
+	// Write the ignore list. This is done twice because the "ignoreList" field
+	// was originally a Chrome-specific extension called "x_google_ignoreList"
+	// and some tools only understand the older name.
+	if len(ignoreList) > 0 {
+		var sb strings.Builder
+		sb.WriteString("[")
+		for i, sourcesIndex := range ignoreList {
+			if i != 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(strconv.Itoa(sourcesIndex))
+		}
+		sb.WriteString("]")
+		j.AddString(",\n  \"ignoreList\": ")
+		j.AddString(sb.String())
+		j.AddString(",\n  \"x_google_ignoreList\": ")
+		j.AddString(sb.String())
+	}
+
 	j.AddString(",\n  \"mappings\": \"")
 
 	// Write the mappings
@@ -54,10 +54,12 @@ const (
 	MsgID_CSS_InvalidCalc
 	MsgID_CSS_JSCommentInCSS
 	MsgID_CSS_UndefinedComposesFrom
+	MsgID_CSS_UndefinedCustomName
 	MsgID_CSS_UnsupportedAtCharset
 	MsgID_CSS_UnsupportedAtNamespace
 	MsgID_CSS_UnsupportedCSSProperty
 	MsgID_CSS_UnsupportedCSSNesting
+	MsgID_CSS_UnsupportedCustomSelector
 
 	// Bundler
 	MsgID_Bundler_AmbiguousReexport
@@ -181,6 +183,8 @@ func StringToMsgIDs(str string, logLevel LogLevel, overrides map[MsgID]LogLevel)
 		overrides[MsgID_CSS_JSCommentInCSS] = logLevel
 	case "undefined-composes-from":
 		overrides[MsgID_CSS_UndefinedComposesFrom] = logLevel
+	case "undefined-custom-name":
+		overrides[MsgID_CSS_UndefinedCustomName] = logLevel
 	case "unsupported-@charset":
 		overrides[MsgID_CSS_UnsupportedAtCharset] = logLevel
 	case "unsupported-@namespace":
@@ -189,6 +193,8 @@ func StringToMsgIDs(str string, logLevel LogLevel, overrides map[MsgID]LogLevel)
 		overrides[MsgID_CSS_UnsupportedCSSProperty] = logLevel
 	case "unsupported-css-nesting":
 		overrides[MsgID_CSS_UnsupportedCSSNesting] = logLevel
+	case "unsupported-custom-selector":
+		overrides[MsgID_CSS_UnsupportedCustomSelector] = logLevel
 
 	// Bundler
 	case "ambiguous-reexport":
@@ -325,6 +331,8 @@ func msgIDToInfo(id MsgID) msgIDInfo {
 		return msgIDInfo{name: "js-comment-in-css", vsID: vsID_CSS_JSCommentInCSS}
 	case MsgID_CSS_UndefinedComposesFrom:
 		return msgIDInfo{name: "undefined-composes-from", vsID: vsID_CSS_UndefinedComposesFrom}
+	case MsgID_CSS_UndefinedCustomName:
+		return msgIDInfo{name: "undefined-custom-name", vsID: vsID_CSS_UndefinedCustomName}
 	case MsgID_CSS_UnsupportedAtCharset:
 		return msgIDInfo{name: "unsupported-@charset", vsID: vsID_CSS_UnsupportedAtCharset}
 	case MsgID_CSS_UnsupportedAtNamespace:
@@ -333,6 +341,8 @@ func msgIDToInfo(id MsgID) msgIDInfo {
 		return msgIDInfo{name: "unsupported-css-property", vsID: vsID_CSS_UnsupportedCSSProperty}
 	case MsgID_CSS_UnsupportedCSSNesting:
 		return msgIDInfo{name: "unsupported-css-nesting", vsID: vsID_CSS_UnsupportedCSSNesting}
+	case MsgID_CSS_UnsupportedCustomSelector:
+		return msgIDInfo{name: "unsupported-custom-selector", vsID: vsID_CSS_UnsupportedCustomSelector}
 
 	// Bundler
 	case MsgID_Bundler_AmbiguousReexport:
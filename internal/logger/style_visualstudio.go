@@ -104,4 +104,6 @@ const (
 	vsID_SourceMap_UnsupportedSourceMapComment = 54
 	vsID_PackageJSON                           = 55
 	vsID_TSConfigJSON                          = 56
+	vsID_CSS_UndefinedCustomName               = 57
+	vsID_CSS_UnsupportedCustomSelector         = 58
 )
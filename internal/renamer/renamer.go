@@ -139,6 +139,16 @@ func (r *MinifyRenamer) NameForSymbol(ref ast.Ref) string {
 	return r.slots[ns][i.GetIndex()].name
 }
 
+// This returns all symbols that were allocated a top-level slot. Note that
+// the order is not deterministic.
+func (r *MinifyRenamer) TopLevelSymbols() []ast.Ref {
+	refs := make([]ast.Ref, 0, len(r.topLevelSymbolToSlot))
+	for ref := range r.topLevelSymbolToSlot {
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
 // The InnerIndex should be stable because the parser for a single file is
 // single-threaded and deterministically assigns out InnerIndex values
 // sequentially. But the SourceIndex should be unstable because the main thread
@@ -257,7 +267,11 @@ func (r *MinifyRenamer) AllocateTopLevelSymbolSlots(topLevelSymbols StableSymbol
 	}
 }
 
-func (r *MinifyRenamer) AssignNamesByFrequency(minifier *ast.NameMinifier) {
+// The "cachedNames" map optionally contains names for top-level symbols that
+// were assigned by a previous build. These names are kept if they are still
+// valid so that unrelated changes don't rename these symbols. All other names
+// are assigned by frequency while avoiding the cached names.
+func (r *MinifyRenamer) AssignNamesByFrequency(minifier *ast.NameMinifier, cachedNames map[ast.Ref]string) {
 	for ns, slots := range r.slots {
 		// Sort symbols by count
 		sorted := make(slotAndCountArray, len(slots))
@@ -266,10 +280,38 @@ func (r *MinifyRenamer) AssignNamesByFrequency(minifier *ast.NameMinifier) {
 		}
 		sort.Sort(sorted)
 
+		// Pin top-level symbols to their cached names first. This is done in
+		// sorted order so that the result is deterministic if more than one
+		// symbol wants the same name.
+		var pinnedNames map[string]bool
+		if ast.SlotNamespace(ns) == ast.SlotDefault && len(cachedNames) > 0 {
+			pinnedNames = make(map[string]bool)
+			for ref, slot := range r.topLevelSymbolToSlot {
+				name, ok := cachedNames[ref]
+				if !ok || r.symbols.Get(ref).SlotNamespace() != ast.SlotDefault || !js_ast.IsIdentifier(name) ||
+					r.reservedNames[name] != 0 || (slots[slot].needsCapitalForJSX != 0 && name[0] >= 'a' && name[0] <= 'z') {
+					continue
+				}
+				slots[slot].name = name
+			}
+			for _, data := range sorted {
+				if slot := &slots[data.slot]; slot.name != "" {
+					if pinnedNames[slot.name] {
+						slot.name = ""
+					} else {
+						pinnedNames[slot.name] = true
+					}
+				}
+			}
+		}
+
 		// Assign names to symbols
 		nextName := 0
 		for _, data := range sorted {
 			slot := &slots[data.slot]
+			if slot.name != "" {
+				continue
+			}
 			name := minifier.NumberToMinifiedName(nextName)
 			nextName++
 
@@ -280,14 +322,14 @@ func (r *MinifyRenamer) AssignNamesByFrequency(minifier *ast.NameMinifier) {
 			// with a "#" character.
 			switch ast.SlotNamespace(ns) {
 			case ast.SlotDefault:
-				for r.reservedNames[name] != 0 {
+				for r.reservedNames[name] != 0 || pinnedNames[name] {
 					name = minifier.NumberToMinifiedName(nextName)
 					nextName++
 				}
 
 				// Make sure names of symbols used in JSX elements start with a capital letter
 				if slot.needsCapitalForJSX != 0 {
-					for name[0] >= 'a' && name[0] <= 'z' {
+					for (name[0] >= 'a' && name[0] <= 'z') || pinnedNames[name] {
 						name = minifier.NumberToMinifiedName(nextName)
 						nextName++
 					}
@@ -655,8 +697,25 @@ func (r *ExportRenamer) NextRenamedName(name string) string {
 	return name
 }
 
+// This marks a name as used so that it won't be generated later. It returns
+// false if the name was already used.
+func (r *ExportRenamer) Reserve(name string) bool {
+	if r.used == nil {
+		r.used = make(map[string]uint32)
+	}
+	if _, ok := r.used[name]; ok {
+		return false
+	}
+	r.used[name] = 1
+	return true
+}
+
 func (r *ExportRenamer) NextMinifiedName() string {
-	name := ast.DefaultNameMinifierJS.NumberToMinifiedName(r.count)
-	r.count++
-	return name
+	for {
+		name := ast.DefaultNameMinifierJS.NumberToMinifiedName(r.count)
+		r.count++
+		if _, ok := r.used[name]; !ok {
+			return name
+		}
+	}
 }
@@ -66,9 +66,10 @@ type packageJSON struct {
 	// free of side effects must be included. This convention does not say
 	// anything about whether any statements within the file have side effects or
 	// not.
-	sideEffectsMap     map[string]bool
-	sideEffectsRegexps []*regexp.Regexp
-	sideEffectsData    *SideEffectsData
+	sideEffectsMap      map[string]bool
+	sideEffectsRegexps  []*regexp.Regexp
+	sideEffectsData     *SideEffectsData
+	hasSideEffectsField bool
 
 	// This represents the "imports" field in this package.json file.
 	importsMap *pjMap
@@ -401,6 +402,7 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 
 	// Read the "sideEffects" property
 	if sideEffectsJSON, sideEffectsLoc, ok := getProperty(json, "sideEffects"); ok {
+		packageJSON.hasSideEffectsField = true
 		switch data := sideEffectsJSON.Data.(type) {
 		case *js_ast.EBoolean:
 			if !data.Value {
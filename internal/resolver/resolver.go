@@ -116,6 +116,14 @@ type ResolveResult struct {
 
 	// This is the "type" field from "package.json"
 	ModuleTypeData js_ast.ModuleTypeData
+
+	// This is the "name" field from "package.json". It's used to look up
+	// per-package overrides for the "sideEffects" field.
+	PackageName string
+
+	// If true, the enclosing "package.json" file has a "sideEffects" field
+	// (even if it's "true"). Side effects are never inferred for these files.
+	PackageHasSideEffectsField bool
 }
 
 type suggestionRange uint8
@@ -915,8 +923,10 @@ func (r resolverQuery) finalizeResolve(result *ResolveResult) {
 					}
 				}
 
-				// Also copy over the "type" field
+				// Also copy over the "type" and "name" fields
 				result.ModuleTypeData = pkgJSON.moduleTypeData
+				result.PackageName = pkgJSON.name
+				result.PackageHasSideEffectsField = pkgJSON.hasSideEffectsField
 			}
 
 			// Copy various fields from the nearest enclosing "tsconfig.json" file if present
@@ -30,6 +30,9 @@ let mustBeString = (value: string | undefined): string | null =>
 let mustBeRegExp = (value: RegExp | undefined): string | null =>
   value instanceof RegExp ? null : 'a RegExp object'
 
+let mustBeRegExpOrFalse = (value: RegExp | false | undefined): string | null =>
+  value instanceof RegExp || value === false ? null : 'a RegExp object or false'
+
 let mustBeInteger = (value: number | undefined): string | null =>
   typeof value === 'number' && value === (value | 0) ? null : 'an integer'
 
@@ -122,6 +125,24 @@ function validateMangleCache(mangleCache: MangleCache | undefined): MangleCache
   return validated
 }
 
+type NameCache = Record<string, string>
+
+function validateNameCache(nameCache: NameCache | undefined): NameCache | undefined {
+  let validated: NameCache | undefined
+  if (nameCache !== undefined) {
+    validated = Object.create(null) as NameCache
+    for (let key in nameCache) {
+      let value = nameCache[key]
+      if (typeof value === 'string') {
+        validated[key] = value
+      } else {
+        throw new Error(`Expected ${quote(key)} in name cache to map to a string`)
+      }
+    }
+  }
+  return validated
+}
+
 type CommonOptions = types.BuildOptions | types.TransformOptions
 
 function pushLogFlags(flags: string[], options: CommonOptions, keys: OptionKeys, isTTY: boolean, logLevelDefault: types.LogLevel): void {
@@ -146,8 +167,10 @@ function validateStringValue(value: unknown, what: string, key?: string): string
 
 function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKeys): void {
   let legalComments = getFlag(options, keys, 'legalComments', mustBeString)
+  let comments = getFlag(options, keys, 'comments', mustBeString)
   let sourceRoot = getFlag(options, keys, 'sourceRoot', mustBeString)
   let sourcesContent = getFlag(options, keys, 'sourcesContent', mustBeBoolean)
+  let sourceMapIgnoreList = getFlag(options, keys, 'sourceMapIgnoreList', mustBeRegExpOrFalse)
   let target = getFlag(options, keys, 'target', mustBeStringOrArrayOfStrings)
   let format = getFlag(options, keys, 'format', mustBeString)
   let globalName = getFlag(options, keys, 'globalName', mustBeString)
@@ -158,44 +181,56 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   let minifySyntax = getFlag(options, keys, 'minifySyntax', mustBeBoolean)
   let minifyWhitespace = getFlag(options, keys, 'minifyWhitespace', mustBeBoolean)
   let minifyIdentifiers = getFlag(options, keys, 'minifyIdentifiers', mustBeBoolean)
+  let inlineFunctions = getFlag(options, keys, 'inlineFunctions', mustBeBoolean)
   let lineLimit = getFlag(options, keys, 'lineLimit', mustBeInteger)
   let drop = getFlag(options, keys, 'drop', mustBeArrayOfStrings)
   let dropLabels = getFlag(options, keys, 'dropLabels', mustBeArrayOfStrings)
   let charset = getFlag(options, keys, 'charset', mustBeString)
   let treeShaking = getFlag(options, keys, 'treeShaking', mustBeBoolean)
   let ignoreAnnotations = getFlag(options, keys, 'ignoreAnnotations', mustBeBoolean)
+  let assumePure = getFlag(options, keys, 'assumePure', mustBeArrayOfStrings)
+  let knownGlobals = getFlag(options, keys, 'knownGlobals', mustBeArrayOfStrings)
   let jsx = getFlag(options, keys, 'jsx', mustBeString)
   let jsxFactory = getFlag(options, keys, 'jsxFactory', mustBeString)
   let jsxFragment = getFlag(options, keys, 'jsxFragment', mustBeString)
   let jsxImportSource = getFlag(options, keys, 'jsxImportSource', mustBeString)
   let jsxDev = getFlag(options, keys, 'jsxDev', mustBeBoolean)
   let jsxSideEffects = getFlag(options, keys, 'jsxSideEffects', mustBeBoolean)
+  let jsxRefresh = getFlag(options, keys, 'jsxRefresh', mustBeBoolean)
   let define = getFlag(options, keys, 'define', mustBeObject)
   let logOverride = getFlag(options, keys, 'logOverride', mustBeObject)
   let supported = getFlag(options, keys, 'supported', mustBeObject)
+  let polyfill = getFlag(options, keys, 'polyfill', mustBeString)
   let pure = getFlag(options, keys, 'pure', mustBeArrayOfStrings)
   let keepNames = getFlag(options, keys, 'keepNames', mustBeBoolean)
+  let externalHelpers = getFlag(options, keys, 'externalHelpers', mustBeString)
   let platform = getFlag(options, keys, 'platform', mustBeString)
   let tsconfigRaw = getFlag(options, keys, 'tsconfigRaw', mustBeStringOrObject)
   let absPaths = getFlag(options, keys, 'absPaths', mustBeArrayOfStrings)
 
   if (legalComments) flags.push(`--legal-comments=${legalComments}`)
+  if (comments) flags.push(`--comments=${comments}`)
   if (sourceRoot !== void 0) flags.push(`--source-root=${sourceRoot}`)
   if (sourcesContent !== void 0) flags.push(`--sources-content=${sourcesContent}`)
+  if (sourceMapIgnoreList !== void 0) flags.push(`--source-map-ignore-list=${sourceMapIgnoreList ? jsRegExpToGoRegExp(sourceMapIgnoreList) : ''}`)
   if (target) flags.push(`--target=${validateAndJoinStringArray(Array.isArray(target) ? target : [target], 'target')}`)
   if (format) flags.push(`--format=${format}`)
   if (globalName) flags.push(`--global-name=${globalName}`)
   if (platform) flags.push(`--platform=${platform}`)
+  if (polyfill) flags.push(`--polyfill=${polyfill}`)
   if (tsconfigRaw) flags.push(`--tsconfig-raw=${typeof tsconfigRaw === 'string' ? tsconfigRaw : JSON.stringify(tsconfigRaw)}`)
 
   if (minify) flags.push('--minify')
   if (minifySyntax) flags.push('--minify-syntax')
   if (minifyWhitespace) flags.push('--minify-whitespace')
   if (minifyIdentifiers) flags.push('--minify-identifiers')
+  if (inlineFunctions) flags.push('--inline-functions')
   if (lineLimit) flags.push(`--line-limit=${lineLimit}`)
   if (charset) flags.push(`--charset=${charset}`)
   if (treeShaking !== void 0) flags.push(`--tree-shaking=${treeShaking}`)
   if (ignoreAnnotations) flags.push(`--ignore-annotations`)
+  if (assumePure) for (let what of assumePure) flags.push(`--assume-pure:${validateStringValue(what, 'assume pure')}`)
+  if (knownGlobals) for (let name of knownGlobals) flags.push(`--known-global:${validateStringValue(name, 'known global')}`)
   if (drop) for (let what of drop) flags.push(`--drop:${validateStringValue(what, 'drop')}`)
   if (dropLabels) flags.push(`--drop-labels=${validateAndJoinStringArray(dropLabels, 'drop label')}`)
   if (absPaths) flags.push(`--abs-paths=${validateAndJoinStringArray(absPaths, 'abs paths')}`)
@@ -209,6 +244,7 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   if (jsxImportSource) flags.push(`--jsx-import-source=${jsxImportSource}`)
   if (jsxDev) flags.push(`--jsx-dev`)
   if (jsxSideEffects) flags.push(`--jsx-side-effects`)
+  if (jsxRefresh) flags.push(`--jsx-refresh`)
 
   if (define) {
     for (let key in define) {
@@ -232,6 +268,7 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   }
   if (pure) for (let fn of pure) flags.push(`--pure:${validateStringValue(fn, 'pure')}`)
   if (keepNames) flags.push(`--keep-names`)
+  if (externalHelpers) flags.push(`--external-helpers=${externalHelpers}`)
 }
 
 function flagsForBuildOptions(
@@ -249,6 +286,7 @@ function flagsForBuildOptions(
   absWorkingDir: string | undefined,
   nodePaths: string[],
   mangleCache: MangleCache | undefined,
+  nameCache: NameCache | undefined,
 } {
   let flags: string[] = []
   let entries: [string, string][] = []
@@ -263,6 +301,9 @@ function flagsForBuildOptions(
   let splitting = getFlag(options, keys, 'splitting', mustBeBoolean)
   let preserveSymlinks = getFlag(options, keys, 'preserveSymlinks', mustBeBoolean)
   let metafile = getFlag(options, keys, 'metafile', mustBeBoolean)
+  let debugIds = getFlag(options, keys, 'debugIds', mustBeStringOrBoolean)
+  let cssModuleTypes = getFlag(options, keys, 'cssModuleTypes', mustBeBoolean)
+  let cssModuleTypesDir = getFlag(options, keys, 'cssModuleTypesDir', mustBeString)
   let outfile = getFlag(options, keys, 'outfile', mustBeString)
   let outdir = getFlag(options, keys, 'outdir', mustBeString)
   let outbase = getFlag(options, keys, 'outbase', mustBeString)
@@ -280,7 +321,10 @@ function flagsForBuildOptions(
   let entryNames = getFlag(options, keys, 'entryNames', mustBeString)
   let chunkNames = getFlag(options, keys, 'chunkNames', mustBeString)
   let assetNames = getFlag(options, keys, 'assetNames', mustBeString)
+  let assetInlineLimit = getFlag(options, keys, 'assetInlineLimit', mustBeInteger)
   let inject = getFlag(options, keys, 'inject', mustBeArrayOfStrings)
+  let inferSideEffects = getFlag(options, keys, 'inferSideEffects', mustBeBoolean)
+  let packageSideEffects = getFlag(options, keys, 'packageSideEffects', mustBeObject)
   let banner = getFlag(options, keys, 'banner', mustBeObject)
   let footer = getFlag(options, keys, 'footer', mustBeObject)
   let entryPoints = getFlag(options, keys, 'entryPoints', mustBeEntryPoints)
@@ -289,10 +333,14 @@ function flagsForBuildOptions(
   let write = getFlag(options, keys, 'write', mustBeBoolean) ?? writeDefault; // Default to true if not specified
   let allowOverwrite = getFlag(options, keys, 'allowOverwrite', mustBeBoolean)
   let mangleCache = getFlag(options, keys, 'mangleCache', mustBeObject)
+  let nameCache = getFlag(options, keys, 'nameCache', mustBeObject)
   keys.plugins = true; // "plugins" has already been read earlier
   checkForInvalidFlags(options, keys, `in ${callName}() call`)
 
   if (sourcemap) flags.push(`--sourcemap${sourcemap === true ? '' : `=${sourcemap}`}`)
+  if (debugIds) flags.push(`--debug-ids${debugIds === true ? '' : `=${debugIds}`}`)
+  if (cssModuleTypes) flags.push('--css-module-types')
+  if (cssModuleTypesDir) flags.push(`--css-module-types-dir=${cssModuleTypesDir}`)
   if (bundle) flags.push('--bundle')
   if (allowOverwrite) flags.push('--allow-overwrite')
   if (splitting) flags.push('--splitting')
@@ -308,6 +356,7 @@ function flagsForBuildOptions(
   if (entryNames) flags.push(`--entry-names=${entryNames}`)
   if (chunkNames) flags.push(`--chunk-names=${chunkNames}`)
   if (assetNames) flags.push(`--asset-names=${assetNames}`)
+  if (assetInlineLimit !== void 0) flags.push(`--asset-inline-limit=${assetInlineLimit}`)
   if (mainFields) flags.push(`--main-fields=${validateAndJoinStringArray(mainFields, 'main field')}`)
   if (conditions) flags.push(`--conditions=${validateAndJoinStringArray(conditions, 'condition')}`)
   if (external) for (let name of external) flags.push(`--external:${validateStringValue(name, 'external')}`)
@@ -330,6 +379,15 @@ function flagsForBuildOptions(
     }
   }
   if (inject) for (let path of inject) flags.push(`--inject:${validateStringValue(path, 'inject')}`)
+  if (inferSideEffects) flags.push(`--infer-side-effects`)
+  if (packageSideEffects) {
+    for (let name in packageSideEffects) {
+      if (name.indexOf('=') >= 0) throw new Error(`Invalid package name in package side effects: ${name}`)
+      const value = packageSideEffects[name]
+      if (typeof value !== 'boolean') throw new Error(`Expected value for package side effects ${quote(name)} to be a boolean, got ${typeof value} instead`)
+      flags.push(`--package-side-effects:${name}=${value}`)
+    }
+  }
   if (loader) {
     for (let ext in loader) {
       if (ext.indexOf('=') >= 0) throw new Error(`Invalid loader extension: ${ext}`)
@@ -398,6 +456,7 @@ function flagsForBuildOptions(
     absWorkingDir,
     nodePaths,
     mangleCache: validateMangleCache(mangleCache),
+    nameCache: validateNameCache(nameCache),
   }
 }
 
@@ -409,6 +468,7 @@ function flagsForTransformOptions(
 ): {
   flags: string[],
   mangleCache: MangleCache | undefined,
+  errorRecovery: boolean,
 } {
   let flags: string[] = []
   let keys: OptionKeys = Object.create(null)
@@ -421,6 +481,7 @@ function flagsForTransformOptions(
   let banner = getFlag(options, keys, 'banner', mustBeString)
   let footer = getFlag(options, keys, 'footer', mustBeString)
   let mangleCache = getFlag(options, keys, 'mangleCache', mustBeObject)
+  let errorRecovery = getFlag(options, keys, 'errorRecovery', mustBeBoolean)
   checkForInvalidFlags(options, keys, `in ${callName}() call`)
 
   if (sourcemap) flags.push(`--sourcemap=${sourcemap === true ? 'external' : sourcemap}`)
@@ -428,10 +489,12 @@ function flagsForTransformOptions(
   if (loader) flags.push(`--loader=${loader}`)
   if (banner) flags.push(`--banner=${banner}`)
   if (footer) flags.push(`--footer=${footer}`)
+  if (errorRecovery) flags.push(`--error-recovery`)
 
   return {
     flags,
     mangleCache: validateMangleCache(mangleCache),
+    errorRecovery: !!errorRecovery,
   }
 }
 
@@ -711,6 +774,7 @@ export function createChannel(streamIn: StreamIn): StreamOut {
         let {
           flags,
           mangleCache,
+          errorRecovery,
         } = flagsForTransformOptions(callName, options, isTTY, transformLogLevelDefault)
         let request: protocol.TransformRequest = {
           command: 'transform',
@@ -734,13 +798,15 @@ export function createChannel(streamIn: StreamIn): StreamOut {
                 map: response!.map,
                 mangleCache: undefined,
                 legalComments: undefined,
+                errors: undefined,
               }
               if ('legalComments' in response!) result.legalComments = response?.legalComments
               if (response!.mangleCache) result.mangleCache = response?.mangleCache
+              if (errorRecovery) result.errors = errors
               callback(null, result)
             }
           }
-          if (errors.length > 0) return callback(failureErrorWithLog('Transform failed', errors, warnings), null)
+          if (errors.length > 0 && !errorRecovery) return callback(failureErrorWithLog('Transform failed', errors, warnings), null)
 
           // Read the JavaScript file from the file system
           if (response!.codeFS) {
@@ -923,6 +989,7 @@ function buildOrContextImpl(
       absWorkingDir,
       nodePaths,
       mangleCache,
+      nameCache,
     } = flagsForBuildOptions(callName, options, isTTY, buildLogLevelDefault, writeDefault)
     if (write && !streamIn.hasFS) throw new Error(`The "write" option is unavailable in this environment`)
 
@@ -941,6 +1008,7 @@ function buildOrContextImpl(
     }
     if (requestPlugins) request.plugins = requestPlugins
     if (mangleCache) request.mangleCache = mangleCache
+    if (nameCache) request.nameCache = nameCache
 
     // Factor out response handling so it can be reused for rebuilds
     const buildResponseToResult = (
@@ -953,12 +1021,14 @@ function buildOrContextImpl(
         outputFiles: undefined,
         metafile: undefined,
         mangleCache: undefined,
+        nameCache: undefined,
       }
       const originalErrors = result.errors.slice()
       const originalWarnings = result.warnings.slice()
       if (response!.outputFiles) result.outputFiles = response!.outputFiles.map(convertOutputFiles)
       if (response!.metafile && response!.metafile.length) result.metafile = parseJSON(response!.metafile)
       if (response!.mangleCache) result.mangleCache = response!.mangleCache
+      if (response!.nameCache) result.nameCache = response!.nameCache
       if (response!.writeToStdout !== void 0) console.log(protocol.decodeUTF8(response!.writeToStdout).replace(/\n$/, ''))
       runOnEndCallbacks(result, (onEndErrors, onEndWarnings) => {
         if (originalErrors.length > 0 || onEndErrors.length > 0) {
@@ -1090,6 +1160,7 @@ function buildOrContextImpl(
           const certfile = getFlag(options, keys, 'certfile', mustBeString)
           const fallback = getFlag(options, keys, 'fallback', mustBeString)
           const cors = getFlag(options, keys, 'cors', mustBeObject)
+          const overlay = getFlag(options, keys, 'overlay', mustBeBoolean)
           const onRequest = getFlag(options, keys, 'onRequest', mustBeFunction)
           checkForInvalidFlags(options, keys, `in serve() call`)
 
@@ -1104,6 +1175,7 @@ function buildOrContextImpl(
           if (keyfile !== void 0) request.keyfile = keyfile
           if (certfile !== void 0) request.certfile = certfile
           if (fallback !== void 0) request.fallback = fallback
+          if (overlay !== void 0) request.overlay = overlay
 
           if (cors) {
             const corsKeys: OptionKeys = {}
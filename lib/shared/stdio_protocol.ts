@@ -19,6 +19,7 @@ export interface BuildRequest {
   context: boolean
   plugins?: BuildPlugin[]
   mangleCache?: Record<string, string | false>
+  nameCache?: Record<string, string>
 }
 
 export interface ServeRequest {
@@ -31,6 +32,7 @@ export interface ServeRequest {
   keyfile?: string
   certfile?: string
   fallback?: string
+  overlay?: boolean
   corsOrigin?: string[]
 }
 
@@ -53,6 +55,7 @@ export interface BuildResponse {
   outputFiles?: BuildOutputFile[]
   metafile?: Uint8Array
   mangleCache?: Record<string, string | false>
+  nameCache?: Record<string, string>
   writeToStdout?: Uint8Array
 }
 
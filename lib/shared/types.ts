@@ -1,10 +1,11 @@
 export type Platform = 'browser' | 'node' | 'neutral'
 export type Format = 'iife' | 'cjs' | 'esm'
-export type Loader = 'base64' | 'binary' | 'copy' | 'css' | 'dataurl' | 'default' | 'empty' | 'file' | 'js' | 'json' | 'jsx' | 'local-css' | 'text' | 'ts' | 'tsx'
+export type Loader = 'auto-dataurl' | 'base64' | 'binary' | 'copy' | 'css' | 'dataurl' | 'default' | 'empty' | 'file' | 'js' | 'json' | 'jsx' | 'local-css' | 'text' | 'ts' | 'tsx'
 export type LogLevel = 'verbose' | 'debug' | 'info' | 'warning' | 'error' | 'silent'
 export type LogStyle = 'default' | 'clang' | 'visualstudio'
 export type Charset = 'ascii' | 'utf8'
 export type Drop = 'console' | 'debugger'
+export type AssumePure = 'getters' | 'property-reads' | 'unknown-globals' | 'try'
 export type AbsPaths = 'code' | 'log' | 'metafile'
 
 interface CommonOptions {
@@ -12,10 +13,14 @@ interface CommonOptions {
   sourcemap?: boolean | 'linked' | 'inline' | 'external' | 'both'
   /** Documentation: https://esbuild.github.io/api/#legal-comments */
   legalComments?: 'none' | 'inline' | 'eof' | 'linked' | 'external'
+  /** Documentation: https://esbuild.github.io/api/#comments */
+  comments?: 'default' | 'preserve'
   /** Documentation: https://esbuild.github.io/api/#source-root */
   sourceRoot?: string
   /** Documentation: https://esbuild.github.io/api/#sources-content */
   sourcesContent?: boolean
+  /** Documentation: https://esbuild.github.io/api/#source-map-ignore-list */
+  sourceMapIgnoreList?: RegExp | false
 
   /** Documentation: https://esbuild.github.io/api/#format */
   format?: Format
@@ -25,6 +30,8 @@ interface CommonOptions {
   target?: string | string[]
   /** Documentation: https://esbuild.github.io/api/#supported */
   supported?: Record<string, boolean>
+  /** Documentation: https://esbuild.github.io/api/#polyfill */
+  polyfill?: string
   /** Documentation: https://esbuild.github.io/api/#platform */
   platform?: Platform
 
@@ -48,6 +55,8 @@ interface CommonOptions {
   minifyIdentifiers?: boolean
   /** Documentation: https://esbuild.github.io/api/#minify */
   minifySyntax?: boolean
+  /** Documentation: https://esbuild.github.io/api/#inline-functions */
+  inlineFunctions?: boolean
   /** Documentation: https://esbuild.github.io/api/#line-limit */
   lineLimit?: number
   /** Documentation: https://esbuild.github.io/api/#charset */
@@ -56,6 +65,10 @@ interface CommonOptions {
   treeShaking?: boolean
   /** Documentation: https://esbuild.github.io/api/#ignore-annotations */
   ignoreAnnotations?: boolean
+  /** Documentation: https://esbuild.github.io/api/#assume-pure */
+  assumePure?: AssumePure[]
+  /** Documentation: https://esbuild.github.io/api/#known-globals */
+  knownGlobals?: string[]
 
   /** Documentation: https://esbuild.github.io/api/#jsx */
   jsx?: 'transform' | 'preserve' | 'automatic'
@@ -69,6 +82,8 @@ interface CommonOptions {
   jsxDev?: boolean
   /** Documentation: https://esbuild.github.io/api/#jsx-side-effects */
   jsxSideEffects?: boolean
+  /** Documentation: https://esbuild.github.io/api/#jsx-refresh */
+  jsxRefresh?: boolean
 
   /** Documentation: https://esbuild.github.io/api/#define */
   define?: { [key: string]: string }
@@ -76,6 +91,8 @@ interface CommonOptions {
   pure?: string[]
   /** Documentation: https://esbuild.github.io/api/#keep-names */
   keepNames?: boolean
+  /** Documentation: https://esbuild.github.io/api/#external-helpers */
+  externalHelpers?: string
 
   /** Documentation: https://esbuild.github.io/api/#abs-paths */
   absPaths?: AbsPaths[]
@@ -124,6 +141,14 @@ export interface BuildOptions extends CommonOptions {
   outfile?: string
   /** Documentation: https://esbuild.github.io/api/#metafile */
   metafile?: boolean
+  /** Documentation: https://esbuild.github.io/api/#debug-ids */
+  debugIds?: boolean | 'comment' | 'register'
+  /** Documentation: https://esbuild.github.io/api/#css-module-types */
+  cssModuleTypes?: boolean
+  /** Documentation: https://esbuild.github.io/api/#css-module-types */
+  cssModuleTypesDir?: string
+  /** Documentation: https://esbuild.github.io/api/#name-cache */
+  nameCache?: Record<string, string>
   /** Documentation: https://esbuild.github.io/api/#outdir */
   outdir?: string
   /** Documentation: https://esbuild.github.io/api/#outbase */
@@ -158,8 +183,14 @@ export interface BuildOptions extends CommonOptions {
   chunkNames?: string
   /** Documentation: https://esbuild.github.io/api/#asset-names */
   assetNames?: string
+  /** Documentation: https://esbuild.github.io/api/#asset-inline-limit */
+  assetInlineLimit?: number
   /** Documentation: https://esbuild.github.io/api/#inject */
   inject?: string[]
+  /** Documentation: https://esbuild.github.io/api/#infer-side-effects */
+  inferSideEffects?: boolean
+  /** Documentation: https://esbuild.github.io/api/#infer-side-effects */
+  packageSideEffects?: Record<string, boolean>
   /** Documentation: https://esbuild.github.io/api/#banner */
   banner?: { [type: string]: string }
   /** Documentation: https://esbuild.github.io/api/#footer */
@@ -232,6 +263,8 @@ export interface BuildResult<ProvidedOptions extends BuildOptions = BuildOptions
   metafile: Metafile | (ProvidedOptions['metafile'] extends true ? never : undefined)
   /** Only when "mangleCache" is present */
   mangleCache: Record<string, string | false> | (ProvidedOptions['mangleCache'] extends Object ? never : undefined)
+  /** Only when "nameCache" is present */
+  nameCache: Record<string, string> | (ProvidedOptions['nameCache'] extends Object ? never : undefined)
 }
 
 export interface BuildFailure extends Error {
@@ -248,6 +281,7 @@ export interface ServeOptions {
   certfile?: string
   fallback?: string
   cors?: CORSOptions
+  overlay?: boolean
   onRequest?: (args: ServeOnRequestArgs) => void
 }
 
@@ -280,6 +314,8 @@ export interface TransformOptions extends CommonOptions {
   banner?: string
   /** Documentation: https://esbuild.github.io/api/#footer */
   footer?: string
+  /** Documentation: https://esbuild.github.io/api/#error-recovery */
+  errorRecovery?: boolean
 }
 
 export interface TransformResult<ProvidedOptions extends TransformOptions = TransformOptions> {
@@ -290,6 +326,8 @@ export interface TransformResult<ProvidedOptions extends TransformOptions = Tran
   mangleCache: Record<string, string | false> | (ProvidedOptions['mangleCache'] extends Object ? never : undefined)
   /** Only when "legalComments" is "external" */
   legalComments: string | (ProvidedOptions['legalComments'] extends 'external' ? never : undefined)
+  /** Only when "errorRecovery" is true */
+  errors: Message[] | (ProvidedOptions['errorRecovery'] extends true ? never : undefined)
 }
 
 export interface TransformFailure extends Error {
@@ -486,6 +524,7 @@ export interface Metafile {
       }[]
       format?: 'cjs' | 'esm'
       with?: Record<string, string>
+      inferredSideEffects?: boolean
     }
   }
   outputs: {
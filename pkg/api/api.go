@@ -99,6 +99,21 @@ const (
 	SourcesContentExclude
 )
 
+type DebugIDs uint8
+
+const (
+	DebugIDsNone DebugIDs = iota
+	DebugIDsComment
+	DebugIDsRegister
+)
+
+type Comments uint8
+
+const (
+	CommentsDefault Comments = iota
+	CommentsPreserve
+)
+
 type LegalComments uint8
 
 const (
@@ -158,6 +173,7 @@ const (
 	LoaderText
 	LoaderTS
 	LoaderTSX
+	LoaderAutoDataURL
 )
 
 type Platform uint8
@@ -268,6 +284,15 @@ const (
 	DropDebugger
 )
 
+type AssumePure uint8
+
+const (
+	AssumePureGetters AssumePure = 1 << iota
+	AssumePurePropertyReads
+	AssumePureUnknownGlobals
+	AssumePureTryBlocks
+)
+
 type MangleQuoted uint8
 
 const (
@@ -298,24 +323,48 @@ type BuildOptions struct {
 	SourceRoot     string         // Documentation: https://esbuild.github.io/api/#source-root
 	SourcesContent SourcesContent // Documentation: https://esbuild.github.io/api/#sources-content
 
+	// Sources matching this regular expression are listed in the "ignoreList"
+	// field of generated source maps. Defaults to sources in "node_modules".
+	// Set "DisableSourceMapIgnoreList" to omit the "ignoreList" field entirely.
+	SourceMapIgnoreList        string // Documentation: https://esbuild.github.io/api/#source-map-ignore-list
+	DisableSourceMapIgnoreList bool   // Documentation: https://esbuild.github.io/api/#source-map-ignore-list
+
+	// This adds a content-derived debug ID to each output file and its source
+	// map so that they can be matched up without relying on their URLs
+	DebugIDs DebugIDs // Documentation: https://esbuild.github.io/api/#debug-ids
+
+	// This writes a ".d.ts" file for each CSS module that declares its local
+	// names. These are written next to each CSS module unless a directory is
+	// provided, in which case they mirror the directory structure of "Outbase".
+	CSSModuleTypes    bool   // Documentation: https://esbuild.github.io/api/#css-module-types
+	CSSModuleTypesDir string // Documentation: https://esbuild.github.io/api/#css-module-types
+
 	Target    Target          // Documentation: https://esbuild.github.io/api/#target
 	Engines   []Engine        // Documentation: https://esbuild.github.io/api/#target
 	Supported map[string]bool // Documentation: https://esbuild.github.io/api/#supported
-
-	MangleProps       string                 // Documentation: https://esbuild.github.io/api/#mangle-props
-	ReserveProps      string                 // Documentation: https://esbuild.github.io/api/#mangle-props
-	MangleQuoted      MangleQuoted           // Documentation: https://esbuild.github.io/api/#mangle-props
-	MangleCache       map[string]interface{} // Documentation: https://esbuild.github.io/api/#mangle-props
-	Drop              Drop                   // Documentation: https://esbuild.github.io/api/#drop
-	DropLabels        []string               // Documentation: https://esbuild.github.io/api/#drop-labels
-	MinifyWhitespace  bool                   // Documentation: https://esbuild.github.io/api/#minify
-	MinifyIdentifiers bool                   // Documentation: https://esbuild.github.io/api/#minify
-	MinifySyntax      bool                   // Documentation: https://esbuild.github.io/api/#minify
-	LineLimit         int                    // Documentation: https://esbuild.github.io/api/#line-limit
-	Charset           Charset                // Documentation: https://esbuild.github.io/api/#charset
-	TreeShaking       TreeShaking            // Documentation: https://esbuild.github.io/api/#tree-shaking
-	IgnoreAnnotations bool                   // Documentation: https://esbuild.github.io/api/#ignore-annotations
-	LegalComments     LegalComments          // Documentation: https://esbuild.github.io/api/#legal-comments
+	Polyfill  string          // Documentation: https://esbuild.github.io/api/#polyfill
+
+	MangleProps        string                 // Documentation: https://esbuild.github.io/api/#mangle-props
+	ReserveProps       string                 // Documentation: https://esbuild.github.io/api/#mangle-props
+	MangleQuoted       MangleQuoted           // Documentation: https://esbuild.github.io/api/#mangle-props
+	MangleCache        map[string]interface{} // Documentation: https://esbuild.github.io/api/#mangle-props
+	NameCache          map[string]interface{} // Documentation: https://esbuild.github.io/api/#name-cache
+	Drop               Drop                   // Documentation: https://esbuild.github.io/api/#drop
+	DropLabels         []string               // Documentation: https://esbuild.github.io/api/#drop-labels
+	MinifyWhitespace   bool                   // Documentation: https://esbuild.github.io/api/#minify
+	MinifyIdentifiers  bool                   // Documentation: https://esbuild.github.io/api/#minify
+	MinifySyntax       bool                   // Documentation: https://esbuild.github.io/api/#minify
+	InlineFunctions    bool                   // Documentation: https://esbuild.github.io/api/#inline-functions
+	LineLimit          int                    // Documentation: https://esbuild.github.io/api/#line-limit
+	Charset            Charset                // Documentation: https://esbuild.github.io/api/#charset
+	TreeShaking        TreeShaking            // Documentation: https://esbuild.github.io/api/#tree-shaking
+	IgnoreAnnotations  bool                   // Documentation: https://esbuild.github.io/api/#ignore-annotations
+	InferSideEffects   bool                   // Documentation: https://esbuild.github.io/api/#infer-side-effects
+	PackageSideEffects map[string]bool        // Documentation: https://esbuild.github.io/api/#infer-side-effects
+	AssumePure         AssumePure             // Documentation: https://esbuild.github.io/api/#assume-pure
+	KnownGlobals       []string               // Documentation: https://esbuild.github.io/api/#known-globals
+	LegalComments      LegalComments          // Documentation: https://esbuild.github.io/api/#legal-comments
+	Comments           Comments               // Documentation: https://esbuild.github.io/api/#comments
 
 	JSX             JSX    // Documentation: https://esbuild.github.io/api/#jsx-mode
 	JSXFactory      string // Documentation: https://esbuild.github.io/api/#jsx-factory
@@ -323,6 +372,7 @@ type BuildOptions struct {
 	JSXImportSource string // Documentation: https://esbuild.github.io/api/#jsx-import-source
 	JSXDev          bool   // Documentation: https://esbuild.github.io/api/#jsx-dev
 	JSXSideEffects  bool   // Documentation: https://esbuild.github.io/api/#jsx-side-effects
+	JSXRefresh      bool   // Documentation: https://esbuild.github.io/api/#jsx-refresh
 
 	Define    map[string]string // Documentation: https://esbuild.github.io/api/#define
 	Pure      []string          // Documentation: https://esbuild.github.io/api/#pure
@@ -351,6 +401,7 @@ type BuildOptions struct {
 	OutExtension      map[string]string // Documentation: https://esbuild.github.io/api/#out-extension
 	PublicPath        string            // Documentation: https://esbuild.github.io/api/#public-path
 	Inject            []string          // Documentation: https://esbuild.github.io/api/#inject
+	ExternalHelpers   string            // Documentation: https://esbuild.github.io/api/#external-helpers
 	Banner            map[string]string // Documentation: https://esbuild.github.io/api/#banner
 	Footer            map[string]string // Documentation: https://esbuild.github.io/api/#footer
 	NodePaths         []string          // Documentation: https://esbuild.github.io/api/#node-paths
@@ -359,6 +410,11 @@ type BuildOptions struct {
 	ChunkNames string // Documentation: https://esbuild.github.io/api/#chunk-names
 	AssetNames string // Documentation: https://esbuild.github.io/api/#asset-names
 
+	// Files loaded with the "auto-dataurl" loader are inlined as data URLs if
+	// they are smaller than this many bytes. This defaults to 4096 when nil. Set
+	// it to 0 to never inline any files.
+	AssetInlineLimit *int // Documentation: https://esbuild.github.io/api/#asset-inline-limit
+
 	EntryPoints         []string     // Documentation: https://esbuild.github.io/api/#entry-points
 	EntryPointsAdvanced []EntryPoint // Documentation: https://esbuild.github.io/api/#entry-points
 
@@ -387,6 +443,7 @@ type BuildResult struct {
 	OutputFiles []OutputFile
 	Metafile    string
 	MangleCache map[string]interface{}
+	NameCache   map[string]interface{}
 }
 
 type OutputFile struct {
@@ -431,9 +488,16 @@ type TransformOptions struct {
 	SourceRoot     string         // Documentation: https://esbuild.github.io/api/#source-root
 	SourcesContent SourcesContent // Documentation: https://esbuild.github.io/api/#sources-content
 
+	// Sources matching this regular expression are listed in the "ignoreList"
+	// field of generated source maps. Defaults to sources in "node_modules".
+	// Set "DisableSourceMapIgnoreList" to omit the "ignoreList" field entirely.
+	SourceMapIgnoreList        string // Documentation: https://esbuild.github.io/api/#source-map-ignore-list
+	DisableSourceMapIgnoreList bool   // Documentation: https://esbuild.github.io/api/#source-map-ignore-list
+
 	Target    Target          // Documentation: https://esbuild.github.io/api/#target
 	Engines   []Engine        // Documentation: https://esbuild.github.io/api/#target
 	Supported map[string]bool // Documentation: https://esbuild.github.io/api/#supported
+	Polyfill  string          // Documentation: https://esbuild.github.io/api/#polyfill
 
 	Platform   Platform // Documentation: https://esbuild.github.io/api/#platform
 	Format     Format   // Documentation: https://esbuild.github.io/api/#format
@@ -448,11 +512,15 @@ type TransformOptions struct {
 	MinifyWhitespace  bool                   // Documentation: https://esbuild.github.io/api/#minify
 	MinifyIdentifiers bool                   // Documentation: https://esbuild.github.io/api/#minify
 	MinifySyntax      bool                   // Documentation: https://esbuild.github.io/api/#minify
+	InlineFunctions   bool                   // Documentation: https://esbuild.github.io/api/#inline-functions
 	LineLimit         int                    // Documentation: https://esbuild.github.io/api/#line-limit
 	Charset           Charset                // Documentation: https://esbuild.github.io/api/#charset
 	TreeShaking       TreeShaking            // Documentation: https://esbuild.github.io/api/#tree-shaking
 	IgnoreAnnotations bool                   // Documentation: https://esbuild.github.io/api/#ignore-annotations
+	AssumePure        AssumePure             // Documentation: https://esbuild.github.io/api/#assume-pure
+	KnownGlobals      []string               // Documentation: https://esbuild.github.io/api/#known-globals
 	LegalComments     LegalComments          // Documentation: https://esbuild.github.io/api/#legal-comments
+	Comments          Comments               // Documentation: https://esbuild.github.io/api/#comments
 
 	JSX             JSX    // Documentation: https://esbuild.github.io/api/#jsx
 	JSXFactory      string // Documentation: https://esbuild.github.io/api/#jsx-factory
@@ -460,6 +528,7 @@ type TransformOptions struct {
 	JSXImportSource string // Documentation: https://esbuild.github.io/api/#jsx-import-source
 	JSXDev          bool   // Documentation: https://esbuild.github.io/api/#jsx-dev
 	JSXSideEffects  bool   // Documentation: https://esbuild.github.io/api/#jsx-side-effects
+	JSXRefresh      bool   // Documentation: https://esbuild.github.io/api/#jsx-refresh
 
 	TsconfigRaw string // Documentation: https://esbuild.github.io/api/#tsconfig-raw
 	Banner      string // Documentation: https://esbuild.github.io/api/#banner
@@ -469,8 +538,15 @@ type TransformOptions struct {
 	Pure      []string          // Documentation: https://esbuild.github.io/api/#pure
 	KeepNames bool              // Documentation: https://esbuild.github.io/api/#keep-names
 
+	ExternalHelpers string // Documentation: https://esbuild.github.io/api/#external-helpers
+
 	Sourcefile string // Documentation: https://esbuild.github.io/api/#sourcefile
 	Loader     Loader // Documentation: https://esbuild.github.io/api/#loader
+
+	// If true, syntax errors in JavaScript and TypeScript input don't prevent
+	// code from being generated. Every error is still reported but only the
+	// statements that contain errors are left out of the output.
+	ErrorRecovery bool // Documentation: https://esbuild.github.io/api/#error-recovery
 }
 
 type TransformResult struct {
@@ -489,6 +565,14 @@ func Transform(input string, options TransformOptions) TransformResult {
 	return transformImpl(input, options)
 }
 
+// This returns a module that exports all of esbuild's runtime helpers. It's
+// intended to be used with the "ExternalHelpers" option. The target, format,
+// and minification options are respected so that the generated helpers match
+// the code that imports them. The "Loader" and "Sourcefile" options are ignored.
+func GenerateHelpers(options TransformOptions) TransformResult {
+	return generateHelpersImpl(options)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Context API
 
@@ -501,6 +585,7 @@ type ServeOptions struct {
 	Certfile  string
 	Fallback  string
 	CORS      CORSOptions
+	Overlay   bool
 	OnRequest func(ServeOnRequestArgs)
 }
 
@@ -562,6 +647,24 @@ func Context(buildOptions BuildOptions) (BuildContext, *ContextError) {
 	return ctx, nil
 }
 
+// A server that can serve the output files of multiple build contexts. Each
+// build context is mounted under its own path prefix (e.g. "/app-a/"). The
+// server provides a single live reload event stream at "/esbuild" for all
+// mounted build contexts, and a directory listing that includes the mounted
+// path prefixes. Otherwise this behaves the same as "BuildContext.Serve".
+type Server interface {
+	// Serve the output files of this build context under this path prefix.
+	// Disposing of the build context removes it from the server.
+	Mount(pathPrefix string, ctx BuildContext) error
+
+	// Stop the server and close all event streams
+	Stop()
+}
+
+func NewServer(options ServeOptions) (Server, ServeResult, error) {
+	return newServerImpl(options)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Plugin API
 
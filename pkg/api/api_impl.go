@@ -36,6 +36,7 @@ import (
 	"github.com/evanw/esbuild/internal/linker"
 	"github.com/evanw/esbuild/internal/logger"
 	"github.com/evanw/esbuild/internal/resolver"
+	"github.com/evanw/esbuild/internal/runtime"
 	"github.com/evanw/esbuild/internal/xxhash"
 )
 
@@ -173,6 +174,44 @@ func validateLegalComments(value LegalComments, bundle bool) config.LegalComment
 	}
 }
 
+func validatePreserveComments(value Comments) bool {
+	switch value {
+	case CommentsDefault:
+		return false
+	case CommentsPreserve:
+		return true
+	default:
+		panic("Invalid comments")
+	}
+}
+
+// This matches the default in other bundlers
+const defaultAssetInlineLimit = 4096
+
+func validateAssetInlineLimit(log logger.Log, value *int) int {
+	if value == nil {
+		return defaultAssetInlineLimit
+	}
+	if *value < 0 {
+		log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid asset inline limit: %d", *value))
+		return 0
+	}
+	return *value
+}
+
+func validateDebugIDs(value DebugIDs) config.DebugIDs {
+	switch value {
+	case DebugIDsNone:
+		return config.DebugIDsNone
+	case DebugIDsComment:
+		return config.DebugIDsComment
+	case DebugIDsRegister:
+		return config.DebugIDsRegister
+	default:
+		panic("Invalid debug IDs")
+	}
+}
+
 func validateColor(value StderrColor) logger.UseColor {
 	switch value {
 	case ColorIfTerminal:
@@ -261,6 +300,8 @@ func validateTreeShaking(value TreeShaking, bundle bool, format Format) bool {
 
 func validateLoader(value Loader) config.Loader {
 	switch value {
+	case LoaderAutoDataURL:
+		return config.LoaderAutoDataURL
 	case LoaderBase64:
 		return config.LoaderBase64
 	case LoaderBinary:
@@ -310,9 +351,9 @@ func extractPathStyle(absPaths AbsPaths, flag AbsPaths) logger.PathStyle {
 
 var versionRegex = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?(-[A-Za-z0-9]+(?:\.[A-Za-z0-9]+)*)?$`)
 
-func validateFeatures(log logger.Log, target Target, engines []Engine) (compat.JSFeature, compat.CSSFeature, map[css_ast.D]compat.CSSPrefix, string) {
+func validateFeatures(log logger.Log, target Target, engines []Engine) (compat.JSFeature, compat.CSSFeature, compat.Builtin, map[css_ast.D]compat.CSSPrefix, compat.CSSNamePrefixes, string) {
 	if target == DefaultTarget && len(engines) == 0 {
-		return 0, 0, nil, ""
+		return 0, 0, 0, nil, compat.CSSNamePrefixes{}, ""
 	}
 
 	constraints := make(map[compat.Engine]compat.Semver)
@@ -389,7 +430,7 @@ func validateFeatures(log logger.Log, target Target, engines []Engine) (compat.J
 	sort.Strings(targets)
 	targetEnv := helpers.StringArrayToQuotedCommaSeparatedString(targets)
 
-	return compat.UnsupportedJSFeatures(constraints), compat.UnsupportedCSSFeatures(constraints), compat.CSSPrefixData(constraints), targetEnv
+	return compat.UnsupportedJSFeatures(constraints), compat.UnsupportedCSSFeatures(constraints), compat.UnsupportedBuiltins(constraints), compat.CSSPrefixData(constraints), compat.CSSNamePrefixData(constraints), targetEnv
 }
 
 func validateSupported(log logger.Log, supported map[string]bool) (
@@ -445,6 +486,22 @@ func validateRegex(log logger.Log, what string, value string) *regexp.Regexp {
 	return regex
 }
 
+// By default, all sources inside a "node_modules" directory are ignore-listed
+var defaultSourceMapIgnoreList = regexp.MustCompile(`(^|/)node_modules/`)
+
+func validateSourceMapIgnoreList(log logger.Log, value string, disable bool) *regexp.Regexp {
+	if disable {
+		if value != "" {
+			log.AddError(nil, logger.Range{}, "Cannot use a source map ignore list when it's disabled")
+		}
+		return nil
+	}
+	if value == "" {
+		return defaultSourceMapIgnoreList
+	}
+	return validateRegex(log, "source map ignore list", value)
+}
+
 func validateExternals(log logger.Log, fs fs.FS, paths []string) config.ExternalSettings {
 	result := config.ExternalSettings{
 		PreResolve:  config.ExternalMatchers{Exact: make(map[string]bool)},
@@ -570,6 +627,7 @@ func validateDefines(
 	log logger.Log,
 	defines map[string]string,
 	pureFns []string,
+	knownGlobals []string,
 	platform config.Platform,
 	isBuildAPI bool,
 	minify bool,
@@ -712,6 +770,20 @@ func validateDefines(
 		rawDefines[mapKey] = define
 	}
 
+	for _, key := range knownGlobals {
+		keyParts := validateGlobalName(log, key, "(known global name)")
+		if keyParts == nil {
+			continue
+		}
+		mapKey := mapKeyForDefine(keyParts)
+
+		// Merge with any previously-specified defines
+		define := rawDefines[mapKey]
+		define.KeyParts = keyParts
+		define.Flags |= config.CanBeRemovedIfUnused
+		rawDefines[mapKey] = define
+	}
+
 	// Processing defines is expensive. Process them once here so the same object
 	// can be shared between all parsers we create using these arguments.
 	definesArray := make([]config.DefineData, 0, len(rawDefines))
@@ -781,6 +853,13 @@ func validateKeepNames(log logger.Log, options *config.Options) {
 	}
 }
 
+func validateExternalHelpers(log logger.Log, options *config.Options) {
+	if options.ExternalHelpers != "" && options.OutputFormat == config.FormatIIFE {
+		log.AddErrorWithNotes(nil, logger.Range{}, "Cannot use \"external helpers\" with the \"iife\" format", []logger.MsgData{{
+			Text: "The runtime helpers must be imported from another module, which is only possible with the \"esm\" and \"cjs\" formats."}})
+	}
+}
+
 func convertLocationToPublic(loc *logger.MsgLocation, pathStyle logger.PathStyle) *Location {
 	if loc != nil {
 		return &Location{
@@ -895,6 +974,49 @@ func cloneMangleCache(log logger.Log, mangleCache map[string]interface{}) map[st
 	return clone
 }
 
+func validateNameCache(log logger.Log, nameCache map[string]interface{}) map[string]interface{} {
+	if nameCache == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(nameCache))
+	for k, v := range nameCache {
+		if name, ok := v.(string); ok && js_ast.IsIdentifier(name) {
+			clone[k] = name
+		} else {
+			log.AddError(nil, logger.Range{},
+				fmt.Sprintf("Expected %q in name cache to map to an identifier", k))
+		}
+	}
+	return clone
+}
+
+func validateAssumePure(value AssumePure) (result js_ast.AssumePure) {
+	if (value & AssumePureGetters) != 0 {
+		result |= js_ast.AssumePureGetters
+	}
+	if (value & AssumePurePropertyReads) != 0 {
+		result |= js_ast.AssumePurePropertyReads
+	}
+	if (value & AssumePureUnknownGlobals) != 0 {
+		result |= js_ast.AssumePureUnknownGlobals
+	}
+	if (value & AssumePureTryBlocks) != 0 {
+		result |= js_ast.AssumePureTryBlocks
+	}
+	return
+}
+
+func validatePackageSideEffects(packageSideEffects map[string]bool) map[string]bool {
+	if packageSideEffects == nil {
+		return nil
+	}
+	clone := make(map[string]bool, len(packageSideEffects))
+	for k, v := range packageSideEffects {
+		clone[k] = v
+	}
+	return clone
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Build API
 
@@ -1259,23 +1381,26 @@ func validateBuildOptions(
 	options config.Options,
 	entryPoints []bundler.EntryPoint,
 ) {
-	jsFeatures, cssFeatures, cssPrefixData, targetEnv := validateFeatures(log, buildOpts.Target, buildOpts.Engines)
+	jsFeatures, cssFeatures, builtins, cssPrefixData, cssNamePrefixData, targetEnv := validateFeatures(log, buildOpts.Target, buildOpts.Engines)
 	jsOverrides, jsMask, cssOverrides, cssMask := validateSupported(log, buildOpts.Supported)
 	outJS, outCSS := validateOutputExtensions(log, buildOpts.OutExtension)
 	bannerJS, bannerCSS := validateBannerOrFooter(log, "banner", buildOpts.Banner)
 	footerJS, footerCSS := validateBannerOrFooter(log, "footer", buildOpts.Footer)
 	minify := buildOpts.MinifyWhitespace && buildOpts.MinifyIdentifiers && buildOpts.MinifySyntax
 	platform := validatePlatform(buildOpts.Platform)
-	defines, injectedDefines := validateDefines(log, buildOpts.Define, buildOpts.Pure, platform, true /* isBuildAPI */, minify, buildOpts.Drop)
+	defines, injectedDefines := validateDefines(log, buildOpts.Define, buildOpts.Pure, buildOpts.KnownGlobals, platform, true /* isBuildAPI */, minify, buildOpts.Drop)
 	options = config.Options{
 		CSSPrefixData:                      cssPrefixData,
+		CSSNamePrefixData:                  cssNamePrefixData,
 		UnsupportedJSFeatures:              jsFeatures.ApplyOverrides(jsOverrides, jsMask),
 		UnsupportedCSSFeatures:             cssFeatures.ApplyOverrides(cssOverrides, cssMask),
 		UnsupportedJSFeatureOverrides:      jsOverrides,
 		UnsupportedJSFeatureOverridesMask:  jsMask,
 		UnsupportedCSSFeatureOverrides:     cssOverrides,
 		UnsupportedCSSFeatureOverridesMask: cssMask,
+		UnsupportedBuiltins:                builtins,
 		OriginalTargetEnv:                  targetEnv,
+		Polyfill:                           buildOpts.Polyfill,
 		JSX: config.JSXOptions{
 			Preserve:         buildOpts.JSX == JSXPreserve,
 			AutomaticRuntime: buildOpts.JSX == JSXAutomatic,
@@ -1284,17 +1409,25 @@ func validateBuildOptions(
 			Development:      buildOpts.JSXDev,
 			ImportSource:     buildOpts.JSXImportSource,
 			SideEffects:      buildOpts.JSXSideEffects,
+			Refresh:          buildOpts.JSXRefresh,
 		},
 		Defines:               defines,
 		InjectedDefines:       injectedDefines,
 		Platform:              platform,
 		SourceMap:             validateSourceMap(buildOpts.Sourcemap),
 		LegalComments:         validateLegalComments(buildOpts.LegalComments, buildOpts.Bundle),
+		PreserveComments:      validatePreserveComments(buildOpts.Comments),
 		SourceRoot:            buildOpts.SourceRoot,
 		ExcludeSourcesContent: buildOpts.SourcesContent == SourcesContentExclude,
+		SourceMapIgnoreList:   validateSourceMapIgnoreList(log, buildOpts.SourceMapIgnoreList, buildOpts.DisableSourceMapIgnoreList),
+		DebugIDs:              validateDebugIDs(buildOpts.DebugIDs),
+		CSSModuleTypes:        buildOpts.CSSModuleTypes || buildOpts.CSSModuleTypesDir != "",
+		AbsCSSModuleTypesDir:  validatePath(log, realFS, buildOpts.CSSModuleTypesDir, "CSS module types directory path"),
 		MinifySyntax:          buildOpts.MinifySyntax,
+		InlineFunctions:       buildOpts.InlineFunctions,
 		MinifyWhitespace:      buildOpts.MinifyWhitespace,
 		MinifyIdentifiers:     buildOpts.MinifyIdentifiers,
+		NameCache:             validateNameCache(log, buildOpts.NameCache),
 		LineLimit:             buildOpts.LineLimit,
 		MangleProps:           validateRegex(log, "mangle props", buildOpts.MangleProps),
 		ReserveProps:          validateRegex(log, "reserve props", buildOpts.ReserveProps),
@@ -1304,6 +1437,9 @@ func validateBuildOptions(
 		AllowOverwrite:        buildOpts.AllowOverwrite,
 		ASCIIOnly:             validateASCIIOnly(buildOpts.Charset),
 		IgnoreDCEAnnotations:  buildOpts.IgnoreAnnotations,
+		AssumePure:            validateAssumePure(buildOpts.AssumePure),
+		InferSideEffects:      buildOpts.InferSideEffects,
+		PackageSideEffects:    validatePackageSideEffects(buildOpts.PackageSideEffects),
 		TreeShaking:           validateTreeShaking(buildOpts.TreeShaking, buildOpts.Bundle, buildOpts.Format),
 		GlobalName:            validateGlobalName(log, buildOpts.GlobalName, "(global name)"),
 		CodeSplitting:         buildOpts.Splitting,
@@ -1315,6 +1451,7 @@ func validateBuildOptions(
 		EntryPathTemplate:     validatePathTemplate(buildOpts.EntryNames),
 		ChunkPathTemplate:     validatePathTemplate(buildOpts.ChunkNames),
 		AssetPathTemplate:     validatePathTemplate(buildOpts.AssetNames),
+		AssetInlineLimit:      validateAssetInlineLimit(log, buildOpts.AssetInlineLimit),
 		OutputExtensionJS:     outJS,
 		OutputExtensionCSS:    outCSS,
 		ExtensionToLoader:     validateLoaders(log, buildOpts.Loader),
@@ -1326,6 +1463,7 @@ func validateBuildOptions(
 		TSConfigRaw:           buildOpts.TsconfigRaw,
 		MainFields:            buildOpts.MainFields,
 		PublicPath:            buildOpts.PublicPath,
+		ExternalHelpers:       buildOpts.ExternalHelpers,
 		KeepNames:             buildOpts.KeepNames,
 		CodePathStyle:         extractPathStyle(buildOpts.AbsPaths, CodeAbsPath),
 		LogPathStyle:          extractPathStyle(buildOpts.AbsPaths, LogAbsPath),
@@ -1399,6 +1537,10 @@ func validateBuildOptions(
 				log.AddError(nil, logger.Range{}, "Cannot use the \"file\" loader without an output path")
 				break
 			}
+			if loader == config.LoaderAutoDataURL {
+				log.AddError(nil, logger.Range{}, "Cannot use the \"auto-dataurl\" loader without an output path")
+				break
+			}
 			if loader == config.LoaderCopy {
 				log.AddError(nil, logger.Range{}, "Cannot use the \"copy\" loader without an output path")
 				break
@@ -1452,6 +1594,8 @@ func validateBuildOptions(
 		log.AddError(nil, logger.Range{}, "Cannot provide \"tsconfig\" as both a raw string and a path")
 	}
 
+	validateExternalHelpers(log, &options)
+
 	// If we aren't writing the output to the file system, then we can allow the
 	// output paths to be the same as the input paths. This helps when serving.
 	if !buildOpts.Write {
@@ -1523,7 +1667,21 @@ func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, m
 	if !log.HasErrors() {
 		// Compile the bundle
 		result.MangleCache = cloneMangleCache(log, args.mangleCache)
-		results, metafile = bundle.Compile(log, timer, result.MangleCache, linker.Link)
+
+		// The name cache is rebuilt from scratch so that names for symbols that
+		// no longer exist are removed. Names that are still in use are copied
+		// over from the previous name cache by the linker. Names are only
+		// assigned when identifiers are minified, so otherwise the previous
+		// name cache is passed through unchanged.
+		if args.options.NameCache != nil {
+			result.NameCache = make(map[string]interface{})
+			if !args.options.MinifyIdentifiers {
+				for k, v := range args.options.NameCache {
+					result.NameCache[k] = v
+				}
+			}
+		}
+		results, metafile = bundle.Compile(log, timer, result.MangleCache, result.NameCache, linker.Link)
 
 		// Canceling a build generates a single error at the end of the build
 		if args.options.CancelFlag.DidCancel() {
@@ -1629,9 +1787,10 @@ func rebuildImpl(args rebuildArgs, oldHashes map[string]string) (rebuildState, m
 		timer.End("Write output files")
 	}
 
-	// Only return the mangle cache for a successful build
+	// Only return the mangle cache and name cache for a successful build
 	if log.HasErrors() {
 		result.MangleCache = nil
+		result.NameCache = nil
 	}
 
 	// Populate the result object with the messages so far
@@ -1729,20 +1888,23 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 	}
 
 	// Convert and validate the transformOpts
-	jsFeatures, cssFeatures, cssPrefixData, targetEnv := validateFeatures(log, transformOpts.Target, transformOpts.Engines)
+	jsFeatures, cssFeatures, builtins, cssPrefixData, cssNamePrefixData, targetEnv := validateFeatures(log, transformOpts.Target, transformOpts.Engines)
 	jsOverrides, jsMask, cssOverrides, cssMask := validateSupported(log, transformOpts.Supported)
 	platform := validatePlatform(transformOpts.Platform)
-	defines, injectedDefines := validateDefines(log, transformOpts.Define, transformOpts.Pure, platform, false /* isBuildAPI */, false /* minify */, transformOpts.Drop)
+	defines, injectedDefines := validateDefines(log, transformOpts.Define, transformOpts.Pure, transformOpts.KnownGlobals, platform, false /* isBuildAPI */, false /* minify */, transformOpts.Drop)
 	mangleCache := cloneMangleCache(log, transformOpts.MangleCache)
 	options := config.Options{
 		CSSPrefixData:                      cssPrefixData,
+		CSSNamePrefixData:                  cssNamePrefixData,
 		UnsupportedJSFeatures:              jsFeatures.ApplyOverrides(jsOverrides, jsMask),
 		UnsupportedCSSFeatures:             cssFeatures.ApplyOverrides(cssOverrides, cssMask),
 		UnsupportedJSFeatureOverrides:      jsOverrides,
 		UnsupportedJSFeatureOverridesMask:  jsMask,
 		UnsupportedCSSFeatureOverrides:     cssOverrides,
 		UnsupportedCSSFeatureOverridesMask: cssMask,
+		UnsupportedBuiltins:                builtins,
 		OriginalTargetEnv:                  targetEnv,
+		Polyfill:                           transformOpts.Polyfill,
 		TSConfigRaw:                        transformOpts.TsconfigRaw,
 		JSX: config.JSXOptions{
 			Preserve:         transformOpts.JSX == JSXPreserve,
@@ -1752,17 +1914,22 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 			Development:      transformOpts.JSXDev,
 			ImportSource:     transformOpts.JSXImportSource,
 			SideEffects:      transformOpts.JSXSideEffects,
+			Refresh:          transformOpts.JSXRefresh,
 		},
 		Defines:               defines,
 		InjectedDefines:       injectedDefines,
 		Platform:              platform,
 		SourceMap:             validateSourceMap(transformOpts.Sourcemap),
 		LegalComments:         validateLegalComments(transformOpts.LegalComments, false /* bundle */),
+		PreserveComments:      validatePreserveComments(transformOpts.Comments),
 		SourceRoot:            transformOpts.SourceRoot,
 		ExcludeSourcesContent: transformOpts.SourcesContent == SourcesContentExclude,
+		SourceMapIgnoreList:   validateSourceMapIgnoreList(log, transformOpts.SourceMapIgnoreList, transformOpts.DisableSourceMapIgnoreList),
 		OutputFormat:          validateFormat(transformOpts.Format),
 		GlobalName:            validateGlobalName(log, transformOpts.GlobalName, "(global name)"),
 		MinifySyntax:          transformOpts.MinifySyntax,
+		InlineFunctions:       transformOpts.InlineFunctions,
+		ErrorRecovery:         transformOpts.ErrorRecovery,
 		MinifyWhitespace:      transformOpts.MinifyWhitespace,
 		MinifyIdentifiers:     transformOpts.MinifyIdentifiers,
 		LineLimit:             transformOpts.LineLimit,
@@ -1773,9 +1940,11 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		DropDebugger:          (transformOpts.Drop & DropDebugger) != 0,
 		ASCIIOnly:             validateASCIIOnly(transformOpts.Charset),
 		IgnoreDCEAnnotations:  transformOpts.IgnoreAnnotations,
+		AssumePure:            validateAssumePure(transformOpts.AssumePure),
 		TreeShaking:           validateTreeShaking(transformOpts.TreeShaking, false /* bundle */, transformOpts.Format),
 		AbsOutputFile:         transformOpts.Sourcefile + "-out",
 		KeepNames:             transformOpts.KeepNames,
+		ExternalHelpers:       transformOpts.ExternalHelpers,
 		CodePathStyle:         extractPathStyle(transformOpts.AbsPaths, CodeAbsPath),
 		LogPathStyle:          extractPathStyle(transformOpts.AbsPaths, LogAbsPath),
 		MetafilePathStyle:     extractPathStyle(transformOpts.AbsPaths, MetafileAbsPath),
@@ -1786,6 +1955,7 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		},
 	}
 	validateKeepNames(log, &options)
+	validateExternalHelpers(log, &options)
 	if options.Stdin.Loader.IsCSS() {
 		options.CSSBanner = transformOpts.Banner
 		options.CSSFooter = transformOpts.Footer
@@ -1827,10 +1997,10 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		mockFS := fs.MockFS(make(map[string]string), fs.MockUnix, "/")
 		bundle := bundler.ScanBundle(config.TransformCall, log, mockFS, caches, nil, options, timer)
 
-		// Stop now if there were errors
-		if !log.HasErrors() {
+		// Stop now if there were errors, unless those errors were recovered from
+		if !log.HasErrors() || (options.ErrorRecovery && bundle.AllFilesWereParsed()) {
 			// Compile the bundle
-			results, _ = bundle.Compile(log, timer, mangleCache, linker.Link)
+			results, _ = bundle.Compile(log, timer, mangleCache, nil, linker.Link)
 		}
 
 		timer.Log(log)
@@ -1876,6 +2046,21 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 	}
 }
 
+func generateHelpersImpl(transformOpts TransformOptions) TransformResult {
+	// Pick the variant of the runtime code that matches the target environment.
+	// Any problems with these options are reported by "transformImpl" below.
+	log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+	jsFeatures, _, _, _, _, _ := validateFeatures(log, transformOpts.Target, transformOpts.Engines)
+	jsOverrides, jsMask, _, _ := validateSupported(log, transformOpts.Supported)
+	source := runtime.Source(jsFeatures.ApplyOverrides(jsOverrides, jsMask))
+
+	// The helpers module can't import its helpers from itself
+	transformOpts.ExternalHelpers = ""
+	transformOpts.Loader = LoaderJS
+	transformOpts.Sourcefile = source.KeyPath.Text
+	return transformImpl(source.Contents, transformOpts)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Plugin API
 
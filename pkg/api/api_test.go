@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/evanw/esbuild/internal/test"
@@ -336,3 +337,110 @@ func TestEngines(t *testing.T) {
 	test.AssertEqualWithDiff(t, checkChromeVersions("9", "99"), "a != null ? a : b;\n")
 	test.AssertEqualWithDiff(t, checkChromeVersions("99", "9"), "a != null ? a : b;\n")
 }
+
+func TestGenerateHelpers(t *testing.T) {
+	options := api.TransformOptions{
+		Target:          api.ES2016,
+		Format:          api.FormatESModule,
+		ExternalHelpers: "esbuild-helpers",
+	}
+
+	code := api.Transform("export let fn = async () => ({ ...a })", options)
+	test.AssertEqual(t, len(code.Errors), 0)
+	test.AssertEqualWithDiff(t, string(code.Code), `import {
+  __async,
+  __spreadValues
+} from "esbuild-helpers";
+let fn = () => __async(null, null, function* () {
+  return __spreadValues({}, a);
+});
+export {
+  fn
+};
+`)
+
+	// Every helper imported above must be exported by the helpers module
+	helpers := api.GenerateHelpers(options)
+	test.AssertEqual(t, len(helpers.Errors), 0)
+	exports := string(helpers.Code)[strings.LastIndex(string(helpers.Code), "export {"):]
+	test.AssertEqual(t, strings.Contains(exports, "  __async,\n"), true)
+	test.AssertEqual(t, strings.Contains(exports, "  __spreadValues,\n"), true)
+	test.AssertEqual(t, strings.Contains(string(helpers.Code), "from \"esbuild-helpers\""), false)
+}
+
+func TestAssetInlineLimit(t *testing.T) {
+	build := func(limit *int) string {
+		result := api.Build(api.BuildOptions{
+			EntryPoints:      []string{"entry.js"},
+			Bundle:           true,
+			Outdir:           "out",
+			AssetInlineLimit: limit,
+			Plugins: []api.Plugin{{
+				Name: "files",
+				Setup: func(build api.PluginBuild) {
+					build.OnResolve(api.OnResolveOptions{Filter: "."}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+						return api.OnResolveResult{Path: "/" + strings.TrimPrefix(args.Path, "./")}, nil
+					})
+					build.OnLoad(api.OnLoadOptions{Filter: "."}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+						if args.Path == "/entry.js" {
+							contents := "import url from './icon.svg'; console.log(url)"
+							return api.OnLoadResult{Contents: &contents}, nil
+						}
+						contents := "<svg/>"
+						return api.OnLoadResult{Contents: &contents, Loader: api.LoaderAutoDataURL}, nil
+					})
+				},
+			}},
+		})
+		test.AssertEqual(t, len(result.Errors), 0)
+		for _, file := range result.OutputFiles {
+			if strings.HasSuffix(file.Path, ".js") {
+				return string(file.Contents)
+			}
+		}
+		return ""
+	}
+
+	zero := 0
+	test.AssertEqual(t, strings.Contains(build(nil), "data:image/svg+xml"), true)
+	test.AssertEqual(t, strings.Contains(build(&zero), "data:image/svg+xml"), false)
+	test.AssertEqual(t, strings.Contains(build(&zero), "./icon-"), true)
+}
+
+func TestSourceMapIgnoreList(t *testing.T) {
+	transform := func(options api.TransformOptions) string {
+		options.Sourcemap = api.SourceMapExternal
+		options.Sourcefile = "node_modules/pkg/index.js"
+		result := api.Transform("let x = 1", options)
+		test.AssertEqual(t, len(result.Errors), 0)
+		return string(result.Map)
+	}
+
+	test.AssertEqual(t, strings.Contains(transform(api.TransformOptions{}), `"ignoreList": [0]`), true)
+	test.AssertEqual(t, strings.Contains(transform(api.TransformOptions{SourceMapIgnoreList: "^src/"}), `"ignoreList"`), false)
+	test.AssertEqual(t, strings.Contains(transform(api.TransformOptions{DisableSourceMapIgnoreList: true}), `"ignoreList"`), false)
+
+	result := api.Transform("", api.TransformOptions{SourceMapIgnoreList: "x", DisableSourceMapIgnoreList: true})
+	test.AssertEqual(t, len(result.Errors), 1)
+}
+
+func TestNameCacheWithoutMinifyIdentifiers(t *testing.T) {
+	build := func(minifyIdentifiers bool) map[string]interface{} {
+		contents := "export let foo = 1"
+		result := api.Build(api.BuildOptions{
+			Stdin:             &api.StdinOptions{Contents: contents, Sourcefile: "entry.js"},
+			Format:            api.FormatESModule,
+			MinifyIdentifiers: minifyIdentifiers,
+			NameCache:         map[string]interface{}{"other.js:bar": "a"},
+		})
+		test.AssertEqual(t, len(result.Errors), 0)
+		return result.NameCache
+	}
+
+	// The name cache is passed through unchanged when no names are assigned
+	test.AssertEqual(t, len(build(false)), 1)
+	test.AssertEqual(t, build(false)["other.js:bar"], "a")
+
+	// Otherwise names for symbols that no longer exist are removed
+	test.AssertEqual(t, build(true)["other.js:bar"], nil)
+}
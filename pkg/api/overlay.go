@@ -0,0 +1,61 @@
+package api
+
+// This is the error overlay client used by esbuild's built-in development
+// server. It's injected into HTML pages when the "overlay" serve option is
+// enabled. It listens for "errors" events on the "/esbuild" event stream and
+// renders them on top of the page. An empty list of errors clears the overlay.
+// Pages served in place of a failed build are reloaded once the errors are
+// fixed (these have a "data-reload" attribute on the script tag).
+const overlayClientJS = `(() => {
+  const script = document.currentScript
+  const reload = script && script.hasAttribute('data-reload')
+  let overlay = null
+
+  const el = (tag, style, text) => {
+    const node = document.createElement(tag)
+    node.setAttribute('style', style)
+    if (text) node.textContent = text
+    return node
+  }
+
+  const codeFrame = loc => {
+    const lines = [loc.file + ':' + loc.line + ':' + loc.column + ':']
+    if (loc.lineText) {
+      const gutter = String(loc.line)
+      lines.push(gutter + ' | ' + loc.lineText)
+      lines.push(' '.repeat(gutter.length) + ' | ' + ' '.repeat(loc.column) + '^'.repeat(Math.max(loc.length, 1)))
+    }
+    return lines.join('\n')
+  }
+
+  const render = errors => {
+    if (overlay) overlay.remove(), overlay = null
+    if (!errors.length) return
+    overlay = el('div', 'position:fixed;inset:0;z-index:2147483647;overflow:auto;padding:30px;' +
+      'background:rgba(0,0,0,0.85);color:#eee;font:14px/1.5 monospace;white-space:pre-wrap')
+    const close = el('button', 'position:absolute;top:10px;right:10px;font:inherit;cursor:pointer', 'Close')
+    close.onclick = () => render([])
+    overlay.appendChild(close)
+    overlay.appendChild(el('div', 'font-size:18px;color:#f66;margin-bottom:20px',
+      'Build failed with ' + errors.length + ' error' + (errors.length === 1 ? '' : 's')))
+    for (const error of errors) {
+      const item = el('div', 'margin-bottom:20px;padding:10px 15px;border-left:3px solid #f66;background:#222')
+      item.appendChild(el('div', 'color:#fff;font-weight:bold',
+        (error.pluginName ? '[plugin ' + error.pluginName + '] ' : '') + error.text))
+      if (error.location) item.appendChild(el('div', 'color:#aaa;margin-top:5px', codeFrame(error.location)))
+      for (const note of error.notes) {
+        item.appendChild(el('div', 'color:#ccc;margin-top:5px', note.text))
+        if (note.location) item.appendChild(el('div', 'color:#888', codeFrame(note.location)))
+      }
+      overlay.appendChild(item)
+    }
+    document.body.appendChild(overlay)
+  }
+
+  new EventSource('/esbuild').addEventListener('errors', e => {
+    const errors = JSON.parse(e.data)
+    if (reload && !errors.length) location.reload()
+    else render(errors)
+  })
+})()
+`
@@ -50,10 +50,21 @@ type apiHandler struct {
 	fallback         string
 	hosts            []string
 	corsOrigin       []string
+	overlay          bool
 	serveWaitGroup   sync.WaitGroup
 	activeStreams    []chan serverSentEvent
 	currentHashes    map[string]string
+	currentErrors    []Message
+	shouldStop       int32
 	mutex            sync.Mutex
+
+	// A server created with "NewServer()" has no build context of its own.
+	// Instead, build contexts are mounted on it under a path prefix. Each
+	// mounted build context gets its own handler that points back to the
+	// server's handler, which owns the HTTP server and the event streams.
+	mounts      []*apiHandler
+	mountPrefix string
+	server      *apiHandler
 }
 
 type serverSentEvent struct {
@@ -102,6 +113,71 @@ func errorsToString(errors []Message) string {
 	return sb.String()
 }
 
+// This is sent over the event stream so that the error overlay client can
+// render the errors. It deliberately omits the "Detail" field since that is
+// arbitrary user data that may not be serializable.
+func messagesToJSON(msgs []Message) string {
+	sb := strings.Builder{}
+	writeLocation := func(loc *Location) {
+		if loc == nil {
+			sb.WriteString("null")
+			return
+		}
+		sb.WriteString("{\"file\":")
+		sb.Write(helpers.QuoteForJSON(loc.File, false))
+		sb.WriteString(",\"namespace\":")
+		sb.Write(helpers.QuoteForJSON(loc.Namespace, false))
+		sb.WriteString(fmt.Sprintf(",\"line\":%d,\"column\":%d,\"length\":%d,\"lineText\":", loc.Line, loc.Column, loc.Length))
+		sb.Write(helpers.QuoteForJSON(loc.LineText, false))
+		sb.WriteString(",\"suggestion\":")
+		sb.Write(helpers.QuoteForJSON(loc.Suggestion, false))
+		sb.WriteRune('}')
+	}
+	sb.WriteRune('[')
+	for i, msg := range msgs {
+		if i > 0 {
+			sb.WriteRune(',')
+		}
+		sb.WriteString("{\"id\":")
+		sb.Write(helpers.QuoteForJSON(msg.ID, false))
+		sb.WriteString(",\"pluginName\":")
+		sb.Write(helpers.QuoteForJSON(msg.PluginName, false))
+		sb.WriteString(",\"text\":")
+		sb.Write(helpers.QuoteForJSON(msg.Text, false))
+		sb.WriteString(",\"location\":")
+		writeLocation(msg.Location)
+		sb.WriteString(",\"notes\":[")
+		for j, note := range msg.Notes {
+			if j > 0 {
+				sb.WriteRune(',')
+			}
+			sb.WriteString("{\"text\":")
+			sb.Write(helpers.QuoteForJSON(note.Text, false))
+			sb.WriteString(",\"location\":")
+			writeLocation(note.Location)
+			sb.WriteRune('}')
+		}
+		sb.WriteString("]}")
+	}
+	sb.WriteRune(']')
+	return sb.String()
+}
+
+// This inserts the error overlay client into an HTML page. It goes before the
+// closing "</body>" tag if there is one so that "document.body" exists when
+// the script runs, and otherwise at the end of the page.
+func injectOverlayScript(html []byte, extraAttrs string) []byte {
+	script := []byte("<script src=\"/esbuild/overlay.js\"" + extraAttrs + "></script>\n")
+	lower := strings.ToLower(string(html))
+	if i := strings.LastIndex(lower, "</body>"); i >= 0 {
+		result := make([]byte, 0, len(html)+len(script))
+		result = append(result, html[:i]...)
+		result = append(result, script...)
+		return append(result, html[i:]...)
+	}
+	return append(append([]byte{}, html...), script...)
+}
+
 func (h *apiHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	start := time.Now()
 
@@ -168,249 +244,318 @@ func (h *apiHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Special-case the error overlay client
+	if h.overlay && (isHEAD || req.Method == "GET") && req.URL.Path == "/esbuild/overlay.js" {
+		res.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		res.Header().Set("Content-Length", fmt.Sprintf("%d", len(overlayClientJS)))
+		go h.notifyRequest(time.Since(start), req, http.StatusOK)
+		res.WriteHeader(http.StatusOK)
+		maybeWriteResponseBody([]byte(overlayClientJS))
+		return
+	}
+
 	// Handle GET and HEAD requests
 	if (isHEAD || req.Method == "GET") && strings.HasPrefix(req.URL.Path, "/") {
 		queryPath := path.Clean(req.URL.Path)[1:]
-		result := h.rebuild()
+		handler := h
+
+		// Forward requests inside a mounted path prefix to that build context
+		h.mutex.Lock()
+		mounts := h.mounts
+		h.mutex.Unlock()
+		for _, mount := range mounts {
+			if relPath, ok := stripDirPrefix(queryPath, mount.mountPrefix, "/"); ok {
+				handler = mount
+				queryPath = relPath
+				break
+			}
+		}
 
-		// Requests fail if the build had errors
-		if len(result.Errors) > 0 {
-			res.Header().Set("Content-Type", "text/plain; charset=utf-8")
-			go h.notifyRequest(time.Since(start), req, http.StatusServiceUnavailable)
-			res.WriteHeader(http.StatusServiceUnavailable)
-			maybeWriteResponseBody([]byte(errorsToString(result.Errors)))
+		if handler.serveFileOrDirectory(start, req, res, queryPath, maybeWriteResponseBody) {
 			return
 		}
+	}
 
-		type fileToServe struct {
-			absPath  string
-			contents fs.OpenedFile
+	// Satisfy requests for "favicon.ico" to avoid errors in Firefox developer tools
+	if req.Method == "GET" && req.URL.Path == "/favicon.ico" {
+		for _, encoding := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+			if semi := strings.IndexByte(encoding, ';'); semi >= 0 {
+				encoding = encoding[:semi]
+			}
+			if strings.TrimSpace(encoding) == "gzip" {
+				res.Header().Set("Content-Encoding", "gzip")
+				res.Header().Set("Content-Type", "image/vnd.microsoft.icon")
+				go h.notifyRequest(time.Since(start), req, http.StatusOK)
+				maybeWriteResponseBody(favicon_ico_gz)
+				return
+			}
 		}
+	}
 
-		var kind fs.EntryKind
-		var file fileToServe
-		dirEntries := make(map[string]bool)
-		fileEntries := make(map[string]bool)
+	// Default to a 404
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	go h.notifyRequest(time.Since(start), req, http.StatusNotFound)
+	res.WriteHeader(http.StatusNotFound)
+	maybeWriteResponseBody([]byte("404 - Not Found"))
+}
 
-		// Check for a match with the results if we're within the output directory
-		if outdirQueryPath, ok := stripDirPrefix(queryPath, h.outdirPathPrefix, "/"); ok {
-			resultKind, inMemoryBytes, absPath, isImplicitIndexHTML := h.matchQueryPathToResult(outdirQueryPath, &result, dirEntries, fileEntries)
-			kind = resultKind
-			file = fileToServe{
-				absPath:  absPath,
-				contents: &fs.InMemoryOpenedFile{Contents: inMemoryBytes},
-			}
-			if isImplicitIndexHTML {
-				queryPath = path.Join(queryPath, "index.html")
-			}
+func addFakeDirEntry(pathPrefix string, queryPath string, dirEntries map[string]bool) bool {
+	p := pathPrefix
+	for p != "" {
+		var dir string
+		var base string
+		if slash := strings.IndexByte(p, '/'); slash == -1 {
+			base = p
 		} else {
-			// Create a fake directory entry for the output path so that it appears to be a real directory
-			p := h.outdirPathPrefix
-			for p != "" {
-				var dir string
-				var base string
-				if slash := strings.IndexByte(p, '/'); slash == -1 {
-					base = p
-				} else {
-					dir = p[:slash]
-					base = p[slash+1:]
-				}
-				if dir == queryPath {
-					kind = fs.DirEntry
-					dirEntries[base] = true
-					break
-				}
-				p = dir
-			}
+			dir = p[:slash]
+			base = p[slash+1:]
+		}
+		if dir == queryPath {
+			dirEntries[base] = true
+			return true
 		}
+		p = dir
+	}
+	return false
+}
 
-		// Check for a file in the "servedir" directory
-		if h.servedir != "" && kind != fs.FileEntry {
-			absPath := h.fs.Join(h.servedir, queryPath)
-			if symlink, ok := h.fs.EvalSymlinks(absPath); ok {
-				absPath = symlink
-			}
-			if absDir := h.fs.Dir(absPath); absDir != absPath {
-				if entries, err, _ := h.fs.ReadDirectory(absDir); err == nil {
-					if entry, _ := entries.Get(h.fs.Base(absPath)); entry != nil && entry.Kind(h.fs) == fs.FileEntry {
-						if strings.EqualFold(absPath, h.keyfileToLower) || strings.EqualFold(absPath, h.certfileToLower) {
-							// Don't serve the HTTPS key or certificate. This uses a case-
-							// insensitive check because some file systems are case-sensitive.
-							go h.notifyRequest(time.Since(start), req, http.StatusForbidden)
-							res.WriteHeader(http.StatusForbidden)
-							maybeWriteResponseBody([]byte("403 - Forbidden"))
-							return
-						}
-						if contents, err, _ := h.fs.OpenFile(absPath); err == nil {
-							defer contents.Close()
-							file = fileToServe{absPath: absPath, contents: contents}
-							kind = fs.FileEntry
-						} else if err != syscall.ENOENT {
-							go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
-							res.WriteHeader(http.StatusInternalServerError)
-							maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
-							return
-						}
+// This handles GET and HEAD requests for the output files of this handler's
+// build context and for the files in the "servedir" directory. It returns
+// false if nothing matched the query path.
+func (h *apiHandler) serveFileOrDirectory(
+	start time.Time,
+	req *http.Request,
+	res http.ResponseWriter,
+	queryPath string,
+	maybeWriteResponseBody func([]byte),
+) bool {
+	result := h.rebuild()
+
+	// Requests fail if the build had errors
+	if len(result.Errors) > 0 {
+		// Let the error overlay take over for page loads. The page reloads
+		// itself once the errors have been fixed.
+		if h.overlay && strings.Contains(req.Header.Get("Accept"), "text/html") {
+			html := injectOverlayScript([]byte(fmt.Sprintf("<!doctype html>\n<meta charset=\"utf8\">\n<title>Build failed</title>\n<pre>%s</pre>\n",
+				escapeForHTML(errorsToString(result.Errors)))), " data-reload")
+			res.Header().Set("Content-Type", "text/html; charset=utf-8")
+			go h.notifyRequest(time.Since(start), req, http.StatusServiceUnavailable)
+			res.WriteHeader(http.StatusServiceUnavailable)
+			maybeWriteResponseBody(html)
+			return true
+		}
+		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		go h.notifyRequest(time.Since(start), req, http.StatusServiceUnavailable)
+		res.WriteHeader(http.StatusServiceUnavailable)
+		maybeWriteResponseBody([]byte(errorsToString(result.Errors)))
+		return true
+	}
+
+	type fileToServe struct {
+		absPath  string
+		contents fs.OpenedFile
+	}
+
+	var kind fs.EntryKind
+	var file fileToServe
+	dirEntries := make(map[string]bool)
+	fileEntries := make(map[string]bool)
+
+	// Check for a match with the results if we're within the output directory
+	if outdirQueryPath, ok := stripDirPrefix(queryPath, h.outdirPathPrefix, "/"); ok {
+		resultKind, inMemoryBytes, absPath, isImplicitIndexHTML := h.matchQueryPathToResult(outdirQueryPath, &result, dirEntries, fileEntries)
+		kind = resultKind
+		file = fileToServe{
+			absPath:  absPath,
+			contents: &fs.InMemoryOpenedFile{Contents: inMemoryBytes},
+		}
+		if isImplicitIndexHTML {
+			queryPath = path.Join(queryPath, "index.html")
+		}
+	} else if addFakeDirEntry(h.outdirPathPrefix, queryPath, dirEntries) {
+		// Create a fake directory entry for the output path so that it appears to be a real directory
+		kind = fs.DirEntry
+	}
+
+	// Also create fake directory entries for the path prefixes of mounted build contexts
+	h.mutex.Lock()
+	for _, mount := range h.mounts {
+		if addFakeDirEntry(mount.mountPrefix, queryPath, dirEntries) {
+			kind = fs.DirEntry
+		}
+	}
+	h.mutex.Unlock()
+
+	// Check for a file in the "servedir" directory
+	if h.servedir != "" && kind != fs.FileEntry {
+		absPath := h.fs.Join(h.servedir, queryPath)
+		if symlink, ok := h.fs.EvalSymlinks(absPath); ok {
+			absPath = symlink
+		}
+		if absDir := h.fs.Dir(absPath); absDir != absPath {
+			if entries, err, _ := h.fs.ReadDirectory(absDir); err == nil {
+				if entry, _ := entries.Get(h.fs.Base(absPath)); entry != nil && entry.Kind(h.fs) == fs.FileEntry {
+					if strings.EqualFold(absPath, h.keyfileToLower) || strings.EqualFold(absPath, h.certfileToLower) {
+						// Don't serve the HTTPS key or certificate. This uses a case-
+						// insensitive check because some file systems are case-sensitive.
+						go h.notifyRequest(time.Since(start), req, http.StatusForbidden)
+						res.WriteHeader(http.StatusForbidden)
+						maybeWriteResponseBody([]byte("403 - Forbidden"))
+						return true
+					}
+					if contents, err, _ := h.fs.OpenFile(absPath); err == nil {
+						defer contents.Close()
+						file = fileToServe{absPath: absPath, contents: contents}
+						kind = fs.FileEntry
+					} else if err != syscall.ENOENT {
+						go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
+						res.WriteHeader(http.StatusInternalServerError)
+						maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
+						return true
 					}
 				}
 			}
 		}
+	}
 
-		// Check for a directory in the "servedir" directory
-		var servedirIndexName string
-		if h.servedir != "" && kind != fs.FileEntry {
-			if entries, err, _ := h.fs.ReadDirectory(h.fs.Join(h.servedir, queryPath)); err == nil {
-				kind = fs.DirEntry
-				for _, name := range entries.SortedKeys() {
-					entry, _ := entries.Get(name)
-					switch entry.Kind(h.fs) {
-					case fs.DirEntry:
-						dirEntries[name] = true
-					case fs.FileEntry:
-						fileEntries[name] = true
-						if name == "index.html" {
-							servedirIndexName = name
-						}
+	// Check for a directory in the "servedir" directory
+	var servedirIndexName string
+	if h.servedir != "" && kind != fs.FileEntry {
+		if entries, err, _ := h.fs.ReadDirectory(h.fs.Join(h.servedir, queryPath)); err == nil {
+			kind = fs.DirEntry
+			for _, name := range entries.SortedKeys() {
+				entry, _ := entries.Get(name)
+				switch entry.Kind(h.fs) {
+				case fs.DirEntry:
+					dirEntries[name] = true
+				case fs.FileEntry:
+					fileEntries[name] = true
+					if name == "index.html" {
+						servedirIndexName = name
 					}
 				}
-			} else if err != syscall.ENOENT {
-				go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
-				res.WriteHeader(http.StatusInternalServerError)
-				maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
-				return
 			}
+		} else if err != syscall.ENOENT {
+			go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
+			res.WriteHeader(http.StatusInternalServerError)
+			maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
+			return true
 		}
+	}
 
-		// Redirect to a trailing slash for directories
-		if kind == fs.DirEntry && !strings.HasSuffix(req.URL.Path, "/") {
-			res.Header().Set("Location", path.Clean(req.URL.Path)+"/")
-			go h.notifyRequest(time.Since(start), req, http.StatusFound)
-			res.WriteHeader(http.StatusFound)
-			maybeWriteResponseBody(nil)
-			return
-		}
+	// Redirect to a trailing slash for directories
+	if kind == fs.DirEntry && !strings.HasSuffix(req.URL.Path, "/") {
+		res.Header().Set("Location", path.Clean(req.URL.Path)+"/")
+		go h.notifyRequest(time.Since(start), req, http.StatusFound)
+		res.WriteHeader(http.StatusFound)
+		maybeWriteResponseBody(nil)
+		return true
+	}
 
-		// Serve an "index.html" file if present
-		if kind == fs.DirEntry && servedirIndexName != "" {
-			queryPath += "/" + servedirIndexName
-			absPath := h.fs.Join(h.servedir, queryPath)
-			if contents, err, _ := h.fs.OpenFile(absPath); err == nil {
-				defer contents.Close()
-				file = fileToServe{absPath: absPath, contents: contents}
-				kind = fs.FileEntry
-			} else if err != syscall.ENOENT {
-				go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
-				res.WriteHeader(http.StatusInternalServerError)
-				maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
-				return
-			}
+	// Serve an "index.html" file if present
+	if kind == fs.DirEntry && servedirIndexName != "" {
+		queryPath += "/" + servedirIndexName
+		absPath := h.fs.Join(h.servedir, queryPath)
+		if contents, err, _ := h.fs.OpenFile(absPath); err == nil {
+			defer contents.Close()
+			file = fileToServe{absPath: absPath, contents: contents}
+			kind = fs.FileEntry
+		} else if err != syscall.ENOENT {
+			go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
+			res.WriteHeader(http.StatusInternalServerError)
+			maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
+			return true
 		}
+	}
 
-		// Serve the fallback HTML page if one was provided
-		if kind != fs.FileEntry && h.fallback != "" {
-			if contents, err, _ := h.fs.OpenFile(h.fallback); err == nil {
-				defer contents.Close()
-				file = fileToServe{absPath: h.fallback, contents: contents}
-				kind = fs.FileEntry
-			} else if err != syscall.ENOENT {
-				go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
-				res.WriteHeader(http.StatusInternalServerError)
-				maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
-				return
-			}
+	// Serve the fallback HTML page if one was provided
+	if kind != fs.FileEntry && h.fallback != "" {
+		if contents, err, _ := h.fs.OpenFile(h.fallback); err == nil {
+			defer contents.Close()
+			file = fileToServe{absPath: h.fallback, contents: contents}
+			kind = fs.FileEntry
+		} else if err != syscall.ENOENT {
+			go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
+			res.WriteHeader(http.StatusInternalServerError)
+			maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
+			return true
 		}
+	}
 
-		// Serve a file
-		if kind == fs.FileEntry {
-			// Default to serving the whole file
-			status := http.StatusOK
-			fileContentsLen := file.contents.Len()
-			begin := 0
-			end := fileContentsLen
-			isRange := false
-
-			// Handle range requests so that video playback works in Safari
-			if rangeBegin, rangeEnd, ok := parseRangeHeader(req.Header.Get("Range"), fileContentsLen); ok && rangeBegin < rangeEnd {
-				// Note: The content range is inclusive so subtract 1 from the end
-				isRange = true
-				begin = rangeBegin
-				end = rangeEnd
-				status = http.StatusPartialContent
-			}
+	// Serve a file
+	if kind == fs.FileEntry {
+		// Default to serving the whole file
+		status := http.StatusOK
+		fileContentsLen := file.contents.Len()
+		begin := 0
+		end := fileContentsLen
+		isRange := false
+
+		// Handle range requests so that video playback works in Safari
+		if rangeBegin, rangeEnd, ok := parseRangeHeader(req.Header.Get("Range"), fileContentsLen); ok && rangeBegin < rangeEnd {
+			// Note: The content range is inclusive so subtract 1 from the end
+			isRange = true
+			begin = rangeBegin
+			end = rangeEnd
+			status = http.StatusPartialContent
+		}
 
-			// Try to read the range from the file, which may fail
-			fileBytes, err := file.contents.Read(begin, end)
-			if err != nil {
-				go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
-				res.WriteHeader(http.StatusInternalServerError)
-				maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
-				return
-			}
+		// Try to read the range from the file, which may fail
+		fileBytes, err := file.contents.Read(begin, end)
+		if err != nil {
+			go h.notifyRequest(time.Since(start), req, http.StatusInternalServerError)
+			res.WriteHeader(http.StatusInternalServerError)
+			maybeWriteResponseBody([]byte(fmt.Sprintf("500 - Internal server error: %s", err.Error())))
+			return true
+		}
 
-			// Try to detect the MIME type
-			contentType := helpers.MimeTypeByExtension(h.fs.Ext(file.absPath))
-			if contentType == "" {
-				if begin == 0 {
-					contentType = http.DetectContentType(fileBytes)
-				} else {
-					// Read the file header for MIME type detection of HTTP range requests
-					limit := 512
-					if limit > fileContentsLen {
-						limit = fileContentsLen
-					}
-					if headerBytes, err := file.contents.Read(0, limit); err == nil {
-						contentType = http.DetectContentType(headerBytes)
-					}
+		// Try to detect the MIME type
+		contentType := helpers.MimeTypeByExtension(h.fs.Ext(file.absPath))
+		if contentType == "" {
+			if begin == 0 {
+				contentType = http.DetectContentType(fileBytes)
+			} else {
+				// Read the file header for MIME type detection of HTTP range requests
+				limit := 512
+				if limit > fileContentsLen {
+					limit = fileContentsLen
+				}
+				if headerBytes, err := file.contents.Read(0, limit); err == nil {
+					contentType = http.DetectContentType(headerBytes)
 				}
 			}
-			if contentType == "" {
-				contentType = "application/octet-stream"
-			}
-
-			// If we get here, the request was successful
-			res.Header().Set("Content-Type", contentType)
-			if isRange {
-				res.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", begin, end-1, fileContentsLen))
-			}
-			res.Header().Set("Content-Length", fmt.Sprintf("%d", len(fileBytes)))
-			go h.notifyRequest(time.Since(start), req, status)
-			res.WriteHeader(status)
-			maybeWriteResponseBody(fileBytes)
-			return
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
 		}
 
-		// Serve a directory listing
-		if kind == fs.DirEntry {
-			html := respondWithDirList(queryPath, dirEntries, fileEntries)
-			res.Header().Set("Content-Type", "text/html; charset=utf-8")
-			res.Header().Set("Content-Length", fmt.Sprintf("%d", len(html)))
-			go h.notifyRequest(time.Since(start), req, http.StatusOK)
-			maybeWriteResponseBody(html)
-			return
+		// Inject the error overlay client into HTML pages
+		if h.overlay && !isRange && strings.HasPrefix(contentType, "text/html") {
+			fileBytes = injectOverlayScript(fileBytes, "")
 		}
-	}
 
-	// Satisfy requests for "favicon.ico" to avoid errors in Firefox developer tools
-	if req.Method == "GET" && req.URL.Path == "/favicon.ico" {
-		for _, encoding := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
-			if semi := strings.IndexByte(encoding, ';'); semi >= 0 {
-				encoding = encoding[:semi]
-			}
-			if strings.TrimSpace(encoding) == "gzip" {
-				res.Header().Set("Content-Encoding", "gzip")
-				res.Header().Set("Content-Type", "image/vnd.microsoft.icon")
-				go h.notifyRequest(time.Since(start), req, http.StatusOK)
-				maybeWriteResponseBody(favicon_ico_gz)
-				return
-			}
+		// If we get here, the request was successful
+		res.Header().Set("Content-Type", contentType)
+		if isRange {
+			res.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", begin, end-1, fileContentsLen))
 		}
+		res.Header().Set("Content-Length", fmt.Sprintf("%d", len(fileBytes)))
+		go h.notifyRequest(time.Since(start), req, status)
+		res.WriteHeader(status)
+		maybeWriteResponseBody(fileBytes)
+		return true
 	}
 
-	// Default to a 404
-	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	go h.notifyRequest(time.Since(start), req, http.StatusNotFound)
-	res.WriteHeader(http.StatusNotFound)
-	maybeWriteResponseBody([]byte("404 - Not Found"))
+	// Serve a directory listing
+	if kind == fs.DirEntry {
+		html := respondWithDirList(path.Join(h.mountPrefix, queryPath), dirEntries, fileEntries)
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		res.Header().Set("Content-Length", fmt.Sprintf("%d", len(html)))
+		go h.notifyRequest(time.Since(start), req, http.StatusOK)
+		maybeWriteResponseBody(html)
+		return true
+	}
+	return false
 }
 
 // This exposes an event stream to clients using server-sent events:
@@ -422,6 +567,7 @@ func (h *apiHandler) serveEventStream(start time.Time, req *http.Request, res ht
 			stream := make(chan serverSentEvent)
 			h.mutex.Lock()
 			h.activeStreams = append(h.activeStreams, stream)
+			currentErrors := h.currentErrorsJSON()
 			h.mutex.Unlock()
 
 			// Start the event stream
@@ -431,6 +577,12 @@ func (h *apiHandler) serveEventStream(start time.Time, req *http.Request, res ht
 			go h.notifyRequest(time.Since(start), req, http.StatusOK)
 			res.WriteHeader(http.StatusOK)
 			res.Write([]byte("retry: 500\n"))
+
+			// Tell new clients about the errors from the most recent build so that
+			// the error overlay is shown immediately when a page is (re)loaded
+			if currentErrors != "[]" {
+				res.Write([]byte(fmt.Sprintf("event: errors\ndata: %s\n\n", currentErrors)))
+			}
 			flusher.Flush()
 
 			// Send incoming messages over the stream
@@ -500,6 +652,9 @@ func (h *apiHandler) broadcastBuildResult(result BuildResult, newHashes map[stri
 			relPath = strings.ReplaceAll(relPath, "\\", "/")
 			relPath = path.Join(h.outdirPathPrefix, relPath)
 			publicPath := h.publicPath
+			if publicPath == "" {
+				relPath = path.Join(h.mountPrefix, relPath)
+			}
 			slash := "/"
 			if publicPath != "" && strings.HasSuffix(h.publicPath, "/") {
 				slash = ""
@@ -539,6 +694,7 @@ func (h *apiHandler) broadcastBuildResult(result BuildResult, newHashes map[stri
 	// Only notify listeners if there's a change that's worth sending. That way
 	// you can implement a simple "reload on any change" script without having
 	// to do this check in the script.
+	var changeJSON string
 	if len(added) > 0 || len(removed) > 0 || len(updated) > 0 {
 		sort.Strings(added)
 		sort.Strings(removed)
@@ -568,15 +724,50 @@ func (h *apiHandler) broadcastBuildResult(result BuildResult, newHashes map[stri
 			sb.Write(helpers.QuoteForJSON(path, false))
 		}
 		sb.WriteString("]}")
-		json := sb.String()
+		changeJSON = sb.String()
+	}
 
-		// Broadcast the diff to all streams
-		for _, stream := range h.activeStreams {
-			stream <- serverSentEvent{event: "change", data: json}
-		}
+	// Also broadcast build errors so that clients can show an error overlay.
+	// An empty list is sent once the errors are fixed so that the overlay can
+	// be cleared. Nothing is sent if the errors didn't change.
+	errorsChanged := messagesToJSON(result.Errors) != messagesToJSON(h.currentErrors)
+	if errorsChanged {
+		h.currentErrors = result.Errors
 	}
 
 	h.mutex.Unlock()
+
+	// Build contexts that are mounted on a shared server use that server's
+	// event streams, which combine the events from all mounted build contexts
+	owner := h
+	if h.server != nil {
+		owner = h.server
+	}
+	owner.mutex.Lock()
+	if changeJSON != "" {
+		for _, stream := range owner.activeStreams {
+			stream <- serverSentEvent{event: "change", data: changeJSON}
+		}
+	}
+	if errorsChanged {
+		errorsJSON := owner.currentErrorsJSON()
+		for _, stream := range owner.activeStreams {
+			stream <- serverSentEvent{event: "errors", data: errorsJSON}
+		}
+	}
+	owner.mutex.Unlock()
+}
+
+// This combines the errors from this handler's build context with the errors
+// from all mounted build contexts. It must be called while holding the mutex.
+func (h *apiHandler) currentErrorsJSON() string {
+	errors := h.currentErrors
+	for _, mount := range h.mounts {
+		mount.mutex.Lock()
+		errors = append(errors[:len(errors):len(errors)], mount.currentErrors...)
+		mount.mutex.Unlock()
+	}
+	return messagesToJSON(errors)
 }
 
 // Handle enough of the range specification so that video playback works in Safari
@@ -758,34 +949,8 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 		return ServeResult{}, errors.New("Serve mode has already been enabled")
 	}
 
-	// Don't allow starting serve mode multiple times
-	if (serveOptions.Keyfile != "") != (serveOptions.Certfile != "") {
-		return ServeResult{}, errors.New("Must specify both key and certificate for HTTPS")
-	}
-
-	// Validate the "servedir" path
-	if serveOptions.Servedir != "" {
-		if absPath, ok := ctx.realFS.Abs(serveOptions.Servedir); ok {
-			serveOptions.Servedir = absPath
-		} else {
-			return ServeResult{}, fmt.Errorf("Invalid serve path: %s", serveOptions.Servedir)
-		}
-	}
-
-	// Validate the "fallback" path
-	if serveOptions.Fallback != "" {
-		if absPath, ok := ctx.realFS.Abs(serveOptions.Fallback); ok {
-			serveOptions.Fallback = absPath
-		} else {
-			return ServeResult{}, fmt.Errorf("Invalid fallback path: %s", serveOptions.Fallback)
-		}
-	}
-
-	// Validate the CORS origins
-	for _, origin := range serveOptions.CORS.Origin {
-		if star := strings.IndexByte(origin, '*'); star >= 0 && strings.ContainsRune(origin[star+1:], '*') {
-			return ServeResult{}, fmt.Errorf("Invalid origin: %s", origin)
-		}
+	if err := validateServeOptions(ctx.realFS, &serveOptions); err != nil {
+		return ServeResult{}, err
 	}
 
 	// Stuff related to the output directory only matters if there are entry points
@@ -822,6 +987,224 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 		}
 	}
 
+	// The first build will just build normally
+	handler := &apiHandler{
+		outdirPathPrefix: outdirPathPrefix,
+		absOutputDir:     ctx.args.options.AbsOutputDir,
+		publicPath:       ctx.args.options.PublicPath,
+	}
+	handler.rebuild = func() BuildResult {
+		if handler.isStopped() {
+			// Don't start more rebuilds if we were told to stop
+			return BuildResult{}
+		} else {
+			return ctx.activeBuildOrRecentBuildOrRebuild()
+		}
+	}
+
+	result, isHTTPS, err := startServer(ctx.realFS, serveOptions, handler)
+	if err != nil {
+		return ServeResult{}, err
+	}
+
+	// Only set the context handler if the server started successfully
+	ctx.handler = handler
+
+	// Print the URL(s) that the server can be reached at
+	if ctx.args.logOptions.LogLevel <= logger.LevelInfo {
+		printURLs(handler.hosts, result.Port, isHTTPS, ctx.args.logOptions.Color)
+	}
+
+	// Start the first build shortly after this function returns (but not
+	// immediately so that stuff we print right after this will come first).
+	//
+	// This also helps the CLI not do two builds when serve and watch mode
+	// are enabled together. Watch mode is enabled after serve mode because
+	// we want the stderr output for watch to come after the stderr output for
+	// serve, but watch mode will do another build if the current build is
+	// not a watch mode build.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		handler.rebuild()
+	}()
+	return result, nil
+}
+
+type internalServer struct {
+	handler *apiHandler
+}
+
+func newServerImpl(serveOptions ServeOptions) (Server, ServeResult, error) {
+	realFS, err := fs.RealFS(fs.RealFSOptions{
+		// This is a long-lived file system object so do not cache calls to
+		// ReadDirectory() (they are normally cached for the duration of a build
+		// for performance).
+		DoNotCache: true,
+	})
+	if err != nil {
+		return nil, ServeResult{}, err
+	}
+
+	if err := validateServeOptions(realFS, &serveOptions); err != nil {
+		return nil, ServeResult{}, err
+	}
+
+	// The server itself has no build context. Everything it serves comes from
+	// either the "servedir" directory or from mounted build contexts.
+	handler := &apiHandler{
+		rebuild: func() BuildResult { return BuildResult{} },
+	}
+
+	result, _, err := startServer(realFS, serveOptions, handler)
+	if err != nil {
+		return nil, ServeResult{}, err
+	}
+	return &internalServer{handler: handler}, result, nil
+}
+
+func (server *internalServer) Mount(pathPrefix string, buildContext BuildContext) error {
+	ctx, ok := buildContext.(*internalContext)
+	if !ok {
+		return errors.New("Cannot mount a build context that wasn't created by esbuild")
+	}
+
+	// Normalize the path prefix so that "/app/", "/app", and "app" are the same
+	pathPrefix = strings.Trim(path.Clean("/"+pathPrefix), "/")
+	if pathPrefix == "" {
+		return errors.New("The mount path prefix must not be empty")
+	}
+	if pathPrefix == "esbuild" || strings.HasPrefix(pathPrefix, "esbuild/") {
+		return fmt.Errorf("The mount path prefix %q is reserved", "/esbuild/")
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	// Ignore disposed contexts
+	if ctx.didDispose {
+		return errors.New("Cannot mount a disposed context")
+	}
+
+	// A context can only be served once
+	if ctx.handler != nil {
+		return errors.New("Serve mode has already been enabled")
+	}
+
+	// Don't allow mounting when builds are written to stdout
+	if len(ctx.args.entryPoints) > 0 && ctx.args.options.WriteToStdout {
+		what := "entry points"
+		if len(ctx.args.entryPoints) == 1 {
+			what = "an entry point"
+		}
+		return fmt.Errorf("Cannot serve %s without an output path", what)
+	}
+
+	h := server.handler
+	mount := &apiHandler{
+		onRequest:    h.onRequest,
+		fs:           h.fs,
+		absOutputDir: ctx.args.options.AbsOutputDir,
+		publicPath:   ctx.args.options.PublicPath,
+		overlay:      h.overlay,
+		mountPrefix:  pathPrefix,
+		server:       h,
+	}
+	mount.rebuild = func() BuildResult {
+		if mount.isStopped() {
+			// Don't start more rebuilds if we were told to stop
+			return BuildResult{}
+		} else {
+			return ctx.activeBuildOrRecentBuildOrRebuild()
+		}
+	}
+
+	// Disposing of the build context unmounts it from the server
+	mount.stop = func() {
+		atomic.StoreInt32(&mount.shouldStop, 1)
+		h.mutex.Lock()
+		for i, it := range h.mounts {
+			if it == mount {
+				h.mounts = append(h.mounts[:i:i], h.mounts[i+1:]...)
+				break
+			}
+		}
+		h.mutex.Unlock()
+	}
+
+	h.mutex.Lock()
+	if atomic.LoadInt32(&h.shouldStop) != 0 {
+		h.mutex.Unlock()
+		return errors.New("Cannot mount a build context on a stopped server")
+	}
+	for _, it := range h.mounts {
+		if it.mountPrefix == pathPrefix ||
+			strings.HasPrefix(it.mountPrefix, pathPrefix+"/") ||
+			strings.HasPrefix(pathPrefix, it.mountPrefix+"/") {
+			h.mutex.Unlock()
+			return fmt.Errorf("The mount path prefix %q overlaps with the mount path prefix %q", "/"+pathPrefix+"/", "/"+it.mountPrefix+"/")
+		}
+	}
+	h.mounts = append(h.mounts, mount)
+	h.mutex.Unlock()
+
+	ctx.handler = mount
+
+	// Start the first build shortly after this function returns
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		mount.rebuild()
+	}()
+	return nil
+}
+
+func (server *internalServer) Stop() {
+	server.handler.stop()
+}
+
+func (h *apiHandler) isStopped() bool {
+	if h.server != nil && atomic.LoadInt32(&h.server.shouldStop) != 0 {
+		return true
+	}
+	return atomic.LoadInt32(&h.shouldStop) != 0
+}
+
+func validateServeOptions(realFS fs.FS, serveOptions *ServeOptions) error {
+	// Don't allow starting serve mode multiple times
+	if (serveOptions.Keyfile != "") != (serveOptions.Certfile != "") {
+		return errors.New("Must specify both key and certificate for HTTPS")
+	}
+
+	// Validate the "servedir" path
+	if serveOptions.Servedir != "" {
+		if absPath, ok := realFS.Abs(serveOptions.Servedir); ok {
+			serveOptions.Servedir = absPath
+		} else {
+			return fmt.Errorf("Invalid serve path: %s", serveOptions.Servedir)
+		}
+	}
+
+	// Validate the "fallback" path
+	if serveOptions.Fallback != "" {
+		if absPath, ok := realFS.Abs(serveOptions.Fallback); ok {
+			serveOptions.Fallback = absPath
+		} else {
+			return fmt.Errorf("Invalid fallback path: %s", serveOptions.Fallback)
+		}
+	}
+
+	// Validate the CORS origins
+	for _, origin := range serveOptions.CORS.Origin {
+		if star := strings.IndexByte(origin, '*'); star >= 0 && strings.ContainsRune(origin[star+1:], '*') {
+			return fmt.Errorf("Invalid origin: %s", origin)
+		}
+	}
+
+	return nil
+}
+
+// This starts listening for HTTP requests. It fills in the parts of the
+// handler that come from the serve options and returns whether HTTPS is used.
+func startServer(realFS fs.FS, serveOptions ServeOptions, handler *apiHandler) (ServeResult, bool, error) {
 	// Determine the host
 	var listener net.Listener
 	network := "tcp4"
@@ -859,7 +1242,7 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 			port = 0 // Pick a random port if the provided port is out of range
 		}
 		if result, err := net.Listen(network, net.JoinHostPort(host, fmt.Sprintf("%d", port))); err != nil {
-			return ServeResult{}, err
+			return ServeResult{}, false, err
 		} else {
 			listener = result
 		}
@@ -903,47 +1286,32 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 	// HTTPS-related files should be absolute paths
 	isHTTPS := serveOptions.Keyfile != "" && serveOptions.Certfile != ""
 	if isHTTPS {
-		serveOptions.Keyfile, _ = ctx.realFS.Abs(serveOptions.Keyfile)
-		serveOptions.Certfile, _ = ctx.realFS.Abs(serveOptions.Certfile)
-		if symlink, ok := ctx.realFS.EvalSymlinks(serveOptions.Keyfile); ok {
+		serveOptions.Keyfile, _ = realFS.Abs(serveOptions.Keyfile)
+		serveOptions.Certfile, _ = realFS.Abs(serveOptions.Certfile)
+		if symlink, ok := realFS.EvalSymlinks(serveOptions.Keyfile); ok {
 			serveOptions.Keyfile = symlink
 		}
-		if symlink, ok := ctx.realFS.EvalSymlinks(serveOptions.Certfile); ok {
+		if symlink, ok := realFS.EvalSymlinks(serveOptions.Certfile); ok {
 			serveOptions.Certfile = symlink
 		}
 	}
 
-	var shouldStop int32
-
-	// The first build will just build normally
-	handler := &apiHandler{
-		onRequest:        serveOptions.OnRequest,
-		outdirPathPrefix: outdirPathPrefix,
-		absOutputDir:     ctx.args.options.AbsOutputDir,
-		publicPath:       ctx.args.options.PublicPath,
-		servedir:         serveOptions.Servedir,
-		keyfileToLower:   strings.ToLower(serveOptions.Keyfile),
-		certfileToLower:  strings.ToLower(serveOptions.Certfile),
-		fallback:         serveOptions.Fallback,
-		hosts:            append([]string{}, result.Hosts...),
-		corsOrigin:       append([]string{}, serveOptions.CORS.Origin...),
-		rebuild: func() BuildResult {
-			if atomic.LoadInt32(&shouldStop) != 0 {
-				// Don't start more rebuilds if we were told to stop
-				return BuildResult{}
-			} else {
-				return ctx.activeBuildOrRecentBuildOrRebuild()
-			}
-		},
-		fs: ctx.realFS,
-	}
+	handler.onRequest = serveOptions.OnRequest
+	handler.servedir = serveOptions.Servedir
+	handler.keyfileToLower = strings.ToLower(serveOptions.Keyfile)
+	handler.certfileToLower = strings.ToLower(serveOptions.Certfile)
+	handler.fallback = serveOptions.Fallback
+	handler.hosts = append([]string{}, result.Hosts...)
+	handler.corsOrigin = append([]string{}, serveOptions.CORS.Origin...)
+	handler.overlay = serveOptions.Overlay
+	handler.fs = realFS
 
 	// Create the server
 	server := &http.Server{Addr: addr, Handler: handler}
 
 	// When stop is called, block further rebuilds and then close the server
 	handler.stop = func() {
-		atomic.StoreInt32(&shouldStop, 1)
+		atomic.StoreInt32(&handler.shouldStop, 1)
 
 		// Close the server and wait for it to close
 		server.Close()
@@ -991,7 +1359,7 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 	// Return an error if the server failed to start accepting connections
 	hack.waitGroup.Wait()
 	if hack.err != nil {
-		return ServeResult{}, hack.err
+		return ServeResult{}, false, hack.err
 	}
 
 	// There appears to be some issue with Linux (but not with macOS) where
@@ -1012,27 +1380,7 @@ func (ctx *internalContext) Serve(serveOptions ServeOptions) (ServeResult, error
 	// setting this timeout to 50ms to be extra safe.
 	time.Sleep(50 * time.Millisecond)
 
-	// Only set the context handler if the server started successfully
-	ctx.handler = handler
-
-	// Print the URL(s) that the server can be reached at
-	if ctx.args.logOptions.LogLevel <= logger.LevelInfo {
-		printURLs(handler.hosts, result.Port, isHTTPS, ctx.args.logOptions.Color)
-	}
-
-	// Start the first build shortly after this function returns (but not
-	// immediately so that stuff we print right after this will come first).
-	//
-	// This also helps the CLI not do two builds when serve and watch mode
-	// are enabled together. Watch mode is enabled after serve mode because
-	// we want the stderr output for watch to come after the stderr output for
-	// serve, but watch mode will do another build if the current build is
-	// not a watch mode build.
-	go func() {
-		time.Sleep(10 * time.Millisecond)
-		handler.rebuild()
-	}()
-	return result, nil
+	return result, isHTTPS, nil
 }
 
 type hackListener struct {
@@ -0,0 +1,99 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package api_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/test"
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+func TestNewServerMount(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newContext := func(name string, contents string) api.BuildContext {
+		t.Helper()
+		ctx, ctxErr := api.Context(api.BuildOptions{
+			Stdin:    &api.StdinOptions{Contents: contents},
+			Outfile:  filepath.Join(cwd, "out-"+name, "app.js"),
+			LogLevel: api.LogLevelSilent,
+		})
+		if ctxErr != nil {
+			t.Fatal(ctxErr)
+		}
+		return ctx
+	}
+
+	ctxA := newContext("a", "a()")
+	defer ctxA.Dispose()
+	ctxB := newContext("b", "b()")
+	defer ctxB.Dispose()
+
+	server, result, err := api.NewServer(api.ServeOptions{Host: "127.0.0.1", Port: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	if err := server.Mount("/app-a/", ctxA); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Mount("app-b", ctxB); err != nil {
+		t.Fatal(err)
+	}
+	ctxC := newContext("c", "")
+	defer ctxC.Dispose()
+	if err := server.Mount("/app-a/nested/", ctxC); err == nil {
+		t.Fatal("Expected overlapping mount path prefixes to fail")
+	}
+	if err := server.Mount("/app-c/", ctxA); err == nil {
+		t.Fatal("Expected mounting the same context twice to fail")
+	}
+
+	fetch := func(path string) (int, string) {
+		t.Helper()
+		res, err := http.Get(fmt.Sprintf("http://%s:%d%s", result.Hosts[0], result.Port, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, string(body)
+	}
+
+	status, body := fetch("/app-a/app.js")
+	test.AssertEqual(t, status, http.StatusOK)
+	test.AssertEqualWithDiff(t, body, "a();\n")
+
+	status, body = fetch("/app-b/app.js")
+	test.AssertEqual(t, status, http.StatusOK)
+	test.AssertEqualWithDiff(t, body, "b();\n")
+
+	status, _ = fetch("/app-c/app.js")
+	test.AssertEqual(t, status, http.StatusNotFound)
+
+	// The directory listing includes the mounted path prefixes
+	status, body = fetch("/")
+	test.AssertEqual(t, status, http.StatusOK)
+	test.AssertEqual(t, strings.Contains(body, `<a href="/app-a/">app-a/</a>`), true)
+	test.AssertEqual(t, strings.Contains(body, `<a href="/app-b/">app-b/</a>`), true)
+
+	// Disposing of a build context unmounts it
+	ctxB.Dispose()
+	status, _ = fetch("/app-b/app.js")
+	test.AssertEqual(t, status, http.StatusNotFound)
+}
@@ -11,6 +11,10 @@ func (*internalContext) Serve(ServeOptions) (ServeResult, error) {
 	return ServeResult{}, fmt.Errorf("The \"serve\" API is not supported when using WebAssembly")
 }
 
+func newServerImpl(ServeOptions) (Server, ServeResult, error) {
+	return nil, ServeResult{}, fmt.Errorf("The \"serve\" API is not supported when using WebAssembly")
+}
+
 type apiHandler struct {
 }
 
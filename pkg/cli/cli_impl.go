@@ -22,12 +22,13 @@ import (
 
 func newBuildOptions() api.BuildOptions {
 	return api.BuildOptions{
-		Banner:      make(map[string]string),
-		Define:      make(map[string]string),
-		Footer:      make(map[string]string),
-		Loader:      make(map[string]api.Loader),
-		LogOverride: make(map[string]api.LogLevel),
-		Supported:   make(map[string]bool),
+		Banner:             make(map[string]string),
+		Define:             make(map[string]string),
+		Footer:             make(map[string]string),
+		Loader:             make(map[string]api.Loader),
+		LogOverride:        make(map[string]api.LogLevel),
+		Supported:          make(map[string]bool),
+		PackageSideEffects: make(map[string]bool),
 	}
 }
 
@@ -54,6 +55,9 @@ type parseOptionsExtras struct {
 	watchDelay  int
 	metafile    *string
 	mangleCache *string
+	nameCache   *string
+
+	generateHelpers bool
 }
 
 func isBoolFlag(arg string, flag string) bool {
@@ -128,6 +132,13 @@ func parseOptionsImpl(
 				extras.watch = value
 			}
 
+		case isBoolFlag(arg, "--generate-helpers") && transformOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				extras.generateHelpers = value
+			}
+
 		case strings.HasPrefix(arg, "--watch-delay=") && buildOpts != nil:
 			value := arg[len("--watch-delay="):]
 			delay, err := strconv.Atoi(value)
@@ -161,6 +172,15 @@ func parseOptionsImpl(
 				transformOpts.MinifySyntax = value
 			}
 
+		case isBoolFlag(arg, "--inline-functions"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.InlineFunctions = value
+			} else {
+				transformOpts.InlineFunctions = value
+			}
+
 		case isBoolFlag(arg, "--minify-whitespace"):
 			if value, err := parseBoolFlag(arg, true); err != nil {
 				return parseOptionsExtras{}, err
@@ -216,6 +236,10 @@ func parseOptionsImpl(
 			value := arg[len("--mangle-cache="):]
 			extras.mangleCache = &value
 
+		case strings.HasPrefix(arg, "--name-cache=") && buildOpts != nil && kind == kindInternal:
+			value := arg[len("--name-cache="):]
+			extras.nameCache = &value
+
 		case strings.HasPrefix(arg, "--drop:"):
 			value := arg[len("--drop:"):]
 			switch value {
@@ -238,6 +262,38 @@ func parseOptionsImpl(
 				)
 			}
 
+		case strings.HasPrefix(arg, "--assume-pure:"):
+			value := arg[len("--assume-pure:"):]
+			var flag api.AssumePure
+			switch value {
+			case "getters":
+				flag = api.AssumePureGetters
+			case "property-reads":
+				flag = api.AssumePurePropertyReads
+			case "unknown-globals":
+				flag = api.AssumePureUnknownGlobals
+			case "try":
+				flag = api.AssumePureTryBlocks
+			default:
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"getters\", \"property-reads\", \"unknown-globals\", or \"try\".",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.AssumePure |= flag
+			} else {
+				transformOpts.AssumePure |= flag
+			}
+
+		case strings.HasPrefix(arg, "--known-global:"):
+			value := arg[len("--known-global:"):]
+			if buildOpts != nil {
+				buildOpts.KnownGlobals = append(buildOpts.KnownGlobals, value)
+			} else {
+				transformOpts.KnownGlobals = append(transformOpts.KnownGlobals, value)
+			}
+
 		case strings.HasPrefix(arg, "--drop-labels="):
 			if buildOpts != nil {
 				buildOpts.DropLabels = splitWithEmptyCheck(arg[len("--drop-labels="):], ",")
@@ -271,6 +327,26 @@ func parseOptionsImpl(
 				transformOpts.LegalComments = legalComments
 			}
 
+		case strings.HasPrefix(arg, "--comments="):
+			value := arg[len("--comments="):]
+			var comments api.Comments
+			switch value {
+			case "default":
+				comments = api.CommentsDefault
+			case "preserve":
+				comments = api.CommentsPreserve
+			default:
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"default\" or \"preserve\".",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.Comments = comments
+			} else {
+				transformOpts.Comments = comments
+			}
+
 		case strings.HasPrefix(arg, "--charset="):
 			var value *api.Charset
 			if buildOpts != nil {
@@ -359,6 +435,25 @@ func parseOptionsImpl(
 			}
 			hasBareSourceMapFlag = false
 
+		case arg == "--debug-ids" && buildOpts != nil:
+			buildOpts.DebugIDs = api.DebugIDsComment
+
+		case strings.HasPrefix(arg, "--debug-ids=") && buildOpts != nil:
+			value := arg[len("--debug-ids="):]
+			switch value {
+			case "none":
+				buildOpts.DebugIDs = api.DebugIDsNone
+			case "comment":
+				buildOpts.DebugIDs = api.DebugIDsComment
+			case "register":
+				buildOpts.DebugIDs = api.DebugIDsRegister
+			default:
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"none\", \"comment\", or \"register\".",
+				)
+			}
+
 		case strings.HasPrefix(arg, "--source-root="):
 			sourceRoot := arg[len("--source-root="):]
 			if buildOpts != nil {
@@ -367,6 +462,17 @@ func parseOptionsImpl(
 				transformOpts.SourceRoot = sourceRoot
 			}
 
+		case strings.HasPrefix(arg, "--source-map-ignore-list="):
+			// An empty value turns off the default ignore list
+			value := arg[len("--source-map-ignore-list="):]
+			if buildOpts != nil {
+				buildOpts.SourceMapIgnoreList = value
+				buildOpts.DisableSourceMapIgnoreList = value == ""
+			} else {
+				transformOpts.SourceMapIgnoreList = value
+				transformOpts.DisableSourceMapIgnoreList = value == ""
+			}
+
 		case isBoolFlag(arg, "--sources-content"):
 			if value, err := parseBoolFlag(arg, true); err != nil {
 				return parseOptionsExtras{}, err
@@ -406,6 +512,13 @@ func parseOptionsImpl(
 		case strings.HasPrefix(arg, "--public-path=") && buildOpts != nil:
 			buildOpts.PublicPath = arg[len("--public-path="):]
 
+		case strings.HasPrefix(arg, "--external-helpers="):
+			if buildOpts != nil {
+				buildOpts.ExternalHelpers = arg[len("--external-helpers="):]
+			} else {
+				transformOpts.ExternalHelpers = arg[len("--external-helpers="):]
+			}
+
 		case strings.HasPrefix(arg, "--global-name="):
 			if buildOpts != nil {
 				buildOpts.GlobalName = arg[len("--global-name="):]
@@ -430,6 +543,16 @@ func parseOptionsImpl(
 		case strings.HasPrefix(arg, "--outbase=") && buildOpts != nil:
 			buildOpts.Outbase = arg[len("--outbase="):]
 
+		case isBoolFlag(arg, "--css-module-types") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.CSSModuleTypes = value
+			}
+
+		case strings.HasPrefix(arg, "--css-module-types-dir=") && buildOpts != nil:
+			buildOpts.CSSModuleTypesDir = arg[len("--css-module-types-dir="):]
+
 		case strings.HasPrefix(arg, "--tsconfig=") && buildOpts != nil:
 			buildOpts.Tsconfig = arg[len("--tsconfig="):]
 
@@ -449,6 +572,17 @@ func parseOptionsImpl(
 		case strings.HasPrefix(arg, "--asset-names=") && buildOpts != nil:
 			buildOpts.AssetNames = arg[len("--asset-names="):]
 
+		case strings.HasPrefix(arg, "--asset-inline-limit=") && buildOpts != nil:
+			value := arg[len("--asset-inline-limit="):]
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"The asset inline limit must be a non-negative integer.",
+				)
+			}
+			buildOpts.AssetInlineLimit = &limit
+
 		case strings.HasPrefix(arg, "--define:"):
 			value := arg[len("--define:"):]
 			equals := strings.IndexByte(value, '=')
@@ -527,6 +661,36 @@ func parseOptionsImpl(
 				transformOpts.Supported[value[:equals]] = isSupported
 			}
 
+		case isBoolFlag(arg, "--infer-side-effects") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.InferSideEffects = value
+			}
+
+		case isBoolFlag(arg, "--error-recovery") && transformOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				transformOpts.ErrorRecovery = value
+			}
+
+		case strings.HasPrefix(arg, "--package-side-effects:") && buildOpts != nil:
+			value := arg[len("--package-side-effects:"):]
+			equals := strings.IndexByte(value, '=')
+			if equals == -1 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Missing \"=\" in %q", arg),
+					"You need to use \"=\" to specify both the name of the package and whether it has side effects or not. "+
+						"For example, \"--package-side-effects:lodash=false\" marks the \"lodash\" package as having no side effects.",
+				)
+			}
+			if hasSideEffects, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.PackageSideEffects[value[:equals]] = hasSideEffects
+			}
+
 		case strings.HasPrefix(arg, "--pure:"):
 			value := arg[len("--pure:"):]
 			if buildOpts != nil {
@@ -558,7 +722,7 @@ func parseOptionsImpl(
 			if err != nil {
 				return parseOptionsExtras{}, err
 			}
-			if loader == api.LoaderFile || loader == api.LoaderCopy {
+			if loader == api.LoaderFile || loader == api.LoaderCopy || loader == api.LoaderAutoDataURL {
 				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
 					fmt.Sprintf("%q is not supported when transforming stdin", arg),
 					fmt.Sprintf("Using esbuild to transform stdin only generates one output file, so you cannot use the %q loader "+
@@ -587,6 +751,14 @@ func parseOptionsImpl(
 				transformOpts.Engines = engines
 			}
 
+		case strings.HasPrefix(arg, "--polyfill="):
+			value := arg[len("--polyfill="):]
+			if buildOpts != nil {
+				buildOpts.Polyfill = value
+			} else {
+				transformOpts.Polyfill = value
+			}
+
 		case strings.HasPrefix(arg, "--out-extension:") && buildOpts != nil:
 			value := arg[len("--out-extension:"):]
 			equals := strings.IndexByte(value, '=')
@@ -747,6 +919,15 @@ func parseOptionsImpl(
 				transformOpts.JSXSideEffects = value
 			}
 
+		case isBoolFlag(arg, "--jsx-refresh"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.JSXRefresh = value
+			} else {
+				transformOpts.JSXRefresh = value
+			}
+
 		case strings.HasPrefix(arg, "--banner=") && transformOpts != nil:
 			transformOpts.Banner = arg[len("--banner="):]
 
@@ -870,8 +1051,14 @@ func parseOptionsImpl(
 			bare := map[string]bool{
 				"allow-overwrite":    true,
 				"bundle":             true,
+				"css-module-types":   true,
+				"error-recovery":     true,
+				"generate-helpers":   true,
 				"ignore-annotations": true,
+				"infer-side-effects": true,
+				"inline-functions":   true,
 				"jsx-dev":            true,
+				"jsx-refresh":        true,
 				"jsx-side-effects":   true,
 				"keep-names":         true,
 				"minify-identifiers": true,
@@ -879,86 +1066,99 @@ func parseOptionsImpl(
 				"minify-whitespace":  true,
 				"minify":             true,
 				"preserve-symlinks":  true,
+				"serve-overlay":      true,
 				"sourcemap":          true,
 				"splitting":          true,
 				"watch":              true,
 			}
 
 			equals := map[string]bool{
-				"abs-paths":          true,
-				"allow-overwrite":    true,
-				"asset-names":        true,
-				"banner":             true,
-				"bundle":             true,
-				"certfile":           true,
-				"charset":            true,
-				"chunk-names":        true,
-				"color":              true,
-				"conditions":         true,
-				"cors-origin":        true,
-				"drop-labels":        true,
-				"entry-names":        true,
-				"footer":             true,
-				"format":             true,
-				"global-name":        true,
-				"ignore-annotations": true,
-				"jsx-factory":        true,
-				"jsx-fragment":       true,
-				"jsx-import-source":  true,
-				"jsx":                true,
-				"keep-names":         true,
-				"keyfile":            true,
-				"legal-comments":     true,
-				"loader":             true,
-				"log-level":          true,
-				"log-limit":          true,
-				"main-fields":        true,
-				"mangle-cache":       true,
-				"mangle-props":       true,
-				"mangle-quoted":      true,
-				"metafile":           true,
-				"minify-identifiers": true,
-				"minify-syntax":      true,
-				"minify-whitespace":  true,
-				"minify":             true,
-				"outbase":            true,
-				"outdir":             true,
-				"outfile":            true,
-				"packages":           true,
-				"platform":           true,
-				"preserve-symlinks":  true,
-				"public-path":        true,
-				"reserve-props":      true,
-				"resolve-extensions": true,
-				"serve-fallback":     true,
-				"serve":              true,
-				"servedir":           true,
-				"source-root":        true,
-				"sourcefile":         true,
-				"sourcemap":          true,
-				"sources-content":    true,
-				"splitting":          true,
-				"target":             true,
-				"tree-shaking":       true,
-				"tsconfig-raw":       true,
-				"tsconfig":           true,
-				"watch":              true,
-				"watch-delay":        true,
+				"abs-paths":            true,
+				"allow-overwrite":      true,
+				"asset-inline-limit":   true,
+				"asset-names":          true,
+				"banner":               true,
+				"bundle":               true,
+				"certfile":             true,
+				"charset":              true,
+				"chunk-names":          true,
+				"color":                true,
+				"comments":             true,
+				"conditions":           true,
+				"cors-origin":          true,
+				"css-module-types-dir": true,
+				"drop-labels":          true,
+				"entry-names":          true,
+				"error-recovery":       true,
+				"external-helpers":     true,
+				"footer":               true,
+				"format":               true,
+				"global-name":          true,
+				"ignore-annotations":   true,
+				"infer-side-effects":   true,
+				"inline-functions":     true,
+				"jsx-factory":          true,
+				"jsx-fragment":         true,
+				"jsx-import-source":    true,
+				"jsx":                  true,
+				"keep-names":           true,
+				"keyfile":              true,
+				"legal-comments":       true,
+				"loader":               true,
+				"log-level":            true,
+				"log-limit":            true,
+				"main-fields":          true,
+				"mangle-cache":         true,
+				"name-cache":           true,
+				"mangle-props":         true,
+				"mangle-quoted":        true,
+				"metafile":             true,
+				"minify-identifiers":   true,
+				"minify-syntax":        true,
+				"minify-whitespace":    true,
+				"minify":               true,
+				"outbase":              true,
+				"outdir":               true,
+				"outfile":              true,
+				"packages":             true,
+				"platform":             true,
+				"polyfill":             true,
+				"preserve-symlinks":    true,
+				"public-path":          true,
+				"reserve-props":        true,
+				"resolve-extensions":   true,
+				"serve-fallback":       true,
+				"serve":                true,
+				"servedir":             true,
+				"source-root":          true,
+				"sourcefile":           true,
+				"sourcemap":            true,
+				"sources-content":      true,
+				"splitting":            true,
+				"target":               true,
+				"tree-shaking":         true,
+				"tsconfig-raw":         true,
+				"tsconfig":             true,
+				"watch":                true,
+				"watch-delay":          true,
 			}
 
 			colon := map[string]bool{
-				"alias":         true,
-				"banner":        true,
-				"define":        true,
-				"drop":          true,
-				"external":      true,
-				"footer":        true,
-				"inject":        true,
-				"loader":        true,
-				"log-override":  true,
-				"out-extension": true,
-				"pure":          true,
-				"supported":     true,
+				"alias":                true,
+				"assume-pure":          true,
+				"banner":               true,
+				"define":               true,
+				"drop":                 true,
+				"external":             true,
+				"footer":               true,
+				"inject":               true,
+				"known-global":         true,
+				"loader":               true,
+				"log-override":         true,
+				"out-extension":        true,
+				"package-side-effects": true,
+				"pure":                 true,
+				"supported":            true,
 			}
 
 			note := ""
@@ -1108,7 +1308,7 @@ func parseOptionsForRun(osArgs []string) (*api.BuildOptions, *api.TransformOptio
 	options.LogLimit = 6
 	options.LogLevel = api.LogLevelInfo
 
-	_, err := parseOptionsImpl(osArgs, nil, &options, kindInternal)
+	extras, err := parseOptionsImpl(osArgs, nil, &options, kindInternal)
 	if err != nil {
 		return nil, nil, parseOptionsExtras{}, err
 	}
@@ -1128,7 +1328,7 @@ func parseOptionsForRun(osArgs []string) (*api.BuildOptions, *api.TransformOptio
 				"since that needs to generate two output files.", sourceMapMode),
 		)
 	}
-	return nil, &options, parseOptionsExtras{}, nil
+	return nil, &options, extras, nil
 }
 
 func splitWithEmptyCheck(s string, sep string) []string {
@@ -1322,7 +1522,7 @@ func runImpl(osArgs []string, plugins []api.Plugin) int {
 				}
 				mangleCacheAbsPath = absPath
 				mangleCacheAbsDir = realFS.Dir(absPath)
-				buildOptions.MangleCache, mangleCacheOrder = parseMangleCache(osArgs, realFS, *extras.mangleCache)
+				buildOptions.MangleCache, mangleCacheOrder = parseMangleCache(osArgs, realFS, *extras.mangleCache, "mangle cache")
 				if buildOptions.MangleCache == nil {
 					return 1 // Stop now if parsing failed
 				}
@@ -1353,6 +1553,48 @@ func runImpl(osArgs []string, plugins []api.Plugin) int {
 			}
 		}
 
+		// Do the same for the name cache
+		var writeNameCache func(map[string]interface{})
+		if extras.nameCache != nil {
+			var nameCacheAbsPath string
+			var nameCacheAbsDir string
+			var nameCacheOrder []string
+			realFS, realFSErr := fs.RealFS(fs.RealFSOptions{AbsWorkingDir: buildOptions.AbsWorkingDir})
+			if realFSErr == nil {
+				absPath, ok := realFS.Abs(*extras.nameCache)
+				if !ok {
+					logger.PrintErrorToStderr(osArgs, fmt.Sprintf("Invalid name cache path: %s", *extras.nameCache))
+					return 1
+				}
+				nameCacheAbsPath = absPath
+				nameCacheAbsDir = realFS.Dir(absPath)
+				buildOptions.NameCache, nameCacheOrder = parseMangleCache(osArgs, realFS, *extras.nameCache, "name cache")
+				if buildOptions.NameCache == nil {
+					return 1 // Stop now if parsing failed
+				}
+			} else {
+				// Don't fail in this case since the error will be reported by "api.Build"
+			}
+
+			writeNameCache = func(nameCache map[string]interface{}) {
+				if nameCache == nil || realFSErr != nil {
+					return // Don't write out the name cache on build errors
+				}
+				fs.BeforeFileOpen()
+				defer fs.AfterFileClose()
+				if err := fs.MkdirAll(realFS, nameCacheAbsDir, 0755); err != nil {
+					logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
+						"Failed to create output directory: %s", err.Error()))
+				} else {
+					bytes := printMangleCache(nameCache, nameCacheOrder, buildOptions.Charset == api.CharsetASCII)
+					if err := ioutil.WriteFile(nameCacheAbsPath, bytes, 0666); err != nil {
+						logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
+							"Failed to write to output file: %s", err.Error()))
+					}
+				}
+			}
+		}
+
 		// Handle post-build actions with a plugin so they also work in watch mode
 		buildOptions.Plugins = append(buildOptions.Plugins, api.Plugin{
 			Name: "PostBuildActions",
@@ -1368,6 +1610,11 @@ func runImpl(osArgs []string, plugins []api.Plugin) int {
 						writeMangleCache(result.MangleCache)
 					}
 
+					// Write the name cache to the file system
+					if writeNameCache != nil {
+						writeNameCache(result.NameCache)
+					}
+
 					return api.OnEndResult{}, nil
 				})
 			},
@@ -1398,6 +1645,16 @@ func runImpl(osArgs []string, plugins []api.Plugin) int {
 			return 1
 		}
 
+	case transformOptions != nil && extras.generateHelpers:
+		// Generate the helpers module instead of transforming stdin
+		result := api.GenerateHelpers(*transformOptions)
+		if len(result.Errors) > 0 {
+			return 1
+		}
+
+		// Write the output to stdout
+		os.Stdout.Write(result.Code)
+
 	case transformOptions != nil:
 		// Read the input from stdin
 		bytes, err := ioutil.ReadAll(os.Stdin)
@@ -1407,9 +1664,13 @@ func runImpl(osArgs []string, plugins []api.Plugin) int {
 			return 1
 		}
 
-		// Run the transform and stop if there were errors
+		// Run the transform and stop if there were errors. In error recovery
+		// mode, still write out whatever code was generated.
 		result := api.Transform(string(bytes), *transformOptions)
 		if len(result.Errors) > 0 {
+			if transformOptions.ErrorRecovery {
+				os.Stdout.Write(result.Code)
+			}
 			return 1
 		}
 
@@ -1431,6 +1692,7 @@ func parseServeOptionsImpl(osArgs []string) (api.ServeOptions, []string, error)
 	keyfile := ""
 	certfile := ""
 	fallback := ""
+	overlay := false
 	var corsOrigin []string
 
 	// Filter out server-specific flags
@@ -1448,6 +1710,8 @@ func parseServeOptionsImpl(osArgs []string) (api.ServeOptions, []string, error)
 			certfile = arg[len("--certfile="):]
 		} else if strings.HasPrefix(arg, "--serve-fallback=") {
 			fallback = arg[len("--serve-fallback="):]
+		} else if arg == "--serve-overlay" {
+			overlay = true
 		} else if strings.HasPrefix(arg, "--cors-origin=") {
 			corsOrigin = strings.Split(arg[len("--cors-origin="):], ",")
 		} else {
@@ -1488,6 +1752,7 @@ func parseServeOptionsImpl(osArgs []string) (api.ServeOptions, []string, error)
 		Keyfile:  keyfile,
 		Certfile: certfile,
 		Fallback: fallback,
+		Overlay:  overlay,
 		CORS: api.CORSOptions{
 			Origin: corsOrigin,
 		},
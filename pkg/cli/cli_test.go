@@ -0,0 +1,64 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/test"
+	"github.com/evanw/esbuild/pkg/cli"
+)
+
+func TestNameCacheFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esbuild-name-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entry := filepath.Join(dir, "entry.js")
+	nameCache := filepath.Join(dir, "name-cache.json")
+	build := func(code string) int {
+		if err := ioutil.WriteFile(entry, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return cli.Run([]string{entry, "--bundle", "--minify", "--format=esm", "--log-level=silent",
+			"--outfile=" + filepath.Join(dir, "out.js"), "--name-cache=" + nameCache})
+	}
+
+	// Keys are relative to the current working directory, so only keep the name
+	readNameCache := func() map[string]interface{} {
+		bytes, err := ioutil.ReadFile(nameCache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cache map[string]interface{}
+		if err := json.Unmarshal(bytes, &cache); err != nil {
+			t.Fatal(err)
+		}
+		result := make(map[string]interface{})
+		for key, value := range cache {
+			result[key[strings.LastIndexByte(key, ':')+1:]] = value
+		}
+		return result
+	}
+
+	test.AssertEqual(t, build("let foo = 1, bar = 2; export let fn = () => foo + bar"), 0)
+	first := readNameCache()
+	test.AssertEqual(t, first["foo"] != nil, true)
+	test.AssertEqual(t, first["bar"] != nil, true)
+
+	// Names that still exist are kept, and names that don't exist are removed
+	test.AssertEqual(t, build("let foo = 1; export let fn = () => foo"), 0)
+	second := readNameCache()
+	test.AssertEqual(t, len(second), len(first)-1)
+	test.AssertEqual(t, second["foo"], first["foo"])
+	test.AssertEqual(t, second["bar"], nil)
+
+	// The name cache isn't written when the build fails
+	test.AssertEqual(t, build("let foo = ;"), 1)
+	test.AssertEqual(t, len(readNameCache()), len(second))
+}
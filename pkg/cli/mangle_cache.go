@@ -4,6 +4,8 @@ package cli
 // decisions. It's a flat map where the keys are strings and the values are
 // either strings or the boolean value "false". This is the case both in JSON
 // and in Go (so the "interface{}" values are also either strings or "false").
+// The name cache uses the same format for top-level identifier names, except
+// that its values are always strings.
 
 import (
 	"fmt"
@@ -20,8 +22,8 @@ import (
 	"github.com/evanw/esbuild/internal/resolver"
 )
 
-func parseMangleCache(osArgs []string, fs fs.FS, absPath string) (map[string]interface{}, []string) {
-	// Log problems with the mangle cache to stderr
+func parseMangleCache(osArgs []string, fs fs.FS, absPath string, kind string) (map[string]interface{}, []string) {
+	// Log problems with the cache to stderr
 	log := logger.NewStderrLog(logger.OutputOptionsForArgs(osArgs))
 	defer log.Done()
 
@@ -40,7 +42,7 @@ func parseMangleCache(osArgs []string, fs fs.FS, absPath string) (map[string]int
 
 		// Otherwise, report the error
 		log.AddError(nil, logger.Range{},
-			fmt.Sprintf("Failed to read from mangle cache file %q: %s", prettyPath, originalError.Error()))
+			fmt.Sprintf("Failed to read from %s file %q: %s", kind, prettyPath, originalError.Error()))
 		return nil, nil
 	}
 
@@ -62,7 +64,7 @@ func parseMangleCache(osArgs []string, fs fs.FS, absPath string) (map[string]int
 	root, ok := result.Data.(*js_ast.EObject)
 	if !ok {
 		log.AddError(&tracker, logger.Range{Loc: result.Loc},
-			"Expected a top-level object in mangle cache file")
+			fmt.Sprintf("Expected a top-level object in %s file", kind))
 		return nil, nil
 	}
 
@@ -77,7 +79,7 @@ func parseMangleCache(osArgs []string, fs fs.FS, absPath string) (map[string]int
 		case *js_ast.EBoolean:
 			if v.Value {
 				log.AddError(&tracker, js_lexer.RangeOfIdentifier(source, property.ValueOrNil.Loc),
-					fmt.Sprintf("Expected %q in mangle cache file to map to either a string or false", key))
+					fmt.Sprintf("Expected %q in %s file to map to either a string or false", key, kind))
 			} else {
 				mangleCache[key] = false
 			}
@@ -87,7 +89,7 @@ func parseMangleCache(osArgs []string, fs fs.FS, absPath string) (map[string]int
 
 		default:
 			log.AddError(&tracker, logger.Range{Loc: property.ValueOrNil.Loc},
-				fmt.Sprintf("Expected %q in mangle cache file to map to either a string or false", key))
+				fmt.Sprintf("Expected %q in %s file to map to either a string or false", key, kind))
 		}
 	}
 
@@ -101,6 +103,21 @@ func printMangleCache(mangleCache map[string]interface{}, originalOrder []string
 	j := helpers.Joiner{}
 	j.AddString("{")
 
+	// Skip keys that are no longer present. The mangle cache never removes
+	// keys but the name cache removes keys for symbols that no longer exist.
+	for i, key := range originalOrder {
+		if _, ok := mangleCache[key]; !ok {
+			kept := append([]string{}, originalOrder[:i]...)
+			for _, key := range originalOrder[i+1:] {
+				if _, ok := mangleCache[key]; ok {
+					kept = append(kept, key)
+				}
+			}
+			originalOrder = kept
+			break
+		}
+	}
+
 	// Determine the order to print the keys in
 	order := originalOrder
 	if len(mangleCache) > len(order) {
@@ -5387,6 +5387,70 @@ let serveTests = {
     }
   },
 
+  async serveWithOverlay({ esbuild, testDir }) {
+    const input = path.join(testDir, 'in.js')
+    const wwwDir = path.join(testDir, 'www')
+    const index = path.join(wwwDir, 'index.html')
+    await mkdirAsync(wwwDir, { recursive: true })
+    await writeFileAsync(input, `console.log(123)`)
+    await writeFileAsync(index, `<body><p>index</p></body>`)
+
+    let endPromise
+    const context = await esbuild.context({
+      entryPoints: [input],
+      outdir: wwwDir,
+      logLevel: 'silent',
+    });
+    try {
+      const result = await context.serve({
+        host: '127.0.0.1',
+        servedir: wwwDir,
+        overlay: true,
+      })
+      const stream = await makeEventStream(result.hosts[0], result.port, '/esbuild')
+
+      let buffer;
+
+      buffer = await fetch(result.hosts[0], result.port, '/')
+      assert.strictEqual(buffer.toString(), `<body><p>index</p><script src="/esbuild/overlay.js"></script>\n</body>`);
+
+      buffer = await fetch(result.hosts[0], result.port, '/esbuild/overlay.js')
+      assert.strictEqual(buffer.headers['content-type'], 'text/javascript; charset=utf-8');
+
+      // A failed build sends the errors
+      var eventPromise = stream.waitFor('errors')
+      await writeFileAsync(input, `console.log(`)
+      await context.rebuild().then(
+        () => Promise.reject(new Error('Expected an error to be thrown')),
+        () => { /* Ignore the build error */ },
+      )
+      var data = JSON.parse((await eventPromise).data)
+      assert.strictEqual(data.length, 1)
+      assert.strictEqual(data[0].text, 'Unexpected end of file')
+      assert.strictEqual(data[0].location.line, 1)
+
+      // Page loads are replaced by an error page while the build is failing
+      buffer = await fetch(result.hosts[0], result.port, '/', { headers: { Accept: 'text/html' } })
+        .then(() => Promise.reject(new Error('Expected an error to be thrown')), error => error)
+      assert.strictEqual(buffer.statusCode, 503)
+
+      // A successful build clears the errors
+      var eventPromise = stream.waitFor('errors')
+      await writeFileAsync(input, `console.log(123)`)
+      await context.rebuild()
+      var data = JSON.parse((await eventPromise).data)
+      assert.deepStrictEqual(data, [])
+
+      // Wait for the stream to end once we call "dispose()" below
+      endPromise = stream.waitFor('close')
+    } finally {
+      await context.dispose();
+    }
+
+    // This stream should end once "dispose()" is called above
+    await endPromise
+  },
+
   async serveHostCheckIPv4({ esbuild, testDir }) {
     const input = path.join(testDir, 'in.js')
     await writeFileAsync(input, `console.log(123)`)
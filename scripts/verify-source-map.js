@@ -758,6 +758,25 @@ const toSearchDefineWithObjectIssue4169 = {
   'test array': '<define:ARRAY>',
 }
 
+const testCasePreserveComments = {
+  'entry.js': `
+    // a
+    let x = f("x0") /* b */ + /* c */ f("x1")
+    if (x /* d */) {
+      /* e */ f("x2", /* f */ "x3") // g
+    }
+    /* h */ function f() { return "x4" /* i */ }
+  `,
+}
+
+const toSearchPreserveComments = {
+  x0: 'entry.js',
+  x1: 'entry.js',
+  x2: 'entry.js',
+  x3: 'entry.js',
+  x4: 'entry.js',
+}
+
 async function check(kind, testCase, toSearch, { outfile, flags, entryPoints, crlf, followUpFlags = [], checkFirstChunk }) {
   let failed = 0
 
@@ -1258,6 +1277,12 @@ async function main() {
           entryPoints: ['entry.js'],
           crlf,
         }),
+        check('preserve-comments' + suffix, testCasePreserveComments, toSearchPreserveComments, {
+          outfile: 'out.js',
+          flags: flags.concat('--comments=preserve'),
+          entryPoints: ['entry.js'],
+          crlf,
+        }),
 
         // Checks for the "names" field
         checkNames('names' + suffix, testCaseNames, {